@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+// CompareArgs represents the arguments of the compare command.
+type CompareArgs struct {
+	left  []string
+	right []string
+}
+
+func newCompareCommand(args *CLIArgs) *cobra.Command {
+	compareArgs := &CompareArgs{}
+
+	compareCmd := &cobra.Command{
+		Use:   "compare --left <targets> --right <targets>",
+		Short: "Report similar functions between two sets of targets",
+		Long: `Compare two sets of targets against each other.
+
+Only cross-set pairs are evaluated: a function from --left is compared with every
+function from --right, but never with another --left function (and vice versa).
+Each function in the output is labeled with the side it belongs to.
+
+Useful for checking whether a forked package has diverged from upstream, or for
+comparing a new module against the legacy one it replaces.
+
+Both flags accept files and directories, either comma-separated or repeated:
+  similarity-go compare --left ./upstream/pkg --right ./fork/pkg
+  similarity-go compare --left old.go,./legacy --right ./v2`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runCompare(args, compareArgs, cmd)
+		},
+	}
+
+	compareCmd.Flags().StringSliceVar(&compareArgs.left, "left", nil, "left-hand targets (files or directories)")
+	compareCmd.Flags().StringSliceVar(&compareArgs.right, "right", nil, "right-hand targets (files or directories)")
+	addAnalysisFlags(compareCmd, args)
+	addGroupingFlags(compareCmd)
+	addFailOnFlag(compareCmd)
+	addUnitsFlag(compareCmd)
+
+	return compareCmd
+}
+
+func runCompare(args *CLIArgs, compareArgs *CompareArgs, cmd *cobra.Command) error {
+	if len(compareArgs.left) == 0 || len(compareArgs.right) == 0 {
+		return errors.New("both --left and --right targets are required")
+	}
+
+	targets := append(append([]string{}, compareArgs.left...), compareArgs.right...)
+
+	// Load and validate configuration
+	cfg, err := loadAndConfigureSetup(args, cmd, targets)
+	if err != nil {
+		return err
+	}
+
+	a, err := analyzer.New(analyzerOptions(cfg, args.verbose)...)
+	if err != nil {
+		return err
+	}
+
+	report, err := a.Compare(commandContext(cmd), compareArgs.left, compareArgs.right)
+	if err != nil {
+		return err
+	}
+
+	if writeErr := writeOutput(report, cfg.CLI.DefaultFormat, args.output); writeErr != nil {
+		return writeErr
+	}
+	return checkRules(cmd, report)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+const compareTestSource = `package sample
+
+func Sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+`
+
+// compareTestVariant is a copy of compareTestSource with an extra statement.
+const compareTestVariant = `package sample
+
+func Sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	total *= 2
+	return total
+}
+`
+
+func TestCompareCommand(t *testing.T) {
+	leftDir := t.TempDir()
+	rightDir := t.TempDir()
+
+	sources := map[string]string{leftDir: compareTestSource, rightDir: compareTestVariant}
+	for dir, source := range sources {
+		if err := os.WriteFile(filepath.Join(dir, "sum.go"), []byte(source), 0o600); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "compare.json")
+
+	cmd := newRootCommand(&CLIArgs{})
+	cmd.SetArgs([]string{
+		"compare",
+		"--left", leftDir,
+		"--right", rightDir,
+		"--min-lines", "3",
+		"--output", outputFile,
+	})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("compare command failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+
+	var report struct {
+		Summary struct {
+			LeftFunctions  int `json:"left_functions"`
+			RightFunctions int `json:"right_functions"`
+		} `json:"summary"`
+		SimilarGroups []struct {
+			Functions []struct {
+				File string `json:"file"`
+				Side string `json:"side"`
+			} `json:"functions"`
+		} `json:"similar_groups"`
+	}
+	if unmarshalErr := json.Unmarshal(content, &report); unmarshalErr != nil {
+		t.Fatalf("failed to parse output: %v", unmarshalErr)
+	}
+
+	if report.Summary.LeftFunctions != 1 || report.Summary.RightFunctions != 1 {
+		t.Errorf("expected 1 function per side, got %+v", report.Summary)
+	}
+
+	// Similar functions from both sides are reported as a group
+	if len(report.SimilarGroups) != 1 {
+		t.Fatalf("expected 1 similar group, got %d", len(report.SimilarGroups))
+	}
+
+	sides := map[string]string{}
+	for _, fn := range report.SimilarGroups[0].Functions {
+		sides[fn.Side] = fn.File
+	}
+	if filepath.Dir(sides[analyzer.SideLeft]) != leftDir || filepath.Dir(sides[analyzer.SideRight]) != rightDir {
+		t.Errorf("expected one function labeled per side, got %v", sides)
+	}
+}
+
+func TestCompareCommandRequiresBothSides(t *testing.T) {
+	cmd := newRootCommand(&CLIArgs{})
+	cmd.SetArgs([]string{"compare", "--left", "./testdata"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error when --right is missing")
+	}
+}
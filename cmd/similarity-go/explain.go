@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/paveg/similarity-go/internal/similarity"
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+const (
+	// explainArgCount is the number of function references explain expects.
+	explainArgCount = 2
+	// maxAlignmentColumnWidth caps the width of the left column of the aligned diff.
+	maxAlignmentColumnWidth = 60
+)
+
+func newExplainCommand(args *CLIArgs) *cobra.Command {
+	explainCmd := &cobra.Command{
+		Use:   "explain <a.go:FuncA> <b.go:FuncB>",
+		Short: "Explain how the similarity of two functions is computed",
+		Long: `Compare two functions and show every step of the similarity calculation:
+
+  - the overall score and whether it passes the threshold
+  - the quick prefilter checks and whether each one passed
+  - each weighted component (tree edit, token, structural, signature)
+    with its score, configured weight and contribution
+  - an aligned line-by-line diff of the normalized bodies
+
+Useful for debugging false positives and justifying findings in code review.
+
+Output is human-readable text unless --format json|yaml is given.
+
+Example:
+  similarity-go explain ./a.go:ParseUser ./b.go:ParseAdmin`,
+		Args: cobra.ExactArgs(explainArgCount),
+		RunE: func(cmd *cobra.Command, references []string) error {
+			return runExplain(args, cmd, references[0], references[1])
+		},
+	}
+
+	addAnalysisFlags(explainCmd, args)
+
+	return explainCmd
+}
+
+func runExplain(args *CLIArgs, cmd *cobra.Command, reference1, reference2 string) error {
+	cfg, err := loadAndConfigureSetup(args, cmd, []string{reference1, reference2})
+	if err != nil {
+		return err
+	}
+
+	a, err := analyzer.New(analyzerOptions(cfg, args.verbose)...)
+	if err != nil {
+		return err
+	}
+
+	explanation, err := a.Explain(commandContext(cmd), reference1, reference2)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Flags().Changed("format") {
+		return writeOutput(explanation, cfg.CLI.DefaultFormat, args.output)
+	}
+
+	out := cmd.OutOrStdout()
+	if args.output != "" {
+		file, createErr := os.Create(args.output)
+		if createErr != nil {
+			return fmt.Errorf("failed to create output file: %w", createErr)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	return writeExplanationText(out, explanation)
+}
+
+// writeExplanationText writes a human-readable explanation.
+func writeExplanationText(out io.Writer, explanation *analyzer.Explanation) error {
+	verdict := "below threshold"
+	if explanation.AboveThreshold {
+		verdict = "above threshold"
+	}
+
+	var lines []string
+	addf := func(format string, a ...any) {
+		lines = append(lines, fmt.Sprintf(format, a...))
+	}
+
+	addf("A: %s", describeFunction(explanation.Functions[0]))
+	addf("B: %s", describeFunction(explanation.Functions[1]))
+	addf("")
+	addf("Similarity: %.4f (threshold %.2f, %s)", explanation.SimilarityScore, explanation.Threshold, verdict)
+	if explanation.Shortcut != "" {
+		addf("Shortcut:   %s (weighted components below are informational)", explanation.Shortcut)
+	}
+
+	addf("")
+	addf("Prefilters:")
+	for _, check := range explanation.Prefilters {
+		status := "pass"
+		if !check.Passed {
+			status = "FAIL"
+		}
+		addf("  [%s] %-18s %s", status, check.Name, check.Detail)
+	}
+
+	addf("")
+	addf("Components:")
+	addf("  %-18s %8s %8s %13s", "name", "score", "weight", "contribution")
+	for _, component := range explanation.Components {
+		addf("  %-18s %8.4f %8.4f %13.4f", component.Name, component.Score, component.Weight, component.Contribution)
+	}
+	addf("  %-18s %8s %8s %13.4f", "weighted total", "", "", explanation.WeightedScore)
+
+	addf("")
+	addf("Normalized bodies (= match, ~ changed, - only in A, + only in B):")
+	lines = append(lines, formatAlignment(explanation.Alignment)...)
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(out, line); err != nil {
+			return fmt.Errorf("failed to write explanation: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// describeFunction returns a one-line description of a function and its location.
+func describeFunction(fn analyzer.FunctionRef) string {
+	return fmt.Sprintf("%s (%s:%d-%d)", fn.DisplayName(), fn.File, fn.StartLine, fn.EndLine)
+}
+
+// formatAlignment renders aligned lines as two columns with a marker per row.
+func formatAlignment(alignment []analyzer.AlignedLine) []string {
+	width := 0
+	for _, line := range alignment {
+		width = max(width, len(line.Left))
+	}
+	width = min(width, maxAlignmentColumnWidth)
+
+	markers := map[string]string{
+		similarity.AlignMatch:     "=",
+		similarity.AlignChanged:   "~",
+		similarity.AlignLeftOnly:  "-",
+		similarity.AlignRightOnly: "+",
+	}
+
+	lines := make([]string, 0, len(alignment))
+	for _, line := range alignment {
+		lines = append(lines, fmt.Sprintf("  %s %-*s | %s", markers[line.Kind], width, line.Left, line.Right))
+	}
+
+	return lines
+}
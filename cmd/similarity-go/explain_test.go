@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeExplainTestFile(t *testing.T) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "sum.go")
+	if err := os.WriteFile(file, []byte(findTestSource), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	return file
+}
+
+func TestExplainCommandText(t *testing.T) {
+	file := writeExplainTestFile(t)
+
+	cmd := newRootCommand(&CLIArgs{})
+	cmd.SetArgs([]string{"explain", file + ":Sum", file + ":Total"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("explain command failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{"Similarity:", "Prefilters:", "tree_edit", "signature", "= return VAR"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in output:\n%s", expected, output)
+		}
+	}
+}
+
+func TestExplainCommandJSON(t *testing.T) {
+	file := writeExplainTestFile(t)
+	outputFile := filepath.Join(t.TempDir(), "explain.json")
+
+	cmd := newRootCommand(&CLIArgs{})
+	cmd.SetArgs([]string{"explain", file + ":Sum", file + ":Total", "--format", "json", "--output", outputFile})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("explain command failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+
+	var report struct {
+		SimilarityScore float64          `json:"similarity_score"`
+		Components      []map[string]any `json:"components"`
+		Prefilters      []map[string]any `json:"prefilters"`
+		Alignment       []map[string]any `json:"alignment"`
+	}
+	if unmarshalErr := json.Unmarshal(content, &report); unmarshalErr != nil {
+		t.Fatalf("failed to parse output: %v", unmarshalErr)
+	}
+
+	if report.SimilarityScore <= 0 || len(report.Components) != 4 || len(report.Prefilters) == 0 {
+		t.Errorf("unexpected explanation: %+v", report)
+	}
+	if len(report.Alignment) == 0 {
+		t.Error("expected aligned body lines")
+	}
+}
+
+func TestExplainCommandOptionalMetrics(t *testing.T) {
+	file := writeExplainTestFile(t)
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	configYAML := `similarity:
+  weights:
+    tree_edit: 0.2
+    token_similarity: 0.2
+    structural: 0.2
+    signature: 0.1
+    control_flow: 0.1
+    dependence: 0.1
+    api_usage: 0.1
+  api_usage:
+    ignore: []
+`
+	if err := os.WriteFile(configFile, []byte(configYAML), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	outputFile := filepath.Join(dir, "explain.json")
+
+	cmd := newRootCommand(&CLIArgs{})
+	cmd.SetArgs([]string{
+		"explain", file + ":Sum", file + ":Total",
+		"--config", configFile, "--format", "json", "--output", outputFile,
+	})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("explain command failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+
+	var report struct {
+		Components []struct {
+			Name string `json:"name"`
+		} `json:"components"`
+	}
+	if unmarshalErr := json.Unmarshal(content, &report); unmarshalErr != nil {
+		t.Fatalf("failed to parse output: %v", unmarshalErr)
+	}
+
+	// The optional metrics weighted in the configuration file are computed
+	if len(report.Components) != 7 || report.Components[6].Name != "api_usage" {
+		t.Errorf("expected the optional metrics among the components, got %+v", report.Components)
+	}
+}
+
+func TestExplainCommandMetricsList(t *testing.T) {
+	file := writeExplainTestFile(t)
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	configYAML := `similarity:
+  metrics:
+    - name: token_similarity
+      weight: 0.7
+    - name: control_flow
+      weight: 0.3
+`
+	if err := os.WriteFile(configFile, []byte(configYAML), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cmd := newRootCommand(&CLIArgs{})
+	cmd.SetArgs([]string{"explain", file + ":Sum", file + ":Total", "--config", configFile})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("explain command failed: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "control_flow") || strings.Contains(output, "tree_edit") {
+		t.Errorf("expected only the listed metrics in output:\n%s", output)
+	}
+}
+
+func TestExplainCommandInvalidReference(t *testing.T) {
+	file := writeExplainTestFile(t)
+
+	cmd := newRootCommand(&CLIArgs{})
+	cmd.SetArgs([]string{"explain", file + ":Sum", file + ":Missing"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error for missing function")
+	}
+}
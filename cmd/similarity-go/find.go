@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+const (
+	// DefaultFindTop is the default number of nearest neighbors reported by find.
+	DefaultFindTop = 10
+	// StdinQuery is the query argument that reads a snippet from standard input.
+	StdinQuery = "-"
+	// findMinArgs is the query plus at least one target.
+	findMinArgs = 2
+)
+
+// FindArgs represents the arguments of the find command.
+type FindArgs struct {
+	top int
+}
+
+func newFindCommand(args *CLIArgs) *cobra.Command {
+	findArgs := &FindArgs{}
+
+	findCmd := &cobra.Command{
+		Use:   "find <file.go:Function | -> <targets...>",
+		Short: "Find the functions most similar to a given function or snippet",
+		Long: `Compare a single function against every function in the targets and report
+the top-K nearest neighbors with their similarity scores.
+
+The query is either a function in a file (path/to/file.go:FunctionName) or "-"
+to read a snippet from standard input. Snippets may omit the package clause.
+Methods sharing a name may be told apart by their receiver type, as in
+client.go:(*Client).Close.
+
+Unlike the default command, find only performs one comparison per target
+function instead of comparing every pair, so it is cheap enough to ask
+"does something like this already exist?" before writing a new helper.
+
+Results are not filtered by the configured threshold unless --threshold is
+given explicitly; the query function itself is never reported.
+
+Examples:
+  similarity-go find ./pkg/foo.go:ParseConfig ./...
+  pbpaste | similarity-go find - ./internal --top 5`,
+		Args: cobra.MinimumNArgs(findMinArgs),
+		RunE: func(cmd *cobra.Command, positional []string) error {
+			return runFind(args, findArgs, cmd, positional[0], positional[1:])
+		},
+	}
+
+	findCmd.Flags().IntVarP(&findArgs.top, "top", "k", DefaultFindTop, "number of nearest neighbors to report")
+	addAnalysisFlags(findCmd, args)
+
+	return findCmd
+}
+
+func runFind(args *CLIArgs, findArgs *FindArgs, cmd *cobra.Command, query string, targets []string) error {
+	// Load and validate configuration
+	cfg, err := loadAndConfigureSetup(args, cmd, targets)
+	if err != nil {
+		return err
+	}
+
+	findQuery := analyzer.Query{Top: findArgs.top}
+	if query == StdinQuery {
+		src, readErr := io.ReadAll(cmd.InOrStdin())
+		if readErr != nil {
+			return fmt.Errorf("failed to read snippet from stdin: %w", readErr)
+		}
+		findQuery.Snippet = src
+	} else {
+		findQuery.Reference = query
+	}
+
+	// Only filter by threshold when it was asked for explicitly
+	if cmd.Flags().Changed("threshold") {
+		findQuery.MinSimilarity = cfg.CLI.DefaultThreshold
+	}
+
+	a, err := analyzer.New(analyzerOptions(cfg, args.verbose)...)
+	if err != nil {
+		return err
+	}
+
+	result, err := a.Find(commandContext(cmd), findQuery, targets)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(result, cfg.CLI.DefaultFormat, args.output)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const findTestSource = `package sample
+
+func Sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func Total(items []int) int {
+	acc := 0
+	for _, item := range items {
+		acc += item
+	}
+	return acc
+}
+`
+
+type findTestReport struct {
+	Query struct {
+		Function string `json:"function"`
+	} `json:"query"`
+	Results []struct {
+		Function        string  `json:"function"`
+		Rank            int     `json:"rank"`
+		SimilarityScore float64 `json:"similarity_score"`
+	} `json:"results"`
+}
+
+func runFindTest(t *testing.T, stdin string, cmdArgs ...string) findTestReport {
+	t.Helper()
+
+	outputFile := filepath.Join(t.TempDir(), "find.json")
+
+	cmd := newRootCommand(&CLIArgs{})
+	cmd.SetArgs(append(append([]string{"find"}, cmdArgs...), "--output", outputFile))
+	cmd.SetIn(strings.NewReader(stdin))
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("find command failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+
+	var report findTestReport
+	if unmarshalErr := json.Unmarshal(content, &report); unmarshalErr != nil {
+		t.Fatalf("failed to parse output: %v", unmarshalErr)
+	}
+
+	return report
+}
+
+func TestFindCommand(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "sum.go")
+	if err := os.WriteFile(file, []byte(findTestSource), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	t.Run("function reference", func(t *testing.T) {
+		report := runFindTest(t, "", file+":Sum", dir+"/...", "--min-lines", "3")
+
+		if report.Query.Function != "Sum" {
+			t.Errorf("expected query Sum, got %s", report.Query.Function)
+		}
+
+		// The query itself is excluded, leaving only Total
+		if len(report.Results) != 1 || report.Results[0].Function != "Total" || report.Results[0].Rank != 1 {
+			t.Fatalf("expected Total as the only result, got %+v", report.Results)
+		}
+	})
+
+	t.Run("snippet from stdin", func(t *testing.T) {
+		snippet := "func Add(xs []int) int {\n\ts := 0\n\tfor _, x := range xs {\n\t\ts += x\n\t}\n\treturn s\n}\n"
+		report := runFindTest(t, snippet, StdinQuery, dir, "--min-lines", "3", "--top", "1")
+
+		if report.Query.Function != "Add" {
+			t.Errorf("expected query Add, got %s", report.Query.Function)
+		}
+		if len(report.Results) != 1 {
+			t.Fatalf("expected --top to limit results to 1, got %d", len(report.Results))
+		}
+	})
+}
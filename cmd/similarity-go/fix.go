@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+// fixFilePerm is the permission of files rewritten by fix when it cannot be preserved.
+const fixFilePerm = 0o644
+
+func newFixCommand(args *CLIArgs) *cobra.Command {
+	fixCmd := &cobra.Command{
+		Use:   "fix [flags] <targets...>",
+		Short: "Rewrite exact clones to delegate to a single copy",
+		Long: `Find exact clones declared in the same package and rewrite every copy but one
+to call the remaining one. Exact clones only differ by formatting, comments and
+the names of their local variables and parameters; functions that differ by
+literals, types or called functions are left alone.
+
+The canonical copy is the first one by file and line, preferring non-test files.
+Only the rewritten bodies are printed; the rest of each file keeps its
+formatting, and imports that become unused are removed.
+
+With --dry-run (the default), the changes are printed as a unified diff.
+With --write, the files are rewritten in place.
+
+Examples:
+  similarity-go fix ./...                 # review the changes
+  similarity-go fix --write ./internal    # apply them`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, targets []string) error {
+			return runFix(args, cmd, targets)
+		},
+	}
+
+	fixCmd.Flags().StringVarP(&args.configFile, "config", "c", "", "config file path")
+	fixCmd.Flags().StringVarP(&args.output, "output", "o", "", "diff output file (default: stdout)")
+	fixCmd.Flags().BoolVarP(&args.verbose, "verbose", "v", false, "verbose output")
+	fixCmd.Flags().String("ignore", "", "ignore file path")
+	fixCmd.Flags().Int("min-lines", 0, "minimum function lines to analyze")
+	fixCmd.Flags().Bool("dry-run", false, "print the changes as a unified diff without writing files (default)")
+	fixCmd.Flags().Bool("write", false, "rewrite the files in place")
+	fixCmd.MarkFlagsMutuallyExclusive("dry-run", "write")
+
+	return fixCmd
+}
+
+func runFix(args *CLIArgs, cmd *cobra.Command, targets []string) error {
+	cfg, err := loadAndConfigureSetup(args, cmd, targets)
+	if err != nil {
+		return err
+	}
+
+	a, err := analyzer.New(analyzerOptions(cfg, args.verbose)...)
+	if err != nil {
+		return err
+	}
+
+	result, err := a.Fix(commandContext(cmd), targets)
+	if err != nil {
+		return err
+	}
+
+	if write, _ := cmd.Flags().GetBool("write"); write {
+		return writeFixedFiles(cmd.OutOrStdout(), result)
+	}
+
+	out := cmd.OutOrStdout()
+	if args.output != "" {
+		file, createErr := os.Create(args.output)
+		if createErr != nil {
+			return fmt.Errorf("failed to create output file: %w", createErr)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	for _, change := range result.Files {
+		if _, writeErr := io.WriteString(out, change.Diff()); writeErr != nil {
+			return fmt.Errorf("failed to write diff: %w", writeErr)
+		}
+	}
+
+	return nil
+}
+
+// writeFixedFiles rewrites the changed files and lists the rewritten functions on out.
+func writeFixedFiles(out io.Writer, result *analyzer.FixResult) error {
+	for _, change := range result.Files {
+		perm := os.FileMode(fixFilePerm)
+		if info, err := os.Stat(change.Path); err == nil {
+			perm = info.Mode().Perm()
+		}
+
+		if err := os.WriteFile(change.Path, change.After, perm); err != nil {
+			return fmt.Errorf("failed to write %s: %w", change.Path, err)
+		}
+	}
+
+	for _, delegation := range result.Delegations {
+		_, err := fmt.Fprintf(out, "%s:%d: %s now calls %s (%s:%d)\n",
+			delegation.Function.File, delegation.Function.StartLine, delegation.Function.DisplayName(),
+			delegation.Canonical.DisplayName(), delegation.Canonical.File, delegation.Canonical.StartLine)
+		if err != nil {
+			return fmt.Errorf("failed to list rewritten functions: %w", err)
+		}
+	}
+
+	return nil
+}
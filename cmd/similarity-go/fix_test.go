@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFixCommand(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte(compareTestSource), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	// The copy is renamed so the package compiles
+	copyPath := filepath.Join(dir, "b.go")
+	source := strings.Replace(compareTestSource, "func Sum(", "func SumCopy(", 1)
+	if err := os.WriteFile(copyPath, []byte(source), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	var out bytes.Buffer
+	cmd := newRootCommand(&CLIArgs{})
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"fix", "--min-lines", "3", dir})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "+\treturn Sum(values)") {
+		t.Errorf("unexpected diff:\n%s", out.String())
+	}
+	if data, _ := os.ReadFile(copyPath); string(data) != source {
+		t.Error("dry run modified the file")
+	}
+
+	out.Reset()
+	cmd = newRootCommand(&CLIArgs{})
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"fix", "--write", "--min-lines", "3", dir})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if data, _ := os.ReadFile(copyPath); !strings.Contains(string(data), "return Sum(values)") {
+		t.Errorf("file was not rewritten:\n%s", data)
+	}
+	if !strings.Contains(out.String(), "sample.SumCopy now calls sample.Sum") {
+		t.Errorf("unexpected summary %q", out.String())
+	}
+
+	cmd = newRootCommand(&CLIArgs{})
+	cmd.SetArgs([]string{"fix", "--write", "--dry-run", dir})
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error for --write with --dry-run")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+// HistoryArgs represents the arguments of the history command.
+type HistoryArgs struct {
+	repo     string
+	revision string
+	samples  int
+}
+
+func newHistoryCommand(args *CLIArgs) *cobra.Command {
+	historyArgs := &HistoryArgs{}
+
+	historyCmd := &cobra.Command{
+		Use:   "history [paths...]",
+		Short: "Trace similar groups back through the history of a git repository",
+		Long: `Find the similar groups of a revision of a git repository and trace them
+back through its first-parent history. For every group the report gives:
+  - the commit introducing each copy, found by bisecting the history
+  - when the group first appeared, with its second copy
+  - the similarity of the copies at sampled revisions, and its drift since
+    the group appeared: stable, diverging or converging
+
+Old and stable groups are safe to consolidate; diverging groups are copies
+that are evolving apart. Members are matched across revisions by file and
+receiver-qualified name, so a moved or renamed copy is introduced by the move.
+
+Paths restrict the analysis to files and directories relative to the root of
+the repository:
+  similarity-go history --repo . ./pkg/...
+  similarity-go history --repo . --revision v1.2.0 --samples 20`,
+		RunE: func(cmd *cobra.Command, paths []string) error {
+			return runHistory(args, historyArgs, cmd, paths)
+		},
+	}
+
+	historyCmd.Flags().StringVar(&historyArgs.repo, "repo", ".", "git repository to trace")
+	historyCmd.Flags().StringVar(&historyArgs.revision, "revision", "HEAD", "revision whose similar groups are traced")
+	historyCmd.Flags().IntVar(
+		&historyArgs.samples,
+		"samples",
+		analyzer.DefaultHistorySamples,
+		"number of revisions at which the similarity of groups is measured",
+	)
+	addAnalysisFlags(historyCmd, args)
+	addGroupingFlags(historyCmd)
+
+	return historyCmd
+}
+
+func runHistory(args *CLIArgs, historyArgs *HistoryArgs, cmd *cobra.Command, paths []string) error {
+	cfg, err := loadAndConfigureSetup(args, cmd, []string{historyArgs.repo})
+	if err != nil {
+		return err
+	}
+
+	a, err := analyzer.New(analyzerOptions(cfg, args.verbose)...)
+	if err != nil {
+		return err
+	}
+
+	report, err := a.History(commandContext(cmd), historyArgs.repo, analyzer.HistoryQuery{
+		Revision: historyArgs.revision,
+		Samples:  historyArgs.samples,
+		Paths:    paths,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(report, cfg.CLI.DefaultFormat, args.output)
+}
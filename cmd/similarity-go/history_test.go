@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestHistoryCommand(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, "sum.go"), []byte(siblingsTestSource), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "add sums"},
+	} {
+		if output, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, output)
+		}
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "history.json")
+
+	cmd := newRootCommand(&CLIArgs{})
+	cmd.SetArgs([]string{"history", "--repo", repo, "--min-lines", "3", "--output", outputFile})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("history command failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+
+	var report struct {
+		Commits int `json:"commits"`
+		Groups  []struct {
+			FirstSeen struct {
+				Subject string `json:"subject"`
+			} `json:"first_seen"`
+			Copies []struct{} `json:"copies"`
+			Trend  string     `json:"trend"`
+		} `json:"groups"`
+	}
+	if unmarshalErr := json.Unmarshal(content, &report); unmarshalErr != nil {
+		t.Fatalf("failed to parse output: %v", unmarshalErr)
+	}
+
+	if report.Commits != 1 || len(report.Groups) != 1 {
+		t.Fatalf("expected one group over one commit, got %s", content)
+	}
+	group := report.Groups[0]
+	if group.FirstSeen.Subject != "add sums" || len(group.Copies) != 2 || group.Trend != "stable" {
+		t.Errorf("unexpected group history %s", content)
+	}
+}
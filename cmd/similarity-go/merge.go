@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+func newMergeCommand(args *CLIArgs) *cobra.Command {
+	mergeCmd := &cobra.Command{
+		Use:   "merge <partials...>",
+		Short: "Merge the partial reports of a sharded analysis into one report",
+		Long: `Combine the partial reports written by --shard i/n into the report a single
+run would have produced. Exactly one partial report per shard is required, and
+every shard must have analyzed the same targets with the same threshold.
+
+Splitting an analysis across CI jobs:
+  similarity-go --shard 1/3 -o part1.json ./...   # job 1
+  similarity-go --shard 2/3 -o part2.json ./...   # job 2
+  similarity-go --shard 3/3 -o part3.json ./...   # job 3
+  similarity-go merge part1.json part2.json part3.json`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, paths []string) error {
+			return runMerge(args, cmd, paths)
+		},
+	}
+
+	mergeCmd.Flags().StringVarP(&args.configFile, "config", "c", "", "config file path")
+	mergeCmd.Flags().StringVarP(&args.output, "output", "o", "", "output file (default: stdout)")
+	mergeCmd.Flags().BoolVarP(&args.verbose, "verbose", "v", false, "verbose output")
+	mergeCmd.Flags().StringP("format", "f", "", "output format (json|yaml)")
+	addGroupingFlags(mergeCmd)
+	addFailOnFlag(mergeCmd)
+
+	return mergeCmd
+}
+
+func runMerge(args *CLIArgs, cmd *cobra.Command, paths []string) error {
+	cfg, err := loadAndConfigureSetup(args, cmd, paths)
+	if err != nil {
+		return err
+	}
+
+	partials := make([]*analyzer.PartialReport, 0, len(paths))
+	for _, path := range paths {
+		partial, readErr := readPartialFile(path)
+		if readErr != nil {
+			return readErr
+		}
+		partials = append(partials, partial)
+	}
+
+	a, err := analyzer.New(analyzerOptions(cfg, args.verbose)...)
+	if err != nil {
+		return err
+	}
+
+	report, err := a.Merge(partials)
+	if err != nil {
+		return fmt.Errorf("failed to merge partial reports: %w", err)
+	}
+
+	if writeErr := writeOutput(report, cfg.CLI.DefaultFormat, args.output); writeErr != nil {
+		return writeErr
+	}
+	return checkRules(cmd, report)
+}
+
+// readPartialFile reads a partial report from a file.
+func readPartialFile(path string) (*analyzer.PartialReport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open partial report: %w", err)
+	}
+	defer file.Close()
+
+	partial, err := analyzer.ReadPartialReport(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return partial, nil
+}
+
+// parseShard parses a shard given as "index/count".
+func parseShard(value string) (int, int, error) {
+	indexText, countText, found := strings.Cut(value, "/")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid shard %q: expected index/count, e.g. 1/4", value)
+	}
+
+	index, indexErr := strconv.Atoi(indexText)
+	count, countErr := strconv.Atoi(countText)
+	if indexErr != nil || countErr != nil {
+		return 0, 0, fmt.Errorf("invalid shard %q: expected index/count, e.g. 1/4", value)
+	}
+
+	return index, count, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+func TestShardAndMergeCommands(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(compareTestSource), 0o600); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+	}
+
+	outDir := t.TempDir()
+	var partials []string
+	for index := 1; index <= 2; index++ {
+		partial := filepath.Join(outDir, fmt.Sprintf("part%d.json", index))
+		cmd := newRootCommand(&CLIArgs{})
+		cmd.SetArgs([]string{"--shard", fmt.Sprintf("%d/2", index), "--min-lines", "3", "-o", partial, dir})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("shard %d failed: %v", index, err)
+		}
+		partials = append(partials, partial)
+	}
+
+	merged := filepath.Join(outDir, "merged.json")
+	cmd := newRootCommand(&CLIArgs{})
+	cmd.SetArgs(append([]string{"merge", "-o", merged}, partials...))
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+
+	data, err := os.ReadFile(merged)
+	if err != nil {
+		t.Fatalf("failed to read merged report: %v", err)
+	}
+
+	var report analyzer.Report
+	if unmarshalErr := json.Unmarshal(data, &report); unmarshalErr != nil {
+		t.Fatalf("failed to decode merged report: %v", unmarshalErr)
+	}
+
+	if report.Summary.TotalFunctions != 3 || report.Summary.TotalDuplications != 3 || len(report.SimilarGroups) != 1 {
+		t.Errorf("unexpected merged report %+v", report)
+	}
+
+	cmd = newRootCommand(&CLIArgs{})
+	cmd.SetArgs(append([]string{"merge"}, partials[0]))
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error when a shard is missing")
+	}
+}
+
+func TestParseShard(t *testing.T) {
+	index, count, err := parseShard("2/5")
+	if err != nil || index != 2 || count != 5 {
+		t.Errorf("parseShard(2/5) = %d, %d, %v", index, count, err)
+	}
+
+	for _, value := range []string{"2", "a/5", "2/b", ""} {
+		if _, _, err := parseShard(value); err == nil {
+			t.Errorf("parseShard(%q): expected error", value)
+		}
+	}
+}
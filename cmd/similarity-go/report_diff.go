@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+// reportDiffArgCount is the number of reports report-diff expects.
+const reportDiffArgCount = 2
+
+func newReportDiffCommand() *cobra.Command {
+	var outputPath, format string
+
+	reportDiffCmd := &cobra.Command{
+		Use:   "report-diff <old.json> <new.json>",
+		Short: "Compare two reports and classify how similar groups changed",
+		Long: `Read two reports produced by similarity-go (JSON or YAML) and classify
+every group as:
+
+  new        only found in the new report
+  resolved   only found in the old report
+  grown      found in both, with more members
+  shrunk     found in both, with fewer members
+  unchanged  found in both, with the same number of members
+
+Groups are matched through location-independent function fingerprints, so
+moving functions to other lines or files does not show up as a change.
+
+Output is a human-readable summary unless --format json|yaml is given.
+
+Example:
+  similarity-go report-diff last-sprint.json current.json`,
+		Args: cobra.ExactArgs(reportDiffArgCount),
+		RunE: func(cmd *cobra.Command, paths []string) error {
+			return runReportDiff(cmd, paths[0], paths[1], format, outputPath)
+		},
+	}
+
+	reportDiffCmd.Flags().StringVarP(&outputPath, "output", "o", "", "output file (default: stdout)")
+	reportDiffCmd.Flags().StringVarP(&format, "format", "f", "", "output format (json|yaml)")
+
+	return reportDiffCmd
+}
+
+func runReportDiff(cmd *cobra.Command, oldPath, newPath, format, outputPath string) error {
+	oldReport, err := readReportFile(oldPath)
+	if err != nil {
+		return err
+	}
+
+	newReport, err := readReportFile(newPath)
+	if err != nil {
+		return err
+	}
+
+	diff := analyzer.DiffReports(oldReport, newReport)
+
+	if format != "" {
+		return writeOutput(diff, format, outputPath)
+	}
+
+	out := cmd.OutOrStdout()
+	if outputPath != "" {
+		file, createErr := os.Create(outputPath)
+		if createErr != nil {
+			return fmt.Errorf("failed to create output file: %w", createErr)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	return writeReportDiffText(out, diff)
+}
+
+// readReportFile reads a report from a file.
+func readReportFile(path string) (*analyzer.Report, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open report: %w", err)
+	}
+	defer file.Close()
+
+	report, err := analyzer.ReadReport(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return report, nil
+}
+
+// writeReportDiffText writes a human-readable summary of a report diff.
+// Unchanged groups are only counted.
+func writeReportDiffText(out io.Writer, diff *analyzer.ReportDiff) error {
+	summary := diff.Summary
+
+	var lines []string
+	addf := func(format string, a ...any) {
+		lines = append(lines, fmt.Sprintf(format, a...))
+	}
+
+	addf("Groups:       %d -> %d", summary.OldGroups, summary.NewGroups)
+	addf("Duplications: %d -> %d", summary.OldDuplications, summary.NewDuplications)
+	addf(
+		"new %d, resolved %d, grown %d, shrunk %d, changed %d, unchanged %d",
+		summary.New,
+		summary.Resolved,
+		summary.Grown,
+		summary.Shrunk,
+		summary.Changed,
+		summary.Unchanged,
+	)
+
+	for _, change := range diff.Groups {
+		switch change.Status {
+		case analyzer.DiffNew:
+			addf("")
+			addf("%-9s %s (%d functions)", change.Status, change.NewID, change.NewSize)
+		case analyzer.DiffResolved:
+			addf("")
+			addf("%-9s %s (%d functions)", change.Status, change.OldID, change.OldSize)
+		case analyzer.DiffGrown, analyzer.DiffShrunk, analyzer.DiffChanged:
+			addf("")
+			addf(
+				"%-9s %s -> %s (%d -> %d functions)",
+				change.Status,
+				change.OldID,
+				change.NewID,
+				change.OldSize,
+				change.NewSize,
+			)
+		default:
+			continue
+		}
+
+		for _, fn := range change.Added {
+			addf("  + %s", describeFunction(fn))
+		}
+		for _, fn := range change.Removed {
+			addf("  - %s", describeFunction(fn))
+		}
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(out, line); err != nil {
+			return fmt.Errorf("failed to write report diff: %w", err)
+		}
+	}
+
+	return nil
+}
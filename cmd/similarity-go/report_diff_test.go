@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+// writeReportFile writes a report with one group per fingerprint list.
+func writeReportFile(t *testing.T, groups ...[]string) string {
+	t.Helper()
+
+	report := analyzer.Report{SchemaVersion: analyzer.SchemaVersion}
+	for i, fingerprints := range groups {
+		group := analyzer.Group{ID: fmt.Sprintf("group_%d", i+1)}
+		for _, fingerprint := range fingerprints {
+			group.Functions = append(group.Functions, analyzer.FunctionRef{
+				File:        fingerprint + ".go",
+				Function:    strings.ToUpper(fingerprint),
+				StartLine:   1,
+				EndLine:     10,
+				Fingerprint: fingerprint,
+			})
+		}
+		report.SimilarGroups = append(report.SimilarGroups, group)
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("failed to encode report: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if writeErr := os.WriteFile(path, data, 0o600); writeErr != nil {
+		t.Fatalf("failed to write report: %v", writeErr)
+	}
+
+	return path
+}
+
+func runReportDiffTest(t *testing.T, cmdArgs ...string) string {
+	t.Helper()
+
+	cmd := newRootCommand(&CLIArgs{})
+	cmd.SetArgs(append([]string{"report-diff"}, cmdArgs...))
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("report-diff command failed: %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestReportDiffCommand(t *testing.T) {
+	oldPath := writeReportFile(t, []string{"a", "b"}, []string{"c", "d"})
+	newPath := writeReportFile(t, []string{"a", "b", "e"})
+
+	text := runReportDiffTest(t, oldPath, newPath)
+	for _, want := range []string{
+		"new 0, resolved 1, grown 1, shrunk 0, changed 0, unchanged 0",
+		"grown     group_1 -> group_1 (2 -> 3 functions)",
+		"  + E (e.go:1-10)",
+		"resolved  group_2 (2 functions)",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, text)
+		}
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "diff.json")
+	runReportDiffTest(t, oldPath, newPath, "--format", "json", "--output", outputFile)
+
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+
+	var diff analyzer.ReportDiff
+	if unmarshalErr := json.Unmarshal(content, &diff); unmarshalErr != nil {
+		t.Fatalf("failed to parse output: %v", unmarshalErr)
+	}
+	if diff.Summary.Grown != 1 || diff.Summary.Resolved != 1 {
+		t.Errorf("unexpected summary %+v", diff.Summary)
+	}
+}
+
+func TestReportDiffCommandRejectsInvalidReport(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"summary": {}}`), 0o600); err != nil {
+		t.Fatalf("failed to write report: %v", err)
+	}
+
+	cmd := newRootCommand(&CLIArgs{})
+	cmd.SetArgs([]string{"report-diff", invalid, writeReportFile(t)})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error for a report without schema_version")
+	}
+}
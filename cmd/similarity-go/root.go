@@ -1,21 +1,18 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 
-	"github.com/paveg/similarity-go/internal/ast"
 	"github.com/paveg/similarity-go/internal/config"
-	"github.com/paveg/similarity-go/internal/similarity"
-	"github.com/paveg/similarity-go/internal/worker"
-	"github.com/paveg/similarity-go/pkg/mathutil"
+	"github.com/paveg/similarity-go/pkg/analyzer"
 )
 
 const (
@@ -36,6 +33,7 @@ type CLIArgs struct {
 	configFile string
 	output     string
 	verbose    bool
+	shard      string
 }
 
 func newRootCommand(args *CLIArgs) *cobra.Command {
@@ -63,21 +61,89 @@ Detects similar code blocks that could be consolidated, helping with refactoring
 	}
 
 	// Add flags - configuration will be loaded inside runSimilarityCheck
-	rootCmd.Flags().StringVarP(&args.configFile, "config", "c", "", "config file path")
-	rootCmd.Flags().StringVarP(&args.output, "output", "o", "", "output file (default: stdout)")
-	rootCmd.Flags().BoolVarP(&args.verbose, "verbose", "v", false, "verbose output")
-
-	// Allow overriding config values via flags - will be parsed in runSimilarityCheck
-	rootCmd.Flags().Float64P("threshold", "t", 0, "similarity threshold (0.0-1.0)")
-	rootCmd.Flags().StringP("format", "f", "", "output format (json|yaml)")
-	rootCmd.Flags().IntP("workers", "w", 0, "number of parallel workers")
-	rootCmd.Flags().Bool("cache", false, "enable caching")
-	rootCmd.Flags().String("ignore", "", "ignore file path")
-	rootCmd.Flags().Int("min-lines", 0, "minimum function lines to analyze")
+	addAnalysisFlags(rootCmd, args)
+	addGroupingFlags(rootCmd)
+	addUnitsFlag(rootCmd)
+	addFailOnFlag(rootCmd)
+	rootCmd.Flags().StringVar(&args.shard, "shard", "", "only compare shard i/n of the pairs and write a partial report for merge")
+
+	rootCmd.AddCommand(newCompareCommand(args))
+	rootCmd.AddCommand(newFindCommand(args))
+	rootCmd.AddCommand(newExplainCommand(args))
+	rootCmd.AddCommand(newReportDiffCommand())
+	rootCmd.AddCommand(newMergeCommand(args))
+	rootCmd.AddCommand(newFixCommand(args))
+	rootCmd.AddCommand(newSiblingsCommand(args))
+	rootCmd.AddCommand(newHistoryCommand(args))
+	rootCmd.AddCommand(newSchemaCommand())
 
 	return rootCmd
 }
 
+// addAnalysisFlags registers the flags shared by every command that runs an analysis.
+func addAnalysisFlags(cmd *cobra.Command, args *CLIArgs) {
+	cmd.Flags().StringVarP(&args.configFile, "config", "c", "", "config file path")
+	cmd.Flags().StringVarP(&args.output, "output", "o", "", "output file (default: stdout)")
+	cmd.Flags().BoolVarP(&args.verbose, "verbose", "v", false, "verbose output")
+
+	// Allow overriding config values via flags - will be parsed in applyFlagOverrides
+	cmd.Flags().Float64P("threshold", "t", 0, "similarity threshold (0.0-1.0)")
+	cmd.Flags().StringP("format", "f", "", "output format (json|yaml)")
+	cmd.Flags().IntP("workers", "w", 0, "number of parallel workers")
+	cmd.Flags().Bool("cache", false, "enable caching")
+	cmd.Flags().String("ignore", "", "ignore file path")
+	cmd.Flags().Int("min-lines", 0, "minimum function lines to analyze")
+}
+
+// addGroupingFlags registers the flags selecting how similar pairs are clustered and
+// which groups are reported.
+func addGroupingFlags(cmd *cobra.Command) {
+	cmd.Flags().String(
+		"grouping",
+		"",
+		"grouping strategy ("+strings.Join(config.GroupingStrategies(), "|")+", default: components)",
+	)
+	cmd.Flags().Int("top", 0, "only report the N groups with the highest refactoring value")
+}
+
+// addUnitsFlag registers the flag selecting the kinds of declarations compared.
+func addUnitsFlag(cmd *cobra.Command) {
+	cmd.Flags().StringSlice(
+		"units",
+		nil,
+		"comma-separated kinds of declarations to compare ("+strings.Join(config.AnalysisUnits(), ",")+", default: funcs)",
+	)
+}
+
+// addFailOnFlag registers the flag failing the command when the report breaks rules.
+func addFailOnFlag(cmd *cobra.Command) {
+	cmd.Flags().StringSlice(
+		"fail-on",
+		nil,
+		"exit with an error when the report has findings of these comma-separated rules ("+
+			strings.Join(analyzer.Rules(), ",")+")",
+	)
+}
+
+// checkRules returns an error when report has findings of the rules given by --fail-on,
+// after the report has been written. Usage is not printed for such errors.
+func checkRules(cmd *cobra.Command, report *analyzer.Report) error {
+	rules, _ := cmd.Flags().GetStringSlice("fail-on")
+
+	var broken []string
+	for _, rule := range rules {
+		if count := report.Violations(rule); count > 0 {
+			broken = append(broken, fmt.Sprintf("%s (%d)", rule, count))
+		}
+	}
+	if len(broken) == 0 {
+		return nil
+	}
+
+	cmd.SilenceUsage = true
+	return fmt.Errorf("report breaks rules: %s", strings.Join(broken, ", "))
+}
+
 func applyFlagOverrides(cfg *config.Config, cmd *cobra.Command) error {
 	// Apply flag overrides to configuration
 	if threshold, _ := cmd.Flags().GetFloat64("threshold"); threshold > 0 {
@@ -98,6 +164,21 @@ func applyFlagOverrides(cfg *config.Config, cmd *cobra.Command) error {
 	if minLines, _ := cmd.Flags().GetInt("min-lines"); minLines > 0 {
 		cfg.CLI.DefaultMinLines = minLines
 	}
+	if grouping, _ := cmd.Flags().GetString("grouping"); grouping != "" {
+		cfg.Processing.Grouping = grouping
+	}
+	if top, _ := cmd.Flags().GetInt("top"); cmd.Flags().Changed("top") {
+		cfg.Output.Top = top
+	}
+	if units, _ := cmd.Flags().GetStringSlice("units"); cmd.Flags().Changed("units") {
+		cfg.Processing.Units = units
+	}
+	rules, _ := cmd.Flags().GetStringSlice("fail-on")
+	for _, rule := range rules {
+		if !slices.Contains(analyzer.Rules(), rule) {
+			return fmt.Errorf("fail-on must list rules among %s, got %q", strings.Join(analyzer.Rules(), ", "), rule)
+		}
+	}
 
 	return cfg.Validate()
 }
@@ -109,25 +190,37 @@ func runSimilarityCheck(args *CLIArgs, cmd *cobra.Command, targets []string) err
 		return err
 	}
 
-	// Initialize parser and detector
-	parser := ast.NewParser()
-	detector := similarity.NewDetectorWithConfig(cfg.CLI.DefaultThreshold, cfg)
+	opts := analyzerOptions(cfg, args.verbose)
+	if args.shard != "" {
+		index, count, parseErr := parseShard(args.shard)
+		if parseErr != nil {
+			return parseErr
+		}
+		opts = append(opts, analyzer.WithShard(index, count))
+	}
 
-	// Parse all target files
-	allFunctions := parseAllTargets(parser, targets, cfg, args.verbose)
+	a, err := analyzer.New(opts...)
+	if err != nil {
+		return err
+	}
 
-	if args.verbose {
-		_, _ = fmt.Fprintf(os.Stderr, "[similarity-go] Found %d functions for analysis\n", len(allFunctions))
+	if args.shard != "" {
+		partial, partialErr := a.Partial(commandContext(cmd), targets)
+		if partialErr != nil {
+			return partialErr
+		}
+		return writeOutput(partial, cfg.CLI.DefaultFormat, args.output)
 	}
 
-	// Find similar functions
-	similarMatches, err := findSimilarFunctions(cfg, detector, allFunctions, args.verbose)
+	report, err := a.Analyze(commandContext(cmd), targets)
 	if err != nil {
 		return err
 	}
 
-	// Generate and output results
-	return generateAndOutputResults(allFunctions, similarMatches, cfg, args.output)
+	if writeErr := writeOutput(report, cfg.CLI.DefaultFormat, args.output); writeErr != nil {
+		return writeErr
+	}
+	return checkRules(cmd, report)
 }
 
 // loadAndConfigureSetup loads configuration and logs setup information.
@@ -158,53 +251,69 @@ func loadAndConfigureSetup(args *CLIArgs, cmd *cobra.Command, targets []string)
 	return cfg, nil
 }
 
-// findSimilarFunctions finds similar functions using appropriate processing method.
-func findSimilarFunctions(
-	cfg *config.Config,
-	detector *similarity.Detector,
-	allFunctions []*ast.Function,
-	verbose bool,
-) ([]similarity.Match, error) {
-	if cfg.CLI.DefaultWorkers > 1 {
-		return findSimilarFunctionsParallel(cfg, detector, allFunctions, verbose)
-	}
-
-	// Use serial processing
-	if verbose {
-		_, _ = fmt.Fprintf(os.Stderr, "[similarity-go] Using serial processing\n")
+// analyzerOptions translates a loaded configuration into analyzer options.
+// In verbose mode, diagnostics and progress are written to stderr.
+func analyzerOptions(cfg *config.Config, verbose bool) []analyzer.Option {
+	weights := cfg.Similarity.Weights
+	thresholds := cfg.Similarity.Thresholds
+	limits := cfg.Similarity.Limits
+
+	opts := []analyzer.Option{
+		analyzer.WithThreshold(cfg.CLI.DefaultThreshold),
+		analyzer.WithMinLines(cfg.CLI.DefaultMinLines),
+		analyzer.WithWorkers(cfg.CLI.DefaultWorkers),
+		analyzer.WithCache(cfg.CLI.DefaultCache),
+		analyzer.WithWeights(analyzer.Weights{
+			TreeEdit:           weights.TreeEdit,
+			TokenSimilarity:    weights.TokenSimilarity,
+			Structural:         weights.Structural,
+			Signature:          weights.Signature,
+			DifferentSignature: weights.DifferentSignature,
+			ControlFlow:        weights.ControlFlow,
+			Dependence:         weights.Dependence,
+			APIUsage:           weights.APIUsage,
+		}),
+		analyzer.WithAPIUsageIgnore(cfg.Similarity.APIUsage.Ignore...),
+		analyzer.WithNormalization(analyzer.Normalization(cfg.Similarity.Normalization)),
+		analyzer.WithThresholds(analyzer.Thresholds{
+			DefaultSimilarOperations: thresholds.DefaultSimilarOperations,
+			StatementCountPenalty:    thresholds.StatementCountPenalty,
+			MinSimilarity:            thresholds.MinSimilarity,
+		}),
+		analyzer.WithLimits(analyzer.Limits{
+			MaxSignatureLengthDiff: limits.MaxSignatureLengthDiff,
+			MaxLineDifferenceRatio: limits.MaxLineDifferenceRatio,
+			MaxCacheSize:           limits.MaxCacheSize,
+		}),
+		analyzer.WithMaxEmptyVsPopulated(cfg.Processing.MaxEmptyVsPopulated),
+		analyzer.WithGrouping(cfg.Processing.Grouping),
+		analyzer.WithUnits(cfg.Processing.Units...),
+		analyzer.WithTop(cfg.Output.Top),
+		analyzer.WithRefactorSuggestion(cfg.Output.RefactorSuggestion),
+		analyzer.WithIgnoreFile(cfg.Ignore.DefaultFile),
+	}
+
+	if len(cfg.Similarity.Metrics) > 0 {
+		metrics := make([]analyzer.MetricWeight, len(cfg.Similarity.Metrics))
+		for i, metric := range cfg.Similarity.Metrics {
+			metrics[i] = analyzer.MetricWeight(metric)
+		}
+		opts = append(opts, analyzer.WithMetrics(metrics...))
 	}
-	return detector.FindSimilarFunctions(allFunctions), nil
-}
 
-// findSimilarFunctionsParallel finds similar functions using parallel processing.
-func findSimilarFunctionsParallel(
-	cfg *config.Config,
-	detector *similarity.Detector,
-	allFunctions []*ast.Function,
-	verbose bool,
-) ([]similarity.Match, error) {
 	if verbose {
-		_, _ = fmt.Fprintf(
-			os.Stderr,
-			"[similarity-go] Using parallel processing with %d workers\n",
-			cfg.CLI.DefaultWorkers,
-		)
+		opts = append(opts, analyzer.WithLogger(os.Stderr), analyzer.WithProgress(createProgressCallback()))
 	}
 
-	// Create progress callback for verbose mode
-	var progressCallback func(completed, total int)
-	if verbose {
-		progressCallback = createProgressCallback()
-	}
+	return opts
+}
 
-	// Use worker for parallel processing
-	parallelWorker := worker.NewSimilarityWorker(detector, cfg.CLI.DefaultWorkers, cfg.CLI.DefaultThreshold)
-	similarMatches, parallelErr := parallelWorker.FindSimilarFunctions(allFunctions, progressCallback)
-	if parallelErr != nil {
-		return nil, fmt.Errorf("parallel similarity calculation failed: %w", parallelErr)
+// commandContext returns the context of cmd, which is unset when it runs outside Execute.
+func commandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
 	}
-
-	return similarMatches, nil
+	return context.Background()
 }
 
 // createProgressCallback creates a progress callback function for verbose output.
@@ -220,32 +329,8 @@ func createProgressCallback() func(completed, total int) {
 	}
 }
 
-// generateAndOutputResults generates output data and writes it.
-func generateAndOutputResults(
-	allFunctions []*ast.Function,
-	similarMatches []similarity.Match,
-	cfg *config.Config,
-	outputPath string,
-) error {
-	// Group similar matches for better output formatting
-	similarGroups := groupSimilarMatches(similarMatches)
-
-	// Prepare output
-	output := map[string]any{
-		"summary": map[string]any{
-			"total_functions":    len(allFunctions),
-			"similar_groups":     len(similarGroups),
-			"total_duplications": countDuplications(similarGroups),
-		},
-		"similar_groups": formatSimilarGroups(similarGroups, cfg),
-	}
-
-	// Output results
-	return writeOutput(output, cfg.CLI.DefaultFormat, outputPath)
-}
-
 // writeOutput writes the given output in the specified format to the given output path.
-func writeOutput(output map[string]any, format, outputPath string) error {
+func writeOutput(output any, format, outputPath string) error {
 	outputWriter := os.Stdout
 	if outputPath != "" {
 		file, createErr := os.Create(outputPath)
@@ -276,413 +361,3 @@ func writeOutput(output map[string]any, format, outputPath string) error {
 
 	return nil
 }
-
-// buildSimilarityGraph creates a graph of function similarities from matches.
-func buildSimilarityGraph(
-	matches []similarity.Match,
-) (map[string]map[string]similarity.Match, map[string]*ast.Function) {
-	functionGraph := make(map[string]map[string]similarity.Match)
-	allFunctions := make(map[string]*ast.Function)
-
-	for _, match := range matches {
-		hash1 := match.Function1.Hash()
-		hash2 := match.Function2.Hash()
-
-		// Store functions by hash
-		allFunctions[hash1] = match.Function1
-		allFunctions[hash2] = match.Function2
-
-		// Add edges in both directions
-		if functionGraph[hash1] == nil {
-			functionGraph[hash1] = make(map[string]similarity.Match)
-		}
-		if functionGraph[hash2] == nil {
-			functionGraph[hash2] = make(map[string]similarity.Match)
-		}
-
-		functionGraph[hash1][hash2] = match
-		functionGraph[hash2][hash1] = similarity.Match{
-			Function1:  match.Function2,
-			Function2:  match.Function1,
-			Similarity: match.Similarity,
-		}
-	}
-
-	return functionGraph, allFunctions
-}
-
-// groupSimilarMatches groups similar matches by functions that appear together.
-// Uses transitive clustering to group functions that are similar to each other.
-func groupSimilarMatches(matches []similarity.Match) [][]similarity.Match {
-	if len(matches) == 0 {
-		return nil
-	}
-
-	// Create a graph of function similarities
-	functionGraph, allFunctions := buildSimilarityGraph(matches)
-
-	// Use Union-Find (Disjoint Set) to group connected components
-	groups := findConnectedGroups(functionGraph, allFunctions)
-
-	// Convert to the required format
-	return convertGroupsToMatches(groups, functionGraph)
-}
-
-// convertGroupsToMatches converts function groups to similarity match groups.
-func convertGroupsToMatches(
-	groups [][]*ast.Function,
-	functionGraph map[string]map[string]similarity.Match,
-) [][]similarity.Match {
-	var result [][]similarity.Match
-
-	for _, group := range groups {
-		const minGroupSize = 2
-		if len(group) < minGroupSize {
-			continue // Skip single function groups
-		}
-
-		var groupMatches []similarity.Match
-		added := make(map[string]bool)
-
-		// Generate all pairwise matches within the group
-		for i, func1 := range group {
-			for j := i + 1; j < len(group); j++ {
-				func2 := group[j]
-				hash1 := func1.Hash()
-				hash2 := func2.Hash()
-
-				// Avoid duplicate matches
-				key := generateMatchKey(hash1, hash2)
-
-				if !added[key] {
-					if match, exists := functionGraph[hash1][hash2]; exists {
-						groupMatches = append(groupMatches, match)
-						added[key] = true
-					}
-				}
-			}
-		}
-
-		if len(groupMatches) > 0 {
-			result = append(result, groupMatches)
-		}
-	}
-
-	return result
-}
-
-// generateMatchKey creates a consistent key for a pair of function hashes.
-func generateMatchKey(hash1, hash2 string) string {
-	return mathutil.CreateConsistentKey(hash1, hash2)
-}
-
-// findConnectedGroups uses DFS to find connected components in the similarity graph.
-func findConnectedGroups(
-	graph map[string]map[string]similarity.Match,
-	allFunctions map[string]*ast.Function,
-) [][]*ast.Function {
-	visited := make(map[string]bool)
-	var groups [][]*ast.Function
-
-	// Perform DFS from each unvisited node
-	for functionHash := range allFunctions {
-		if !visited[functionHash] {
-			var group []*ast.Function
-			dfsVisit(graph, allFunctions, functionHash, visited, &group)
-			if len(group) > 1 { // Only include groups with multiple functions
-				groups = append(groups, group)
-			}
-		}
-	}
-
-	return groups
-}
-
-// dfsVisit performs depth-first search to collect all connected functions.
-func dfsVisit(graph map[string]map[string]similarity.Match, allFunctions map[string]*ast.Function,
-	currentHash string, visited map[string]bool, group *[]*ast.Function) {
-	visited[currentHash] = true
-	if function, exists := allFunctions[currentHash]; exists {
-		*group = append(*group, function)
-	}
-
-	// Visit all connected functions
-	if neighbors, exists := graph[currentHash]; exists {
-		for neighborHash := range neighbors {
-			if !visited[neighborHash] {
-				dfsVisit(graph, allFunctions, neighborHash, visited, group)
-			}
-		}
-	}
-}
-
-// countDuplications counts the total number of unique duplicate functions across all groups.
-func countDuplications(groups [][]similarity.Match) int {
-	uniqueFunctions := make(map[string]bool)
-
-	for _, group := range groups {
-		for _, match := range group {
-			// Use function hash to identify unique functions
-			uniqueFunctions[match.Function1.Hash()] = true
-			uniqueFunctions[match.Function2.Hash()] = true
-		}
-	}
-
-	return len(uniqueFunctions)
-}
-
-// formatSimilarGroups formats similarity groups for output.
-func formatSimilarGroups(groups [][]similarity.Match, cfg *config.Config) []map[string]any {
-	var result []map[string]any
-
-	for i, group := range groups {
-		if len(group) == 0 {
-			continue
-		}
-
-		// For now, each group contains one match (pair of similar functions)
-		match := group[0]
-
-		functions := []map[string]any{
-			{
-				"file":       match.Function1.File,
-				"function":   match.Function1.Name,
-				"start_line": match.Function1.StartLine,
-				"end_line":   match.Function1.EndLine,
-				"hash":       match.Function1.Hash(),
-			},
-			{
-				"file":       match.Function2.File,
-				"function":   match.Function2.Name,
-				"start_line": match.Function2.StartLine,
-				"end_line":   match.Function2.EndLine,
-				"hash":       match.Function2.Hash(),
-			},
-		}
-
-		groupData := map[string]any{
-			"id":                  fmt.Sprintf("group_%d", i+1),
-			"similarity_score":    match.Similarity,
-			"functions":           functions,
-			"refactor_suggestion": cfg.Output.RefactorSuggestion,
-		}
-
-		result = append(result, groupData)
-	}
-
-	return result
-}
-
-// parseGoFile parses a single Go file and returns functions that meet the minimum line criteria.
-func parseGoFile(parser *ast.Parser, filePath string, cfg *config.Config, _ bool) ([]*ast.Function, error) {
-	result := parser.ParseFile(filePath)
-	if result.IsErr() {
-		return nil, result.Error()
-	}
-
-	parseResult := result.Unwrap()
-	var functions []*ast.Function
-
-	// Filter functions by minimum lines
-	for _, fn := range parseResult.Functions {
-		if fn.LineCount >= cfg.CLI.DefaultMinLines {
-			functions = append(functions, fn)
-		}
-	}
-
-	return functions, nil
-}
-
-// parseAllTargets parses all target files and directories, returning all functions.
-// Errors from individual targets are logged but do not stop processing.
-func parseAllTargets(parser *ast.Parser, targets []string, cfg *config.Config, verbose bool) []*ast.Function {
-	var allFunctions []*ast.Function
-
-	for _, target := range targets {
-		if verbose {
-			_, _ = fmt.Fprintf(os.Stderr, "[similarity-go] Parsing target: %s\n", target)
-		}
-
-		// Process target (file or directory)
-		var functions []*ast.Function
-		var parseErr error
-
-		if strings.HasSuffix(target, ".go") {
-			functions, parseErr = parseGoFile(parser, target, cfg, verbose)
-		} else {
-			functions, parseErr = scanDirectory(parser, target, cfg, verbose)
-		}
-
-		if parseErr != nil {
-			if verbose {
-				_, _ = fmt.Fprintf(os.Stderr, "[similarity-go] Error processing %s: %v\n", target, parseErr)
-			}
-			continue
-		}
-
-		allFunctions = append(allFunctions, functions...)
-	}
-
-	return allFunctions
-}
-
-// scanDirectory recursively scans a directory for Go files and parses them.
-func scanDirectory(parser *ast.Parser, dirPath string, cfg *config.Config, verbose bool) ([]*ast.Function, error) {
-	info, err := os.Stat(dirPath)
-	if err != nil {
-		return nil, fmt.Errorf("cannot access %s: %w", dirPath, err)
-	}
-
-	if !info.IsDir() {
-		return parseGoFile(parser, dirPath, cfg, verbose)
-	}
-
-	var allFunctions []*ast.Function
-	walkFunc := createWalkFunc(parser, cfg, &allFunctions, verbose)
-
-	err = filepath.Walk(dirPath, walkFunc)
-	if err != nil {
-		return nil, fmt.Errorf("error walking directory %s: %w", dirPath, err)
-	}
-
-	return allFunctions, nil
-}
-
-// createWalkFunc creates a filepath.WalkFunc for directory traversal.
-func createWalkFunc(
-	parser *ast.Parser,
-	cfg *config.Config,
-	allFunctions *[]*ast.Function,
-	verbose bool,
-) filepath.WalkFunc {
-	return func(path string, _ os.FileInfo, err error) error {
-		if err != nil {
-			if verbose {
-				_, _ = fmt.Fprintf(os.Stderr, "[similarity-go] Error accessing %s: %v\n", path, err)
-			}
-			return nil
-		}
-
-		if !strings.HasSuffix(path, ".go") {
-			return nil
-		}
-
-		if shouldIgnoreFile(path, cfg) {
-			if verbose {
-				_, _ = fmt.Fprintf(os.Stderr, "[similarity-go] Ignoring %s\n", path)
-			}
-			return nil
-		}
-
-		return processGoFile(parser, path, cfg, allFunctions, verbose)
-	}
-}
-
-// processGoFile parses a Go file and adds functions to the collection.
-func processGoFile(
-	parser *ast.Parser,
-	path string,
-	cfg *config.Config,
-	allFunctions *[]*ast.Function,
-	verbose bool,
-) error {
-	if verbose {
-		_, _ = fmt.Fprintf(os.Stderr, "[similarity-go] Parsing file: %s\n", path)
-	}
-
-	functions, parseErr := parseGoFile(parser, path, cfg, verbose)
-	if parseErr != nil {
-		if verbose {
-			_, _ = fmt.Fprintf(os.Stderr, "[similarity-go] Error parsing %s: %v\n", path, parseErr)
-		}
-		return nil
-	}
-
-	*allFunctions = append(*allFunctions, functions...)
-	return nil
-}
-
-// shouldIgnoreFile determines if a file should be ignored based on configuration.
-func shouldIgnoreFile(filePath string, cfg *config.Config) bool {
-	// Skip hidden files and directories
-	base := filepath.Base(filePath)
-	if strings.HasPrefix(base, ".") {
-		return true
-	}
-
-	// Skip vendor directories
-	if strings.Contains(filePath, "/vendor/") || strings.Contains(filePath, "\\vendor\\") {
-		return true
-	}
-
-	// Skip common build/output directories
-	ignoreDirs := []string{"/bin/", "/build/", "/dist/", "/target/", "/.git/"}
-	for _, ignoreDir := range ignoreDirs {
-		if strings.Contains(filePath, ignoreDir) ||
-			strings.Contains(filePath, strings.ReplaceAll(ignoreDir, "/", "\\")) {
-			return true
-		}
-	}
-
-	// Check .similarityignore file patterns
-	if cfg.Ignore.DefaultFile != "" {
-		return matchesIgnorePatterns(filePath, cfg.GetIgnoreFilePath())
-	}
-
-	return false
-}
-
-// matchesIgnorePatterns checks if a file path matches any patterns in the ignore file.
-func matchesIgnorePatterns(filePath, ignoreFilePath string) bool {
-	ignoreFile, err := os.Open(ignoreFilePath)
-	if err != nil {
-		// If ignore file doesn't exist or can't be read, don't ignore anything
-		return false
-	}
-	defer ignoreFile.Close()
-
-	scanner := bufio.NewScanner(ignoreFile)
-	for scanner.Scan() {
-		pattern := strings.TrimSpace(scanner.Text())
-
-		// Skip empty lines and comments
-		if pattern == "" || strings.HasPrefix(pattern, "#") {
-			continue
-		}
-
-		// Check if pattern matches the file path
-		if matchesPattern(filePath, pattern) {
-			return true
-		}
-	}
-
-	return false
-}
-
-// matchesPattern checks if a file path matches a glob-like pattern.
-func matchesPattern(filePath, pattern string) bool {
-	// Normalize path separators
-	filePath = filepath.ToSlash(filePath)
-	pattern = filepath.ToSlash(pattern)
-
-	// Handle simple wildcards and exact matches
-	matched, err := filepath.Match(pattern, filepath.Base(filePath))
-	if err == nil && matched {
-		return true
-	}
-
-	// Check if pattern matches anywhere in the path
-	if strings.Contains(filePath, pattern) {
-		return true
-	}
-
-	// Handle directory patterns (ending with /)
-	if strings.HasSuffix(pattern, "/") {
-		dirPattern := strings.TrimSuffix(pattern, "/")
-		if strings.Contains(filePath, "/"+dirPattern+"/") || strings.HasPrefix(filePath, dirPattern+"/") {
-			return true
-		}
-	}
-
-	return false
-}
@@ -9,9 +9,7 @@ import (
 
 	"encoding/json"
 
-	"github.com/paveg/similarity-go/internal/ast"
 	"github.com/paveg/similarity-go/internal/config"
-	"github.com/paveg/similarity-go/internal/similarity"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
@@ -187,10 +185,12 @@ func TestApplyFlagOverrides(t *testing.T) {
 	// Create a command with flags set
 	args := &CLIArgs{}
 	cmd := newRootCommand(args)
-	cmd.SetArgs([]string{"--threshold", "0.9", "--format", "yaml", "./testdata"})
+	flags := []string{"--threshold", "0.9", "--format", "yaml", "--grouping", "cliques", "--top", "5",
+		"--units", "funcs,types", "./testdata"}
+	cmd.SetArgs(flags)
 
 	// Parse the flags
-	err := cmd.ParseFlags([]string{"--threshold", "0.9", "--format", "yaml", "./testdata"})
+	err := cmd.ParseFlags(flags)
 	if err != nil {
 		t.Fatalf("Failed to parse flags: %v", err)
 	}
@@ -208,112 +208,25 @@ func TestApplyFlagOverrides(t *testing.T) {
 	if cfg.CLI.DefaultFormat != "yaml" {
 		t.Errorf("Expected format yaml, got %s", cfg.CLI.DefaultFormat)
 	}
-}
-
-func TestGenerateMatchKey(t *testing.T) {
-	key1 := generateMatchKey("abc", "def")
-	key2 := generateMatchKey("def", "abc")
-
-	// Should generate the same key regardless of order
-	if key1 != key2 {
-		t.Errorf("Expected same key, got %s and %s", key1, key2)
-	}
 
-	// Should contain both hashes
-	if !strings.Contains(key1, "abc") || !strings.Contains(key1, "def") {
-		t.Errorf("Key %s should contain both hashes", key1)
+	if cfg.Processing.Grouping != config.GroupingCliques {
+		t.Errorf("Expected grouping cliques, got %s", cfg.Processing.Grouping)
 	}
-}
 
-func TestMatchesPattern(t *testing.T) {
-	tests := []struct {
-		name     string
-		path     string
-		pattern  string
-		expected bool
-	}{
-		{
-			name:     "exact match",
-			path:     "test.go",
-			pattern:  "test.go",
-			expected: true,
-		},
-		{
-			name:     "wildcard match",
-			path:     "main_test.go",
-			pattern:  "*_test.go",
-			expected: true,
-		},
-		{
-			name:     "no match",
-			path:     "main.go",
-			pattern:  "*_test.go",
-			expected: false,
-		},
-		{
-			name:     "directory pattern with slash",
-			path:     "vendor/pkg/file.go",
-			pattern:  "vendor/",
-			expected: true,
-		},
-		{
-			name:     "contains pattern",
-			path:     "vendor/pkg/file.go",
-			pattern:  "vendor",
-			expected: true,
-		},
+	if cfg.Output.Top != 5 {
+		t.Errorf("Expected top 5, got %d", cfg.Output.Top)
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := matchesPattern(tt.path, tt.pattern)
-			if result != tt.expected {
-				t.Errorf("matchesPattern(%s, %s) = %v, expected %v",
-					tt.path, tt.pattern, result, tt.expected)
-			}
-		})
+	if !cfg.Processing.HasUnit(config.UnitFuncs) || !cfg.Processing.HasUnit(config.UnitTypes) {
+		t.Errorf("Expected units funcs and types, got %v", cfg.Processing.Units)
 	}
-}
-
-func TestShouldIgnoreFile(t *testing.T) {
-	cfg := config.Default()
-	cfg.Ignore.Patterns = []string{"*_test.go", "vendor/", ".git/"}
 
-	tests := []struct {
-		name     string
-		path     string
-		expected bool
-	}{
-		{
-			name:     "should ignore hidden files",
-			path:     ".hidden",
-			expected: true,
-		},
-		{
-			name:     "should ignore vendor files",
-			path:     "some/vendor/pkg/file.go",
-			expected: true,
-		},
-		{
-			name:     "should not ignore regular go files",
-			path:     "main.go",
-			expected: false,
-		},
-		{
-			name:     "should ignore git directories",
-			path:     "some/.git/config",
-			expected: true,
-		},
+	// Unknown strategies are rejected by validation
+	if parseErr := cmd.ParseFlags([]string{"--grouping", "single-linkage"}); parseErr != nil {
+		t.Fatalf("Failed to parse flags: %v", parseErr)
 	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := shouldIgnoreFile(tt.path, cfg)
-			if result != tt.expected {
-				t.Errorf("shouldIgnoreFile(%s) = %v, expected %v",
-					tt.path, result, tt.expected)
-			}
-		})
+	if overrideErr := applyFlagOverrides(config.Default(), cmd); overrideErr == nil {
+		t.Error("Expected error for unknown grouping strategy")
 	}
 }
 
@@ -363,197 +276,6 @@ func TestCLIArgs(t *testing.T) {
 	}
 }
 
-func TestParseAllTargets(t *testing.T) {
-	parser := ast.NewParser()
-	cfg := config.Default()
-
-	// Test with empty targets
-	functions := parseAllTargets(parser, []string{}, cfg, false)
-	if len(functions) != 0 {
-		t.Errorf("Expected 0 functions for empty targets, got %d", len(functions))
-	}
-
-	// Test with invalid target (should be skipped)
-	functions = parseAllTargets(parser, []string{"nonexistent.go"}, cfg, true)
-	if len(functions) != 0 {
-		t.Errorf("Expected 0 functions for nonexistent file, got %d", len(functions))
-	}
-
-	// Test with testdata directory (may not exist)
-	functions = parseAllTargets(parser, []string{"./testdata"}, cfg, true)
-	// Should return empty slice for nonexistent directory, not nil
-	if len(functions) != 0 {
-		t.Errorf("Expected 0 functions for nonexistent testdata directory, got %d", len(functions))
-	}
-}
-
-func TestParseGoFile(t *testing.T) {
-	parser := ast.NewParser()
-	cfg := config.Default()
-
-	// Test with nonexistent file
-	functions, err := parseGoFile(parser, "nonexistent.go", cfg, false)
-	if err == nil {
-		t.Error("Expected error for nonexistent file")
-	}
-	if len(functions) != 0 {
-		t.Errorf("Expected 0 functions for nonexistent file, got %d", len(functions))
-	}
-}
-
-func TestScanDirectory(t *testing.T) {
-	parser := ast.NewParser()
-	cfg := config.Default()
-
-	// Test with nonexistent directory
-	functions, err := scanDirectory(parser, "nonexistent", cfg, false)
-	if err == nil {
-		t.Error("Expected error for nonexistent directory")
-	}
-	if len(functions) != 0 {
-		t.Errorf("Expected 0 functions for nonexistent directory, got %d", len(functions))
-	}
-
-	// Test with current directory (should find some Go files)
-	functions, err = scanDirectory(parser, ".", cfg, true)
-	if err != nil {
-		t.Errorf("Unexpected error scanning current directory: %v", err)
-	}
-	// Should return valid functions slice
-	if functions == nil {
-		t.Error("Expected non-nil functions slice from current directory")
-	}
-}
-
-func TestMatchesIgnorePatterns(t *testing.T) {
-	tempDir := t.TempDir()
-	ignoreFile := filepath.Join(tempDir, ".gitignore")
-
-	// Create ignore file with patterns
-	content := "vendor/\n*.tmp\n# comment line\n\ntest_data/"
-	err := os.WriteFile(ignoreFile, []byte(content), 0644)
-	if err != nil {
-		t.Fatalf("Failed to create ignore file: %v", err)
-	}
-
-	tests := []struct {
-		name     string
-		path     string
-		expected bool
-	}{
-		{
-			name:     "matches vendor pattern",
-			path:     "vendor/pkg/file.go",
-			expected: true,
-		},
-		{
-			name:     "matches tmp pattern",
-			path:     "temp.tmp",
-			expected: true,
-		},
-		{
-			name:     "matches test_data pattern",
-			path:     "test_data/file.go",
-			expected: true,
-		},
-		{
-			name:     "no match",
-			path:     "main.go",
-			expected: false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := matchesIgnorePatterns(tt.path, ignoreFile)
-			if result != tt.expected {
-				t.Errorf("matchesIgnorePatterns(%s, %s) = %v, expected %v",
-					tt.path, ignoreFile, result, tt.expected)
-			}
-		})
-	}
-
-	// Test with nonexistent ignore file
-	result := matchesIgnorePatterns("main.go", "nonexistent.gitignore")
-	if result != false {
-		t.Error("Expected false for nonexistent ignore file")
-	}
-}
-
-func TestGroupSimilarMatches(t *testing.T) {
-	// Create mock functions for testing
-	func1 := &ast.Function{Name: "func1", File: "file1.go"}
-	func2 := &ast.Function{Name: "func2", File: "file2.go"}
-	func3 := &ast.Function{Name: "func3", File: "file3.go"}
-
-	// Test with empty matches
-	matches := []similarity.Match{}
-	groups := groupSimilarMatches(matches)
-	if groups != nil {
-		t.Errorf("Expected nil groups for empty matches, got %d groups", len(groups))
-	}
-
-	// Test with single match
-	matches = []similarity.Match{
-		{Function1: func1, Function2: func2, Similarity: 0.8},
-	}
-	groups = groupSimilarMatches(matches)
-	if len(groups) != 1 {
-		t.Errorf("Expected 1 group for single match, got %d", len(groups))
-	}
-	if len(groups[0]) != 1 {
-		t.Errorf("Expected 1 match in group, got %d", len(groups[0]))
-	}
-
-	// Test with multiple matches forming chain
-	matches = []similarity.Match{
-		{Function1: func1, Function2: func2, Similarity: 0.8},
-		{Function1: func2, Function2: func3, Similarity: 0.9},
-	}
-	groups = groupSimilarMatches(matches)
-	if len(groups) != 1 {
-		t.Errorf("Expected 1 group for chained matches, got %d", len(groups))
-	}
-	if len(groups[0]) != 2 {
-		t.Errorf("Expected 2 matches in group, got %d", len(groups[0]))
-	}
-}
-
-func TestCountDuplications(t *testing.T) {
-	func1 := &ast.Function{Name: "func1", File: "file1.go"}
-	func2 := &ast.Function{Name: "func2", File: "file2.go"}
-	func3 := &ast.Function{Name: "func3", File: "file3.go"}
-
-	// Test empty groups
-	groups := [][]similarity.Match{}
-	count := countDuplications(groups)
-	if count != 0 {
-		t.Errorf("Expected 0 duplications for empty groups, got %d", count)
-	}
-
-	// Test single group with matches
-	groups = [][]similarity.Match{
-		{
-			{Function1: func1, Function2: func2, Similarity: 0.8},
-			{Function1: func2, Function2: func3, Similarity: 0.9},
-		},
-	}
-	count = countDuplications(groups)
-	if count != 3 {
-		t.Errorf("Expected 3 unique functions, got %d", count)
-	}
-
-	// Test multiple groups
-	groups = [][]similarity.Match{
-		{{Function1: func1, Function2: func2, Similarity: 0.8}}, // 2 functions
-		{{Function1: func3, Function2: func1, Similarity: 0.7}}, // 1 new function (func3)
-	}
-	count = countDuplications(groups)
-	if count != 3 {
-		t.Errorf("Expected 3 total unique functions, got %d", count)
-	}
-}
-
 func TestParallelProcessing(t *testing.T) {
 	// Create temporary test files
 	tempDir := t.TempDir()
@@ -593,6 +315,59 @@ func sum(x, y int) int {
 	// The JSON output in the test log shows it's working correctly
 }
 
+func TestIdenticalCopiesStayDistinct(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Identical copies share their structural hash, but not their location
+	source := `package main
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+`
+	for _, name := range []string{"a.go", "b.go"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(source), 0o600); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+	}
+
+	outputPath := filepath.Join(tempDir, "report.json")
+	cmd := newRootCommand(&CLIArgs{})
+	cmd.SetArgs([]string{"--min-lines", "3", "--output", outputPath, tempDir})
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	var report struct {
+		SimilarGroups []struct {
+			Functions []struct {
+				File string `json:"file"`
+			} `json:"functions"`
+		} `json:"similar_groups"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+
+	if len(report.SimilarGroups) != 1 || len(report.SimilarGroups[0].Functions) != 2 {
+		t.Fatalf("expected one group of both copies, got %+v", report.SimilarGroups)
+	}
+	if files := report.SimilarGroups[0].Functions; files[0].File == files[1].File {
+		t.Errorf("expected copies from different files, got %+v", files)
+	}
+}
+
 func TestProgressCallback(t *testing.T) {
 	// Test progress callback functionality
 	callback := createProgressCallback()
@@ -728,3 +503,67 @@ func TestFlagValidation(t *testing.T) {
 		t.Error("expected error with invalid format")
 	}
 }
+
+func TestFailOnRules(t *testing.T) {
+	source := `package sample
+
+func TotalWidth(boxes []Box) int {
+	sum := 0
+	for _, box := range boxes {
+		sum += box.Width
+		sum += box.Padding
+	}
+	return sum
+}
+
+func TotalHeight(boxes []Box) int {
+	total := 0
+	for _, box := range boxes {
+		total += box.Height
+		sum += box.Padding
+	}
+	return total
+}
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "boxes.go")
+	if err := os.WriteFile(path, []byte(source), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		rules       string
+		expectError string
+	}{
+		{name: "no rule", rules: ""},
+		{name: "broken rule", rules: "inconsistent-rename", expectError: "inconsistent-rename (1)"},
+		{name: "unknown rule", rules: "typos", expectError: "fail-on must list rules"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := []string{"--min-lines", "3", "-o", filepath.Join(dir, "report.json"), path}
+			if tt.rules != "" {
+				args = append([]string{"--fail-on", tt.rules}, args...)
+			}
+
+			var buf bytes.Buffer
+			cmd := newRootCommand(&CLIArgs{})
+			cmd.SetOut(&buf)
+			cmd.SetErr(&buf)
+			cmd.SetArgs(args)
+
+			err := cmd.Execute()
+			if tt.expectError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
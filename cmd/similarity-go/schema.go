@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+func newSchemaCommand() *cobra.Command {
+	var outputPath string
+
+	schemaCmd := &cobra.Command{
+		Use:   "schema [report|find|explain|diff|partial|siblings|history]",
+		Short: "Print the JSON Schema of the report format",
+		Long: fmt.Sprintf(`Print the JSON Schema describing the JSON/YAML output of a command.
+
+Every report carries a schema_version field (currently %s). The minor version
+grows when fields are added; the major version changes when fields are removed
+or change meaning, so consumers only need to check the major version.
+
+Kinds:
+  report   output of the default command and of compare (default)
+  find     output of find
+  explain  output of explain --format json|yaml
+  diff     output of report-diff --format json|yaml
+  partial  output of the default command with --shard
+  siblings output of siblings
+  history  output of history`, analyzer.SchemaVersion),
+		Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: analyzer.SchemaKinds(),
+		RunE: func(cmd *cobra.Command, kinds []string) error {
+			kind := analyzer.SchemaKindReport
+			if len(kinds) > 0 {
+				kind = kinds[0]
+			}
+			return runSchema(cmd, kind, outputPath)
+		},
+	}
+
+	schemaCmd.Flags().StringVarP(&outputPath, "output", "o", "", "output file (default: stdout)")
+
+	return schemaCmd
+}
+
+func runSchema(cmd *cobra.Command, kind, outputPath string) error {
+	schema, err := analyzer.JSONSchema(kind)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if outputPath != "" {
+		file, createErr := os.Create(outputPath)
+		if createErr != nil {
+			return fmt.Errorf("failed to create output file: %w", createErr)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if encodeErr := encoder.Encode(schema); encodeErr != nil {
+		return fmt.Errorf("failed to encode JSON schema: %w", encodeErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+func TestSchemaCommand(t *testing.T) {
+	cmd := newRootCommand(&CLIArgs{})
+	cmd.SetArgs([]string{"schema", "find"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("schema command failed: %v", err)
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	if schema["title"] != "similarity-go find result" {
+		t.Errorf("unexpected schema title %v", schema["title"])
+	}
+
+	cmd = newRootCommand(&CLIArgs{})
+	cmd.SetArgs([]string{"schema", "unknown"})
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error for unknown schema kind")
+	}
+}
+
+func TestReportRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(compareTestSource), 0o600); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+	}
+
+	for _, format := range []string{"json", "yaml"} {
+		outputFile := filepath.Join(t.TempDir(), "report."+format)
+
+		cmd := newRootCommand(&CLIArgs{})
+		cmd.SetArgs([]string{dir, "--min-lines", "3", "--format", format, "--output", outputFile})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("analysis failed: %v", err)
+		}
+
+		file, err := os.Open(outputFile)
+		if err != nil {
+			t.Fatalf("failed to open report: %v", err)
+		}
+
+		report, err := analyzer.ReadReport(file)
+		file.Close()
+		if err != nil {
+			t.Fatalf("%s report could not be read back: %v", format, err)
+		}
+
+		if report.SchemaVersion != analyzer.SchemaVersion || len(report.SimilarGroups) != 1 {
+			t.Errorf("unexpected %s report %+v", format, report)
+		}
+	}
+}
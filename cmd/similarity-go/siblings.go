@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+// SiblingsArgs represents the arguments of the siblings command.
+type SiblingsArgs struct {
+	base string
+	head string
+	repo string
+}
+
+func newSiblingsCommand(args *CLIArgs) *cobra.Command {
+	siblingsArgs := &SiblingsArgs{}
+
+	siblingsCmd := &cobra.Command{
+		Use:   "siblings --base <dir|revision> --head <dir|revision>",
+		Short: "Warn about changes applied to only some copies of similar functions",
+		Long: `Find the similar groups of the base version whose members were changed
+inconsistently in the head version: some members were edited while their
+siblings were left untouched, as when a bug fix is applied to only one copy.
+
+Every warning lists the changed members with their changed lines, and the
+untouched siblings. Groups are formed from the base version as the default
+command would. Members are matched by file and receiver-qualified name, so
+moved, renamed or removed functions are not reported.
+
+--base and --head are directory trees, or revisions of the git repository
+given by --repo:
+  similarity-go siblings --base ./v1 --head ./v2
+  similarity-go siblings --repo . --base main --head HEAD`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runSiblings(args, siblingsArgs, cmd)
+		},
+	}
+
+	siblingsCmd.Flags().StringVar(&siblingsArgs.base, "base", "", "base directory, or revision with --repo")
+	siblingsCmd.Flags().StringVar(&siblingsArgs.head, "head", "", "head directory, or revision with --repo")
+	siblingsCmd.Flags().StringVar(&siblingsArgs.repo, "repo", "", "git repository whose revisions --base and --head are")
+	addAnalysisFlags(siblingsCmd, args)
+	addGroupingFlags(siblingsCmd)
+
+	return siblingsCmd
+}
+
+func runSiblings(args *CLIArgs, siblingsArgs *SiblingsArgs, cmd *cobra.Command) error {
+	if siblingsArgs.base == "" || siblingsArgs.head == "" {
+		return errors.New("both --base and --head are required")
+	}
+
+	targets := []string{siblingsArgs.base, siblingsArgs.head}
+
+	cfg, err := loadAndConfigureSetup(args, cmd, targets)
+	if err != nil {
+		return err
+	}
+
+	a, err := analyzer.New(analyzerOptions(cfg, args.verbose)...)
+	if err != nil {
+		return err
+	}
+
+	var report *analyzer.SiblingReport
+	if siblingsArgs.repo != "" {
+		report, err = a.SiblingRevisions(commandContext(cmd), siblingsArgs.repo, siblingsArgs.base, siblingsArgs.head)
+	} else {
+		report, err = a.Siblings(commandContext(cmd), siblingsArgs.base, siblingsArgs.head)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(report, cfg.CLI.DefaultFormat, args.output)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const siblingsTestSource = `package sample
+
+func SumInts(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func SumSizes(sizes []int) int {
+	total := 0
+	for _, v := range sizes {
+		total += v
+	}
+	return total
+}
+`
+
+func TestSiblingsCommand(t *testing.T) {
+	baseDir := t.TempDir()
+	headDir := t.TempDir()
+
+	head := strings.Replace(siblingsTestSource, "range values", "range values[1:]", 1)
+	for dir, source := range map[string]string{baseDir: siblingsTestSource, headDir: head} {
+		if err := os.WriteFile(filepath.Join(dir, "sum.go"), []byte(source), 0o600); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "siblings.json")
+
+	cmd := newRootCommand(&CLIArgs{})
+	cmd.SetArgs([]string{
+		"siblings",
+		"--base", baseDir,
+		"--head", headDir,
+		"--min-lines", "3",
+		"--output", outputFile,
+	})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("siblings command failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+
+	var report struct {
+		InconsistentChanges []struct {
+			Changed []struct {
+				Function struct {
+					File     string `json:"file"`
+					Function string `json:"function"`
+				} `json:"function"`
+			} `json:"changed"`
+			Untouched []struct {
+				Function string `json:"function"`
+			} `json:"untouched"`
+		} `json:"inconsistent_changes"`
+	}
+	if unmarshalErr := json.Unmarshal(content, &report); unmarshalErr != nil {
+		t.Fatalf("failed to parse output: %v", unmarshalErr)
+	}
+
+	if len(report.InconsistentChanges) != 1 {
+		t.Fatalf("expected one inconsistent change, got %s", content)
+	}
+	change := report.InconsistentChanges[0]
+	if len(change.Changed) != 1 || change.Changed[0].Function.Function != "SumInts" || change.Changed[0].Function.File != "sum.go" {
+		t.Errorf("expected SumInts to be changed, got %s", content)
+	}
+	if len(change.Untouched) != 1 || change.Untouched[0].Function != "SumSizes" {
+		t.Errorf("expected SumSizes to be untouched, got %s", content)
+	}
+
+	cmd = newRootCommand(&CLIArgs{})
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	cmd.SetArgs([]string{"siblings", "--base", baseDir})
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected an error without --head")
+	}
+}
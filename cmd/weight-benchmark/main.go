@@ -125,7 +125,7 @@ func pickBestWeights(
 	currentScore float64,
 	gridResult similarity.OptimizationResult,
 	geneticResult similarity.GeneticResult,
-) (string, config.SimilarityWeights, float64) {
+) (string, config.MetricWeights, float64) {
 	bestMethod := "Current Default"
 	bestWeights := defaultWeights()
 	bestScore := currentScore
@@ -145,24 +145,17 @@ func pickBestWeights(
 	return bestMethod, bestWeights, bestScore
 }
 
-func printRecommendations(out io.Writer, method string, bestWeights config.SimilarityWeights, bestScore float64) {
+func printRecommendations(out io.Writer, method string, bestWeights config.MetricWeights, bestScore float64) {
 	_, _ = fmt.Fprintln(out, "\n💡 STEP 6: Weight Recommendations")
 	_, _ = fmt.Fprintf(out, "🎖️  Best performing method: %s (Score: %.4f)\n", method, bestScore)
-	_, _ = fmt.Fprintln(out, "\n📋 Recommended Weight Configuration:")
-	_, _ = fmt.Fprintln(out, "const (")
-	_, _ = fmt.Fprintf(out, "    TreeEditWeight        = %.3f\n", bestWeights.TreeEdit)
-	_, _ = fmt.Fprintf(out, "    TokenSimilarityWeight = %.3f\n", bestWeights.TokenSimilarity)
-	_, _ = fmt.Fprintf(out, "    StructuralWeight      = %.3f\n", bestWeights.Structural)
-	_, _ = fmt.Fprintf(out, "    SignatureWeight       = %.3f\n", bestWeights.Signature)
-	_, _ = fmt.Fprintln(out, ")")
-
-	_, _ = fmt.Fprintln(out, "\n📄 YAML Configuration:")
+
+	_, _ = fmt.Fprintln(out, "\n📄 Recommended YAML Configuration:")
 	_, _ = fmt.Fprintln(out, "similarity:")
-	_, _ = fmt.Fprintln(out, "  weights:")
-	_, _ = fmt.Fprintf(out, "    tree_edit: %.3f\n", bestWeights.TreeEdit)
-	_, _ = fmt.Fprintf(out, "    token_similarity: %.3f\n", bestWeights.TokenSimilarity)
-	_, _ = fmt.Fprintf(out, "    structural: %.3f\n", bestWeights.Structural)
-	_, _ = fmt.Fprintf(out, "    signature: %.3f\n", bestWeights.Signature)
+	_, _ = fmt.Fprintln(out, "  metrics:")
+	for _, metric := range bestWeights {
+		_, _ = fmt.Fprintf(out, "    - name: %s\n", metric.Name)
+		_, _ = fmt.Fprintf(out, "      weight: %.3f\n", metric.Weight)
+	}
 
 	_, _ = fmt.Fprintln(out, "\n✅ Weight optimization benchmark completed!")
 	_, _ = fmt.Fprintln(out, "Recommendation: Review the results above and consider updating")
@@ -207,12 +200,6 @@ func printComparisonRow(out io.Writer, label string, score float64, validation s
 	)
 }
 
-func defaultWeights() config.SimilarityWeights {
-	return config.SimilarityWeights{
-		TreeEdit:           config.TreeEditWeight,
-		TokenSimilarity:    config.TokenSimilarityWeight,
-		Structural:         config.StructuralWeight,
-		Signature:          config.SignatureWeight,
-		DifferentSignature: config.DifferentSignatureWeight,
-	}
+func defaultWeights() config.MetricWeights {
+	return config.Default().Similarity.EnabledMetrics()
 }
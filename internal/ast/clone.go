@@ -0,0 +1,54 @@
+package ast
+
+import (
+	"go/ast"
+	"reflect"
+)
+
+// CloneNode returns a deep copy of an AST node that can be modified without affecting
+// the original. Objects and scopes, which link identifiers back to their declarations,
+// are shared with the original.
+func CloneNode[T ast.Node](node T) T {
+	//nolint:errcheck // The copy has the same dynamic type as node
+	return cloneValue(reflect.ValueOf(node)).Interface().(T)
+}
+
+// cloneValue deep copies the pointers, interfaces, slices and structs reachable from v.
+func cloneValue(v reflect.Value) reflect.Value {
+	//nolint:exhaustive // Other kinds are values and are copied by assignment
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || v.Type() == reflect.TypeFor[*ast.Object]() || v.Type() == reflect.TypeFor[*ast.Scope]() {
+			return v
+		}
+		copied := reflect.New(v.Type().Elem())
+		copied.Elem().Set(cloneValue(v.Elem()))
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(cloneValue(v.Elem()))
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			copied.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		for i := range v.NumField() {
+			if copied.Field(i).CanSet() {
+				copied.Field(i).Set(cloneValue(v.Field(i)))
+			}
+		}
+		return copied
+	default:
+		return v
+	}
+}
@@ -8,12 +8,17 @@ import (
 	"go/ast"
 	"go/format"
 	"go/token"
+	"strings"
 	"sync"
 )
 
 // Function represents a Go function with its metadata and AST representation.
 type Function struct {
 	Name       string        // Function name
+	Receiver   string        // Receiver type of a method, such as *Client; empty for functions
+	Package    string        // Name of the declaring package
+	ImportPath string        // Import path of the declaring package; empty when unknown
+	Imports    []string      // Names of the packages imported by the declaring file; nil when unknown
 	File       string        // Source file path
 	StartLine  int           // Starting line number
 	EndLine    int           // Ending line number
@@ -25,6 +30,28 @@ type Function struct {
 	mu         sync.RWMutex  // Protects cached fields (hash, signature)
 }
 
+// MethodName returns the name of the function qualified by its receiver type, such as
+// (*Client).Close or Point.String, or only its name for functions.
+func (f *Function) MethodName() string {
+	switch {
+	case f.Receiver == "":
+		return f.Name
+	case strings.HasPrefix(f.Receiver, "*"):
+		return "(" + f.Receiver + ")." + f.Name
+	default:
+		return f.Receiver + "." + f.Name
+	}
+}
+
+// QualifiedName returns the name of the function qualified by its package name and
+// receiver type, such as store.(*Client).Close, which tells apart methods sharing a name.
+func (f *Function) QualifiedName() string {
+	if f.Package == "" {
+		return f.MethodName()
+	}
+	return f.Package + "." + f.MethodName()
+}
+
 // GetSignature returns the function signature as a string.
 // The signature is cached after first computation.
 func (f *Function) GetSignature() string {
@@ -158,13 +185,74 @@ func (f *Function) Hash() string {
 	return f.hash
 }
 
+// Fingerprint returns a location-independent identity of the function derived from its
+// source without the doc comment. Unlike Hash, it does not change when the function moves
+// to other lines or another file, so it can match functions across runs.
+func (f *Function) Fingerprint() string {
+	content := f.Name + "\n" + f.GetSignature()
+
+	if f.AST != nil {
+		decl := *f.AST
+		decl.Doc = nil
+
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), &decl); err == nil {
+			content = buf.String()
+		}
+	}
+
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Complexity returns the cyclomatic complexity of the function: one plus the number of
+// branches introduced by conditions, loops, cases and short-circuit operators.
+func (f *Function) Complexity() int {
+	complexity := 1
+	if f.AST == nil || f.AST.Body == nil {
+		return complexity
+	}
+
+	ast.Inspect(f.AST.Body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if node.List != nil { // default clauses add no branch
+				complexity++
+			}
+		case *ast.CommClause:
+			if node.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if node.Op == token.LAND || node.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+
+	return complexity
+}
+
 // Normalize returns a normalized version of the function for comparison.
 // Normalization removes variable names, literal values, and other non-structural elements
 // while preserving the essential structure for similarity comparison.
 func (f *Function) Normalize() *Function {
+	return f.NormalizeWith(NormalizeOptions{})
+}
+
+// NormalizeWith normalizes the function like Normalize after rewriting it in the canonical
+// form selected by opts. Functions already normalized are returned as they are.
+func (f *Function) NormalizeWith(opts NormalizeOptions) *Function {
 	if f.Normalized != nil {
 		return &Function{
 			Name:       f.Name,
+			Receiver:   f.Receiver,
+			Package:    f.Package,
+			ImportPath: f.ImportPath,
+			Imports:    f.Imports,
 			File:       f.File,
 			StartLine:  f.StartLine,
 			EndLine:    f.EndLine,
@@ -180,11 +268,18 @@ func (f *Function) Normalize() *Function {
 
 	// Create a deep copy of the AST and normalize it
 	normalizedAST := f.deepCopyFuncDecl(f.AST)
+	if opts.Enabled() {
+		(&rewriter{fn: f, opts: opts}).rewrite(normalizedAST.Body)
+	}
 	f.normalizeNode(normalizedAST)
 
 	// Return a new Function without modifying the original
 	return &Function{
 		Name:       f.Name,
+		Receiver:   f.Receiver,
+		Package:    f.Package,
+		ImportPath: f.ImportPath,
+		Imports:    f.Imports,
 		File:       f.File,
 		StartLine:  f.StartLine,
 		EndLine:    f.EndLine,
@@ -202,12 +297,16 @@ func (f *Function) DeepCopy() *Function {
 
 	// Create new function with deep copied AST
 	copied := &Function{
-		Name:      f.Name,
-		File:      f.File,
-		StartLine: f.StartLine,
-		EndLine:   f.EndLine,
-		AST:       nil,
-		LineCount: f.LineCount,
+		Name:       f.Name,
+		Receiver:   f.Receiver,
+		Package:    f.Package,
+		ImportPath: f.ImportPath,
+		Imports:    f.Imports,
+		File:       f.File,
+		StartLine:  f.StartLine,
+		EndLine:    f.EndLine,
+		AST:        nil,
+		LineCount:  f.LineCount,
 		// Don't copy Normalized field - let normalization happen independently
 	}
 
@@ -227,13 +326,13 @@ func (f *Function) deepCopyFuncDecl(original *ast.FuncDecl) *ast.FuncDecl {
 
 	// Create new function declaration with deep copies of all fields
 	copied := &ast.FuncDecl{
-		Doc:  original.Doc,  // Doc comments are typically not modified
-		Recv: original.Recv, // Receiver is typically not modified
+		Doc:  original.Doc, // Doc comments are not modified
+		Recv: CloneNode(original.Recv),
 		Name: &ast.Ident{
 			Name: original.Name.Name,
 			Obj:  original.Name.Obj, // Object reference can be shared
 		},
-		Type: original.Type, // Function type is typically not modified
+		Type: CloneNode(original.Type),
 		Body: f.deepCopyBlockStmt(original.Body),
 	}
 
@@ -277,7 +376,7 @@ func (f *Function) deepCopyStmt(original ast.Stmt) ast.Stmt {
 		case *ast.BlockStmt:
 			return f.deepCopyBlockStmt(stmt)
 		default:
-			return stmt
+			return CloneNode(stmt)
 		}
 	}).(ast.Stmt)
 }
@@ -374,7 +473,7 @@ func (f *Function) deepCopyExpr(original ast.Expr) ast.Expr {
 		case *ast.CallExpr:
 			return f.copyCallExpr(expr)
 		default:
-			return expr
+			return CloneNode(expr)
 		}
 	}).(ast.Expr)
 }
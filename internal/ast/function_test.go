@@ -218,6 +218,128 @@ func add(x, y int) int {
 	// This will be implemented when we add the Hash method
 }
 
+func TestFunction_Fingerprint(t *testing.T) {
+	base := `package main
+
+func add(a, b int) int {
+	return a + b
+}
+`
+	moved := `package main
+
+// add returns the sum of a and b.
+//
+// The doc comment and blank lines above do not change the fingerprint.
+func add(a, b int) int {
+	return a + b // comments inside the body are ignored too
+}
+`
+	edited := `package main
+
+func add(a, b int) int {
+	return b + a
+}
+`
+
+	parser := astpkg.NewParser()
+	fingerprint := func(filename, source string) string {
+		t.Helper()
+		result := parser.ParseSource(filename, []byte(source))
+		if result.IsErr() {
+			t.Fatalf("failed to parse %s: %v", filename, result.Error())
+		}
+		return result.Unwrap().Functions[0].Fingerprint()
+	}
+
+	original := fingerprint("a.go", base)
+	if original == "" {
+		t.Fatal("expected non-empty fingerprint")
+	}
+	if got := fingerprint("b.go", moved); got != original {
+		t.Errorf("expected moving the function to keep its fingerprint, got %s and %s", original, got)
+	}
+	if got := fingerprint("a.go", edited); got == original {
+		t.Error("expected editing the body to change the fingerprint")
+	}
+}
+
+func TestFunction_NormalizeKeepsOriginal(t *testing.T) {
+	source := `package main
+
+func (s *Store) total(values []int, label string) int {
+	sum := 0
+	for _, v := range values {
+		switch {
+		case v > limit:
+			sum += s.cap
+		default:
+			sum += v
+		}
+	}
+	defer log(label, "done")
+	return sum
+}
+`
+
+	result := astpkg.NewParser().ParseSource("a.go", []byte(source))
+	if result.IsErr() {
+		t.Fatalf("failed to parse: %v", result.Error())
+	}
+	fn := result.Unwrap().Functions[0]
+
+	before, err := fn.GetSource()
+	if err != nil {
+		t.Fatalf("failed to print function: %v", err)
+	}
+
+	fn.Normalize()
+
+	after, err := fn.GetSource()
+	if err != nil {
+		t.Fatalf("failed to print function: %v", err)
+	}
+	if after != before {
+		t.Errorf("normalizing changed the original function:\n%s\nbecame\n%s", before, after)
+	}
+}
+
+func TestFunction_Complexity(t *testing.T) {
+	source := `package main
+
+func classify(values []int, done chan bool) string {
+	for _, v := range values {
+		if v > 0 && v < 10 {
+			return "small"
+		}
+	}
+	switch len(values) {
+	case 0:
+		return "empty"
+	default:
+	}
+	select {
+	case <-done:
+	default:
+	}
+	return "other"
+}
+`
+
+	result := astpkg.NewParser().ParseSource("a.go", []byte(source))
+	if result.IsErr() {
+		t.Fatalf("failed to parse: %v", result.Error())
+	}
+
+	// 1 + range + if + && + case 0 + case <-done
+	if got := result.Unwrap().Functions[0].Complexity(); got != 6 {
+		t.Errorf("expected complexity 6, got %d", got)
+	}
+
+	if got := (&astpkg.Function{Name: "stub"}).Complexity(); got != 1 {
+		t.Errorf("expected complexity 1 without AST, got %d", got)
+	}
+}
+
 func TestFunction_Normalize(t *testing.T) {
 	source := `package main
 func add(a, b int) int {
@@ -0,0 +1,130 @@
+package ast
+
+import (
+	"bufio"
+	"go/ast"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// goModFile is the name of the file declaring a module.
+const goModFile = "go.mod"
+
+// importPath returns the import path of the package declared in filename, from the
+// module path of the nearest go.mod above it. It returns an empty string when the file
+// does not exist, as for sources held in memory, or is outside any module.
+func (p *Parser) importPath(filename string) string {
+	if _, err := os.Stat(filename); err != nil {
+		return ""
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return ""
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if importPath, exists := p.importPaths[dir]; exists {
+		return importPath
+	}
+
+	importPath := ""
+	for moduleDir := dir; ; moduleDir = filepath.Dir(moduleDir) {
+		if modulePath := readModulePath(filepath.Join(moduleDir, goModFile)); modulePath != "" {
+			rel, err := filepath.Rel(moduleDir, dir)
+			if err == nil {
+				importPath = path.Join(modulePath, filepath.ToSlash(rel))
+			}
+			break
+		}
+		if filepath.Dir(moduleDir) == moduleDir {
+			break
+		}
+	}
+
+	if p.importPaths == nil {
+		p.importPaths = make(map[string]string)
+	}
+	p.importPaths[dir] = importPath
+
+	return importPath
+}
+
+// readModulePath returns the module path declared by a go.mod file, or an empty string
+// when the file cannot be read or has no module directive.
+func readModulePath(goModPath string) string {
+	file, err := os.Open(goModPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "//"); idx >= 0 {
+			line = line[:idx]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(fields[1]); err == nil {
+			return unquoted
+		}
+		return fields[1]
+	}
+
+	return ""
+}
+
+// ImportNames returns the names under which file refers to the packages it imports: their
+// alias, or the name guessed from the last element of their path, such as yaml for
+// gopkg.in/yaml.v3 or sqlite3 for github.com/mattn/go-sqlite3. Blank and dot imports are
+// left out. The result is never nil.
+func ImportNames(file *ast.File) []string {
+	names := make([]string, 0, len(file.Imports))
+	for _, spec := range file.Imports {
+		if spec.Name != nil {
+			if spec.Name.Name != "_" && spec.Name.Name != "." {
+				names = append(names, spec.Name.Name)
+			}
+			continue
+		}
+
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		names = append(names, packageName(importPath))
+	}
+	return names
+}
+
+// packageName guesses the name of the package at importPath from its last element,
+// skipping major version suffixes and a go- prefix.
+func packageName(importPath string) string {
+	name := path.Base(importPath)
+	if isMajorVersion(name) && path.Dir(importPath) != "." {
+		name = path.Base(path.Dir(importPath))
+	}
+	if dot := strings.LastIndex(name, "."); dot > 0 && isMajorVersion(name[dot+1:]) {
+		name = name[:dot]
+	}
+	return strings.TrimPrefix(name, "go-")
+}
+
+// isMajorVersion reports whether element is a major version such as v2.
+func isMajorVersion(element string) bool {
+	if len(element) < 2 || element[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(element[1:])
+	return err == nil
+}
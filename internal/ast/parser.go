@@ -5,18 +5,22 @@ import (
 	"go/parser"
 	"go/token"
 	"os"
+	"sync"
 
 	"github.com/paveg/similarity-go/pkg/types"
 )
 
 // Parser handles parsing Go source files and extracting function information.
 type Parser struct {
-	fileSet *token.FileSet
+	fileSet     *token.FileSet
+	mu          sync.Mutex        // Protects importPaths
+	importPaths map[string]string // Import path by absolute directory
 }
 
 // ParseResult contains the results of parsing one or more Go files.
 type ParseResult struct {
 	Functions []*Function  // Successfully parsed functions
+	Types     []*TypeDecl  // Struct, interface and function type declarations
 	Errors    []error      // Errors encountered during parsing
 	Metadata  FileMetadata // Additional metadata about the parsing operation
 }
@@ -31,7 +35,8 @@ type FileMetadata struct {
 // NewParser creates a new Parser instance.
 func NewParser() *Parser {
 	return &Parser{
-		fileSet: token.NewFileSet(),
+		fileSet:     token.NewFileSet(),
+		importPaths: make(map[string]string),
 	}
 }
 
@@ -43,17 +48,25 @@ func (p *Parser) ParseFile(filename string) types.Result[*ParseResult] {
 		return types.Err[*ParseResult](err)
 	}
 
+	return p.ParseSource(filename, src)
+}
+
+// ParseSource parses Go source code held in memory and extracts function information.
+// The filename is only used for positions and as the File of extracted functions.
+func (p *Parser) ParseSource(filename string, src []byte) types.Result[*ParseResult] {
 	// Parse the file
 	file, err := parser.ParseFile(p.fileSet, filename, src, parser.ParseComments)
 	if err != nil {
 		return types.Err[*ParseResult](err)
 	}
 
-	// Extract functions
-	functions := p.extractFunctions(file, filename)
+	// Extract functions and type declarations
+	importPath := p.importPath(filename)
+	functions := p.extractFunctions(file, filename, importPath)
 
 	return types.Ok(&ParseResult{
 		Functions: functions,
+		Types:     p.extractTypes(file, filename, importPath),
 		Errors:    []error{},
 		Metadata: FileMetadata{
 			TotalFiles:      1,
@@ -68,6 +81,8 @@ func (p *Parser) ParseFile(filename string) types.Result[*ParseResult] {
 func (p *Parser) ParseFiles(filenames []string) types.Result[*ParseResult] {
 	var allFunctions []*Function
 
+	var allTypes []*TypeDecl
+
 	var allErrors []error
 
 	successCount := 0
@@ -77,6 +92,7 @@ func (p *Parser) ParseFiles(filenames []string) types.Result[*ParseResult] {
 		if result.IsOk() {
 			parseResult := result.Unwrap()
 			allFunctions = append(allFunctions, parseResult.Functions...)
+			allTypes = append(allTypes, parseResult.Types...)
 			successCount++
 		} else {
 			allErrors = append(allErrors, result.Error())
@@ -85,6 +101,7 @@ func (p *Parser) ParseFiles(filenames []string) types.Result[*ParseResult] {
 
 	return types.Ok(&ParseResult{
 		Functions: allFunctions,
+		Types:     allTypes,
 		Errors:    allErrors,
 		Metadata: FileMetadata{
 			TotalFiles:      len(filenames),
@@ -95,9 +112,12 @@ func (p *Parser) ParseFiles(filenames []string) types.Result[*ParseResult] {
 }
 
 // extractFunctions extracts all function declarations from an AST file.
-func (p *Parser) extractFunctions(file *ast.File, filename string) []*Function {
+func (p *Parser) extractFunctions(file *ast.File, filename, importPath string) []*Function {
 	var functions []*Function
 
+	packageName := file.Name.Name
+	imports := ImportNames(file)
+
 	ast.Inspect(file, func(n ast.Node) bool {
 		if node, ok := n.(*ast.FuncDecl); ok {
 			// Skip interface method declarations (they have no body)
@@ -106,6 +126,9 @@ func (p *Parser) extractFunctions(file *ast.File, filename string) []*Function {
 			}
 
 			fn := p.createFunction(node, filename)
+			fn.Package = packageName
+			fn.ImportPath = importPath
+			fn.Imports = imports
 			functions = append(functions, fn)
 		}
 
@@ -124,6 +147,7 @@ func (p *Parser) createFunction(funcDecl *ast.FuncDecl, filename string) *Functi
 
 	return &Function{
 		Name:      funcDecl.Name.Name,
+		Receiver:  receiverType(funcDecl),
 		File:      filename,
 		StartLine: startPos.Line,
 		EndLine:   endPos.Line,
@@ -131,3 +155,31 @@ func (p *Parser) createFunction(funcDecl *ast.FuncDecl, filename string) *Functi
 		LineCount: lineCount,
 	}
 }
+
+// receiverType returns the receiver type of a method without its type parameters, such
+// as *List for func (l *List[T]) Push(v T), or an empty string for functions.
+func receiverType(funcDecl *ast.FuncDecl) string {
+	if funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+		return ""
+	}
+
+	expr := funcDecl.Recv.List[0].Type
+	pointer := ""
+	for {
+		switch t := expr.(type) {
+		case *ast.ParenExpr:
+			expr = t.X
+		case *ast.StarExpr:
+			pointer = "*"
+			expr = t.X
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.IndexListExpr:
+			expr = t.X
+		case *ast.Ident:
+			return pointer + t.Name
+		default:
+			return ""
+		}
+	}
+}
@@ -2,6 +2,8 @@ package ast_test
 
 import (
 	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/paveg/similarity-go/internal/ast"
@@ -460,3 +462,157 @@ func stringSliceEqual(a, b []string) bool {
 
 	return true
 }
+
+func TestParser_ParseSource(t *testing.T) {
+	parser := ast.NewParser()
+
+	result := parser.ParseSource("snippet.go", []byte(`package main
+
+func add(a, b int) int {
+	return a + b
+}`))
+	if result.IsErr() {
+		t.Fatalf("unexpected error: %v", result.Error())
+	}
+
+	functions := result.Unwrap().Functions
+	if len(functions) != 1 {
+		t.Fatalf("expected 1 function, got %d", len(functions))
+	}
+
+	if functions[0].Name != "add" || functions[0].File != "snippet.go" || functions[0].StartLine != 3 {
+		t.Errorf("unexpected function metadata: %s %s:%d", functions[0].Name, functions[0].File, functions[0].StartLine)
+	}
+
+	if result := parser.ParseSource("broken.go", []byte("func missingPackage() {}")); result.IsOk() {
+		t.Error("expected error for source without package clause")
+	}
+}
+
+func TestParser_Imports(t *testing.T) {
+	result := ast.NewParser().ParseSource("imports.go", []byte(`package main
+
+import (
+	"fmt"
+	_ "embed"
+	. "strings"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattn/go-sqlite3"
+	"gopkg.in/yaml.v3"
+	"github.com/jackc/pgx/v5"
+)
+
+func run() {}`))
+	if result.IsErr() {
+		t.Fatalf("unexpected error: %v", result.Error())
+	}
+
+	want := []string{"fmt", "sq", "sqlite3", "yaml", "pgx"}
+	if got := result.Unwrap().Functions[0].Imports; !slices.Equal(got, want) {
+		t.Errorf("Imports = %v, want %v", got, want)
+	}
+}
+
+func TestParser_FunctionIdentity(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app // comment\n"), 0o644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "store"), 0o755); err != nil {
+		t.Fatalf("failed to create package directory: %v", err)
+	}
+	filename := filepath.Join(dir, "store", "client.go")
+	source := `package store
+
+func (c *Client) Close() error { return nil }
+
+func (l List[T]) Len() int { return 0 }
+
+func Open() *Client { return nil }
+`
+	if err := os.WriteFile(filename, []byte(source), 0o644); err != nil {
+		t.Fatalf("failed to write source: %v", err)
+	}
+
+	result := ast.NewParser().ParseFile(filename)
+	if result.IsErr() {
+		t.Fatalf("unexpected error: %v", result.Error())
+	}
+
+	expected := []struct {
+		receiver  string
+		qualified string
+	}{
+		{receiver: "*Client", qualified: "store.(*Client).Close"},
+		{receiver: "List", qualified: "store.List.Len"},
+		{receiver: "", qualified: "store.Open"},
+	}
+
+	functions := result.Unwrap().Functions
+	if len(functions) != len(expected) {
+		t.Fatalf("expected %d functions, got %d", len(expected), len(functions))
+	}
+	for i, fn := range functions {
+		if fn.Receiver != expected[i].receiver || fn.QualifiedName() != expected[i].qualified {
+			t.Errorf("function %d: got receiver %q and name %s, want %q and %s",
+				i, fn.Receiver, fn.QualifiedName(), expected[i].receiver, expected[i].qualified)
+		}
+		if fn.Package != "store" || fn.ImportPath != "example.com/app/store" {
+			t.Errorf("function %d: got package %s (%s)", i, fn.Package, fn.ImportPath)
+		}
+	}
+
+	// Sources held in memory have no import path
+	inMemory := ast.NewParser().ParseSource("<stdin>", []byte(source)).Unwrap().Functions[0]
+	if inMemory.ImportPath != "" || inMemory.QualifiedName() != "store.(*Client).Close" {
+		t.Errorf("unexpected identity for in-memory source: %q, %s", inMemory.ImportPath, inMemory.QualifiedName())
+	}
+}
+
+func TestParser_TypeDeclarations(t *testing.T) {
+	source := `package store
+
+type Client struct {
+	Name string
+	Port int
+}
+
+type (
+	Reader interface{ Read(p []byte) (int, error) }
+	Handler func(name string) error
+	ID int
+)
+
+type Alias = Client
+`
+
+	result := ast.NewParser().ParseSource("client.go", []byte(source))
+	if result.IsErr() {
+		t.Fatalf("unexpected error: %v", result.Error())
+	}
+
+	expected := []struct {
+		qualified string
+		kind      string
+		startLine int
+		endLine   int
+	}{
+		{qualified: "store.Client", kind: ast.TypeKindStruct, startLine: 3, endLine: 6},
+		{qualified: "store.Reader", kind: ast.TypeKindInterface, startLine: 9, endLine: 9},
+		{qualified: "store.Handler", kind: ast.TypeKindFunc, startLine: 10, endLine: 10},
+	}
+
+	types := result.Unwrap().Types
+	if len(types) != len(expected) {
+		t.Fatalf("expected %d types, got %d", len(expected), len(types))
+	}
+	for i, typeDecl := range types {
+		want := expected[i]
+		if typeDecl.QualifiedName() != want.qualified || typeDecl.Kind() != want.kind ||
+			typeDecl.StartLine != want.startLine || typeDecl.EndLine != want.endLine {
+			t.Errorf("type %d: got %s %s at lines %d-%d, want %s %s at lines %d-%d",
+				i, typeDecl.Kind(), typeDecl.QualifiedName(), typeDecl.StartLine, typeDecl.EndLine,
+				want.kind, want.qualified, want.startLine, want.endLine)
+		}
+	}
+}
@@ -0,0 +1,463 @@
+package ast
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"sort"
+)
+
+// NormalizeOptions selects the rewrites bringing equivalent code written in different
+// ways to one canonical form before it is normalized. Every rewrite is disabled by
+// default. The rewrites work without type information, so they may also merge code that
+// only looks equivalent, such as index loops over strings, whose range form yields runes.
+type NormalizeOptions struct {
+	// CommutativeOperands orders the operands of ==, !=, *, &, | and ^ and of additions
+	// of operands known to be numeric, flattening chains of associative operators. The
+	// operands of && and || are kept in order, as evaluation stops at the first operand
+	// deciding the result.
+	CommutativeOperands bool
+	// NegatedConditions rewrites if !c {A} else {B} as if c {B} else {A}.
+	NegatedConditions bool
+	// NilComparisons writes comparisons with nil as x == nil and x != nil.
+	NilComparisons bool
+	// VarDeclarations rewrites var x T = v as x := v.
+	VarDeclarations bool
+	// IncDec rewrites i += 1 as i++ and i -= 1 as i--.
+	IncDec bool
+	// RangeLoops rewrites for i := 0; i < len(s); i++ as for i := range s, folding a
+	// leading v := s[i] into the range value, and for i := 0; i < n; i++ as for i := range n.
+	RangeLoops bool
+}
+
+// Enabled reports whether any rewrite is selected.
+func (o NormalizeOptions) Enabled() bool {
+	return o.CommutativeOperands || o.NegatedConditions || o.NilComparisons ||
+		o.VarDeclarations || o.IncDec || o.RangeLoops
+}
+
+// Rewrite returns a copy of the function whose body is rewritten in the canonical form
+// selected by opts, keeping names and literals. The function itself is not modified.
+func (f *Function) Rewrite(opts NormalizeOptions) *Function {
+	rewritten := f.DeepCopy()
+	if rewritten != nil && rewritten.AST != nil {
+		(&rewriter{fn: f, opts: opts}).rewrite(rewritten.AST.Body)
+	}
+	return rewritten
+}
+
+// rewriter applies the rewrites selected by opts to a copy of the AST of fn.
+type rewriter struct {
+	fn   *Function
+	opts NormalizeOptions
+}
+
+// rewrite rewrites body in place. Statements are rewritten from the outside in, as they
+// are visited, and binary expressions from the inside out, so that their operands are
+// already canonical when they are ordered.
+func (r *rewriter) rewrite(body *ast.BlockStmt) {
+	if body == nil {
+		return
+	}
+
+	var binaries []*ast.BinaryExpr
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.BlockStmt:
+			r.stmtList(node.List)
+		case *ast.CaseClause:
+			r.stmtList(node.Body)
+		case *ast.CommClause:
+			r.stmtList(node.Body)
+		case *ast.LabeledStmt:
+			node.Stmt = r.stmt(node.Stmt)
+		case *ast.ForStmt:
+			if node.Post != nil {
+				node.Post = r.stmt(node.Post)
+			}
+		case *ast.IfStmt:
+			r.ifStmt(node)
+		case *ast.BinaryExpr:
+			binaries = append(binaries, node)
+		}
+		return true
+	})
+
+	for i := len(binaries) - 1; i >= 0; i-- {
+		r.binaryExpr(binaries[i])
+	}
+}
+
+// stmtList rewrites the statements of list in place.
+func (r *rewriter) stmtList(list []ast.Stmt) {
+	for i, stmt := range list {
+		list[i] = r.stmt(stmt)
+	}
+}
+
+// stmt returns the canonical form of stmt, without rewriting the statements nested in it.
+func (r *rewriter) stmt(stmt ast.Stmt) ast.Stmt {
+	switch s := stmt.(type) {
+	case *ast.DeclStmt:
+		if r.opts.VarDeclarations {
+			return shortVarDecl(s)
+		}
+	case *ast.AssignStmt:
+		if r.opts.IncDec {
+			return incDecStmt(s)
+		}
+	case *ast.ForStmt:
+		if r.opts.RangeLoops {
+			return rangeLoop(s)
+		}
+	}
+	return stmt
+}
+
+// shortVarDecl rewrites var x T = v as x := v. Declarations of several specs or without
+// a value for every name are kept.
+func shortVarDecl(stmt *ast.DeclStmt) ast.Stmt {
+	decl, ok := stmt.Decl.(*ast.GenDecl)
+	if !ok || decl.Tok != token.VAR || len(decl.Specs) != 1 {
+		return stmt
+	}
+	spec, ok := decl.Specs[0].(*ast.ValueSpec)
+	if !ok || len(spec.Values) == 0 || len(spec.Values) != len(spec.Names) {
+		return stmt
+	}
+
+	lhs := make([]ast.Expr, len(spec.Names))
+	for i, name := range spec.Names {
+		lhs[i] = name
+	}
+	return &ast.AssignStmt{Lhs: lhs, TokPos: decl.TokPos, Tok: token.DEFINE, Rhs: spec.Values}
+}
+
+// incDecStmt rewrites i += 1 as i++ and i -= 1 as i--.
+func incDecStmt(stmt *ast.AssignStmt) ast.Stmt {
+	if len(stmt.Lhs) != 1 || len(stmt.Rhs) != 1 || !isOne(stmt.Rhs[0]) {
+		return stmt
+	}
+
+	//nolint:exhaustive // Other assignments have no increment form
+	switch stmt.Tok {
+	case token.ADD_ASSIGN:
+		return &ast.IncDecStmt{X: stmt.Lhs[0], TokPos: stmt.TokPos, Tok: token.INC}
+	case token.SUB_ASSIGN:
+		return &ast.IncDecStmt{X: stmt.Lhs[0], TokPos: stmt.TokPos, Tok: token.DEC}
+	default:
+		return stmt
+	}
+}
+
+// rangeLoop rewrites a loop counting an index from 0 up to the length of a slice, or up
+// to a count, as the equivalent range loop. Loops whose body assigns the index, or whose
+// bound is not a variable or a constant, are kept.
+func rangeLoop(loop *ast.ForStmt) ast.Stmt {
+	index := loopIndex(loop)
+	if index == nil {
+		return loop
+	}
+	cond, ok := loop.Cond.(*ast.BinaryExpr)
+	if !ok || cond.Op != token.LSS || !isVar(cond.X, index) || !increments(loop.Post, index) {
+		return loop
+	}
+	if assigns(loop.Body, index) {
+		return loop
+	}
+
+	rng := &ast.RangeStmt{For: loop.For, Key: index, TokPos: index.NamePos, Tok: token.DEFINE, Body: loop.Body}
+	if seq := lenArg(cond.Y); seq != nil {
+		rng.X = seq
+		if value := elementValue(loop.Body, seq, index); value != nil {
+			rng.Value = value
+			rng.Body.List = rng.Body.List[1:]
+			if !uses(rng.Body, index) {
+				// The parser declares blank range keys as variables too
+				rng.Key = &ast.Ident{NamePos: index.NamePos, Name: "_", Obj: ast.NewObj(ast.Var, "_")}
+			}
+		}
+		return rng
+	}
+
+	if _, isLit := cond.Y.(*ast.BasicLit); !isLit && !isStable(cond.Y) {
+		return loop
+	}
+	rng.X = cond.Y
+	return rng
+}
+
+// loopIndex returns the index declared as i := 0 by the init statement of loop, or nil.
+func loopIndex(loop *ast.ForStmt) *ast.Ident {
+	init, ok := loop.Init.(*ast.AssignStmt)
+	if !ok || init.Tok != token.DEFINE || len(init.Lhs) != 1 || len(init.Rhs) != 1 {
+		return nil
+	}
+	lit, ok := init.Rhs[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT || lit.Value != "0" {
+		return nil
+	}
+	index, ok := init.Lhs[0].(*ast.Ident)
+	if !ok || index.Obj == nil {
+		return nil
+	}
+	return index
+}
+
+// increments reports whether post increments index by one.
+func increments(post ast.Stmt, index *ast.Ident) bool {
+	switch s := post.(type) {
+	case *ast.IncDecStmt:
+		return s.Tok == token.INC && isVar(s.X, index)
+	case *ast.AssignStmt:
+		return s.Tok == token.ADD_ASSIGN && len(s.Lhs) == 1 && len(s.Rhs) == 1 &&
+			isVar(s.Lhs[0], index) && isOne(s.Rhs[0])
+	default:
+		return false
+	}
+}
+
+// lenArg returns the argument of len(s), or nil when expr is not the length of a
+// variable.
+func lenArg(expr ast.Expr) ast.Expr {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 || call.Ellipsis.IsValid() {
+		return nil
+	}
+	if fun, isIdent := call.Fun.(*ast.Ident); !isIdent || fun.Name != "len" || fun.Obj != nil {
+		return nil
+	}
+	if !isStable(call.Args[0]) {
+		return nil
+	}
+	return call.Args[0]
+}
+
+// elementValue returns v when the first statement of body is v := seq[index], or nil.
+func elementValue(body *ast.BlockStmt, seq ast.Expr, index *ast.Ident) *ast.Ident {
+	if len(body.List) == 0 {
+		return nil
+	}
+	assign, ok := body.List[0].(*ast.AssignStmt)
+	if !ok || assign.Tok != token.DEFINE || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return nil
+	}
+	element, ok := assign.Rhs[0].(*ast.IndexExpr)
+	if !ok || !isVar(element.Index, index) || types.ExprString(element.X) != types.ExprString(seq) {
+		return nil
+	}
+	value, ok := assign.Lhs[0].(*ast.Ident)
+	if !ok || value.Name == "_" {
+		return nil
+	}
+	return value
+}
+
+// assigns reports whether node assigns variable, or takes its address.
+func assigns(node ast.Node, variable *ast.Ident) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch s := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range s.Lhs {
+				found = found || isVar(lhs, variable)
+			}
+		case *ast.IncDecStmt:
+			found = found || isVar(s.X, variable)
+		case *ast.UnaryExpr:
+			found = found || (s.Op == token.AND && isVar(s.X, variable))
+		}
+		return !found
+	})
+	return found
+}
+
+// uses reports whether node refers to variable.
+func uses(node ast.Node, variable *ast.Ident) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		found = found || isVar(n, variable)
+		return !found
+	})
+	return found
+}
+
+// isVar reports whether node is an identifier of the same object as variable.
+func isVar(node ast.Node, variable *ast.Ident) bool {
+	ident, ok := node.(*ast.Ident)
+	return ok && ident.Obj != nil && ident.Obj == variable.Obj
+}
+
+// isStable reports whether evaluating expr has no side effect, so that it can be
+// evaluated once rather than on every iteration: a variable or a field of one.
+func isStable(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return true
+	case *ast.SelectorExpr:
+		return isStable(e.X)
+	case *ast.ParenExpr:
+		return isStable(e.X)
+	default:
+		return false
+	}
+}
+
+// isOne reports whether expr is the integer literal 1.
+func isOne(expr ast.Expr) bool {
+	lit, ok := expr.(*ast.BasicLit)
+	return ok && lit.Kind == token.INT && lit.Value == "1"
+}
+
+// isNil reports whether expr is the predeclared nil.
+func isNil(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "nil" && ident.Obj == nil
+}
+
+// ifStmt rewrites if !c {A} else {B} as if c {B} else {A}. Negated conditions followed
+// by else if are kept.
+func (r *rewriter) ifStmt(stmt *ast.IfStmt) {
+	if !r.opts.NegatedConditions {
+		return
+	}
+	not, ok := stmt.Cond.(*ast.UnaryExpr)
+	if !ok || not.Op != token.NOT {
+		return
+	}
+	elseBlock, ok := stmt.Else.(*ast.BlockStmt)
+	if !ok {
+		return
+	}
+
+	stmt.Cond = ast.Unparen(not.X)
+	stmt.Body, stmt.Else = elseBlock, stmt.Body
+}
+
+// binaryExpr orders the operands of expr.
+func (r *rewriter) binaryExpr(expr *ast.BinaryExpr) {
+	//nolint:exhaustive // Other operators are not commutative
+	switch expr.Op {
+	case token.EQL, token.NEQ:
+		switch {
+		case (r.opts.NilComparisons || r.opts.CommutativeOperands) && isNil(expr.X) && !isNil(expr.Y):
+			expr.X, expr.Y = expr.Y, expr.X
+		case r.opts.CommutativeOperands && !isNil(expr.Y) && r.key(expr.Y) < r.key(expr.X):
+			expr.X, expr.Y = expr.Y, expr.X
+		}
+	case token.ADD, token.MUL, token.AND, token.OR, token.XOR:
+		if r.opts.CommutativeOperands {
+			r.orderChain(expr)
+		}
+	}
+}
+
+// orderChain sorts the operands of a chain of the associative operator of expr, such as
+// a + b + c, and rebuilds it from left to right. Additions are kept unless an operand is
+// known to be numeric, since they may concatenate strings, which is not commutative.
+// The operands of an addition share a type, so one numeric operand is enough.
+func (r *rewriter) orderChain(expr *ast.BinaryExpr) {
+	operands := chainOperands(expr, expr.Op, nil)
+	if expr.Op == token.ADD && !slices.ContainsFunc(operands, isNumeric) {
+		return
+	}
+
+	keys := make(map[ast.Expr]string, len(operands))
+	for _, operand := range operands {
+		keys[operand] = r.key(operand)
+	}
+	sort.SliceStable(operands, func(i, j int) bool { return keys[operands[i]] < keys[operands[j]] })
+
+	x := operands[0]
+	for _, y := range operands[1 : len(operands)-1] {
+		x = &ast.BinaryExpr{X: x, OpPos: expr.OpPos, Op: expr.Op, Y: y}
+	}
+	expr.X, expr.Y = x, operands[len(operands)-1]
+}
+
+// isNumeric reports whether expr is known to be a number without type information: a
+// numeric literal, an operation only defined on numbers, a call to len or cap, a
+// conversion to a predeclared numeric type, or a variable declared with such a type or
+// value.
+func isNumeric(expr ast.Expr) bool {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.BasicLit:
+		return e.Kind != token.STRING
+	case *ast.UnaryExpr:
+		return e.Op == token.SUB || e.Op == token.XOR || (e.Op == token.ADD && isNumeric(e.X))
+	case *ast.BinaryExpr:
+		//nolint:exhaustive // Other operators are not only defined on numbers
+		switch e.Op {
+		case token.ADD:
+			return isNumeric(e.X) || isNumeric(e.Y)
+		case token.SUB, token.MUL, token.QUO, token.REM, token.AND, token.OR, token.XOR,
+			token.SHL, token.SHR, token.AND_NOT:
+			return true
+		}
+	case *ast.CallExpr:
+		if fun, ok := e.Fun.(*ast.Ident); ok && fun.Obj == nil && (fun.Name == "len" || fun.Name == "cap") {
+			return true
+		}
+		return isNumericType(e.Fun)
+	case *ast.Ident:
+		return e.Obj != nil && e.Obj.Kind == ast.Var && declaredNumeric(e)
+	}
+	return false
+}
+
+// declaredNumeric reports whether the declaration of the variable ident gives it a
+// numeric type or value.
+func declaredNumeric(ident *ast.Ident) bool {
+	switch decl := ident.Obj.Decl.(type) {
+	case *ast.Field:
+		return isNumericType(decl.Type)
+	case *ast.ValueSpec:
+		if decl.Type != nil {
+			return isNumericType(decl.Type)
+		}
+		for i, name := range decl.Names {
+			if name.Name == ident.Name && i < len(decl.Values) && len(decl.Values) == len(decl.Names) {
+				return isNumeric(decl.Values[i])
+			}
+		}
+	case *ast.AssignStmt:
+		for i, lhs := range decl.Lhs {
+			if name, ok := lhs.(*ast.Ident); ok && name.Name == ident.Name && len(decl.Rhs) == len(decl.Lhs) {
+				return isNumeric(decl.Rhs[i])
+			}
+		}
+	}
+	return false
+}
+
+// isNumericType reports whether expr names a predeclared numeric type.
+func isNumericType(expr ast.Expr) bool {
+	ident, ok := ast.Unparen(expr).(*ast.Ident)
+	if !ok || ident.Obj != nil {
+		return false
+	}
+	typeName, ok := types.Universe.Lookup(ident.Name).(*types.TypeName)
+	if !ok {
+		return false
+	}
+	basic, ok := typeName.Type().(*types.Basic)
+	return ok && basic.Info()&types.IsNumeric != 0
+}
+
+// chainOperands appends the operands of the chain of op starting at expr to operands.
+func chainOperands(expr ast.Expr, op token.Token, operands []ast.Expr) []ast.Expr {
+	if binary, ok := expr.(*ast.BinaryExpr); ok && binary.Op == op {
+		operands = chainOperands(binary.X, op, operands)
+		return chainOperands(binary.Y, op, operands)
+	}
+	return append(operands, expr)
+}
+
+// key orders operands by their normalized form, so that their order does not depend on
+// the names they use.
+func (r *rewriter) key(expr ast.Expr) string {
+	normalized := CloneNode(expr)
+	r.fn.normalizeNode(normalized)
+	return types.ExprString(normalized)
+}
@@ -0,0 +1,200 @@
+package ast_test
+
+import (
+	"strings"
+	"testing"
+
+	astpkg "github.com/paveg/similarity-go/internal/ast"
+)
+
+// parseFunction parses src, a file declaring a single function.
+func parseFunction(t *testing.T, src string) *astpkg.Function {
+	t.Helper()
+
+	result := astpkg.NewParser().ParseSource("a.go", []byte(src))
+	if result.IsErr() {
+		t.Fatalf("failed to parse: %v", result.Error())
+	}
+	functions := result.Unwrap().Functions
+	if len(functions) != 1 {
+		t.Fatalf("expected 1 function, got %d", len(functions))
+	}
+	return functions[0]
+}
+
+// body renders the statements of the body of fn, one per line.
+func body(t *testing.T, fn *astpkg.Function) string {
+	t.Helper()
+
+	src, err := fn.GetSource()
+	if err != nil {
+		t.Fatalf("failed to print function: %v", err)
+	}
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines[1:len(lines)-1], "\n")
+}
+
+func TestFunction_Rewrite(t *testing.T) {
+	tests := []struct {
+		name string
+		opts astpkg.NormalizeOptions
+		body string
+		want string
+	}{
+		{
+			name: "commutative operands",
+			opts: astpkg.NormalizeOptions{CommutativeOperands: true},
+			body: "x := 2 * a\ny := c + b + a*3\nreturn x == y",
+			want: "x := 2 * a\ny := 3*a + c + b\nreturn x == y",
+		},
+		{
+			name: "string concatenation is kept",
+			opts: astpkg.NormalizeOptions{CommutativeOperands: true},
+			body: `s := "a" + b` + "\nreturn len(s)",
+			want: `s := "a" + b` + "\nreturn len(s)",
+		},
+		{
+			name: "concatenation of string variables is kept",
+			opts: astpkg.NormalizeOptions{CommutativeOperands: true},
+			body: "name := fmt.Sprint(a)\nreturn len(name + fmt.Sprint(b))",
+			want: "name := fmt.Sprint(a)\nreturn len(name + fmt.Sprint(b))",
+		},
+		{
+			name: "additions of numeric variables are ordered",
+			opts: astpkg.NormalizeOptions{CommutativeOperands: true},
+			body: "total := len(s)\nreturn total + c*2",
+			want: "total := len(s)\nreturn 2*c + total",
+		},
+		{
+			name: "short-circuit operands are kept",
+			opts: astpkg.NormalizeOptions{CommutativeOperands: true},
+			body: "if len(s) > 0 && s[0] > a {\nreturn 1\n}\nreturn 2",
+			want: "if len(s) > 0 && s[0] > a {\nreturn 1\n}\nreturn 2",
+		},
+		{
+			name: "commutative operands disabled",
+			opts: astpkg.NormalizeOptions{},
+			body: "return 2 * a",
+			want: "return 2 * a",
+		},
+		{
+			name: "negated conditions",
+			opts: astpkg.NormalizeOptions{NegatedConditions: true},
+			body: "if !(a > 0) {\nreturn 1\n} else {\nreturn 2\n}",
+			want: "if a > 0 {\nreturn 2\n} else {\nreturn 1\n}",
+		},
+		{
+			name: "negated condition without else is kept",
+			opts: astpkg.NormalizeOptions{NegatedConditions: true},
+			body: "if !ok {\nreturn 1\n}\nreturn 2",
+			want: "if !ok {\nreturn 1\n}\nreturn 2",
+		},
+		{
+			name: "nil comparisons",
+			opts: astpkg.NormalizeOptions{NilComparisons: true},
+			body: "if nil != err {\nreturn 1\n}\nreturn 2",
+			want: "if err != nil {\nreturn 1\n}\nreturn 2",
+		},
+		{
+			name: "var declarations",
+			opts: astpkg.NormalizeOptions{VarDeclarations: true},
+			body: "var x int = a\nvar y, z = 1, 2\nvar w int\nreturn x + y + z + w",
+			want: "x := a\ny, z := 1, 2\nvar w int\nreturn x + y + z + w",
+		},
+		{
+			name: "increments",
+			opts: astpkg.NormalizeOptions{IncDec: true},
+			body: "a += 1\na -= 1\na += 2\nreturn a",
+			want: "a++\na--\na += 2\nreturn a",
+		},
+		{
+			name: "index loop over a slice",
+			opts: astpkg.NormalizeOptions{RangeLoops: true},
+			body: "for i := 0; i < len(s); i++ {\nv := s[i]\nprintln(v)\n}\nreturn 0",
+			want: "for _, v := range s {\nprintln(v)\n}\nreturn 0",
+		},
+		{
+			name: "index loop using its index",
+			opts: astpkg.NormalizeOptions{RangeLoops: true},
+			body: "for i := 0; i < len(s); i += 1 {\nprintln(i, s[i])\n}\nreturn 0",
+			want: "for i := range s {\nprintln(i, s[i])\n}\nreturn 0",
+		},
+		{
+			name: "counting loop",
+			opts: astpkg.NormalizeOptions{RangeLoops: true},
+			body: "for i := 0; i < a; i++ {\nprintln(i)\n}\nreturn 0",
+			want: "for i := range a {\nprintln(i)\n}\nreturn 0",
+		},
+		{
+			name: "loop assigning its index is kept",
+			opts: astpkg.NormalizeOptions{RangeLoops: true},
+			body: "for i := 0; i < len(s); i++ {\ni++\n}\nreturn 0",
+			want: "for i := 0; i < len(s); i++ {\ni++\n}\nreturn 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := parseFunction(t, "package main\n\nfunc f(a, b, c int, s []int, err error, ok bool) int {\n"+
+				tt.body+"\n}\n")
+			before := body(t, fn)
+
+			if got := body(t, fn.Rewrite(tt.opts)); got != tt.want {
+				t.Errorf("Rewrite() body =\n%s\nwant\n%s", got, tt.want)
+			}
+			if after := body(t, fn); after != before {
+				t.Errorf("Rewrite() changed the original function:\n%s", after)
+			}
+		})
+	}
+}
+
+func TestFunction_NormalizeWith(t *testing.T) {
+	original := parseFunction(t, `package main
+
+func sum(values []int, limit int) int {
+	total := 0
+	for i := 0; i < len(values); i++ {
+		v := values[i]
+		if !(v < limit) {
+			total += 1
+		} else {
+			total = v + total
+		}
+	}
+	return total
+}
+`)
+	rewritten := parseFunction(t, `package main
+
+func add(items []int, max int) int {
+	var sum int = 0
+	for _, item := range items {
+		if item < max {
+			sum = sum + item
+		} else {
+			sum++
+		}
+	}
+	return sum
+}
+`)
+
+	all := astpkg.NormalizeOptions{
+		CommutativeOperands: true,
+		NegatedConditions:   true,
+		NilComparisons:      true,
+		VarDeclarations:     true,
+		IncDec:              true,
+		RangeLoops:          true,
+	}
+	if got, want := body(t, original.NormalizeWith(all)), body(t, rewritten.NormalizeWith(all)); got != want {
+		t.Errorf("normalized bodies differ:\n%s\nand\n%s", got, want)
+	}
+	if body(t, original.Normalize()) == body(t, rewritten.Normalize()) {
+		t.Error("expected the bodies to differ without rewrites")
+	}
+}
@@ -0,0 +1,122 @@
+package ast
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"go/ast"
+	"go/format"
+	"go/token"
+)
+
+// Kinds of declared types analyzed for duplication.
+const (
+	TypeKindStruct    = "struct"
+	TypeKindInterface = "interface"
+	TypeKindFunc      = "func"
+)
+
+// TypeDecl represents a struct, interface or function type declaration.
+type TypeDecl struct {
+	Name       string        // Type name
+	Package    string        // Name of the declaring package
+	ImportPath string        // Import path of the declaring package; empty when unknown
+	File       string        // Source file path
+	StartLine  int           // Starting line number
+	EndLine    int           // Ending line number
+	LineCount  int           // Number of lines in the declaration
+	AST        *ast.TypeSpec // Original AST node
+}
+
+// Kind returns the kind of the declared type: struct, interface or func.
+func (t *TypeDecl) Kind() string {
+	if t.AST == nil {
+		return ""
+	}
+	return typeKind(t.AST.Type)
+}
+
+// QualifiedName returns the name of the type qualified by its package name, such as
+// store.Client.
+func (t *TypeDecl) QualifiedName() string {
+	if t.Package == "" {
+		return t.Name
+	}
+	return t.Package + "." + t.Name
+}
+
+// Fingerprint returns a location-independent identity of the declaration derived from its
+// source without comments, like Function.Fingerprint.
+func (t *TypeDecl) Fingerprint() string {
+	content := t.Name
+
+	if t.AST != nil {
+		spec := *t.AST
+		spec.Doc = nil
+		spec.Comment = nil
+
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), &spec); err == nil {
+			content = buf.String()
+		}
+	}
+
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// typeKind returns the kind of a type expression, or an empty string for kinds that are
+// not analyzed, such as named basic types.
+func typeKind(expr ast.Expr) string {
+	switch expr.(type) {
+	case *ast.StructType:
+		return TypeKindStruct
+	case *ast.InterfaceType:
+		return TypeKindInterface
+	case *ast.FuncType:
+		return TypeKindFunc
+	default:
+		return ""
+	}
+}
+
+// extractTypes extracts the struct, interface and function type declarations of a file.
+// Aliases are skipped, as they do not declare a new type.
+func (p *Parser) extractTypes(file *ast.File, filename, importPath string) []*TypeDecl {
+	var types []*TypeDecl
+
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok || typeSpec.Assign.IsValid() || typeKind(typeSpec.Type) == "" {
+				continue
+			}
+
+			// A declaration without parentheses starts at the type keyword
+			start := typeSpec.Pos()
+			if !genDecl.Lparen.IsValid() {
+				start = genDecl.Pos()
+			}
+			startPos := p.fileSet.Position(start)
+			endPos := p.fileSet.Position(typeSpec.End())
+
+			types = append(types, &TypeDecl{
+				Name:       typeSpec.Name.Name,
+				Package:    file.Name.Name,
+				ImportPath: importPath,
+				File:       filename,
+				StartLine:  startPos.Line,
+				EndLine:    endPos.Line,
+				LineCount:  endPos.Line - startPos.Line + 1,
+				AST:        typeSpec,
+			})
+		}
+	}
+
+	return types
+}
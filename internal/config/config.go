@@ -1,9 +1,13 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"os"
+	"path"
+	"slices"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -20,6 +24,9 @@ const (
 	StructuralWeight         = 0.25
 	SignatureWeight          = 0.15
 	DifferentSignatureWeight = 0.3
+	ControlFlowWeight        = 0.0
+	DependenceWeight         = 0.0
+	APIUsageWeight           = 0.0
 	MaxSignatureLengthDiff   = 50
 	MaxLineDifferenceRatio   = 3.0
 	MaxCacheSize             = 10000
@@ -28,6 +35,47 @@ const (
 	WeightSumTolerance       = 0.05
 )
 
+// Names of the built-in similarity metrics, as listed in the metrics configuration.
+const (
+	MetricTreeEdit        = "tree_edit"
+	MetricTokenSimilarity = "token_similarity"
+	MetricStructural      = "structural"
+	MetricSignature       = "signature"
+	MetricControlFlow     = "control_flow"
+	MetricDependence      = "dependence"
+	MetricAPIUsage        = "api_usage"
+)
+
+// Grouping strategies clustering similar pairs into groups.
+const (
+	// GroupingComponents groups every function transitively connected by a similar pair.
+	GroupingComponents = "components"
+	// GroupingCompleteLinkage merges clusters while every cross pair is similar.
+	GroupingCompleteLinkage = "complete-linkage"
+	// GroupingAverageLinkage merges clusters while their average cross similarity reaches the threshold.
+	GroupingAverageLinkage = "average-linkage"
+	// GroupingCliques repeatedly extracts the largest set of mutually similar functions.
+	GroupingCliques = "cliques"
+)
+
+// GroupingStrategies returns the supported grouping strategies.
+func GroupingStrategies() []string {
+	return []string{GroupingComponents, GroupingCompleteLinkage, GroupingAverageLinkage, GroupingCliques}
+}
+
+// Analysis units, the kinds of declarations compared with each other.
+const (
+	// UnitFuncs compares function and method declarations.
+	UnitFuncs = "funcs"
+	// UnitTypes compares struct, interface and function type declarations.
+	UnitTypes = "types"
+)
+
+// AnalysisUnits returns the supported analysis units.
+func AnalysisUnits() []string {
+	return []string{UnitFuncs, UnitTypes}
+}
+
 // Config represents the complete application configuration.
 type Config struct {
 	CLI        CLIConfig        `yaml:"cli"`
@@ -50,7 +98,12 @@ type CLIConfig struct {
 type SimilarityConfig struct {
 	Thresholds SimilarityThresholds `yaml:"thresholds"`
 	Weights    SimilarityWeights    `yaml:"weights"`
-	Limits     SimilarityLimits     `yaml:"limits"`
+	// Metrics lists the enabled metrics by name with their weights. When set, it replaces
+	// the metric weights of Weights, so metrics registered by other packages can be used.
+	Metrics       MetricWeights       `yaml:"metrics"`
+	Limits        SimilarityLimits    `yaml:"limits"`
+	APIUsage      APIUsageConfig      `yaml:"api_usage"`
+	Normalization NormalizationConfig `yaml:"normalization"`
 }
 
 // SimilarityThresholds contains various threshold values.
@@ -67,6 +120,125 @@ type SimilarityWeights struct {
 	Structural         float64 `yaml:"structural"`
 	Signature          float64 `yaml:"signature"`
 	DifferentSignature float64 `yaml:"different_signature"`
+	ControlFlow        float64 `yaml:"control_flow"` // 0 disables the control-flow graph metric
+	Dependence         float64 `yaml:"dependence"`   // 0 disables the program-dependence graph metric
+	APIUsage           float64 `yaml:"api_usage"`    // 0 disables the API usage metric
+}
+
+// Metrics lists the metrics enabled by the weights: the four base metrics, followed by
+// the optional metrics with a positive weight.
+func (w SimilarityWeights) Metrics() MetricWeights {
+	metrics := MetricWeights{
+		{Name: MetricTreeEdit, Weight: w.TreeEdit},
+		{Name: MetricTokenSimilarity, Weight: w.TokenSimilarity},
+		{Name: MetricStructural, Weight: w.Structural},
+		{Name: MetricSignature, Weight: w.Signature},
+	}
+	for _, optional := range (MetricWeights{
+		{Name: MetricControlFlow, Weight: w.ControlFlow},
+		{Name: MetricDependence, Weight: w.Dependence},
+		{Name: MetricAPIUsage, Weight: w.APIUsage},
+	}) {
+		if optional.Weight > 0 {
+			metrics = append(metrics, optional)
+		}
+	}
+	return metrics
+}
+
+// MetricWeight enables a similarity metric by name with its weight.
+type MetricWeight struct {
+	Name   string  `yaml:"name"`
+	Weight float64 `yaml:"weight"`
+}
+
+// MetricWeights lists enabled metrics with their weights, in the order they are reported.
+type MetricWeights []MetricWeight
+
+// Weight returns the weight of the named metric, or 0 when it is not enabled.
+func (m MetricWeights) Weight(name string) float64 {
+	for _, metric := range m {
+		if metric.Name == name {
+			return metric.Weight
+		}
+	}
+	return 0
+}
+
+// Sum returns the total weight of the metrics.
+func (m MetricWeights) Sum() float64 {
+	var total float64
+	for _, metric := range m {
+		total += metric.Weight
+	}
+	return total
+}
+
+// Names returns the names of the metrics.
+func (m MetricWeights) Names() []string {
+	names := make([]string, len(m))
+	for i, metric := range m {
+		names[i] = metric.Name
+	}
+	return names
+}
+
+// Apply returns weights with the weight of every built-in metric taken from the list, so
+// that it enables the same built-in metrics; metrics of other packages are left out.
+func (m MetricWeights) Apply(weights SimilarityWeights) SimilarityWeights {
+	weights.TreeEdit = m.Weight(MetricTreeEdit)
+	weights.TokenSimilarity = m.Weight(MetricTokenSimilarity)
+	weights.Structural = m.Weight(MetricStructural)
+	weights.Signature = m.Weight(MetricSignature)
+	weights.ControlFlow = m.Weight(MetricControlFlow)
+	weights.Dependence = m.Weight(MetricDependence)
+	weights.APIUsage = m.Weight(MetricAPIUsage)
+	return weights
+}
+
+// EnabledMetrics returns the metrics combined into the similarity score: the metrics
+// list when set, and the metrics enabled by the weights otherwise.
+func (s SimilarityConfig) EnabledMetrics() MetricWeights {
+	if len(s.Metrics) > 0 {
+		return s.Metrics
+	}
+	return s.Weights.Metrics()
+}
+
+// APIUsageConfig configures the API usage metric.
+type APIUsageConfig struct {
+	// Ignore lists path.Match patterns of calls left out of the comparison, such as
+	// "log.*" for package functions or ".Debugf" for methods.
+	Ignore []string `yaml:"ignore"`
+}
+
+// NormalizationConfig selects the rewrites bringing equivalent code written in different
+// ways to one canonical form before functions are compared, so that superficially
+// rewritten copies are still detected. Every rewrite is disabled by default.
+type NormalizationConfig struct {
+	// CommutativeOperands orders the operands of commutative operators, so a + b matches b + a.
+	CommutativeOperands bool `yaml:"commutative_operands"`
+	// NegatedConditions rewrites if !c {A} else {B} as if c {B} else {A}.
+	NegatedConditions bool `yaml:"negated_conditions"`
+	// NilComparisons writes comparisons with nil as x != nil rather than nil != x.
+	NilComparisons bool `yaml:"nil_comparisons"`
+	// VarDeclarations rewrites var x T = v as x := v.
+	VarDeclarations bool `yaml:"var_declarations"`
+	// IncDec rewrites i += 1 as i++ and i -= 1 as i--.
+	IncDec bool `yaml:"inc_dec"`
+	// RangeLoops rewrites index loops over slices and counts as range loops.
+	RangeLoops bool `yaml:"range_loops"`
+}
+
+// DefaultAPIUsageIgnore returns the logging calls ignored by the API usage metric by
+// default, since they are added to functions regardless of what they do: the functions
+// of the log and log/slog packages and the usual methods of loggers. Method patterns
+// start with a dot so that they do not match package functions such as fmt.Println.
+func DefaultAPIUsageIgnore() []string {
+	return []string{
+		"log.*", "slog.*",
+		".Debug", ".Debugf", ".Info", ".Infof", ".Warn", ".Warnf", ".Printf", ".Println",
+	}
 }
 
 // SimilarityLimits contains performance and quality limits.
@@ -78,12 +250,20 @@ type SimilarityLimits struct {
 
 // ProcessingConfig contains processing-related configuration.
 type ProcessingConfig struct {
-	MaxEmptyVsPopulated int `yaml:"max_empty_vs_populated"`
+	MaxEmptyVsPopulated int      `yaml:"max_empty_vs_populated"`
+	Grouping            string   `yaml:"grouping"`
+	Units               []string `yaml:"units"`
+}
+
+// HasUnit reports whether unit is one of the analysis units.
+func (p ProcessingConfig) HasUnit(unit string) bool {
+	return slices.Contains(p.Units, unit)
 }
 
 // OutputConfig contains output formatting configuration.
 type OutputConfig struct {
 	RefactorSuggestion string `yaml:"refactor_suggestion"`
+	Top                int    `yaml:"top"` // 0 reports every group
 }
 
 // IgnoreConfig contains ignore pattern configuration.
@@ -114,15 +294,23 @@ func Default() *Config {
 				Structural:         StructuralWeight,
 				Signature:          SignatureWeight,
 				DifferentSignature: DifferentSignatureWeight,
+				ControlFlow:        ControlFlowWeight,
+				Dependence:         DependenceWeight,
+				APIUsage:           APIUsageWeight,
 			},
 			Limits: SimilarityLimits{
 				MaxSignatureLengthDiff: MaxSignatureLengthDiff,
 				MaxLineDifferenceRatio: MaxLineDifferenceRatio,
 				MaxCacheSize:           MaxCacheSize,
 			},
+			APIUsage: APIUsageConfig{
+				Ignore: DefaultAPIUsageIgnore(),
+			},
 		},
 		Processing: ProcessingConfig{
 			MaxEmptyVsPopulated: MaxEmptyVsPopulated,
+			Grouping:            GroupingComponents,
+			Units:               []string{UnitFuncs},
 		},
 		Output: OutputConfig{
 			RefactorSuggestion: "Consider extracting common logic into a shared function",
@@ -233,35 +421,111 @@ func (c *Config) Validate() error {
 	// Validate weights sum to reasonable values
 	weights := c.Similarity.Weights
 
-	if weights.TreeEdit <= 0 || weights.TokenSimilarity <= 0 ||
-		weights.Structural <= 0 || weights.Signature <= 0 {
+	if len(c.Similarity.Metrics) > 0 {
+		if err := c.Similarity.Metrics.validate(); err != nil {
+			return err
+		}
+	} else if err := weights.validate(); err != nil {
+		return err
+	}
+
+	if weights.DifferentSignature < 0.0 || weights.DifferentSignature > 1.0 {
 		return fmt.Errorf(
-			"similarity weights must be positive (tree_edit=%.4f, token_similarity=%.4f, structural=%.4f, signature=%.4f)",
-			weights.TreeEdit,
-			weights.TokenSimilarity,
-			weights.Structural,
-			weights.Signature,
+			"different_signature weight must be between 0.0 and 1.0, got %.4f",
+			weights.DifferentSignature,
 		)
 	}
 
-	totalWeight := weights.TreeEdit + weights.TokenSimilarity + weights.Structural + weights.Signature
+	for _, pattern := range c.Similarity.APIUsage.Ignore {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("api_usage ignore pattern %q is invalid: %w", pattern, err)
+		}
+	}
+
+	if c.Output.Top < 0 {
+		return fmt.Errorf("top must not be negative, got %d", c.Output.Top)
+	}
 
-	if math.Abs(totalWeight-WeightSumTarget) > WeightSumTolerance {
+	if !slices.Contains(GroupingStrategies(), c.Processing.Grouping) {
 		return fmt.Errorf(
-			"similarity weights must sum to %.2f±%.2f, got %.4f",
-			WeightSumTarget,
-			WeightSumTolerance,
-			totalWeight,
+			"grouping must be one of %s, got %q",
+			strings.Join(GroupingStrategies(), ", "),
+			c.Processing.Grouping,
 		)
 	}
 
-	if weights.DifferentSignature < 0.0 || weights.DifferentSignature > 1.0 {
+	if len(c.Processing.Units) == 0 {
+		return fmt.Errorf("units must list at least one of %s", strings.Join(AnalysisUnits(), ", "))
+	}
+	for _, unit := range c.Processing.Units {
+		if !slices.Contains(AnalysisUnits(), unit) {
+			return fmt.Errorf("units must be among %s, got %q", strings.Join(AnalysisUnits(), ", "), unit)
+		}
+	}
+
+	return nil
+}
+
+// validate checks that the base metrics are enabled, the optional metrics are not
+// negative, and the weights sum to WeightSumTarget.
+func (w SimilarityWeights) validate() error {
+	if w.TreeEdit <= 0 || w.TokenSimilarity <= 0 || w.Structural <= 0 || w.Signature <= 0 {
 		return fmt.Errorf(
-			"different_signature weight must be between 0.0 and 1.0, got %.4f",
-			weights.DifferentSignature,
+			"similarity weights must be positive (tree_edit=%.4f, token_similarity=%.4f, structural=%.4f, signature=%.4f)",
+			w.TreeEdit,
+			w.TokenSimilarity,
+			w.Structural,
+			w.Signature,
 		)
 	}
 
+	// Optional metrics are disabled by a zero weight
+	for _, optional := range []MetricWeight{
+		{Name: MetricControlFlow, Weight: w.ControlFlow},
+		{Name: MetricDependence, Weight: w.Dependence},
+		{Name: MetricAPIUsage, Weight: w.APIUsage},
+	} {
+		if optional.Weight < 0 {
+			return fmt.Errorf("%s weight must not be negative, got %.4f", optional.Name, optional.Weight)
+		}
+	}
+
+	return validateWeightSum("similarity weights", w.Metrics().Sum())
+}
+
+// validate checks that the metrics are named once each with a positive weight, and that
+// the weights sum to WeightSumTarget. Whether the names are registered is checked by the
+// similarity package.
+func (m MetricWeights) validate() error {
+	seen := make(map[string]bool, len(m))
+	for _, metric := range m {
+		if metric.Name == "" {
+			return errors.New("metrics must be named")
+		}
+		if seen[metric.Name] {
+			return fmt.Errorf("metric %q is listed more than once", metric.Name)
+		}
+		seen[metric.Name] = true
+
+		if metric.Weight <= 0 {
+			return fmt.Errorf("weight of metric %q must be positive, got %.4f", metric.Name, metric.Weight)
+		}
+	}
+
+	return validateWeightSum("metric weights", m.Sum())
+}
+
+// validateWeightSum checks that the total weight of the enabled metrics is WeightSumTarget.
+func validateWeightSum(what string, total float64) error {
+	if math.Abs(total-WeightSumTarget) > WeightSumTolerance {
+		return fmt.Errorf(
+			"%s must sum to %.2f±%.2f, got %.4f",
+			what,
+			WeightSumTarget,
+			WeightSumTolerance,
+			total,
+		)
+	}
 	return nil
 }
 
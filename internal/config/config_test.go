@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -106,6 +107,108 @@ func TestValidate(t *testing.T) {
 			},
 			wantError: true,
 		},
+		{
+			name: "control flow weight included in the sum",
+			modifier: func(c *Config) {
+				c.Similarity.Weights.TreeEdit = 0.2
+				c.Similarity.Weights.ControlFlow = 0.1
+			},
+			wantError: false,
+		},
+		{
+			name: "negative control flow weight",
+			modifier: func(c *Config) {
+				c.Similarity.Weights.TreeEdit = 0.4
+				c.Similarity.Weights.ControlFlow = -0.1
+			},
+			wantError: true,
+		},
+		{
+			name: "negative dependence weight",
+			modifier: func(c *Config) {
+				c.Similarity.Weights.TreeEdit = 0.4
+				c.Similarity.Weights.Dependence = -0.1
+			},
+			wantError: true,
+		},
+		{
+			name: "dependence weight included in the sum",
+			modifier: func(c *Config) {
+				c.Similarity.Weights.TokenSimilarity = 0.2
+				c.Similarity.Weights.Dependence = 0.1
+			},
+			wantError: false,
+		},
+		{
+			name: "invalid api usage ignore pattern",
+			modifier: func(c *Config) {
+				c.Similarity.APIUsage.Ignore = []string{"log.["}
+			},
+			wantError: true,
+		},
+		{
+			name: "negative api usage weight",
+			modifier: func(c *Config) {
+				c.Similarity.Weights.TreeEdit = 0.4
+				c.Similarity.Weights.APIUsage = -0.1
+			},
+			wantError: true,
+		},
+		{
+			name: "control flow weight breaking the sum",
+			modifier: func(c *Config) {
+				c.Similarity.Weights.ControlFlow = 0.2
+			},
+			wantError: true,
+		},
+		{
+			name: "metrics list replacing the weights",
+			modifier: func(c *Config) {
+				c.Similarity.Weights = SimilarityWeights{DifferentSignature: DifferentSignatureWeight}
+				c.Similarity.Metrics = MetricWeights{
+					{Name: MetricTreeEdit, Weight: 0.6},
+					{Name: "naming_style", Weight: 0.4},
+				}
+			},
+			wantError: false,
+		},
+		{
+			name: "metrics list not summing to one",
+			modifier: func(c *Config) {
+				c.Similarity.Metrics = MetricWeights{
+					{Name: MetricTreeEdit, Weight: 0.6},
+					{Name: MetricDependence, Weight: 0.2},
+				}
+			},
+			wantError: true,
+		},
+		{
+			name: "metric listed twice",
+			modifier: func(c *Config) {
+				c.Similarity.Metrics = MetricWeights{
+					{Name: MetricTreeEdit, Weight: 0.5},
+					{Name: MetricTreeEdit, Weight: 0.5},
+				}
+			},
+			wantError: true,
+		},
+		{
+			name: "unnamed metric",
+			modifier: func(c *Config) {
+				c.Similarity.Metrics = MetricWeights{{Weight: 1.0}}
+			},
+			wantError: true,
+		},
+		{
+			name: "zero metric weight",
+			modifier: func(c *Config) {
+				c.Similarity.Metrics = MetricWeights{
+					{Name: MetricTreeEdit, Weight: 1.0},
+					{Name: MetricSignature, Weight: 0},
+				}
+			},
+			wantError: true,
+		},
 		{
 			name: "different signature weight out of range",
 			modifier: func(c *Config) {
@@ -113,6 +216,48 @@ func TestValidate(t *testing.T) {
 			},
 			wantError: true,
 		},
+		{
+			name: "average linkage grouping",
+			modifier: func(c *Config) {
+				c.Processing.Grouping = GroupingAverageLinkage
+			},
+			wantError: false,
+		},
+		{
+			name: "negative top",
+			modifier: func(c *Config) {
+				c.Output.Top = -1
+			},
+			wantError: true,
+		},
+		{
+			name: "unknown grouping",
+			modifier: func(c *Config) {
+				c.Processing.Grouping = "single-linkage"
+			},
+			wantError: true,
+		},
+		{
+			name: "functions and types",
+			modifier: func(c *Config) {
+				c.Processing.Units = []string{UnitFuncs, UnitTypes}
+			},
+			wantError: false,
+		},
+		{
+			name: "no units",
+			modifier: func(c *Config) {
+				c.Processing.Units = nil
+			},
+			wantError: true,
+		},
+		{
+			name: "unknown unit",
+			modifier: func(c *Config) {
+				c.Processing.Units = []string{"consts"}
+			},
+			wantError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -129,6 +274,33 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestEnabledMetrics(t *testing.T) {
+	cfg := Default()
+	cfg.Similarity.Weights.TreeEdit = 0.2
+	cfg.Similarity.Weights.Dependence = 0.1
+
+	enabled := cfg.Similarity.EnabledMetrics()
+	want := []string{MetricTreeEdit, MetricTokenSimilarity, MetricStructural, MetricSignature, MetricDependence}
+	if !slices.Equal(enabled.Names(), want) {
+		t.Errorf("EnabledMetrics() = %v, want metrics %v", enabled, want)
+	}
+	if enabled.Weight(MetricDependence) != 0.1 || enabled.Weight(MetricControlFlow) != 0 {
+		t.Errorf("unexpected weights %v", enabled)
+	}
+
+	cfg.Similarity.Metrics = MetricWeights{{Name: "naming_style", Weight: 1.0}}
+	if enabled := cfg.Similarity.EnabledMetrics(); len(enabled) != 1 || enabled[0].Name != "naming_style" {
+		t.Errorf("expected the metrics list to replace the weights, got %v", enabled)
+	}
+
+	applied := MetricWeights{{Name: MetricTreeEdit, Weight: 0.7}, {Name: MetricAPIUsage, Weight: 0.3}}.
+		Apply(Default().Similarity.Weights)
+	if applied.TreeEdit != 0.7 || applied.APIUsage != 0.3 || applied.Structural != 0 ||
+		applied.DifferentSignature != DifferentSignatureWeight {
+		t.Errorf("unexpected applied weights %+v", applied)
+	}
+}
+
 func TestLoadAndSave(t *testing.T) {
 	tempDir := t.TempDir()
 	configPath := filepath.Join(tempDir, "test-config.yaml")
@@ -163,6 +335,31 @@ func TestLoadAndSave(t *testing.T) {
 	}
 }
 
+func TestLoadNormalization(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `similarity:
+  normalization:
+    nil_comparisons: true
+    range_loops: true
+`
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	want := NormalizationConfig{NilComparisons: true, RangeLoops: true}
+	if cfg.Similarity.Normalization != want {
+		t.Errorf("Normalization = %+v, want %+v", cfg.Similarity.Normalization, want)
+	}
+	if Default().Similarity.Normalization != (NormalizationConfig{}) {
+		t.Error("expected every normalization rewrite to be disabled by default")
+	}
+}
+
 func TestGetIgnoreFilePath(t *testing.T) {
 	cfg := Default()
 
@@ -0,0 +1,119 @@
+package refactor
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// Constraints proposed for type parameters.
+const (
+	constraintAny        = "any"
+	constraintComparable = "comparable"
+	constraintOrdered    = "cmp.Ordered"
+)
+
+// constraintPackage is the package declaring constraintOrdered.
+const constraintPackage = "cmp"
+
+// operators records which kinds of operators a function body uses.
+type operators struct {
+	equality   bool // == and !=
+	ordering   bool // <, <=, > and >=
+	addition   bool // +, also defined on strings
+	arithmetic bool // Other arithmetic operators, only defined on numbers
+	constants  bool // Numeric constants, only assignable to numbers
+}
+
+// collectOperators returns the operators used by body.
+func collectOperators(body *ast.BlockStmt) operators {
+	var ops operators
+
+	record := func(tok token.Token) {
+		//nolint:exhaustive // Other tokens do not restrict the operand types
+		switch tok {
+		case token.EQL, token.NEQ:
+			ops.equality = true
+		case token.LSS, token.LEQ, token.GTR, token.GEQ:
+			ops.ordering = true
+		case token.ADD, token.ADD_ASSIGN:
+			ops.addition = true
+		case token.SUB, token.MUL, token.QUO, token.REM, token.INC, token.DEC,
+			token.SUB_ASSIGN, token.MUL_ASSIGN, token.QUO_ASSIGN, token.REM_ASSIGN:
+			ops.arithmetic = true
+		}
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.BinaryExpr:
+			record(node.Op)
+		case *ast.UnaryExpr:
+			record(node.Op)
+		case *ast.AssignStmt:
+			record(node.Tok)
+		case *ast.IncDecStmt:
+			record(node.Tok)
+		case *ast.BasicLit:
+			ops.constants = ops.constants || node.Kind != token.STRING
+		}
+		return true
+	})
+
+	return ops
+}
+
+// inferConstraint proposes the constraint of a type parameter replacing names, from the
+// operators and constants used by the body. Operators are not attributed to values of
+// the type parameter, as that needs type checking, so the constraint may be stricter
+// than needed; it is always satisfied by names. cmp.Ordered includes string, so it is
+// only proposed when the body neither does arithmetic nor uses numeric constants.
+func inferConstraint(names []string, ops operators) string {
+	allNumeric, allOrdered, allComparable := true, true, true
+	for _, name := range names {
+		info := basicInfo(name)
+		allNumeric = allNumeric && info&types.IsNumeric != 0
+		allOrdered = allOrdered && info&types.IsOrdered != 0
+		allComparable = allComparable && info&(types.IsBoolean|types.IsNumeric|types.IsString) != 0
+	}
+
+	switch {
+	case allNumeric && (ops.arithmetic || ops.addition || ops.constants):
+		return unionConstraint(names)
+	case ops.ordering && allOrdered:
+		return constraintOrdered
+	case ops.addition && allOrdered:
+		return unionConstraint(names)
+	case ops.equality && allComparable:
+		return constraintComparable
+	default:
+		return constraintAny
+	}
+}
+
+// unionConstraint returns the union of the underlying types of names, without duplicates.
+func unionConstraint(names []string) string {
+	seen := make(map[string]bool, len(names))
+	var terms []string
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			terms = append(terms, "~"+name)
+		}
+	}
+	return strings.Join(terms, " | ")
+}
+
+// basicInfo returns the properties of the predeclared basic type name, or 0 when name
+// is not one.
+func basicInfo(name string) types.BasicInfo {
+	obj, ok := types.Universe.Lookup(name).(*types.TypeName)
+	if !ok {
+		return 0
+	}
+	if basic, ok := obj.Type().(*types.Basic); ok {
+		return basic.Info()
+	}
+	return 0
+}
@@ -0,0 +1,31 @@
+package refactor
+
+import "testing"
+
+func TestInferConstraint(t *testing.T) {
+	tests := []struct {
+		name  string
+		types []string
+		ops   operators
+		want  string
+	}{
+		{name: "arithmetic", types: []string{"int", "int64", "int"}, ops: operators{arithmetic: true, ordering: true}, want: "~int | ~int64"},
+		{name: "ordering", types: []string{"int", "float64", "string"}, ops: operators{ordering: true}, want: "cmp.Ordered"},
+		{name: "ordering with constants", types: []string{"int", "float64"}, ops: operators{ordering: true, constants: true}, want: "~int | ~float64"},
+		{name: "ordering with addition", types: []string{"int", "int64"}, ops: operators{ordering: true, addition: true}, want: "~int | ~int64"},
+		{name: "concatenation", types: []string{"string", "int"}, ops: operators{addition: true}, want: "~string | ~int"},
+		{name: "complex numbers are not ordered", types: []string{"complex128", "int"}, ops: operators{ordering: true}, want: "any"},
+		{name: "equality", types: []string{"string", "int"}, ops: operators{equality: true}, want: "comparable"},
+		{name: "equality of booleans", types: []string{"bool", "complex64"}, ops: operators{equality: true}, want: "comparable"},
+		{name: "named types", types: []string{"User", "Admin"}, ops: operators{equality: true}, want: "any"},
+		{name: "no operators", types: []string{"int", "string"}, want: "any"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inferConstraint(tt.types, tt.ops); got != tt.want {
+				t.Errorf("inferConstraint(%v) = %q, want %q", tt.types, got, tt.want)
+			}
+		})
+	}
+}
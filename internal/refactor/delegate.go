@@ -0,0 +1,84 @@
+package refactor
+
+import (
+	"errors"
+	"go/ast"
+)
+
+var (
+	// ErrNotIdentical is returned by Delegate when the declarations differ by more than
+	// the names of their local variables and parameters.
+	ErrNotIdentical = errors.New("declarations are not identical")
+
+	// ErrUnnamedParameter is returned by Delegate when the receiver or a parameter of the
+	// duplicate cannot be forwarded because it is unnamed or blank.
+	ErrUnnamedParameter = errors.New("unnamed parameters cannot be forwarded")
+)
+
+// Delegate returns a body for duplicate that calls canonical instead, forwarding its
+// receiver and parameters. The declarations must be exact clones: identical once their
+// local variables and parameters are renamed consistently.
+func Delegate(canonical, duplicate *ast.FuncDecl) (*ast.BlockStmt, error) {
+	u, _, err := antiUnify([]*ast.FuncDecl{canonical, duplicate})
+	if err != nil || len(u.holes) > 0 {
+		return nil, ErrNotIdentical
+	}
+
+	var fun ast.Expr = ast.NewIdent(canonical.Name.Name)
+	if duplicate.Recv != nil {
+		receivers, namesErr := fieldNames(duplicate.Recv)
+		if namesErr != nil {
+			return nil, namesErr
+		}
+		fun = &ast.SelectorExpr{X: receivers[0], Sel: ast.NewIdent(canonical.Name.Name)}
+	}
+
+	if typeParams := duplicate.Type.TypeParams; typeParams != nil && len(typeParams.List) > 0 {
+		names, namesErr := fieldNames(typeParams)
+		if namesErr != nil {
+			return nil, namesErr
+		}
+		fun = &ast.IndexListExpr{X: fun, Indices: names}
+	}
+
+	args, err := fieldNames(duplicate.Type.Params)
+	if err != nil {
+		return nil, err
+	}
+	if isVariadic(duplicate, lastIdent(args)) {
+		last := args[len(args)-1].(*ast.Ident) //nolint:errcheck // fieldNames only returns identifiers
+		args[len(args)-1] = ast.NewIdent(last.Name + "...")
+	}
+
+	call := &ast.CallExpr{Fun: fun, Args: args}
+	if results := duplicate.Type.Results; results != nil && len(results.List) > 0 {
+		return &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{call}}}}, nil
+	}
+	return &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: call}}}, nil
+}
+
+// fieldNames returns the names declared by a field list, failing on unnamed or blank fields.
+func fieldNames(list *ast.FieldList) ([]ast.Expr, error) {
+	var names []ast.Expr
+	for _, field := range list.List {
+		if len(field.Names) == 0 {
+			return nil, ErrUnnamedParameter
+		}
+		for _, name := range field.Names {
+			if name.Name == "_" {
+				return nil, ErrUnnamedParameter
+			}
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// lastIdent returns the last of names, or nil when there are none.
+func lastIdent(names []ast.Expr) *ast.Ident {
+	if len(names) == 0 {
+		return nil
+	}
+	ident, _ := names[len(names)-1].(*ast.Ident)
+	return ident
+}
@@ -0,0 +1,78 @@
+package refactor
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDelegate(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+		err  error
+	}{
+		{
+			name: "renamed parameters",
+			src: `
+func total(values []int) int {
+	sum := 0
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
+
+func sumAll(items []int) int {
+	acc := 0
+	for _, item := range items {
+		acc += item
+	}
+	return acc
+}`,
+			want: "{\n\treturn total(items)\n}",
+		},
+		{
+			name: "methods and variadic parameters",
+			src: `
+func (l *Logger) Info(format string, args ...any) { l.write("info", format, args...) }
+func (log *Logger) Notice(f string, values ...any) { log.write("info", f, values...) }`,
+			want: "{\n\tlog.Info(f, values...)\n}",
+		},
+		{
+			name: "generic functions",
+			src: `
+func first[T any](values []T) T { return values[0] }
+func head[T any](items []T) T { return items[0] }`,
+			want: "{\n\treturn first[T](items)\n}",
+		},
+		{
+			name: "different literals",
+			src: `
+func a() string { return "a" }
+func b() string { return "b" }`,
+			err: ErrNotIdentical,
+		},
+		{
+			name: "blank parameter",
+			src: `
+func a(_ int) string { return "a" }
+func b(_ int) string { return "a" }`,
+			err: ErrUnnamedParameter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decls := parseDecls(t, tt.src)
+
+			body, err := Delegate(decls[0], decls[1])
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if err == nil && render(body) != tt.want {
+				t.Errorf("body = %q, want %q", render(body), tt.want)
+			}
+		})
+	}
+}
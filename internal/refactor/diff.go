@@ -0,0 +1,177 @@
+package refactor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// Operations of a line-based edit script.
+const (
+	opEqual  = ' '
+	opDelete = '-'
+	opInsert = '+'
+)
+
+// diffLine is a line of an edit script.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// UnifiedDiff returns the changes from before to after in unified diff format, with
+// path as the name of both files under the a/ and b/ prefixes used by git. It returns an
+// empty string when nothing changed.
+func UnifiedDiff(path string, before, after []byte) string {
+	path = strings.TrimPrefix(path, "/")
+	script := editScript(splitLines(string(before)), splitLines(string(after)))
+
+	var b strings.Builder
+	for start := 0; start < len(script); {
+		// Find the next change and extend the hunk while changes are close enough
+		first := start
+		for first < len(script) && script[first].op == opEqual {
+			first++
+		}
+		if first == len(script) {
+			break
+		}
+
+		last := first
+		for i := first; i < len(script); i++ {
+			if script[i].op != opEqual {
+				last = i
+			} else if i-last > 2*diffContext {
+				break
+			}
+		}
+
+		from := max(first-diffContext, start)
+		to := min(last+diffContext+1, len(script))
+
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", path, path)
+		}
+		writeHunk(&b, script, from, to)
+
+		start = to
+	}
+
+	return b.String()
+}
+
+// writeHunk writes the lines from..to of an edit script as one hunk.
+func writeHunk(b *strings.Builder, script []diffLine, from, to int) {
+	// Line numbers of the hunk start in each file, counting from 1
+	beforeLine, afterLine := 1, 1
+	for _, line := range script[:from] {
+		if line.op != opInsert {
+			beforeLine++
+		}
+		if line.op != opDelete {
+			afterLine++
+		}
+	}
+
+	var beforeCount, afterCount int
+	for _, line := range script[from:to] {
+		if line.op != opInsert {
+			beforeCount++
+		}
+		if line.op != opDelete {
+			afterCount++
+		}
+	}
+
+	fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(beforeLine, beforeCount), hunkRange(afterLine, afterCount))
+	for _, line := range script[from:to] {
+		b.WriteByte(line.op)
+		b.WriteString(line.text)
+		if !strings.HasSuffix(line.text, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats the start and length of a hunk in one file.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// An empty range refers to the line before it
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits text into lines, keeping their line terminators.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// editScript returns the shortest sequence of line operations turning before into after,
+// computed from their longest common subsequence. The common prefix and suffix are
+// skipped first, as edits usually touch a small part of a file.
+func editScript(before, after []string) []diffLine {
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix &&
+		before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+
+	oldLines := before[prefix : len(before)-suffix]
+	newLines := after[prefix : len(after)-suffix]
+
+	// common[i][j] is the length of the longest common subsequence of oldLines[i:] and newLines[j:]
+	common := make([][]int, len(oldLines)+1)
+	for i := range common {
+		common[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	script := make([]diffLine, 0, len(before)+len(newLines))
+	for _, line := range before[:prefix] {
+		script = append(script, diffLine{op: opEqual, text: line})
+	}
+
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			script = append(script, diffLine{op: opEqual, text: oldLines[i]})
+			i++
+			j++
+		case j == len(newLines) || (i < len(oldLines) && common[i+1][j] >= common[i][j+1]):
+			script = append(script, diffLine{op: opDelete, text: oldLines[i]})
+			i++
+		default:
+			script = append(script, diffLine{op: opInsert, text: newLines[j]})
+			j++
+		}
+	}
+
+	for _, line := range before[len(before)-suffix:] {
+		script = append(script, diffLine{op: opEqual, text: line})
+	}
+
+	return script
+}
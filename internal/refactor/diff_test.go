@@ -0,0 +1,55 @@
+package refactor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	var before, after []string
+	for i := 1; i <= 20; i++ {
+		line := strings.Repeat("x", i)
+		before = append(before, line)
+		switch i {
+		case 2:
+			after = append(after, "changed")
+		case 15:
+			after = append(after, line, "added")
+		default:
+			after = append(after, line)
+		}
+	}
+
+	got := UnifiedDiff("file.go",
+		[]byte(strings.Join(before, "\n")+"\n"), []byte(strings.Join(after, "\n")+"\n"))
+
+	want := `--- a/file.go
++++ b/file.go
+@@ -1,5 +1,5 @@
+ x
+-xx
++changed
+ xxx
+ xxxx
+ xxxxx
+@@ -13,6 +13,7 @@
+ xxxxxxxxxxxxx
+ xxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxx
++added
+ xxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxxx
+`
+	if got != want {
+		t.Errorf("unexpected diff:\n%s", got)
+	}
+
+	if diff := UnifiedDiff("file.go", []byte("same\n"), []byte("same\n")); diff != "" {
+		t.Errorf("expected no diff, got:\n%s", diff)
+	}
+
+	if diff := UnifiedDiff("file.go", []byte("a"), []byte("b")); !strings.Contains(diff, "\\ No newline at end of file") {
+		t.Errorf("expected a missing newline marker, got:\n%s", diff)
+	}
+}
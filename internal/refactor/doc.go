@@ -0,0 +1,26 @@
+// Package refactor proposes refactorings for groups of similar functions.
+//
+// Extract anti-unifies the declarations of a group: it walks their syntax trees in
+// parallel and keeps everything they share, turning every differing sub-expression
+// into a parameter of a shared function.
+//
+// Key Components:
+//   - Extract: Computes the shared function of a group of declarations
+//   - Extraction: Signature and body of the shared function, and the call replacing each declaration
+//   - Parameter: A difference between the declarations and its value in each of them
+//   - RenameInconsistencies: Finds the places where a clone does not rename a variable the
+//     way it does everywhere else, a typical copy-paste bug
+//
+// The result is a sketch, not a verified rewrite: without type checking, the types of
+// value parameters are only known for literals, and other parameters are typed any.
+//
+// Example Usage:
+//
+//	extraction, err := refactor.Extract([]*ast.FuncDecl{first, second})
+//	if err == nil {
+//		fmt.Println(extraction.Body)
+//		for _, call := range extraction.Calls {
+//			fmt.Println(call)
+//		}
+//	}
+package refactor
@@ -0,0 +1,880 @@
+package refactor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+
+	astpkg "github.com/paveg/similarity-go/internal/ast"
+)
+
+// Kinds of differences between the declarations of a group.
+const (
+	KindLiteral    = "literal"
+	KindIdentifier = "identifier"
+	KindCall       = "call"
+	KindType       = "type"
+	KindExpression = "expression"
+)
+
+// MaxParameters is the number of differences above which no extraction is suggested:
+// a shared function taking that many extra parameters is rarely an improvement.
+const MaxParameters = 6
+
+// minDeclarations is the number of declarations needed to find differences.
+const minDeclarations = 2
+
+var (
+	// ErrDifferentStructure is returned when the declarations differ by more than
+	// expressions, so no single function can replace them.
+	ErrDifferentStructure = errors.New("declarations differ in structure")
+
+	// ErrLocalDependency is returned when a difference depends on a variable declared
+	// inside the function body, which a call site cannot pass.
+	ErrLocalDependency = errors.New("a difference depends on a local variable")
+
+	// ErrTooManyDifferences is returned when extracting would need more than
+	// MaxParameters extra parameters.
+	ErrTooManyDifferences = errors.New("too many differences to extract")
+)
+
+// Parameter is a difference between the declarations, turned into a parameter of the
+// extracted function.
+type Parameter struct {
+	Name   string   // Name of the parameter, or of the type parameter for KindType
+	Type   string   // Type of the parameter, or constraint for KindType
+	Kind   string   // One of the Kind constants
+	Values []string // Differing code, one per declaration
+}
+
+// Extraction is a shared function that can replace a group of similar declarations.
+type Extraction struct {
+	Name       string      // Name of the shared function
+	Signature  string      // Signature of the shared function
+	Body       string      // Source of the shared function
+	Parameters []Parameter // Differences turned into parameters
+	Calls      []string    // Body replacing each declaration, one per declaration
+	Generic    bool        // The declarations only differ by types
+	Imports    []string    // Packages the shared function needs besides those of the declarations
+}
+
+// Extract anti-unifies declarations: it walks their syntax trees in parallel and
+// replaces every sub-expression that differs between them with a parameter. Literals,
+// identifiers, called functions and whole expressions become value parameters; types
+// become type parameters. Local variables renamed consistently are not differences.
+// Types that cannot be told without type checking are given as any. The constraints of
+// type parameters are inferred from the types they replace and the operators the body
+// uses: cmp.Ordered, a union of the types, comparable or any.
+func Extract(decls []*ast.FuncDecl) (*Extraction, error) {
+	if len(decls) < minDeclarations {
+		return nil, fmt.Errorf("need at least %d declarations, got %d", minDeclarations, len(decls))
+	}
+
+	u, clone, err := antiUnify(decls)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range u.holes {
+		for i, value := range h.values {
+			if referencesBodyLocal(decls[i], value) {
+				return nil, ErrLocalDependency
+			}
+		}
+	}
+
+	params := u.parameters(clone)
+	if len(params) > MaxParameters {
+		return nil, ErrTooManyDifferences
+	}
+
+	ops := collectOperators(decls[0].Body)
+	for i := range params {
+		if params[i].Kind == KindType {
+			params[i].Type = inferConstraint(params[i].Values, ops)
+		}
+	}
+
+	return u.extraction(clone, params), nil
+}
+
+// antiUnify walks decls in parallel and returns their differences, along with a copy
+// of the first declaration where each difference is replaced by a placeholder.
+func antiUnify(decls []*ast.FuncDecl) (*unifier, *ast.FuncDecl, error) {
+	for _, decl := range decls {
+		if decl.Body == nil || (decl.Recv == nil) != (decls[0].Recv == nil) {
+			return nil, nil, ErrDifferentStructure
+		}
+	}
+
+	clone := astpkg.CloneNode(decls[0])
+	u := newUnifier(decls)
+
+	parts := []func(*ast.FuncDecl) any{
+		func(decl *ast.FuncDecl) any { return decl.Recv },
+		func(decl *ast.FuncDecl) any { return decl.Type },
+		func(decl *ast.FuncDecl) any { return decl.Body },
+	}
+	for _, part := range parts {
+		values := make([]reflect.Value, 0, len(decls)+1)
+		for _, decl := range decls {
+			values = append(values, reflect.ValueOf(part(decl)))
+		}
+		values = append(values, reflect.ValueOf(part(clone)))
+
+		if !u.unify(values, context{}) {
+			return nil, nil, ErrDifferentStructure
+		}
+	}
+
+	return u, clone, nil
+}
+
+// context describes the position of the expression being unified.
+type context struct {
+	typ       bool // The expression denotes a type
+	fixed     bool // The expression cannot be replaced by a parameter
+	call      bool // The expression is the function of a call
+	arity     int  // Number of arguments of the call
+	discarded bool // The result of the call is not used
+}
+
+// hole is a sub-expression that differs between the declarations.
+type hole struct {
+	placeholder *ast.Ident // Replaces the expression in the shared function
+	kind        string
+	typ         string
+	values      []ast.Expr // Expression of each declaration
+}
+
+// unifier walks declarations in parallel, collecting their differences.
+type unifier struct {
+	decls []*ast.FuncDecl
+	holes []*hole
+
+	// toFirst and fromFirst map the names of the local variables of each declaration
+	// to the names used by the first one, and back.
+	toFirst   []map[string]string
+	fromFirst []map[string]string
+}
+
+func newUnifier(decls []*ast.FuncDecl) *unifier {
+	u := &unifier{
+		decls:     decls,
+		toFirst:   make([]map[string]string, len(decls)),
+		fromFirst: make([]map[string]string, len(decls)),
+	}
+	for i := range decls {
+		u.toFirst[i] = make(map[string]string)
+		u.fromFirst[i] = make(map[string]string)
+	}
+	return u
+}
+
+// unify compares the values of every declaration, followed by the value of the clone
+// of the first declaration, where differences are replaced by placeholders. It reports
+// false when the values differ and cannot be replaced at this level.
+func (u *unifier) unify(values []reflect.Value, ctx context) bool {
+	originals := values[:len(values)-1]
+	first := originals[0]
+
+	//nolint:exhaustive // Remaining kinds are compared by value
+	switch first.Kind() {
+	case reflect.Interface:
+		return u.unifyInterface(values, ctx)
+	case reflect.Pointer:
+		return u.unifyPointer(values, ctx)
+	case reflect.Slice:
+		for _, value := range originals[1:] {
+			if value.Len() != first.Len() {
+				return false
+			}
+		}
+		for i := range first.Len() {
+			if !u.unify(mapValues(values, func(v reflect.Value) reflect.Value { return v.Index(i) }), ctx) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		return u.unifyStruct(values, ctx)
+	default:
+		for _, value := range originals[1:] {
+			if value.Interface() != first.Interface() {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// unifyInterface compares nodes held by an interface. Differing expressions become holes.
+func (u *unifier) unifyInterface(values []reflect.Value, ctx context) bool {
+	originals := values[:len(values)-1]
+	first := originals[0]
+
+	for _, value := range originals[1:] {
+		if value.IsNil() != first.IsNil() {
+			return false
+		}
+	}
+	if first.IsNil() {
+		return true
+	}
+
+	elems := mapValues(values, reflect.Value.Elem)
+	sameType := true
+	for _, elem := range elems[1 : len(elems)-1] {
+		sameType = sameType && elem.Type() == elems[0].Type()
+	}
+
+	mark := len(u.holes)
+	if sameType && u.unify(elems, ctx) {
+		return true
+	}
+
+	// Differences found inside are covered by the hole replacing the whole expression
+	u.holes = u.holes[:mark]
+	if first.Type() != reflect.TypeFor[ast.Expr]() {
+		return false
+	}
+	return u.addHole(values, ctx)
+}
+
+// unifyPointer compares the nodes pointed to by values.
+func (u *unifier) unifyPointer(values []reflect.Value, ctx context) bool {
+	originals := values[:len(values)-1]
+	first := originals[0]
+
+	for _, value := range originals[1:] {
+		if value.IsNil() != first.IsNil() {
+			return false
+		}
+	}
+	if first.IsNil() {
+		return true
+	}
+
+	switch first.Type() {
+	case reflect.TypeFor[*ast.Object](), reflect.TypeFor[*ast.Scope](), reflect.TypeFor[*ast.CommentGroup]():
+		return true
+	case reflect.TypeFor[*ast.Ident]():
+		idents := make([]*ast.Ident, len(originals))
+		for i, value := range originals {
+			idents[i] = value.Interface().(*ast.Ident) //nolint:errcheck // Checked by the type switch
+		}
+		return u.unifyIdent(idents, ctx)
+	case reflect.TypeFor[*ast.BasicLit]():
+		lit := first.Interface().(*ast.BasicLit) //nolint:errcheck // Checked by the type switch
+		for _, value := range originals[1:] {
+			other := value.Interface().(*ast.BasicLit) //nolint:errcheck // Checked by the type switch
+			if other.Kind != lit.Kind || other.Value != lit.Value {
+				return false
+			}
+		}
+		return true
+	default:
+		return u.unify(mapValues(values, reflect.Value.Elem), ctx)
+	}
+}
+
+// unifyStruct compares the fields of nodes, skipping positions.
+func (u *unifier) unifyStruct(values []reflect.Value, ctx context) bool {
+	structType := values[0].Type()
+
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		if field.Type == reflect.TypeFor[token.Pos]() {
+			continue
+		}
+
+		fields := mapValues(values, func(v reflect.Value) reflect.Value { return v.Field(i) })
+		if !u.unify(fields, childContext(structType, field.Name, values[0], ctx)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// childContext returns the context of a field of a node.
+func childContext(parent reflect.Type, field string, node reflect.Value, ctx context) context {
+	child := context{typ: ctx.typ}
+
+	switch parent {
+	case reflect.TypeFor[ast.AssignStmt]():
+		child.fixed = field == "Lhs"
+	case reflect.TypeFor[ast.IncDecStmt]():
+		child.fixed = field == "X"
+	case reflect.TypeFor[ast.RangeStmt]():
+		child.fixed = field == "Key" || field == "Value"
+	case reflect.TypeFor[ast.KeyValueExpr]():
+		// Keys of struct literals are field names
+		child.fixed = field == "Key"
+	case reflect.TypeFor[ast.SelectorExpr]():
+		// Package names cannot be passed as values
+		child.fixed = field == "X" && isUnresolvedIdent(node.FieldByName("X"))
+	case reflect.TypeFor[ast.ExprStmt]():
+		child.discarded = true
+	case reflect.TypeFor[ast.CallExpr]():
+		if field == "Fun" {
+			child.call = true
+			child.arity = node.FieldByName("Args").Len()
+			child.discarded = ctx.discarded
+		}
+	case reflect.TypeFor[ast.CompositeLit]():
+		child.typ = field == "Type"
+	case reflect.TypeFor[ast.ValueSpec](), reflect.TypeFor[ast.Field](), reflect.TypeFor[ast.TypeAssertExpr]():
+		child.typ = field == "Type"
+	case reflect.TypeFor[ast.ArrayType]():
+		child.typ = field == "Elt"
+	case reflect.TypeFor[ast.MapType](), reflect.TypeFor[ast.ChanType](), reflect.TypeFor[ast.Ellipsis]():
+		child.typ = true
+	case reflect.TypeFor[ast.FuncLit]():
+		child.typ = false
+	}
+
+	return child
+}
+
+// isUnresolvedIdent reports whether v holds an identifier that is not declared in the
+// file, such as a package name.
+func isUnresolvedIdent(v reflect.Value) bool {
+	ident, ok := v.Interface().(*ast.Ident)
+	return ok && ident.Obj == nil
+}
+
+// unifyIdent compares identifiers. Local variables match when they are renamed
+// consistently across the whole declaration.
+func (u *unifier) unifyIdent(idents []*ast.Ident, ctx context) bool {
+	first := idents[0]
+	firstLocal := u.isLocal(0, first)
+
+	for i := 1; i < len(idents); i++ {
+		ident := idents[i]
+		if u.isLocal(i, ident) != firstLocal {
+			return false
+		}
+
+		if !firstLocal || first.Name == "_" || ident.Name == "_" || ctx.typ {
+			if ident.Name != first.Name {
+				return false
+			}
+			continue
+		}
+
+		mapped, seen := u.toFirst[i][ident.Name]
+		back, seenBack := u.fromFirst[i][first.Name]
+		if !seen && !seenBack {
+			u.toFirst[i][ident.Name] = first.Name
+			u.fromFirst[i][first.Name] = ident.Name
+			continue
+		}
+		if mapped != first.Name || back != ident.Name {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isLocal reports whether ident refers to a variable declared inside declaration i,
+// including its receiver and parameters.
+func (u *unifier) isLocal(i int, ident *ast.Ident) bool {
+	return ident.Obj != nil && ident.Obj.Kind == ast.Var && declaredIn(u.decls[i], ident.Obj)
+}
+
+// declaredIn reports whether obj is declared inside decl.
+func declaredIn(decl *ast.FuncDecl, obj *ast.Object) bool {
+	pos := obj.Pos()
+	return pos.IsValid() && pos >= decl.Pos() && pos < decl.End()
+}
+
+// addHole replaces differing expressions with a placeholder.
+func (u *unifier) addHole(values []reflect.Value, ctx context) bool {
+	if ctx.fixed {
+		return false
+	}
+
+	originals := values[:len(values)-1]
+	exprs := make([]ast.Expr, len(originals))
+	for i, value := range originals {
+		exprs[i] = value.Interface().(ast.Expr) //nolint:errcheck // Only expressions become holes
+	}
+
+	h := &hole{placeholder: ast.NewIdent("_"), values: exprs}
+	h.kind, h.typ = classify(exprs, ctx)
+
+	values[len(values)-1].Set(reflect.ValueOf(h.placeholder))
+	u.holes = append(u.holes, h)
+
+	return true
+}
+
+// classify returns the kind of a difference and the type of its parameter.
+func classify(exprs []ast.Expr, ctx context) (string, string) {
+	if ctx.typ || allOf(exprs, isTypeExpr) {
+		return KindType, constraintAny
+	}
+
+	if ctx.call {
+		params := strings.TrimSuffix(strings.Repeat("any, ", ctx.arity), ", ")
+		if ctx.discarded {
+			return KindCall, "func(" + params + ")"
+		}
+		return KindCall, "func(" + params + ") any"
+	}
+
+	if lit, ok := exprs[0].(*ast.BasicLit); ok && allOf(exprs, func(expr ast.Expr) bool {
+		other, isLit := expr.(*ast.BasicLit)
+		return isLit && other.Kind == lit.Kind
+	}) {
+		return KindLiteral, literalType(lit.Kind)
+	}
+
+	if allOf(exprs, func(expr ast.Expr) bool {
+		switch expr.(type) {
+		case *ast.Ident, *ast.SelectorExpr:
+			return true
+		default:
+			return false
+		}
+	}) {
+		return KindIdentifier, "any"
+	}
+
+	return KindExpression, "any"
+}
+
+// literalType returns the default type of a literal.
+func literalType(kind token.Token) string {
+	//nolint:exhaustive // Only literal tokens reach this point
+	switch kind {
+	case token.INT:
+		return "int"
+	case token.FLOAT:
+		return "float64"
+	case token.IMAG:
+		return "complex128"
+	case token.CHAR:
+		return "rune"
+	default:
+		return "string"
+	}
+}
+
+// isTypeExpr reports whether expr can only denote a type.
+func isTypeExpr(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType, *ast.StructType:
+		return true
+	case *ast.Ident:
+		return e.Obj == nil && isPredeclaredType(e.Name)
+	case *ast.StarExpr:
+		return isTypeExpr(e.X)
+	default:
+		return false
+	}
+}
+
+// isPredeclaredType reports whether name is a predeclared type.
+func isPredeclaredType(name string) bool {
+	_, ok := types.Universe.Lookup(name).(*types.TypeName)
+	return ok
+}
+
+// isPredeclared reports whether name is a predeclared identifier, which the shared
+// function should not shadow.
+func isPredeclared(name string) bool {
+	return types.Universe.Lookup(name) != nil
+}
+
+// referencesBodyLocal reports whether expr uses a variable declared in the body of decl,
+// as opposed to its receiver or parameters.
+func referencesBodyLocal(decl *ast.FuncDecl, expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok || ident.Obj == nil || ident.Obj.Kind != ast.Var || !declaredIn(decl, ident.Obj) {
+			return !found
+		}
+		if !isParameter(decl, ident.Obj) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// isParameter reports whether obj is the receiver or a parameter of decl.
+func isParameter(decl *ast.FuncDecl, obj *ast.Object) bool {
+	field, ok := obj.Decl.(*ast.Field)
+	if !ok {
+		return false
+	}
+
+	for _, list := range []*ast.FieldList{decl.Recv, decl.Type.Params} {
+		if list == nil {
+			continue
+		}
+		for _, candidate := range list.List {
+			if candidate == field {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parameters names the holes, merging the ones with the same values into one parameter.
+func (u *unifier) parameters(clone *ast.FuncDecl) []Parameter {
+	reserved := make(map[string]bool)
+	ast.Inspect(clone, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			reserved[ident.Name] = true
+		}
+		return true
+	})
+
+	typeHoles := 0
+	seen := make(map[string]bool)
+	for _, h := range u.holes {
+		if key := holeKey(h); h.kind == KindType && !seen[key] {
+			seen[key] = true
+			typeHoles++
+		}
+	}
+
+	var params []Parameter
+	byKey := make(map[string]string)
+	typeIndex := 0
+
+	for _, h := range u.holes {
+		key := holeKey(h)
+		if name, exists := byKey[key]; exists {
+			h.placeholder.Name = name
+			continue
+		}
+
+		var name string
+		if h.kind == KindType {
+			typeIndex++
+			name = "T"
+			if typeHoles > 1 {
+				name = fmt.Sprintf("T%d", typeIndex)
+			}
+		} else {
+			name = uniqueName(parameterName(h), reserved)
+		}
+		reserved[name] = true
+		byKey[key] = name
+		h.placeholder.Name = name
+
+		values := make([]string, len(h.values))
+		for i, value := range h.values {
+			values[i] = render(value)
+		}
+		params = append(params, Parameter{Name: name, Type: h.typ, Kind: h.kind, Values: values})
+	}
+
+	return params
+}
+
+// holeKey identifies holes with the same values.
+func holeKey(h *hole) string {
+	parts := []string{h.kind}
+	for _, value := range h.values {
+		parts = append(parts, render(value))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// parameterName proposes a name for the parameter replacing a hole.
+func parameterName(h *hole) string {
+	switch h.kind {
+	case KindLiteral:
+		switch h.typ {
+		case "string":
+			return "text"
+		case "int":
+			return "n"
+		default:
+			return "value"
+		}
+	case KindCall:
+		if prefix := commonWords(h.values); prefix != "" {
+			return prefix + "Fn"
+		}
+		return "fn"
+	case KindIdentifier:
+		if prefix := commonWords(h.values); prefix != "" {
+			return prefix
+		}
+	}
+	return "value"
+}
+
+// commonWords returns the lowerCamelCase words shared by the start of the names of
+// identifiers or selectors.
+func commonWords(exprs []ast.Expr) string {
+	var common []string
+	for i, expr := range exprs {
+		var name string
+		switch e := expr.(type) {
+		case *ast.Ident:
+			name = e.Name
+		case *ast.SelectorExpr:
+			name = e.Sel.Name
+		default:
+			return ""
+		}
+
+		words := splitWords(name)
+		if i == 0 {
+			common = words
+			continue
+		}
+		n := 0
+		for n < len(common) && n < len(words) && strings.EqualFold(common[n], words[n]) {
+			n++
+		}
+		common = common[:n]
+	}
+
+	return lowerCamel(common)
+}
+
+// splitWords splits a camelCase, PascalCase or snake_case name into words.
+func splitWords(name string) []string {
+	var words []string
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		start := 0
+		runes := []rune(part)
+		for i := 1; i < len(runes); i++ {
+			if unicode.IsUpper(runes[i]) && !unicode.IsUpper(runes[i-1]) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// lowerCamel joins words into a lowerCamelCase name.
+func lowerCamel(words []string) string {
+	var b strings.Builder
+	for i, word := range words {
+		if i == 0 {
+			b.WriteString(strings.ToLower(word))
+			continue
+		}
+		runes := []rune(strings.ToLower(word))
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+// uniqueName returns name, with a numeric suffix if it is already used or a keyword.
+func uniqueName(name string, reserved map[string]bool) string {
+	if token.IsKeyword(name) {
+		name += "Value"
+	}
+	candidate := name
+	for i := 2; reserved[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	return candidate
+}
+
+// extraction assembles the shared function from the clone with its placeholders named.
+func (u *unifier) extraction(clone *ast.FuncDecl, params []Parameter) *Extraction {
+	name := u.sharedName()
+
+	var typeParams, valueParams []*ast.Field
+	for _, param := range params {
+		field := &ast.Field{Names: []*ast.Ident{ast.NewIdent(param.Name)}, Type: ast.NewIdent(param.Type)}
+		if param.Kind == KindType {
+			typeParams = append(typeParams, field)
+		} else {
+			valueParams = append(valueParams, field)
+		}
+	}
+
+	// The receiver becomes the first parameter; blank and unnamed parameters cannot be
+	// used by the body and are dropped
+	var fields []*ast.Field
+	if clone.Recv != nil {
+		fields = append(fields, clone.Recv.List...)
+	}
+	fields = append(fields, clone.Type.Params.List...)
+
+	var kept []*ast.Field
+	var forwarded []*ast.Ident
+	for _, field := range fields {
+		var names []*ast.Ident
+		for _, ident := range field.Names {
+			if ident.Name != "_" {
+				names = append(names, ident)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		field.Names = names
+		kept = append(kept, field)
+		forwarded = append(forwarded, names...)
+	}
+
+	shared := &ast.FuncDecl{
+		Name: ast.NewIdent(name),
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{List: append(kept, valueParams...)},
+			Results: clone.Type.Results,
+		},
+	}
+	if len(typeParams) > 0 {
+		shared.Type.TypeParams = &ast.FieldList{List: typeParams}
+	}
+
+	extraction := &Extraction{
+		Name:       name,
+		Signature:  render(shared),
+		Parameters: params,
+		Generic:    len(params) > 0,
+	}
+	for _, param := range params {
+		extraction.Generic = extraction.Generic && param.Kind == KindType
+		if strings.HasPrefix(param.Type, constraintPackage+".") && !slices.Contains(extraction.Imports, constraintPackage) {
+			extraction.Imports = append(extraction.Imports, constraintPackage)
+		}
+	}
+
+	shared.Body = clone.Body
+	extraction.Body = render(shared)
+
+	for i := range u.decls {
+		extraction.Calls = append(extraction.Calls, u.call(i, name, forwarded, params, clone))
+	}
+
+	return extraction
+}
+
+// call renders the body replacing declaration i with a call of the shared function.
+func (u *unifier) call(i int, name string, forwarded []*ast.Ident, params []Parameter, clone *ast.FuncDecl) string {
+	var typeArgs, args []string
+
+	for _, ident := range forwarded {
+		arg := ident.Name
+		if i > 0 {
+			if renamed, ok := u.fromFirst[i][ident.Name]; ok {
+				arg = renamed
+			}
+		}
+		if isVariadic(clone, ident) {
+			arg += "..."
+		}
+		args = append(args, arg)
+	}
+
+	for _, param := range params {
+		if param.Kind == KindType {
+			typeArgs = append(typeArgs, param.Values[i])
+		} else {
+			args = append(args, param.Values[i])
+		}
+	}
+
+	call := name
+	if len(typeArgs) > 0 {
+		call += "[" + strings.Join(typeArgs, ", ") + "]"
+	}
+	call += "(" + strings.Join(args, ", ") + ")"
+
+	if clone.Type.Results != nil && len(clone.Type.Results.List) > 0 {
+		return "return " + call
+	}
+	return call
+}
+
+// isVariadic reports whether ident is the variadic parameter of decl.
+func isVariadic(decl *ast.FuncDecl, ident *ast.Ident) bool {
+	params := decl.Type.Params.List
+	if len(params) == 0 {
+		return false
+	}
+
+	last := params[len(params)-1]
+	if _, ok := last.Type.(*ast.Ellipsis); !ok {
+		return false
+	}
+	for _, name := range last.Names {
+		if name == ident {
+			return true
+		}
+	}
+	return false
+}
+
+// sharedName proposes a name for the shared function from the words its members share.
+func (u *unifier) sharedName() string {
+	names := make([]ast.Expr, len(u.decls))
+	for i, decl := range u.decls {
+		names[i] = decl.Name
+	}
+
+	name := commonWords(names)
+	if name == "" {
+		name = "shared"
+	}
+	if token.IsKeyword(name) || isPredeclared(name) {
+		return name + "Shared"
+	}
+	for _, decl := range u.decls {
+		if decl.Name.Name == name {
+			return name + "Shared"
+		}
+	}
+	return name
+}
+
+// allOf reports whether every expression satisfies predicate.
+func allOf(exprs []ast.Expr, predicate func(ast.Expr) bool) bool {
+	for _, expr := range exprs {
+		if !predicate(expr) {
+			return false
+		}
+	}
+	return true
+}
+
+// mapValues applies fn to every value.
+func mapValues(values []reflect.Value, fn func(reflect.Value) reflect.Value) []reflect.Value {
+	mapped := make([]reflect.Value, len(values))
+	for i, value := range values {
+		mapped[i] = fn(value)
+	}
+	return mapped
+}
+
+// render prints a node as Go source.
+func render(node any) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
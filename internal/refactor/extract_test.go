@@ -0,0 +1,320 @@
+package refactor
+
+import (
+	"errors"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// parseDecls parses a file and returns its function declarations.
+func parseDecls(t *testing.T, src string) []*ast.FuncDecl {
+	t.Helper()
+
+	file, err := parser.ParseFile(token.NewFileSet(), "test.go", "package test\n"+src, 0)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	var decls []*ast.FuncDecl
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			decls = append(decls, fn)
+		}
+	}
+	return decls
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		signature string
+		params    []Parameter
+		calls     []string
+	}{
+		{
+			name: "literals and renamed locals",
+			src: `
+func greetUser(name string) string {
+	msg := "Hello, " + name
+	return strings.Repeat(msg, 2)
+}
+
+func greetAdmin(user string) string {
+	text := "Welcome, " + user
+	return strings.Repeat(text, 3)
+}`,
+			signature: "func greet(name string, text string, n int) string",
+			params: []Parameter{
+				{Name: "text", Type: "string", Kind: KindLiteral, Values: []string{`"Hello, "`, `"Welcome, "`}},
+				{Name: "n", Type: "int", Kind: KindLiteral, Values: []string{"2", "3"}},
+			},
+			calls: []string{`return greet(name, "Hello, ", 2)`, `return greet(user, "Welcome, ", 3)`},
+		},
+		{
+			name: "called functions",
+			src: `
+func (s *Store) SaveUser(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	persistUser(s.db, id)
+}
+
+func (s *Store) SaveOrder(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	persistOrder(s.db, id)
+}`,
+			signature: "func save(s *Store, id int, persistFn func(any, any))",
+			params: []Parameter{
+				{Name: "persistFn", Type: "func(any, any)", Kind: KindCall, Values: []string{"persistUser", "persistOrder"}},
+			},
+			calls: []string{"save(s, id, persistUser)", "save(s, id, persistOrder)"},
+		},
+		{
+			name: "types",
+			src: `
+func sumInts(values []int) int {
+	var total int
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func sumFloats(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}`,
+			signature: "func sum[T ~int | ~float64](values []T) T",
+			params: []Parameter{
+				{Name: "T", Type: "~int | ~float64", Kind: KindType, Values: []string{"int", "float64"}},
+			},
+			calls: []string{"return sum[int](values)", "return sum[float64](values)"},
+		},
+		{
+			name: "ordered types",
+			src: `
+func maxInt(values []int) int {
+	best := values[0]
+	for _, v := range values[1:] {
+		if v > best {
+			best = v
+		}
+	}
+	return best
+}
+
+func maxString(values []string) string {
+	best := values[0]
+	for _, v := range values[1:] {
+		if v > best {
+			best = v
+		}
+	}
+	return best
+}`,
+			signature: "func maxShared[T cmp.Ordered](values []T) T",
+			params: []Parameter{
+				{Name: "T", Type: "cmp.Ordered", Kind: KindType, Values: []string{"int", "string"}},
+			},
+			calls: []string{"return maxShared[int](values)", "return maxShared[string](values)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extraction, err := Extract(parseDecls(t, tt.src))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if extraction.Signature != tt.signature {
+				t.Errorf("signature = %q, want %q", extraction.Signature, tt.signature)
+			}
+			if !reflect.DeepEqual(extraction.Parameters, tt.params) {
+				t.Errorf("parameters = %+v, want %+v", extraction.Parameters, tt.params)
+			}
+			if !reflect.DeepEqual(extraction.Calls, tt.calls) {
+				t.Errorf("calls = %q, want %q", extraction.Calls, tt.calls)
+			}
+			if generic := tt.params[0].Kind == KindType; extraction.Generic != generic {
+				t.Errorf("generic = %t, want %t", extraction.Generic, generic)
+			}
+			if needsCmp := strings.Contains(tt.signature, "cmp."); slices.Contains(extraction.Imports, "cmp") != needsCmp {
+				t.Errorf("imports = %v", extraction.Imports)
+			}
+			if !strings.HasPrefix(extraction.Body, tt.signature+" {") {
+				t.Errorf("body does not start with the signature:\n%s", extraction.Body)
+			}
+		})
+	}
+}
+
+func TestExtractGenericTypeChecks(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        string
+		types      []string
+		constraint string
+	}{
+		{
+			name: "arithmetic with constants",
+			src: `
+func sumT(values []T) T {
+	var total T
+	for _, v := range values {
+		if v > 0 {
+			total += v
+		}
+	}
+	return total
+}`,
+			types:      []string{"int", "float64", "int64"},
+			constraint: "~int | ~float64 | ~int64",
+		},
+		{
+			name: "comparisons",
+			src: `
+func maxT(values []T) T {
+	best := values[0]
+	for _, v := range values[1:] {
+		if v > best {
+			best = v
+		}
+	}
+	return best
+}`,
+			types:      []string{"int", "string"},
+			constraint: "cmp.Ordered",
+		},
+		{
+			name: "equality",
+			src: `
+func containsT(values []T, target T) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}`,
+			types:      []string{"bool", "complex128"},
+			constraint: "comparable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var src string
+			for _, typ := range tt.types {
+				src += strings.ReplaceAll(tt.src, "T", typ)
+			}
+			extraction, err := Extract(parseDecls(t, src))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if extraction.Parameters[0].Type != tt.constraint {
+				t.Errorf("constraint = %q, want %q", extraction.Parameters[0].Type, tt.constraint)
+			}
+
+			// The generic function is valid Go
+			file := "package test\n"
+			for _, path := range extraction.Imports {
+				file += "import \"" + path + "\"\n"
+			}
+			fset := token.NewFileSet()
+			parsed, err := parser.ParseFile(fset, "shared.go", file+extraction.Body, 0)
+			if err != nil {
+				t.Fatalf("failed to parse:\n%s\n%v", extraction.Body, err)
+			}
+			config := types.Config{Importer: importer.Default()}
+			if _, err := config.Check("test", fset, []*ast.File{parsed}, nil); err != nil {
+				t.Errorf("generic function does not type-check:\n%s\n%v", extraction.Body, err)
+			}
+		})
+	}
+}
+
+func TestExtractBodyUsesParameters(t *testing.T) {
+	decls := parseDecls(t, `
+func limitUsers(items []string) []string {
+	if len(items) > 10 {
+		return items[:10]
+	}
+	return items
+}
+
+func limitOrders(items []string) []string {
+	if len(items) > 20 {
+		return items[:20]
+	}
+	return items
+}`)
+
+	extraction, err := Extract(decls)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Both occurrences of the same difference share one parameter
+	if len(extraction.Parameters) != 1 {
+		t.Fatalf("expected 1 parameter, got %+v", extraction.Parameters)
+	}
+	if !strings.Contains(extraction.Body, "len(items) > n") || !strings.Contains(extraction.Body, "items[:n]") {
+		t.Errorf("body does not use the parameter:\n%s", extraction.Body)
+	}
+
+	// The originals are left untouched
+	if name := decls[0].Name.Name; name != "limitUsers" {
+		t.Errorf("original declaration was modified: %s", name)
+	}
+}
+
+func TestExtractFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want error
+	}{
+		{
+			name: "different statements",
+			src: `
+func a(x int) int { return x }
+func b(x int) int { x++; return x }`,
+			want: ErrDifferentStructure,
+		},
+		{
+			name: "difference on a local variable",
+			src: `
+func a(x int) int { y := x * 2; z := x * 3; return y + z }
+func b(x int) int { y := x * 2; z := x * 3; return z + y }`,
+			want: ErrLocalDependency,
+		},
+		{
+			name: "too many differences",
+			src: `
+func a() []int { return []int{1, 2, 3, 4, 5, 6, 7} }
+func b() []int { return []int{8, 9, 10, 11, 12, 13, 14} }`,
+			want: ErrTooManyDifferences,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Extract(parseDecls(t, tt.src)); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package refactor
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+	"sort"
+)
+
+// minConsistentRenames is the number of times a variable must be renamed the same way
+// before a different name in its place is considered a mistake rather than a change.
+const minConsistentRenames = 2
+
+// RenameInconsistency is a place where a clone does not follow the renaming it applies
+// everywhere else, typically a variable that was forgotten when renaming a copy.
+type RenameInconsistency struct {
+	Name       string    // Variable of the original declaration
+	Renamed    string    // Name the clone uses for it everywhere else
+	Found      string    // Name the clone uses at Pos instead
+	Pos        token.Pos // Position of the inconsistent identifier in the clone
+	Original   token.Pos // Position of the variable in the original at the same place
+	Consistent int       // Number of places where the variable is renamed to Renamed
+}
+
+// RenameInconsistencies walks original and clone in parallel, before any normalization,
+// and maps every local variable of original, including its receiver and parameters, to
+// the identifiers found at the same places in clone. A variable renamed the same way at
+// least twice, and in most of its uses, is reported wherever clone uses another name
+// for it. Variables kept under the same name are not reported, since their differing
+// uses are more likely deliberate changes. Sub-trees whose structure differs between
+// the declarations are skipped.
+func RenameInconsistencies(original, clone *ast.FuncDecl) []RenameInconsistency {
+	if original == nil || clone == nil {
+		return nil
+	}
+
+	m := &renameMapper{original: original, uses: make(map[string][]renameUse)}
+	m.walk(reflect.ValueOf(original), reflect.ValueOf(clone))
+
+	names := make([]string, 0, len(m.uses))
+	for name := range m.uses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result []RenameInconsistency
+	for _, name := range names {
+		result = append(result, inconsistentUses(name, m.uses[name])...)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Pos < result[j].Pos })
+	return result
+}
+
+// renameUse is the identifier found in the clone in place of a variable of the original.
+type renameUse struct {
+	name     string
+	pos      token.Pos
+	original token.Pos
+}
+
+// renameMapper collects the identifiers of the clone found in place of each local variable
+// of the original.
+type renameMapper struct {
+	original *ast.FuncDecl
+	uses     map[string][]renameUse
+}
+
+// walk visits a and b in parallel, stopping wherever their structure differs.
+func (m *renameMapper) walk(a, b reflect.Value) {
+	if a.Kind() != b.Kind() {
+		return
+	}
+
+	//nolint:exhaustive // Remaining kinds hold no identifier
+	switch a.Kind() {
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() || a.Elem().Type() != b.Elem().Type() {
+			return
+		}
+		m.walk(a.Elem(), b.Elem())
+	case reflect.Pointer:
+		if a.IsNil() || b.IsNil() {
+			return
+		}
+		switch a.Type() {
+		case reflect.TypeFor[*ast.Object](), reflect.TypeFor[*ast.Scope](), reflect.TypeFor[*ast.CommentGroup]():
+			return
+		case reflect.TypeFor[*ast.Ident]():
+			m.record(a.Interface().(*ast.Ident), b.Interface().(*ast.Ident)) //nolint:errcheck // Checked by the type switch
+			return
+		}
+		m.walk(a.Elem(), b.Elem())
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return
+		}
+		for i := range a.Len() {
+			m.walk(a.Index(i), b.Index(i))
+		}
+	case reflect.Struct:
+		for i := range a.NumField() {
+			m.walk(a.Field(i), b.Field(i))
+		}
+	}
+}
+
+// record maps a local variable of the original to the identifier of the clone at the
+// same place. Other identifiers, such as field names, types and packages, are not
+// renamed by copying and are skipped.
+func (m *renameMapper) record(ident, other *ast.Ident) {
+	if ident.Name == "_" || ident.Obj == nil || ident.Obj.Kind != ast.Var || !declaredIn(m.original, ident.Obj) {
+		return
+	}
+	m.uses[ident.Name] = append(m.uses[ident.Name], renameUse{name: other.Name, pos: other.Pos(), original: ident.Pos()})
+}
+
+// inconsistentUses returns the uses of a variable that depart from the name it is
+// renamed to in most of its uses.
+func inconsistentUses(name string, uses []renameUse) []RenameInconsistency {
+	counts := make(map[string]int)
+	for _, use := range uses {
+		counts[use.name]++
+	}
+
+	renamed := ""
+	for candidate, count := range counts {
+		if count > counts[renamed] || (count == counts[renamed] && candidate < renamed) {
+			renamed = candidate
+		}
+	}
+
+	consistent := counts[renamed]
+	if renamed == name || consistent < minConsistentRenames || 2*consistent <= len(uses) {
+		return nil
+	}
+
+	var result []RenameInconsistency
+	for _, use := range uses {
+		if use.name != renamed {
+			result = append(result, RenameInconsistency{
+				Name:       name,
+				Renamed:    renamed,
+				Found:      use.name,
+				Pos:        use.pos,
+				Original:   use.original,
+				Consistent: consistent,
+			})
+		}
+	}
+	return result
+}
@@ -0,0 +1,111 @@
+package refactor
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestRenameInconsistencies(t *testing.T) {
+	tests := []struct {
+		name         string
+		src          string
+		expected     []RenameInconsistency
+		line         int
+		originalLine int
+	}{
+		{
+			name: "forgotten rename",
+			src: `
+func totalWidth(left, right []Box) int {
+	sum := 0
+	for _, box := range left {
+		sum += box.Width
+	}
+	return sum
+}
+
+func totalHeight(left, right []Box) int {
+	total := 0
+	for _, box := range left {
+		total += box.Height
+	}
+	return sum
+}`,
+			expected:     []RenameInconsistency{{Name: "sum", Renamed: "total", Found: "sum", Consistent: 2}},
+			line:         16,
+			originalLine: 8,
+		},
+		{
+			name: "consistent rename",
+			src: `
+func greetUser(user string) string {
+	message := "hello " + user
+	return message
+}
+
+func greetAdmin(admin string) string {
+	text := "hello " + admin
+	return text
+}`,
+		},
+		{
+			name: "variable kept under its name",
+			src: `
+func copyLeft(left, right []int) []int {
+	out := append([]int{}, left...)
+	return append(out, left...)
+}
+
+func copyRight(left, right []int) []int {
+	out := append([]int{}, right...)
+	return append(out, left...)
+}`,
+		},
+		{
+			name: "different structure is skipped",
+			src: `
+func first(items []int) int {
+	n := 0
+	n += len(items)
+	return n
+}
+
+func second(values []int) int {
+	count := 0
+	count++
+	return n
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "test.go", "package test\n"+tt.src, 0)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			original := file.Decls[0].(*ast.FuncDecl) //nolint:errcheck // Test sources only declare functions
+			clone := file.Decls[1].(*ast.FuncDecl)    //nolint:errcheck // Test sources only declare functions
+
+			got := RenameInconsistencies(original, clone)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected %d inconsistencies, got %+v", len(tt.expected), got)
+			}
+			for i, want := range tt.expected {
+				want.Pos, want.Original = got[i].Pos, got[i].Original
+				if got[i] != want {
+					t.Errorf("inconsistency %d: got %+v, want %+v", i, got[i], want)
+				}
+				if line := fset.Position(got[i].Pos).Line; line != tt.line {
+					t.Errorf("inconsistency %d: got line %d, want %d", i, line, tt.line)
+				}
+				if line := fset.Position(got[i].Original).Line; line != tt.originalLine {
+					t.Errorf("inconsistency %d: got original line %d, want %d", i, line, tt.originalLine)
+				}
+			}
+		})
+	}
+}
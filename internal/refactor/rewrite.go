@@ -0,0 +1,179 @@
+package refactor
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// printerTabWidth is the tab width used by gofmt.
+const printerTabWidth = 8
+
+// BodyEdit replaces the body of a function declaration.
+type BodyEdit struct {
+	Decl *ast.FuncDecl
+	Body *ast.BlockStmt
+}
+
+// ApplyEdits returns src, the source of file as parsed with fset, with the bodies of
+// the edited declarations replaced. Only the new bodies are printed, with go/printer,
+// so the rest of the file keeps its comments. Imports that were only used by the
+// replaced bodies are removed, and the result is formatted as gofmt would.
+func ApplyEdits(fset *token.FileSet, file *ast.File, src []byte, edits []BodyEdit) ([]byte, error) {
+	removedPackages := make(map[string]bool)
+	for _, edit := range edits {
+		for name := range packageReferences(edit.Decl.Body) {
+			removedPackages[name] = true
+		}
+	}
+
+	sorted := append([]BodyEdit(nil), edits...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Decl.Body.Lbrace > sorted[j].Decl.Body.Lbrace
+	})
+
+	updated := append([]byte(nil), src...)
+	printerConfig := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: printerTabWidth}
+
+	for _, edit := range sorted {
+		var body bytes.Buffer
+		if err := printerConfig.Fprint(&body, token.NewFileSet(), edit.Body); err != nil {
+			return nil, fmt.Errorf("failed to print the body of %s: %w", edit.Decl.Name.Name, err)
+		}
+
+		start := fset.Position(edit.Decl.Body.Lbrace).Offset
+		end := fset.Position(edit.Decl.Body.Rbrace).Offset + 1
+		updated = append(updated[:start:start], append(body.Bytes(), updated[end:]...)...)
+	}
+
+	updated, err := removeUnusedImports(fset.Position(file.Pos()).Filename, updated, removedPackages)
+	if err != nil {
+		return nil, err
+	}
+
+	// Removed imports leave blank lines behind that gofmt would collapse
+	formatted, err := format.Source(updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format the rewritten source: %w", err)
+	}
+	return formatted, nil
+}
+
+// removeUnusedImports removes the imports of candidates that src no longer uses.
+func removeUnusedImports(filename string, src []byte, candidates map[string]bool) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("rewritten source does not parse: %w", err)
+	}
+
+	used := make(map[string]bool)
+	for _, decl := range file.Decls {
+		for name := range packageReferences(decl) {
+			used[name] = true
+		}
+	}
+
+	// Ranges of bytes to delete, as pairs of offsets
+	var ranges [][2]int
+	lineRange := func(from, to token.Pos) [2]int {
+		start := fset.Position(from).Offset
+		end := fset.Position(to).Offset
+		for start > 0 && src[start-1] != '\n' {
+			start--
+		}
+		for end < len(src) && src[end] != '\n' {
+			end++
+		}
+		return [2]int{start, min(end+1, len(src))}
+	}
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+
+		var unused []ast.Spec
+		for _, spec := range gen.Specs {
+			name := importName(spec.(*ast.ImportSpec)) //nolint:errcheck // Import declarations hold import specs
+			if candidates[name] && !used[name] {
+				unused = append(unused, spec)
+			}
+		}
+
+		switch {
+		case len(unused) == 0:
+			continue
+		case len(unused) == len(gen.Specs):
+			ranges = append(ranges, lineRange(gen.Pos(), gen.End()))
+		default:
+			for _, spec := range unused {
+				ranges = append(ranges, lineRange(spec.Pos(), spec.End()))
+			}
+		}
+	}
+
+	for i := len(ranges) - 1; i >= 0; i-- {
+		src = append(src[:ranges[i][0]:ranges[i][0]], src[ranges[i][1]:]...)
+	}
+
+	return src, nil
+}
+
+// packageReferences returns the names that node uses as the package of a selector.
+func packageReferences(node ast.Node) map[string]bool {
+	names := make(map[string]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		if selector, ok := n.(*ast.SelectorExpr); ok {
+			if ident, isIdent := selector.X.(*ast.Ident); isIdent && ident.Obj == nil {
+				names[ident.Name] = true
+			}
+		}
+		return true
+	})
+	return names
+}
+
+// importName returns the name an import is referred to by. Without an explicit name,
+// it is guessed from the path, as go/types is not available here.
+func importName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+
+	path, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return ""
+	}
+
+	elements := strings.Split(path, "/")
+	name := elements[len(elements)-1]
+	if len(elements) > 1 && isMajorVersion(name) {
+		name = elements[len(elements)-2]
+	}
+	if base, _, found := strings.Cut(name, ".v"); found {
+		name = base
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+
+	return name
+}
+
+// isMajorVersion reports whether an import path element is a major version, such as v2.
+func isMajorVersion(element string) bool {
+	version, found := strings.CutPrefix(element, "v")
+	if !found {
+		return false
+	}
+	_, err := strconv.Atoi(version)
+	return err == nil
+}
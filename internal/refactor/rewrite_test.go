@@ -0,0 +1,164 @@
+package refactor
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestApplyEdits(t *testing.T) {
+	src := `package sample
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Upper returns s in upper case.
+func Upper(s string) string {
+	return strings.ToUpper(s)
+}
+
+// Shout is a copy of Upper.
+func Shout(text string) string {
+	return strings.ToUpper(text) // Loud
+}
+
+func Print(s string) {
+	fmt.Println(s)
+}
+`
+	want := `package sample
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Upper returns s in upper case.
+func Upper(s string) string {
+	return strings.ToUpper(s)
+}
+
+// Shout is a copy of Upper.
+func Shout(text string) string {
+	return Upper(text)
+}
+
+func Print(s string) {
+	fmt.Println(s)
+}
+`
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "sample.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	upper := file.Decls[1].(*ast.FuncDecl)
+	shout := file.Decls[2].(*ast.FuncDecl)
+
+	body, err := Delegate(upper, shout)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := ApplyEdits(fset, file, []byte(src), []BodyEdit{{Decl: shout, Body: body}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("unexpected source:\n%s", got)
+	}
+}
+
+func TestApplyEditsRemovesUnusedImports(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		body string
+		want string
+	}{
+		{
+			name: "one of several imports",
+			body: "fmt.Println(s)",
+			src: `package sample
+
+import (
+	"fmt"
+	"strings"
+)
+
+func Print(s string) {
+	fmt.Println(strings.TrimSpace(s))
+}
+`,
+			want: `package sample
+
+import (
+	"fmt"
+)
+
+func Print(s string) {
+	fmt.Println(s)
+}
+`,
+		},
+		{
+			name: "whole import declaration",
+			body: "println(s)",
+			src: `package sample
+
+import "strings"
+
+func Print(s string) {
+	println(strings.TrimSpace(s))
+}
+`,
+			want: `package sample
+
+func Print(s string) {
+	println(s)
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "sample.go", tt.src, parser.ParseComments)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			decl := file.Decls[len(file.Decls)-1].(*ast.FuncDecl)
+			body := parseDecls(t, "func f(s string) { "+tt.body+" }")[0].Body
+
+			got, err := ApplyEdits(fset, file, []byte(tt.src), []BodyEdit{{Decl: decl, Body: body}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("unexpected source:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestImportName(t *testing.T) {
+	tests := map[string]string{
+		`"fmt"`:                          "fmt",
+		`"gopkg.in/yaml.v3"`:             "yaml",
+		`"github.com/spf13/cobra"`:       "cobra",
+		`"github.com/google/go-cmp/cmp"`: "cmp",
+		`"example.com/mod/v2"`:           "mod",
+		`"github.com/mattn/go-isatty"`:   "isatty",
+	}
+
+	for path, want := range tests {
+		if got := importName(&ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: path}}); got != want {
+			t.Errorf("importName(%s) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package similarity
+
+import (
+	goast "go/ast"
+	"go/types"
+	"path"
+	"slices"
+
+	"github.com/paveg/similarity-go/internal/ast"
+)
+
+// apiSetWeight is the share of the API usage similarity given to the calls made,
+// regardless of their order; the rest goes to the order of the calls.
+const apiSetWeight = 0.5
+
+// APIUsageSimilarity compares the external APIs two functions call, by name and before
+// normalization: package functions such as sql.Open or json.Marshal, methods such as
+// Scan, and functions of the same package. It averages the weighted Jaccard index of the
+// calls made and the longest common subsequence of their order, so functions using the
+// same APIs in the same order score 1 even when their control structure differs. Calls
+// matching one of the ignore patterns, matched with path.Match against names such as
+// "log.Printf" or ".Debugf" for methods, are left out, as are builtin functions and
+// conversions. Functions calling no API at all score 1.
+func APIUsageSimilarity(func1, func2 *ast.Function, ignore []string) float64 {
+	if func1 == nil || func2 == nil || func1.AST == nil || func2.AST == nil {
+		return 0.0
+	}
+
+	calls1 := apiCalls(func1.AST.Body, func1.Imports, ignore)
+	calls2 := apiCalls(func2.AST.Body, func2.Imports, ignore)
+	if len(calls1) == 0 && len(calls2) == 0 {
+		return 1.0
+	}
+
+	sequence := 2 * float64(longestCommonSubsequence(calls1, calls2)) / float64(len(calls1)+len(calls2))
+	return apiSetWeight*histogramSimilarity(callCounts(calls1), callCounts(calls2)) + (1-apiSetWeight)*sequence
+}
+
+// apiCalls returns the names of the APIs called in body, in source order. Imports are the
+// names of the packages imported by the file of body, or nil when unknown.
+func apiCalls(body *goast.BlockStmt, imports []string, ignore []string) []string {
+	if body == nil {
+		return nil
+	}
+
+	var calls []string
+	goast.Inspect(body, func(n goast.Node) bool {
+		call, ok := n.(*goast.CallExpr)
+		if !ok {
+			return true
+		}
+		if name := callName(call.Fun, imports); name != "" && !ignoredCall(name, ignore) {
+			calls = append(calls, name)
+		}
+		return true
+	})
+	return calls
+}
+
+// callName names the function called through fun: "pkg.Func" for package-qualified
+// functions, ".Method" for methods, and the name of functions of the same package. It
+// returns an empty name for builtins, conversions to predeclared types, function
+// values and function literals. Variables declared in other files of the package are
+// unresolved like packages, so when imports are known, only the receivers they name are
+// packages.
+func callName(fun goast.Expr, imports []string) string {
+	switch f := fun.(type) {
+	case *goast.Ident:
+		if f.Obj == nil && types.Universe.Lookup(f.Name) != nil {
+			return ""
+		}
+		if f.Obj != nil && f.Obj.Kind != goast.Fun {
+			return ""
+		}
+		return f.Name
+	case *goast.SelectorExpr:
+		if pkg, ok := f.X.(*goast.Ident); ok && pkg.Obj == nil && (imports == nil || slices.Contains(imports, pkg.Name)) {
+			return pkg.Name + "." + f.Sel.Name
+		}
+		return "." + f.Sel.Name
+	case *goast.IndexExpr:
+		return callName(f.X, imports)
+	case *goast.IndexListExpr:
+		return callName(f.X, imports)
+	case *goast.ParenExpr:
+		return callName(f.X, imports)
+	default:
+		return ""
+	}
+}
+
+// ignoredCall reports whether name matches one of the ignore patterns.
+func ignoredCall(name string, ignore []string) bool {
+	for _, pattern := range ignore {
+		if matched, err := path.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// callCounts returns the histogram of calls.
+func callCounts(calls []string) map[string]int {
+	counts := make(map[string]int, len(calls))
+	for _, call := range calls {
+		counts[call]++
+	}
+	return counts
+}
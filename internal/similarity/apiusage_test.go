@@ -0,0 +1,144 @@
+package similarity
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/config"
+	"github.com/paveg/similarity-go/internal/testhelpers"
+)
+
+func TestAPIUsageSimilarity(t *testing.T) {
+	loadUsers := testhelpers.CreateFunctionFromSource(t, `package main
+func loadUsers(dsn string) ([]byte, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query("SELECT name FROM users")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		rows.Scan(&name)
+		names = append(names, name)
+	}
+	return json.Marshal(names)
+}`, "loadUsers")
+	// Same calls in the same order, different control structure and logging
+	exportOrders := testhelpers.CreateFunctionFromSource(t, `package main
+func exportOrders(dsn string) (out []byte, err error) {
+	log.Printf("exporting orders")
+	db, openErr := sql.Open("mysql", dsn)
+	switch {
+	case openErr != nil:
+		err = openErr
+	default:
+		result, _ := db.Query("SELECT id FROM orders")
+		ids := make([]string, 0)
+		for i := 0; result.Next(); i++ {
+			var id string
+			result.Scan(&id)
+			ids = append(ids, id)
+		}
+		out, err = json.Marshal(ids)
+	}
+	return out, err
+}`, "exportOrders")
+	unrelated := testhelpers.CreateFunctionFromSource(t, `package main
+func render(w io.Writer, page Page) error {
+	tmpl := template.Must(template.New("page").Parse(layout))
+	return tmpl.Execute(w, page)
+}`, "render")
+
+	ignore := config.DefaultAPIUsageIgnore()
+	if got := APIUsageSimilarity(loadUsers, exportOrders, ignore); got != 1.0 {
+		t.Errorf("expected the same APIs in the same order to score 1, got %f", got)
+	}
+	if got := APIUsageSimilarity(loadUsers, exportOrders, nil); got >= 1.0 {
+		t.Errorf("expected logging calls to count without ignore patterns, got %f", got)
+	}
+	if got := APIUsageSimilarity(loadUsers, unrelated, ignore); got != 0.0 {
+		t.Errorf("expected unrelated APIs to score 0, got %f", got)
+	}
+	if got := APIUsageSimilarity(nil, unrelated, ignore); got != 0.0 {
+		t.Errorf("expected 0 for a missing function, got %f", got)
+	}
+}
+
+func TestAPICalls(t *testing.T) {
+	// The parser records the imports of the file
+	result := ast.NewParser().ParseSource("handle.go", []byte(`package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+func helper() int { return 1 }
+
+func handle(r *http.Request, convert func(string) int) int {
+	slog.Info("handling")
+	value := convert(r.URL.Query().Get("id"))
+	buf := make([]byte, len(r.Header))
+	logger.Debugf("read %d", len(buf))
+	fmt.Println(value)
+	store.Save(buf)
+	return value + helper() + int(strconv.IntSize) + Parse[int](string(buf))
+}`))
+	if result.IsErr() {
+		t.Fatalf("failed to parse: %v", result.Error())
+	}
+	fn := result.Unwrap().Functions[1]
+
+	// fmt.Println is not a logger method, and store, not being imported, is a variable
+	got := apiCalls(fn.AST.Body, fn.Imports, config.DefaultAPIUsageIgnore())
+	want := []string{".Get", ".Query", "fmt.Println", ".Save", "helper", "Parse"}
+	if !slices.Equal(got, want) {
+		t.Errorf("apiCalls() = %v, want %v", got, want)
+	}
+}
+
+func TestDetector_APIUsageWeight(t *testing.T) {
+	cfg := config.Default()
+	cfg.Similarity.Weights.TreeEdit -= 0.1
+	cfg.Similarity.Weights.APIUsage = 0.1
+	cfg.Similarity.APIUsage.Ignore = []string{"fmt.*"}
+	detector := NewDetectorWithConfig(0.8, cfg)
+
+	first := testhelpers.CreateFunctionFromSource(t, `package main
+func first(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	fmt.Println(len(data))
+	return nil
+}`, "first")
+	second := testhelpers.CreateFunctionFromSource(t, `package main
+func second(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	return json.Unmarshal(data, &settings)
+}`, "second")
+
+	explanation := detector.Explain(first, second)
+	last := explanation.Components[len(explanation.Components)-1]
+	if last.Name != ComponentAPIUsage || last.Weight != 0.1 {
+		t.Fatalf("expected a weighted api_usage component, got %+v", explanation.Components)
+	}
+	// os.ReadFile is shared, json.Unmarshal is not: set 1/2, sequence 2/3
+	if testhelpers.AbsFloat(last.Score-(0.5*0.5+0.5*2.0/3.0)) > 1e-9 {
+		t.Errorf("unexpected api_usage score %f", last.Score)
+	}
+	if testhelpers.AbsFloat(explanation.Weighted-explanation.Similarity) > 1e-9 {
+		t.Errorf("expected weighted total %f to equal similarity %f", explanation.Weighted, explanation.Similarity)
+	}
+}
@@ -162,7 +162,7 @@ func (cb *CodebaseBenchmark) SetParameters(minLines, maxFunctions int, excludePa
 // BenchmarkWeights benchmarks weights against real codebases.
 func (cb *CodebaseBenchmark) BenchmarkWeights(
 	_ *testing.T,
-	weights config.SimilarityWeights,
+	weights config.MetricWeights,
 ) ([]BenchmarkResult, error) {
 	var results []BenchmarkResult
 
@@ -180,7 +180,7 @@ func (cb *CodebaseBenchmark) BenchmarkWeights(
 // benchmarkSingleCodebase benchmarks weights against a single codebase.
 func (cb *CodebaseBenchmark) benchmarkSingleCodebase(
 	basePath string,
-	weights config.SimilarityWeights,
+	weights config.MetricWeights,
 ) (*BenchmarkResult, error) {
 	startTime := time.Now()
 
@@ -198,7 +198,7 @@ func (cb *CodebaseBenchmark) benchmarkSingleCodebase(
 	// Calculate similarity pairs
 	detector := NewDetector(detectorThreshold) // Use 0.7 threshold for real-world analysis
 	cfg := config.Default()
-	cfg.Similarity.Weights = weights
+	cfg.Similarity.Metrics = weights
 	detector.config = cfg
 
 	similarityPairs := cb.findSimilarityPairs(functions, detector)
@@ -408,14 +408,14 @@ func (cb *CodebaseBenchmark) calculateComponents(
 	// For now, we'll estimate based on the final similarity score
 	totalSim := detector.CalculateSimilarity(func1, func2)
 
-	weights := detector.config.Similarity.Weights
+	weights := detector.config.Similarity.EnabledMetrics()
 
 	// Rough estimation - in practice you'd calculate each component separately
 	components := SimilarityComponents{
-		TreeEdit:        totalSim * weights.TreeEdit,
-		TokenSimilarity: totalSim * weights.TokenSimilarity,
-		Structural:      totalSim * weights.Structural,
-		Signature:       totalSim * weights.Signature,
+		TreeEdit:        totalSim * weights.Weight(config.MetricTreeEdit),
+		TokenSimilarity: totalSim * weights.Weight(config.MetricTokenSimilarity),
+		Structural:      totalSim * weights.Weight(config.MetricStructural),
+		Signature:       totalSim * weights.Weight(config.MetricSignature),
 		WeightedScore:   totalSim,
 	}
 
@@ -638,13 +638,7 @@ func Divide(numerator, denominator int) int {
 	benchmark := NewCodebaseBenchmark([]string{tempDir})
 	benchmark.SetParameters(3, 100, nil)
 
-	weights := config.SimilarityWeights{
-		TreeEdit:           0.35,
-		TokenSimilarity:    0.30,
-		Structural:         0.25,
-		Signature:          0.10,
-		DifferentSignature: 0.30,
-	}
+	weights := baseWeights(0.35, 0.30, 0.25, 0.10)
 
 	results, err := benchmark.BenchmarkWeights(t, weights)
 	if err != nil {
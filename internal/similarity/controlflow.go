@@ -0,0 +1,349 @@
+package similarity
+
+import (
+	goast "go/ast"
+	"go/token"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/paveg/similarity-go/internal/ast"
+)
+
+// Kinds of the basic blocks of a control-flow graph. Blocks are labeled by the statement
+// starting them, so that graphs compare by shape rather than by the code they hold.
+const (
+	blockEntry  = "entry"
+	blockExit   = "exit"
+	blockPlain  = "plain"
+	blockIf     = "if"
+	blockFor    = "for"
+	blockRange  = "range"
+	blockSwitch = "switch"
+	blockCase   = "case"
+	blockSelect = "select"
+	blockComm   = "comm"
+	blockReturn = "return"
+	blockLabel  = "label"
+	blockDefer  = "defer"
+)
+
+// wlIterations is the number of Weisfeiler-Lehman refinements: each one extends the
+// label of a block with the labels of its successors, so blocks are compared by their
+// neighborhood up to this many edges away.
+const wlIterations = 3
+
+// cfgBlock is a basic block of a control-flow graph.
+type cfgBlock struct {
+	kind  string
+	succs []*cfgBlock
+}
+
+// controlFlowGraph is the control-flow graph of a function body.
+type controlFlowGraph struct {
+	blocks []*cfgBlock
+}
+
+// loopTargets are the blocks break and continue statements jump to.
+type loopTargets struct {
+	label         string
+	breakBlock    *cfgBlock
+	continueBlock *cfgBlock // nil for switch and select statements
+}
+
+// cfgBuilder builds the control-flow graph of a function body.
+type cfgBuilder struct {
+	graph   *controlFlowGraph
+	exit    *cfgBlock
+	targets []loopTargets
+	labels  map[string]*cfgBlock
+	// label is the label of the statement being built, registered with its targets.
+	label string
+}
+
+// ControlFlowSimilarity compares the shapes of the control-flow graphs of two functions,
+// built from basic blocks split at if, for, range, switch, select, return, goto, defer
+// and labeled statements. The graphs are compared with a Weisfeiler-Lehman subtree
+// kernel: the histograms of block labels refined with their successors are compared as
+// a weighted Jaccard index, so functions with the same branching and looping structure
+// score 1 regardless of the statements they hold.
+func ControlFlowSimilarity(func1, func2 *ast.Function) float64 {
+	if func1 == nil || func2 == nil || func1.AST == nil || func2.AST == nil {
+		return 0.0
+	}
+
+	return wlSimilarity(buildControlFlowGraph(func1.AST.Body), buildControlFlowGraph(func2.AST.Body))
+}
+
+// buildControlFlowGraph builds the control-flow graph of body.
+func buildControlFlowGraph(body *goast.BlockStmt) *controlFlowGraph {
+	b := &cfgBuilder{graph: &controlFlowGraph{}, labels: make(map[string]*cfgBlock)}
+
+	entry := b.newBlock(blockEntry)
+	b.exit = b.newBlock(blockExit)
+	if body != nil {
+		b.link(b.stmtList(body.List, entry), b.exit)
+	} else {
+		b.link(entry, b.exit)
+	}
+
+	return b.graph
+}
+
+// newBlock adds a block of the given kind.
+func (b *cfgBuilder) newBlock(kind string) *cfgBlock {
+	block := &cfgBlock{kind: kind}
+	b.graph.blocks = append(b.graph.blocks, block)
+	return block
+}
+
+// link adds an edge from the end of a reachable block.
+func (b *cfgBuilder) link(from, to *cfgBlock) {
+	if from != nil {
+		from.succs = append(from.succs, to)
+	}
+}
+
+// branch starts a block of the given kind following cur.
+func (b *cfgBuilder) branch(cur *cfgBlock, kind string) *cfgBlock {
+	block := b.newBlock(kind)
+	b.link(cur, block)
+	return block
+}
+
+// labelBlock returns the block of a label, created on its first reference.
+func (b *cfgBuilder) labelBlock(name string) *cfgBlock {
+	block, exists := b.labels[name]
+	if !exists {
+		block = b.newBlock(blockLabel)
+		b.labels[name] = block
+	}
+	return block
+}
+
+// stmtList builds list from cur and returns the block control continues in, or nil when
+// the end of list is unreachable.
+func (b *cfgBuilder) stmtList(list []goast.Stmt, cur *cfgBlock) *cfgBlock {
+	for _, stmt := range list {
+		if cur == nil {
+			// Code after a jump starts an unreachable block
+			cur = b.newBlock(blockPlain)
+		}
+		cur = b.stmt(stmt, cur)
+	}
+	return cur
+}
+
+// stmt builds stmt from cur and returns the block control continues in, or nil when it
+// never continues after stmt.
+func (b *cfgBuilder) stmt(stmt goast.Stmt, cur *cfgBlock) *cfgBlock {
+	label := b.label
+	b.label = ""
+
+	switch s := stmt.(type) {
+	case *goast.BlockStmt:
+		return b.stmtList(s.List, cur)
+	case *goast.IfStmt:
+		return b.ifStmt(s, cur)
+	case *goast.ForStmt:
+		head := b.branch(cur, blockFor)
+		after := b.newBlock(blockPlain)
+		if s.Cond != nil {
+			b.link(head, after)
+		}
+		return b.loop(s.Body, head, after, label)
+	case *goast.RangeStmt:
+		head := b.branch(cur, blockRange)
+		after := b.newBlock(blockPlain)
+		b.link(head, after)
+		return b.loop(s.Body, head, after, label)
+	case *goast.SwitchStmt:
+		return b.clauses(s.Body, b.branch(cur, blockSwitch), blockCase, label)
+	case *goast.TypeSwitchStmt:
+		return b.clauses(s.Body, b.branch(cur, blockSwitch), blockCase, label)
+	case *goast.SelectStmt:
+		return b.clauses(s.Body, b.branch(cur, blockSelect), blockComm, label)
+	case *goast.ReturnStmt:
+		b.link(b.branch(cur, blockReturn), b.exit)
+		return nil
+	case *goast.DeferStmt:
+		return b.branch(cur, blockDefer)
+	case *goast.LabeledStmt:
+		block := b.labelBlock(s.Label.Name)
+		b.link(cur, block)
+		b.label = s.Label.Name
+		return b.stmt(s.Stmt, block)
+	case *goast.BranchStmt:
+		return b.branchStmt(s, cur)
+	default:
+		return cur
+	}
+}
+
+// ifStmt builds an if statement and its else branches.
+func (b *cfgBuilder) ifStmt(s *goast.IfStmt, cur *cfgBlock) *cfgBlock {
+	head := b.branch(cur, blockIf)
+	after := b.newBlock(blockPlain)
+
+	b.link(b.stmtList(s.Body.List, b.branch(head, blockPlain)), after)
+	if s.Else != nil {
+		b.link(b.stmt(s.Else, b.branch(head, blockPlain)), after)
+	} else {
+		b.link(head, after)
+	}
+
+	return after
+}
+
+// loop builds the body of a loop whose condition is evaluated in head and which exits
+// to after.
+func (b *cfgBuilder) loop(body *goast.BlockStmt, head, after *cfgBlock, label string) *cfgBlock {
+	b.targets = append(b.targets, loopTargets{label: label, breakBlock: after, continueBlock: head})
+	b.link(b.stmtList(body.List, b.branch(head, blockPlain)), head)
+	b.targets = b.targets[:len(b.targets)-1]
+	return after
+}
+
+// clauses builds the clauses of a switch or select statement starting in head. Switch
+// statements without default clause may skip every clause; select statements block
+// until one of their clauses runs.
+func (b *cfgBuilder) clauses(body *goast.BlockStmt, head *cfgBlock, kind, label string) *cfgBlock {
+	after := b.newBlock(blockPlain)
+	b.targets = append(b.targets, loopTargets{label: label, breakBlock: after})
+
+	starts := make([]*cfgBlock, len(body.List))
+	for i := range body.List {
+		starts[i] = b.branch(head, kind)
+	}
+
+	hasDefault := head.kind == blockSelect
+	for i, clause := range body.List {
+		var list []goast.Stmt
+		switch c := clause.(type) {
+		case *goast.CaseClause:
+			list = c.Body
+			hasDefault = hasDefault || c.List == nil
+		case *goast.CommClause:
+			list = c.Body
+		}
+
+		end := b.stmtList(list, starts[i])
+		if fallsThrough(list) && i+1 < len(starts) {
+			b.link(end, starts[i+1])
+		} else {
+			b.link(end, after)
+		}
+	}
+	if !hasDefault {
+		b.link(head, after)
+	}
+
+	b.targets = b.targets[:len(b.targets)-1]
+	return after
+}
+
+// branchStmt builds a break, continue, goto or fallthrough statement.
+func (b *cfgBuilder) branchStmt(s *goast.BranchStmt, cur *cfgBlock) *cfgBlock {
+	//nolint:exhaustive // Other tokens are not branch statements
+	switch s.Tok {
+	case token.GOTO:
+		b.link(cur, b.labelBlock(s.Label.Name))
+		return nil
+	case token.BREAK, token.CONTINUE:
+		for i := len(b.targets) - 1; i >= 0; i-- {
+			target := b.targets[i]
+			if s.Label != nil && target.label != s.Label.Name {
+				continue
+			}
+			if s.Tok == token.BREAK {
+				b.link(cur, target.breakBlock)
+				return nil
+			}
+			if target.continueBlock != nil {
+				b.link(cur, target.continueBlock)
+				return nil
+			}
+		}
+	}
+
+	// Fallthrough is linked by the enclosing switch
+	return cur
+}
+
+// fallsThrough reports whether the clause body list ends with a fallthrough statement.
+func fallsThrough(list []goast.Stmt) bool {
+	if len(list) == 0 {
+		return false
+	}
+	branch, ok := list[len(list)-1].(*goast.BranchStmt)
+	return ok && branch.Tok == token.FALLTHROUGH
+}
+
+// wlSimilarity compares two graphs with a Weisfeiler-Lehman subtree kernel normalized as
+// a weighted Jaccard index of their label histograms.
+func wlSimilarity(g1, g2 *controlFlowGraph) float64 {
+	return histogramSimilarity(wlLabels(g1), wlLabels(g2))
+}
+
+// histogramSimilarity is the weighted Jaccard index of two label histograms: the labels
+// they share over the labels of either, counted with multiplicity. Two empty histograms
+// are identical.
+func histogramSimilarity(counts1, counts2 map[string]int) float64 {
+	shared, total := 0, 0
+	for label, count1 := range counts1 {
+		count2 := counts2[label]
+		shared += min(count1, count2)
+		total += max(count1, count2)
+	}
+	for label, count2 := range counts2 {
+		if _, exists := counts1[label]; !exists {
+			total += count2
+		}
+	}
+
+	if total == 0 {
+		return 1.0
+	}
+	return float64(shared) / float64(total)
+}
+
+// wlLabels returns the histogram of the labels of the blocks of g over every
+// Weisfeiler-Lehman refinement.
+func wlLabels(g *controlFlowGraph) map[string]int {
+	index := make(map[*cfgBlock]int, len(g.blocks))
+	labels := make([]string, len(g.blocks))
+	for i, block := range g.blocks {
+		index[block] = i
+		labels[i] = block.kind
+	}
+
+	counts := make(map[string]int)
+	for iteration := 0; ; iteration++ {
+		for _, label := range labels {
+			counts[label]++
+		}
+		if iteration == wlIterations {
+			return counts
+		}
+
+		refined := make([]string, len(labels))
+		for i, block := range g.blocks {
+			succs := make([]string, len(block.succs))
+			for j, succ := range block.succs {
+				succs[j] = labels[index[succ]]
+			}
+			sort.Strings(succs)
+			refined[i] = compressLabel(labels[i] + "(" + strings.Join(succs, ",") + ")")
+		}
+		labels = refined
+	}
+}
+
+// compressLabel shortens a refined label, which would otherwise grow with every
+// iteration, into a hash.
+func compressLabel(label string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(label))
+	return strconv.FormatUint(h.Sum64(), 36)
+}
@@ -0,0 +1,139 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/paveg/similarity-go/internal/config"
+	"github.com/paveg/similarity-go/internal/testhelpers"
+)
+
+func TestControlFlowSimilarity(t *testing.T) {
+	sumPositive := testhelpers.CreateFunctionFromSource(t, `package main
+func sumPositive(values []int) int {
+	total := 0
+	for _, v := range values {
+		if v < 0 {
+			continue
+		}
+		total += v
+	}
+	return total
+}`, "sumPositive")
+	// Same control flow, different statements
+	countLong := testhelpers.CreateFunctionFromSource(t, `package main
+func countLong(words []string, limit int) (n int) {
+	for i := range words {
+		word := strings.TrimSpace(words[i])
+		if len(word) <= limit {
+			continue
+		}
+		n++
+		log.Println(word)
+	}
+	return n
+}`, "countLong")
+	// Same statements, different control flow
+	dispatch := testhelpers.CreateFunctionFromSource(t, `package main
+func dispatch(values []int) int {
+	total := 0
+	switch len(values) {
+	case 0:
+		return 0
+	case 1:
+		fallthrough
+	default:
+		defer cleanup()
+	}
+	return total
+}`, "dispatch")
+
+	if got := ControlFlowSimilarity(sumPositive, countLong); got != 1.0 {
+		t.Errorf("expected identical control flow to score 1, got %f", got)
+	}
+
+	different := ControlFlowSimilarity(sumPositive, dispatch)
+	if different >= 0.5 {
+		t.Errorf("expected different control flow to score low, got %f", different)
+	}
+	if reversed := ControlFlowSimilarity(dispatch, sumPositive); reversed != different {
+		t.Errorf("expected a symmetric score, got %f and %f", different, reversed)
+	}
+
+	if got := ControlFlowSimilarity(sumPositive, nil); got != 0.0 {
+		t.Errorf("expected 0 for a missing function, got %f", got)
+	}
+}
+
+func TestBuildControlFlowGraph(t *testing.T) {
+	fn := testhelpers.CreateFunctionFromSource(t, `package main
+func retry(attempts int) error {
+outer:
+	for i := 0; i < attempts; i++ {
+		select {
+		case <-done:
+			break outer
+		case err := <-errs:
+			if err == nil {
+				goto finish
+			}
+		}
+	}
+	return errFailed
+finish:
+	return nil
+}`, "retry")
+
+	kinds := make(map[string]int)
+	for _, block := range buildControlFlowGraph(fn.AST.Body).blocks {
+		kinds[block.kind]++
+	}
+
+	want := map[string]int{
+		blockEntry: 1, blockExit: 1, blockLabel: 2, blockFor: 1, blockSelect: 1, blockComm: 2, blockIf: 1, blockReturn: 2,
+	}
+	for kind, count := range want {
+		if kinds[kind] != count {
+			t.Errorf("expected %d %s blocks, got %d (%v)", count, kind, kinds[kind], kinds)
+		}
+	}
+}
+
+func TestDetector_ControlFlowWeight(t *testing.T) {
+	loop := testhelpers.CreateFunctionFromSource(t, `package main
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}`, "sum")
+	branch := testhelpers.CreateFunctionFromSource(t, `package main
+func pick(values []int) int {
+	total := 0
+	if len(values) > 0 {
+		total += values[0]
+	}
+	return total
+}`, "pick")
+
+	cfg := config.Default()
+	without := NewDetectorWithConfig(0.8, cfg).CalculateSimilarity(loop, branch)
+
+	cfg = config.Default()
+	cfg.Similarity.Weights.TreeEdit -= 0.15
+	cfg.Similarity.Weights.TokenSimilarity -= 0.15
+	cfg.Similarity.Weights.ControlFlow = 0.3
+	detector := NewDetectorWithConfig(0.8, cfg)
+
+	explanation := detector.Explain(loop, branch)
+	last := explanation.Components[len(explanation.Components)-1]
+	if last.Name != ComponentControlFlow || last.Weight != 0.3 {
+		t.Fatalf("expected a weighted control_flow component, got %+v", explanation.Components)
+	}
+	if testhelpers.AbsFloat(explanation.Weighted-explanation.Similarity) > 1e-9 {
+		t.Errorf("expected weighted total %f to equal similarity %f", explanation.Weighted, explanation.Similarity)
+	}
+	if explanation.Similarity == without {
+		t.Errorf("expected the control-flow metric to change the score %f", without)
+	}
+}
@@ -0,0 +1,272 @@
+package similarity
+
+import (
+	"fmt"
+	goast "go/ast"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/paveg/similarity-go/internal/ast"
+)
+
+// pdgIterations is the number of refinements of the label of every statement with the
+// labels of the statements it depends on.
+const pdgIterations = 2
+
+// noParent is the parent of the statements not controlled by another statement.
+const noParent = -1
+
+// pdgNode is a statement of a program-dependence graph.
+type pdgNode struct {
+	label  string
+	parent int   // Statement controlling this one, or noParent
+	deps   []int // Statements defining the variables this one uses
+}
+
+// pdgBuilder builds the program-dependence graph of a function body, visiting its
+// statements in source order.
+type pdgBuilder struct {
+	nodes []pdgNode
+	// lastDef is the last statement assigning each local variable.
+	lastDef map[*goast.Object]int
+}
+
+// DependenceSimilarity compares the program-dependence graphs of two functions: their
+// statements, labeled by their kind and the shape of their expressions without names,
+// linked to the statement controlling them and to the statements defining the local
+// variables they use. Statements are matched when their labels and dependence
+// neighborhoods agree, an approximation of subgraph isomorphism computed like a
+// Weisfeiler-Lehman kernel, so independent statements that were reordered, or interleaved
+// with unrelated code, still match. Loop-carried dependences are not tracked.
+func DependenceSimilarity(func1, func2 *ast.Function) float64 {
+	if func1 == nil || func2 == nil || func1.AST == nil || func2.AST == nil {
+		return 0.0
+	}
+
+	return histogramSimilarity(
+		pdgLabels(buildDependenceGraph(func1.AST.Body)),
+		pdgLabels(buildDependenceGraph(func2.AST.Body)),
+	)
+}
+
+// buildDependenceGraph builds the program-dependence graph of body.
+func buildDependenceGraph(body *goast.BlockStmt) []pdgNode {
+	b := &pdgBuilder{lastDef: make(map[*goast.Object]int)}
+	if body != nil {
+		b.stmtList(body.List, noParent)
+	}
+	return b.nodes
+}
+
+// stmtList adds the statements of list controlled by parent.
+func (b *pdgBuilder) stmtList(list []goast.Stmt, parent int) {
+	for _, stmt := range list {
+		b.stmt(stmt, parent)
+	}
+}
+
+// stmt adds stmt, controlled by parent, and the statements it controls. The init
+// statements of compound statements run before them and are controlled by parent.
+func (b *pdgBuilder) stmt(stmt goast.Stmt, parent int) {
+	if stmt == nil {
+		return
+	}
+
+	switch s := stmt.(type) {
+	case *goast.BlockStmt:
+		b.stmtList(s.List, parent)
+		return
+	case *goast.LabeledStmt:
+		b.stmt(s.Stmt, parent)
+		return
+	case *goast.EmptyStmt:
+		return
+	case *goast.IfStmt:
+		b.stmt(s.Init, parent)
+	case *goast.ForStmt:
+		b.stmt(s.Init, parent)
+	case *goast.SwitchStmt:
+		b.stmt(s.Init, parent)
+	case *goast.TypeSwitchStmt:
+		b.stmt(s.Init, parent)
+		b.stmt(s.Assign, parent)
+	}
+
+	index := b.addNode(stmt, parent)
+
+	switch s := stmt.(type) {
+	case *goast.IfStmt:
+		b.stmtList(s.Body.List, index)
+		b.stmt(s.Else, index)
+	case *goast.ForStmt:
+		b.stmtList(s.Body.List, index)
+		b.stmt(s.Post, index)
+	case *goast.RangeStmt:
+		b.stmtList(s.Body.List, index)
+	case *goast.SwitchStmt:
+		b.stmtList(s.Body.List, index)
+	case *goast.TypeSwitchStmt:
+		b.stmtList(s.Body.List, index)
+	case *goast.SelectStmt:
+		b.stmtList(s.Body.List, index)
+	case *goast.CaseClause:
+		b.stmtList(s.Body, index)
+	case *goast.CommClause:
+		b.stmt(s.Comm, index)
+		b.stmtList(s.Body, index)
+	}
+}
+
+// addNode adds stmt without the statements nested in it, and links it to the statements
+// defining the variables it uses.
+func (b *pdgBuilder) addNode(stmt goast.Stmt, parent int) int {
+	index := len(b.nodes)
+	defined, assigned := definedIdents(stmt)
+
+	var shape []string
+	deps := make(map[int]bool)
+	goast.Inspect(stmt, func(n goast.Node) bool {
+		if n == nil {
+			return false
+		}
+		if _, nested := n.(goast.Stmt); nested && n != stmt {
+			return false
+		}
+
+		shape = append(shape, fmt.Sprintf("%T", n))
+		switch node := n.(type) {
+		case *goast.Ident:
+			if isLocalVar(node) && !assigned[node] {
+				if def, exists := b.lastDef[node.Obj]; exists {
+					deps[def] = true
+				}
+			}
+		case *goast.AssignStmt:
+			shape = append(shape, node.Tok.String())
+		case *goast.IncDecStmt:
+			shape = append(shape, node.Tok.String())
+		case *goast.BranchStmt:
+			shape = append(shape, node.Tok.String())
+		case *goast.BinaryExpr:
+			shape = append(shape, node.Op.String())
+		case *goast.UnaryExpr:
+			shape = append(shape, node.Op.String())
+		case *goast.BasicLit:
+			shape = append(shape, node.Kind.String())
+		}
+		return true
+	})
+
+	node := pdgNode{label: compressLabel(strings.Join(shape, " ")), parent: parent}
+	for def := range deps {
+		node.deps = append(node.deps, def)
+	}
+	sort.Ints(node.deps)
+	b.nodes = append(b.nodes, node)
+
+	for _, ident := range defined {
+		b.lastDef[ident.Obj] = index
+	}
+	return index
+}
+
+// definedIdents returns the local variables stmt assigns, and among them the identifiers
+// that are only assigned rather than also read, such as the left side of x = y but not
+// of x += y or x.f = y.
+func definedIdents(stmt goast.Stmt) ([]*goast.Ident, map[*goast.Ident]bool) {
+	var defined []*goast.Ident
+	assigned := make(map[*goast.Ident]bool)
+
+	add := func(expr goast.Expr, onlyAssigned bool) {
+		for {
+			switch e := expr.(type) {
+			case *goast.Ident:
+				if isLocalVar(e) {
+					defined = append(defined, e)
+					assigned[e] = assigned[e] || onlyAssigned
+				}
+				return
+			case *goast.SelectorExpr:
+				expr, onlyAssigned = e.X, false
+			case *goast.IndexExpr:
+				expr, onlyAssigned = e.X, false
+			case *goast.StarExpr:
+				expr, onlyAssigned = e.X, false
+			case *goast.ParenExpr:
+				expr = e.X
+			default:
+				return
+			}
+		}
+	}
+
+	switch s := stmt.(type) {
+	case *goast.AssignStmt:
+		onlyAssigned := s.Tok == token.ASSIGN || s.Tok == token.DEFINE
+		for _, lhs := range s.Lhs {
+			add(lhs, onlyAssigned)
+		}
+	case *goast.IncDecStmt:
+		add(s.X, false)
+	case *goast.RangeStmt:
+		if s.Key != nil {
+			add(s.Key, true)
+		}
+		if s.Value != nil {
+			add(s.Value, true)
+		}
+	case *goast.DeclStmt:
+		if decl, ok := s.Decl.(*goast.GenDecl); ok && decl.Tok == token.VAR {
+			for _, spec := range decl.Specs {
+				if valueSpec, isValue := spec.(*goast.ValueSpec); isValue {
+					for _, name := range valueSpec.Names {
+						add(name, true)
+					}
+				}
+			}
+		}
+	}
+
+	return defined, assigned
+}
+
+// isLocalVar reports whether ident refers to a variable resolved by the parser, such as
+// a local variable or a parameter.
+func isLocalVar(ident *goast.Ident) bool {
+	return ident.Name != "_" && ident.Obj != nil && ident.Obj.Kind == goast.Var
+}
+
+// pdgLabels returns the histogram of the labels of the statements of nodes over every
+// refinement with the labels of the statements controlling them and they depend on.
+func pdgLabels(nodes []pdgNode) map[string]int {
+	labels := make([]string, len(nodes))
+	for i, node := range nodes {
+		labels[i] = node.label
+	}
+
+	counts := make(map[string]int)
+	for iteration := 0; ; iteration++ {
+		for _, label := range labels {
+			counts[label]++
+		}
+		if iteration == pdgIterations {
+			return counts
+		}
+
+		refined := make([]string, len(labels))
+		for i, node := range nodes {
+			parent := ""
+			if node.parent != noParent {
+				parent = labels[node.parent]
+			}
+			deps := make([]string, len(node.deps))
+			for j, dep := range node.deps {
+				deps[j] = labels[dep]
+			}
+			sort.Strings(deps)
+			refined[i] = compressLabel(labels[i] + "|" + parent + "|" + strings.Join(deps, ","))
+		}
+		labels = refined
+	}
+}
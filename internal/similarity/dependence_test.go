@@ -0,0 +1,125 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/paveg/similarity-go/internal/config"
+	"github.com/paveg/similarity-go/internal/testhelpers"
+)
+
+func TestDependenceSimilarity(t *testing.T) {
+	original := testhelpers.CreateFunctionFromSource(t, `package main
+func summarize(orders []Order) Summary {
+	count := len(orders)
+	total := 0
+	for _, order := range orders {
+		total += order.Amount
+	}
+	names := make([]string, 0, count)
+	for _, order := range orders {
+		names = append(names, order.Customer)
+	}
+	return Summary{Count: count, Total: total, Names: names}
+}`, "summarize")
+	// Independent statements reordered
+	reordered := testhelpers.CreateFunctionFromSource(t, `package main
+func digest(items []Order) Summary {
+	sum := 0
+	n := len(items)
+	names := make([]string, 0, n)
+	for _, item := range items {
+		names = append(names, item.Customer)
+	}
+	for _, item := range items {
+		sum += item.Amount
+	}
+	return Summary{Count: n, Total: sum, Names: names}
+}`, "digest")
+	// Same statements with different dependences
+	rewired := testhelpers.CreateFunctionFromSource(t, `package main
+func rewired(orders []Order) Summary {
+	count := len(orders)
+	total := 0
+	for _, order := range orders {
+		count += order.Amount
+	}
+	names := make([]string, 0, total)
+	for _, order := range orders {
+		names = append(names, order.Customer)
+	}
+	return Summary{Count: count, Total: total, Names: names}
+}`, "rewired")
+
+	if got := DependenceSimilarity(original, reordered); got != 1.0 {
+		t.Errorf("expected reordered independent statements to score 1, got %f", got)
+	}
+	if tokens := TokenSequenceSimilarity(original, reordered); tokens >= 1.0 {
+		t.Errorf("expected the token metric to be affected by the reordering, got %f", tokens)
+	}
+
+	different := DependenceSimilarity(original, rewired)
+	if different >= 1.0 || different <= 0.0 {
+		t.Errorf("expected different dependences to lower the score, got %f", different)
+	}
+
+	if got := DependenceSimilarity(nil, original); got != 0.0 {
+		t.Errorf("expected 0 for a missing function, got %f", got)
+	}
+}
+
+func TestBuildDependenceGraph(t *testing.T) {
+	fn := testhelpers.CreateFunctionFromSource(t, `package main
+func parse(input string) (int, error) {
+	if trimmed := strings.TrimSpace(input); trimmed != "" {
+		value, err := strconv.Atoi(trimmed)
+		return value, err
+	}
+	return 0, errEmpty
+}`, "parse")
+
+	nodes := buildDependenceGraph(fn.AST.Body)
+	// trimmed :=, if, value :=, return value, return 0
+	if len(nodes) != 5 {
+		t.Fatalf("expected 5 statements, got %d", len(nodes))
+	}
+	if nodes[0].parent != noParent || nodes[1].parent != noParent || nodes[2].parent != 1 || nodes[3].parent != 1 {
+		t.Errorf("unexpected control dependences %+v", nodes)
+	}
+	if len(nodes[1].deps) != 1 || nodes[1].deps[0] != 0 || len(nodes[2].deps) != 1 || nodes[2].deps[0] != 0 ||
+		len(nodes[3].deps) != 1 || nodes[3].deps[0] != 2 {
+		t.Errorf("unexpected data dependences %+v", nodes)
+	}
+}
+
+func TestDetector_DependenceWeight(t *testing.T) {
+	cfg := config.Default()
+	cfg.Similarity.Weights.TreeEdit -= 0.1
+	cfg.Similarity.Weights.Dependence = 0.1
+	detector := NewDetectorWithConfig(0.8, cfg)
+
+	sum := testhelpers.CreateFunctionFromSource(t, `package main
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}`, "sum")
+	product := testhelpers.CreateFunctionFromSource(t, `package main
+func product(values []int) int {
+	result := 1
+	for _, v := range values {
+		result *= v
+	}
+	return result
+}`, "product")
+
+	explanation := detector.Explain(sum, product)
+	last := explanation.Components[len(explanation.Components)-1]
+	if last.Name != ComponentDependence || last.Weight != 0.1 {
+		t.Fatalf("expected a weighted dependence component, got %+v", explanation.Components)
+	}
+	if testhelpers.AbsFloat(explanation.Weighted-explanation.Similarity) > 1e-9 {
+		t.Errorf("expected weighted total %f to equal similarity %f", explanation.Weighted, explanation.Similarity)
+	}
+}
@@ -8,6 +8,9 @@ import (
 	goast "go/ast"
 	"go/format"
 	"go/token"
+	"go/types"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/paveg/similarity-go/internal/ast"
@@ -83,28 +86,19 @@ func (d *Detector) CalculateSimilarity(func1, func2 *ast.Function) float64 {
 		return 0.0 // Functions are too different to be similar
 	}
 
+	func1, func2 = d.rewrite(func1), d.rewrite(func2)
+
 	// If both functions have the same normalized AST structure, they are identical
 	if d.compareNormalizedAST(func1, func2) {
 		return 1.0
 	}
 
-	// Use multiple similarity metrics and combine them
-	// 1. Tree edit distance similarity
-	treeEditSim := d.calculateTreeEditSimilarity(func1, func2)
-
-	// 2. Token sequence similarity
-	tokenSim := TokenSequenceSimilarity(func1, func2)
-
-	// 3. Structural similarity (existing algorithm)
-	structuralSim := d.calculateStructuralSimilarity(func1, func2)
-
-	// 4. Signature similarity
-	signatureSim := d.calculateSignatureSimilarity(func1, func2)
-
-	// Weighted combination: prioritize tree edit and token similarity
-	// as they are more sophisticated algorithms
-	weights := d.config.Similarity.Weights
-	result := weights.TreeEdit*treeEditSim + weights.TokenSimilarity*tokenSim + weights.Structural*structuralSim + weights.Signature*signatureSim
+	// Combine the enabled metrics, such as tree edit distance, token sequence, structural
+	// and signature similarity, by their configured weights
+	var result float64
+	for _, component := range d.weightedMetrics(func1, func2) {
+		result += component.Contribution
+	}
 
 	// Cache the result for future use (with size limit)
 	d.cacheMu.Lock()
@@ -116,6 +110,42 @@ func (d *Detector) CalculateSimilarity(func1, func2 *ast.Function) float64 {
 	return result
 }
 
+// calculateAPIUsageSimilarity compares the APIs called by two functions, ignoring the
+// configured noise.
+func (d *Detector) calculateAPIUsageSimilarity(func1, func2 *ast.Function) float64 {
+	return APIUsageSimilarity(func1, func2, d.config.Similarity.APIUsage.Ignore)
+}
+
+// normalizeOptions returns the rewrites enabled by the normalization configuration.
+func (d *Detector) normalizeOptions() ast.NormalizeOptions {
+	normalization := d.config.Similarity.Normalization
+	return ast.NormalizeOptions{
+		CommutativeOperands: normalization.CommutativeOperands,
+		NegatedConditions:   normalization.NegatedConditions,
+		NilComparisons:      normalization.NilComparisons,
+		VarDeclarations:     normalization.VarDeclarations,
+		IncDec:              normalization.IncDec,
+		RangeLoops:          normalization.RangeLoops,
+	}
+}
+
+// Normalize normalizes fn after rewriting it in the canonical form selected by the
+// normalization configuration.
+func (d *Detector) Normalize(fn *ast.Function) *ast.Function {
+	return fn.NormalizeWith(d.normalizeOptions())
+}
+
+// rewrite returns fn rewritten in the canonical form selected by the normalization
+// configuration, so that every metric compares the canonical forms. Functions already
+// normalized by Normalize are returned as they are.
+func (d *Detector) rewrite(fn *ast.Function) *ast.Function {
+	opts := d.normalizeOptions()
+	if !opts.Enabled() || fn.AST == nil || fn.AST == fn.Normalized {
+		return fn
+	}
+	return fn.Rewrite(opts)
+}
+
 // IsAboveThreshold checks if similarity is above the configured threshold.
 func (d *Detector) IsAboveThreshold(similarity float64) bool {
 	return similarity >= d.threshold
@@ -141,6 +171,75 @@ func (d *Detector) FindSimilarFunctions(functions []*ast.Function) []Match {
 	return matches
 }
 
+// FindSimilarFunctionsBetween finds similar pairs where one function comes from left and
+// the other from right. Pairs within the same side are never compared, and Function1 of
+// every returned match always belongs to left.
+func (d *Detector) FindSimilarFunctionsBetween(left, right []*ast.Function) []Match {
+	var matches []Match
+
+	for _, leftFn := range left {
+		for _, rightFn := range right {
+			similarity := d.CalculateSimilarity(leftFn, rightFn)
+			if d.IsAboveThreshold(similarity) {
+				matches = append(matches, Match{
+					Function1:  leftFn,
+					Function2:  rightFn,
+					Similarity: similarity,
+				})
+			}
+		}
+	}
+
+	return matches
+}
+
+// FindMostSimilar compares query against each candidate and returns the limit most
+// similar candidates, best first. Only len(candidates) comparisons are performed, so
+// this is much cheaper than FindSimilarFunctions for "does something like this exist?"
+// lookups. Candidates scoring below minSimilarity, or 0.0, are dropped; a non-positive
+// limit returns every remaining candidate. Ties are broken by file and start line.
+func (d *Detector) FindMostSimilar(
+	query *ast.Function,
+	candidates []*ast.Function,
+	limit int,
+	minSimilarity float64,
+) []Match {
+	var matches []Match
+
+	for _, candidate := range candidates {
+		if candidate == query {
+			continue
+		}
+
+		similarity := d.CalculateSimilarity(query, candidate)
+		if similarity <= 0.0 || similarity < minSimilarity {
+			continue
+		}
+
+		matches = append(matches, Match{
+			Function1:  query,
+			Function2:  candidate,
+			Similarity: similarity,
+		})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Similarity != matches[j].Similarity {
+			return matches[i].Similarity > matches[j].Similarity
+		}
+		if matches[i].Function2.File != matches[j].Function2.File {
+			return matches[i].Function2.File < matches[j].Function2.File
+		}
+		return matches[i].Function2.StartLine < matches[j].Function2.StartLine
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches
+}
+
 // ParallelProcessor defines the interface for parallel similarity processing.
 type ParallelProcessor interface {
 	FindSimilarFunctions(functions []*ast.Function, progressCallback func(completed, total int)) ([]Match, error)
@@ -158,27 +257,47 @@ func (d *Detector) FindSimilarFunctionsWithProcessor(
 // couldBeSimilar performs quick heuristic checks to filter out obviously dissimilar functions.
 // This avoids expensive similarity calculations for functions that are clearly different.
 func (d *Detector) couldBeSimilar(func1, func2 *ast.Function) bool {
-	// Check signature length difference
-	sig1 := func1.GetSignature()
-	sig2 := func2.GetSignature()
-
-	if mathutil.Abs(len(sig1)-len(sig2)) > d.config.Similarity.Limits.MaxSignatureLengthDiff {
-		return false
+	for _, check := range d.prefilterChecks(func1, func2) {
+		if !check.Passed {
+			return false
+		}
 	}
 
+	return true // Passed quick checks, allow full comparison
+}
+
+// prefilterChecks evaluates the quick heuristic checks used by couldBeSimilar.
+// Checks that cannot be evaluated are omitted rather than reported as failed.
+func (d *Detector) prefilterChecks(func1, func2 *ast.Function) []PrefilterCheck {
+	var checks []PrefilterCheck
+
+	// Check signature length difference, ignoring parameter names
+	sig1 := d.getStructuralSignature(func1)
+	sig2 := d.getStructuralSignature(func2)
+
+	sigDiff := mathutil.Abs(len(sig1) - len(sig2))
+	maxSigDiff := d.config.Similarity.Limits.MaxSignatureLengthDiff
+	checks = append(checks, PrefilterCheck{
+		Name:   PrefilterSignatureLength,
+		Passed: sigDiff <= maxSigDiff,
+		Detail: fmt.Sprintf("signature length difference %d (max %d)", sigDiff, maxSigDiff),
+	})
+
 	// Check line count ratio
 	lines1 := func1.LineCount
 	lines2 := func2.LineCount
 
 	if lines1 == 0 || lines2 == 0 {
-		return true // Can't determine, let full comparison decide
+		return checks // Can't determine, let full comparison decide
 	}
 
 	ratio := float64(lines1) / float64(lines2)
 	maxRatio := d.config.Similarity.Limits.MaxLineDifferenceRatio
-	if ratio > maxRatio || ratio < 1.0/maxRatio {
-		return false
-	}
+	checks = append(checks, PrefilterCheck{
+		Name:   PrefilterLineRatio,
+		Passed: ratio <= maxRatio && ratio >= 1.0/maxRatio,
+		Detail: fmt.Sprintf("line count %d vs %d, ratio %.2f (max %.2f)", lines1, lines2, ratio, maxRatio),
+	})
 
 	// Check basic structural compatibility
 	if func1.AST != nil && func2.AST != nil {
@@ -189,13 +308,21 @@ func (d *Detector) couldBeSimilar(func1, func2 *ast.Function) bool {
 
 			// If one is empty and other has many statements, likely different
 			maxEmpty := d.config.Processing.MaxEmptyVsPopulated
-			if (stmt1Count == 0 && stmt2Count > maxEmpty) || (stmt2Count == 0 && stmt1Count > maxEmpty) {
-				return false
-			}
+			checks = append(checks, PrefilterCheck{
+				Name: PrefilterStatementCount,
+				Passed: !(stmt1Count == 0 && stmt2Count > maxEmpty) &&
+					!(stmt2Count == 0 && stmt1Count > maxEmpty),
+				Detail: fmt.Sprintf(
+					"top-level statements %d vs %d (max %d against an empty body)",
+					stmt1Count,
+					stmt2Count,
+					maxEmpty,
+				),
+			})
 		}
 	}
 
-	return true // Passed quick checks, allow full comparison
+	return checks
 }
 
 // getCacheKey creates a consistent cache key for two function hashes.
@@ -355,10 +482,11 @@ func (d *Detector) calculateStructuralSimilarity(func1, func2 *ast.Function) flo
 	return bodyScore * d.config.Similarity.Weights.DifferentSignature // Different signatures result in lower similarity
 }
 
-// calculateSignatureSimilarity compares function signatures.
+// calculateSignatureSimilarity compares the structural signatures of functions, so that
+// parameter names do not count but every part of their types does.
 func (d *Detector) calculateSignatureSimilarity(func1, func2 *ast.Function) float64 {
-	sig1 := func1.GetSignature()
-	sig2 := func2.GetSignature()
+	sig1 := d.getStructuralSignature(func1)
+	sig2 := d.getStructuralSignature(func2)
 
 	if sig1 == sig2 {
 		return 1.0
@@ -368,62 +496,189 @@ func (d *Detector) calculateSignatureSimilarity(func1, func2 *ast.Function) floa
 	return d.stringSimilarity(sig1, sig2)
 }
 
-// getStructuralSignature gets signature without parameter names.
-//
-//nolint:gocognit // Complex structural signature generation algorithm acceptable
+// getStructuralSignature gets signature without parameter names. Methods differ from
+// functions, and pointer from value receivers, but the receiver type itself does not
+// count, so that a method copied to another type keeps its signature.
 func (d *Detector) getStructuralSignature(fn *ast.Function) string {
 	if fn.AST == nil || fn.AST.Type == nil {
 		return ""
 	}
 
-	// Create a simplified signature focusing on types, not names
-	var paramTypes []string
+	return d.renderReceiver(fn.AST) + d.renderSignature(fn.AST.Type, d.typeParamNames(fn.AST.Type))
+}
+
+// renderReceiver renders the kind of receiver of a method, (*) or (), followed by a space,
+// or an empty string for functions.
+func (d *Detector) renderReceiver(decl *goast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return ""
+	}
+
+	recv := decl.Recv.List[0].Type
+	if paren, ok := recv.(*goast.ParenExpr); ok {
+		recv = paren.X
+	}
+	if _, ok := recv.(*goast.StarExpr); ok {
+		return "(*) "
+	}
+	return "() "
+}
 
-	if fn.AST.Type.Params != nil {
-		for _, param := range fn.AST.Type.Params.List {
-			if param.Type != nil {
-				paramTypes = append(paramTypes, d.typeToString(param.Type))
+// TypeShape gets the structural signature with every predeclared basic type replaced by
+// a placeholder numbered by first use, so that copies of a function written for
+// different types, such as int and float64, share it. It does not affect similarity
+// scores; it tells whether a group can be consolidated into a generic function.
+func (d *Detector) TypeShape(fn *ast.Function) string {
+	if fn.AST == nil || fn.AST.Type == nil {
+		return ""
+	}
+
+	return d.renderReceiver(fn.AST) + d.renderSignature(fn.AST.Type, d.typeShapeNames(fn.AST.Type))
+}
+
+// TypeVariantKey gets a key shared by the functions that are identical apart from the
+// basic types of their signatures: their type shapes are equal, and so are their bodies
+// once those types are replaced by the same placeholders. Such copies are type-only
+// variants whatever their similarity score. The key is empty for functions whose
+// signature has no basic type to substitute.
+func (d *Detector) TypeVariantKey(fn *ast.Function) string {
+	if fn.AST == nil || fn.AST.Type == nil || fn.AST.Body == nil {
+		return ""
+	}
+
+	names := d.typeShapeNames(fn.AST.Type)
+	if len(names) == len(d.typeParamNames(fn.AST.Type)) {
+		return ""
+	}
+
+	body := ast.CloneNode(fn.AST.Body)
+	var substitute func(n goast.Node) bool
+	substitute = func(n goast.Node) bool {
+		switch node := n.(type) {
+		case *goast.SelectorExpr:
+			// Selected fields and methods are never types
+			goast.Inspect(node.X, substitute)
+			return false
+		case *goast.Ident:
+			if name, exists := names[node.Name]; exists {
+				node.Name = name
 			}
 		}
+		return true
 	}
+	goast.Inspect(body, substitute)
 
-	resultTypes := ""
-	resultCount := 0
+	return d.renderReceiver(fn.AST) + d.renderSignature(fn.AST.Type, names) + d.generateASTHash(body)
+}
 
-	if fn.AST.Type.Results != nil {
-		for _, result := range fn.AST.Type.Results.List {
-			if result.Type != nil {
-				if resultTypes != "" {
-					resultTypes += ", "
+// typeShapeNames names the type parameters of funcType, then the predeclared basic types
+// of its parameters and results by first use.
+func (d *Detector) typeShapeNames(funcType *goast.FuncType) map[string]string {
+	names := d.typeParamNames(funcType)
+	for _, list := range []*goast.FieldList{funcType.Params, funcType.Results} {
+		if list == nil {
+			continue
+		}
+		for _, field := range list.List {
+			goast.Inspect(field.Type, func(n goast.Node) bool {
+				switch node := n.(type) {
+				case *goast.SelectorExpr:
+					// Qualified types are kept as they are
+					return false
+				case *goast.Ident:
+					if _, exists := names[node.Name]; !exists && isBasicTypeName(node.Name) {
+						names[node.Name] = fmt.Sprintf("$%d", len(names))
+					}
 				}
+				return true
+			})
+		}
+	}
 
-				resultTypes += d.typeToString(result.Type)
-				resultCount++
-			}
+	return names
+}
+
+// isBasicTypeName reports whether name is a predeclared boolean, numeric or string type.
+func isBasicTypeName(name string) bool {
+	obj, ok := types.Universe.Lookup(name).(*types.TypeName)
+	if !ok {
+		return false
+	}
+	basic, ok := obj.Type().(*types.Basic)
+	return ok && basic.Info()&(types.IsBoolean|types.IsNumeric|types.IsString) != 0
+}
+
+// typeParamNames names the type parameters of a function by position, so that renaming
+// them does not change its signature.
+func (d *Detector) typeParamNames(funcType *goast.FuncType) map[string]string {
+	return d.fieldListNames(funcType.TypeParams)
+}
+
+// fieldListNames names the type parameters declared by list by position.
+func (d *Detector) fieldListNames(list *goast.FieldList) map[string]string {
+	names := make(map[string]string)
+	if list == nil {
+		return names
+	}
+
+	for _, field := range list.List {
+		for _, name := range field.Names {
+			names[name.Name] = fmt.Sprintf("$%d", len(names))
 		}
 	}
 
-	signature := "func("
+	return names
+}
 
-	for i, pt := range paramTypes {
-		if i > 0 {
-			signature += ", "
+// renderSignature renders a function type without parameter names, replacing type names
+// with their entry in names.
+func (d *Detector) renderSignature(funcType *goast.FuncType, names map[string]string) string {
+	var constraints []string
+	if funcType.TypeParams != nil {
+		for _, field := range funcType.TypeParams.List {
+			for range field.Names {
+				constraints = append(constraints, d.renderType(field.Type, names))
+			}
 		}
+	}
+
+	// Create a simplified signature focusing on types, not names
+	paramTypes := d.renderFieldTypes(funcType.Params, names)
+	resultTypes := d.renderFieldTypes(funcType.Results, names)
 
-		signature += pt
+	signature := "func"
+	if len(constraints) > 0 {
+		signature += "[" + strings.Join(constraints, ", ") + "]"
 	}
+	signature += "(" + strings.Join(paramTypes, ", ") + ")"
+
+	switch len(resultTypes) {
+	case 0:
+	case 1:
+		signature += " " + resultTypes[0]
+	default:
+		signature += " (" + strings.Join(resultTypes, ", ") + ")"
+	}
+
+	return signature
+}
 
-	signature += ")"
+// renderFieldTypes renders the type of every parameter or result in list, once per name,
+// so that func(a, b int) and func(a int, b int) render the same.
+func (d *Detector) renderFieldTypes(list *goast.FieldList, names map[string]string) []string {
+	if list == nil {
+		return nil
+	}
 
-	if resultTypes != "" {
-		if resultCount > 1 {
-			signature += " (" + resultTypes + ")"
-		} else {
-			signature += " " + resultTypes
+	var fieldTypes []string
+	for _, field := range list.List {
+		fieldType := d.renderType(field.Type, names)
+		for range max(1, len(field.Names)) {
+			fieldTypes = append(fieldTypes, fieldType)
 		}
 	}
 
-	return signature
+	return fieldTypes
 }
 
 // compareBodyStructure compares the structure of function bodies.
@@ -484,18 +739,133 @@ func (d *Detector) generateASTHash(node goast.Node) string {
 
 // typeToString converts an AST type to its string representation.
 func (d *Detector) typeToString(expr goast.Expr) string {
+	return d.renderType(expr, nil)
+}
+
+// renderType converts an AST type to its string representation, replacing the names of
+// type parameters with their entry in typeParams.
+func (d *Detector) renderType(expr goast.Expr, typeParams map[string]string) string {
 	switch t := expr.(type) {
 	case *goast.Ident:
+		if name, ok := typeParams[t.Name]; ok {
+			return name
+		}
 		return t.Name
 	case *goast.StarExpr:
-		return "*" + d.typeToString(t.X)
+		return "*" + d.renderType(t.X, typeParams)
 	case *goast.SelectorExpr:
-		return d.typeToString(t.X) + "." + t.Sel.Name
+		return d.renderType(t.X, typeParams) + "." + t.Sel.Name
+	case *goast.ParenExpr:
+		return "(" + d.renderType(t.X, typeParams) + ")"
+	case *goast.ArrayType:
+		if t.Len == nil {
+			return "[]" + d.renderType(t.Elt, typeParams)
+		}
+		return "[" + d.renderArrayLength(t.Len) + "]" + d.renderType(t.Elt, typeParams)
+	case *goast.Ellipsis:
+		return "..." + d.renderType(t.Elt, typeParams)
+	case *goast.MapType:
+		return "map[" + d.renderType(t.Key, typeParams) + "]" + d.renderType(t.Value, typeParams)
+	case *goast.ChanType:
+		return d.renderChanType(t, typeParams)
+	case *goast.FuncType:
+		return d.renderSignature(t, typeParams)
+	case *goast.InterfaceType:
+		return d.renderInterfaceType(t, typeParams)
+	case *goast.StructType:
+		return d.renderStructType(t, typeParams)
+	case *goast.IndexExpr:
+		return d.renderType(t.X, typeParams) + "[" + d.renderType(t.Index, typeParams) + "]"
+	case *goast.IndexListExpr:
+		indices := make([]string, len(t.Indices))
+		for i, index := range t.Indices {
+			indices[i] = d.renderType(index, typeParams)
+		}
+		return d.renderType(t.X, typeParams) + "[" + strings.Join(indices, ", ") + "]"
+	case *goast.BinaryExpr:
+		// Union of constraint terms
+		return d.renderType(t.X, typeParams) + " " + t.Op.String() + " " + d.renderType(t.Y, typeParams)
+	case *goast.UnaryExpr:
+		// Underlying type term such as ~int
+		return t.Op.String() + d.renderType(t.X, typeParams)
 	default:
 		return "unknown"
 	}
 }
 
+// renderArrayLength renders the length of an array type, which is a constant expression
+// or ... in composite literals.
+func (d *Detector) renderArrayLength(length goast.Expr) string {
+	if _, ok := length.(*goast.Ellipsis); ok {
+		return "..."
+	}
+	return types.ExprString(length)
+}
+
+// renderChanType renders a channel type with its direction.
+func (d *Detector) renderChanType(t *goast.ChanType, typeParams map[string]string) string {
+	elem := d.renderType(t.Value, typeParams)
+
+	switch t.Dir {
+	case goast.SEND:
+		return "chan<- " + elem
+	case goast.RECV:
+		return "<-chan " + elem
+	default:
+		// chan (<-chan T) needs parentheses, as chan <-chan T means chan<- (chan T)
+		if inner, ok := t.Value.(*goast.ChanType); ok && inner.Dir == goast.RECV {
+			elem = "(" + elem + ")"
+		}
+		return "chan " + elem
+	}
+}
+
+// renderInterfaceType renders an interface type with its methods and embedded types or
+// type sets, in declaration order.
+func (d *Detector) renderInterfaceType(t *goast.InterfaceType, typeParams map[string]string) string {
+	var elems []string
+	for _, field := range t.Methods.List {
+		if len(field.Names) == 0 {
+			elems = append(elems, d.renderType(field.Type, typeParams))
+			continue
+		}
+		signature := strings.TrimPrefix(d.renderType(field.Type, typeParams), "func")
+		for _, name := range field.Names {
+			elems = append(elems, name.Name+signature)
+		}
+	}
+
+	if len(elems) == 0 {
+		return "interface{}"
+	}
+	return "interface{ " + strings.Join(elems, "; ") + " }"
+}
+
+// renderStructType renders a struct type with its fields and tags, in declaration order.
+// Field names are kept, as they are part of the type identity.
+func (d *Detector) renderStructType(t *goast.StructType, typeParams map[string]string) string {
+	var fields []string
+	for _, field := range t.Fields.List {
+		fieldType := d.renderType(field.Type, typeParams)
+		if field.Tag != nil {
+			fieldType += " " + field.Tag.Value
+		}
+
+		if len(field.Names) == 0 {
+			fields = append(fields, fieldType)
+			continue
+		}
+		for _, name := range field.Names {
+			fields = append(fields, name.Name+" "+fieldType)
+		}
+	}
+
+	if len(fields) == 0 {
+		return "struct{}"
+	}
+	return "struct{ " + strings.Join(fields, "; ") + " }"
+}
+
 // stringSimilarity calculates simple string similarity.
 func (d *Detector) stringSimilarity(s1, s2 string) float64 {
 	if s1 == s2 {
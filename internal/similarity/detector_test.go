@@ -838,6 +838,174 @@ func test(a int, b string) (int, error) {
 	}
 }
 
+func TestDetector_GetStructuralSignatureGenerics(t *testing.T) {
+	detector := NewDetector(0.5)
+
+	source := `package main
+func Max[T cmp.Ordered](a, b T) T { return a }
+func Largest[E cmp.Ordered](x, y E) E { return x }
+func Sum[T ~int | ~float64](values Set[T]) T { return 0 }`
+
+	maxSig := detector.getStructuralSignature(testhelpers.CreateFunctionFromSource(t, source, "Max"))
+	largestSig := detector.getStructuralSignature(testhelpers.CreateFunctionFromSource(t, source, "Largest"))
+	sumSig := detector.getStructuralSignature(testhelpers.CreateFunctionFromSource(t, source, "Sum"))
+
+	if maxSig != "func[cmp.Ordered]($0, $0) $0" {
+		t.Errorf("unexpected signature %s", maxSig)
+	}
+	if largestSig != maxSig {
+		t.Errorf("renaming type parameters changed the signature: %s != %s", largestSig, maxSig)
+	}
+	if sumSig != "func[~int | ~float64](Set[$0]) $0" {
+		t.Errorf("unexpected signature %s", sumSig)
+	}
+}
+
+func TestDetector_GetStructuralSignatureTypes(t *testing.T) {
+	detector := NewDetector(0.5)
+
+	source := `package main
+func Ints(values []int) {}
+func Flags(values map[string]bool) {}
+func Handle(fn func(ctx context.Context, n int) (string, error), opts ...Option) {}
+func Consume(in <-chan struct{ ID int ` + "`json:\"id\"`" + ` }, out chan<- [2]float64) {}
+func Close(c interface{ io.Closer; Name() string }) {}`
+
+	signature := func(name string) string {
+		return detector.getStructuralSignature(testhelpers.CreateFunctionFromSource(t, source, name))
+	}
+
+	if signature("Ints") == signature("Flags") {
+		t.Errorf("slice and map parameters share a signature: %s", signature("Ints"))
+	}
+
+	tests := map[string]string{
+		"Ints":    "func([]int)",
+		"Flags":   "func(map[string]bool)",
+		"Handle":  "func(func(context.Context, int) (string, error), ...Option)",
+		"Consume": "func(<-chan struct{ ID int `json:\"id\"` }, chan<- [2]float64)",
+		"Close":   "func(interface{ io.Closer; Name() string })",
+	}
+	for name, want := range tests {
+		if got := signature(name); got != want {
+			t.Errorf("%s: signature = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestDetector_GetStructuralSignatureReceivers(t *testing.T) {
+	detector := NewDetector(0.5)
+
+	source := `package main
+func (c *Client) Close() error { return nil }
+func (s *Server) Close() error { return nil }
+func (p Point) Close() error { return nil }
+func Close() error { return nil }`
+
+	signature := func(name string, line int) string {
+		for _, fn := range ast.NewParser().ParseSource("receivers.go", []byte(source)).Unwrap().Functions {
+			if fn.Name == name && fn.StartLine == line {
+				return detector.getStructuralSignature(fn)
+			}
+		}
+		t.Fatalf("function %s not found at line %d", name, line)
+		return ""
+	}
+
+	client, server, point, function := signature("Close", 2), signature("Close", 3), signature("Close", 4), signature("Close", 5)
+	if client != "(*) func() error" || client != server {
+		t.Errorf("pointer methods should share a signature: %s, %s", client, server)
+	}
+	if point != "() func() error" {
+		t.Errorf("unexpected value receiver signature %s", point)
+	}
+	if function != "func() error" {
+		t.Errorf("unexpected function signature %s", function)
+	}
+}
+
+func TestDetector_CalculateSignatureSimilarityIgnoresNames(t *testing.T) {
+	detector := NewDetector(0.5)
+
+	source := `package main
+func A(values []int, limit int) error { return nil }
+func B(items []int, max int) error { return nil }`
+
+	a := testhelpers.CreateFunctionFromSource(t, source, "A")
+	b := testhelpers.CreateFunctionFromSource(t, source, "B")
+
+	if sim := detector.calculateSignatureSimilarity(a, b); sim != 1.0 {
+		t.Errorf("Expected 1.0 for signatures only differing by parameter names, got %.2f", sim)
+	}
+}
+
+func TestDetector_TypeShape(t *testing.T) {
+	detector := NewDetector(0.5)
+
+	source := `package main
+func SumInts(values []int) int { return 0 }
+func SumFloats(values []float64) float64 { return 0 }
+func Mixed(values []float64) int { return 0 }
+func Users(values []User) User { return User{} }`
+
+	shape := func(name string) string {
+		return detector.TypeShape(testhelpers.CreateFunctionFromSource(t, source, name))
+	}
+
+	if shape("SumInts") != shape("SumFloats") {
+		t.Errorf("copies for int and float64 should share a shape: %s, %s", shape("SumInts"), shape("SumFloats"))
+	}
+	if shape("SumInts") == shape("Mixed") {
+		t.Errorf("inconsistent type substitutions should not share a shape: %s", shape("Mixed"))
+	}
+	if shape("SumInts") == shape("Users") {
+		t.Errorf("named types should not be abstracted: %s", shape("Users"))
+	}
+
+	// Sharing a shape does not make signatures structurally identical
+	sumInts := testhelpers.CreateFunctionFromSource(t, source, "SumInts")
+	sumFloats := testhelpers.CreateFunctionFromSource(t, source, "SumFloats")
+	if score := detector.calculateStructuralSimilarity(sumInts, sumFloats); score >= 1.0 {
+		t.Errorf("expected the different signature penalty, got %f", score)
+	}
+}
+
+func TestDetector_TypeVariantKey(t *testing.T) {
+	detector := NewDetector(0.5)
+
+	source := `package main
+func SumInts(values []int) int {
+	var total int
+	for _, v := range values { total += v }
+	return total
+}
+func SumFloats(values []float64) float64 {
+	var total float64
+	for _, v := range values { total += v }
+	return total
+}
+func MaxFloats(values []float64) float64 {
+	var best float64
+	for _, v := range values { best = max(best, v) }
+	return best
+}
+func Users(values []User) User { return values[0] }`
+
+	key := func(name string) string {
+		return detector.TypeVariantKey(testhelpers.CreateFunctionFromSource(t, source, name))
+	}
+
+	if key("SumInts") == "" || key("SumInts") != key("SumFloats") {
+		t.Errorf("copies for int and float64 should share a key: %q, %q", key("SumInts"), key("SumFloats"))
+	}
+	if key("SumFloats") == key("MaxFloats") {
+		t.Errorf("different bodies should not share a key")
+	}
+	if key("Users") != "" {
+		t.Errorf("signatures without basic types should have no key, got %q", key("Users"))
+	}
+}
+
 func TestDetector_CompareBodyStructure(t *testing.T) {
 	detector := NewDetector(0.5)
 
@@ -924,6 +1092,47 @@ func TestDetector_TypeToString(t *testing.T) {
 			expr:     &goast.SelectorExpr{X: &goast.Ident{Name: "fmt"}, Sel: &goast.Ident{Name: "Print"}},
 			expected: "fmt.Print",
 		},
+		{
+			name: "generic instantiation",
+			expr: &goast.IndexListExpr{
+				X:       &goast.Ident{Name: "Pair"},
+				Indices: []goast.Expr{&goast.Ident{Name: "string"}, &goast.StarExpr{X: &goast.Ident{Name: "int"}}},
+			},
+			expected: "Pair[string, *int]",
+		},
+		{
+			name:     "slice",
+			expr:     &goast.ArrayType{Elt: &goast.Ident{Name: "int"}},
+			expected: "[]int",
+		},
+		{
+			name:     "array",
+			expr:     &goast.ArrayType{Len: &goast.BasicLit{Kind: token.INT, Value: "4"}, Elt: &goast.Ident{Name: "byte"}},
+			expected: "[4]byte",
+		},
+		{
+			name:     "map",
+			expr:     &goast.MapType{Key: &goast.Ident{Name: "string"}, Value: &goast.Ident{Name: "bool"}},
+			expected: "map[string]bool",
+		},
+		{
+			name:     "receive channel",
+			expr:     &goast.ChanType{Dir: goast.RECV, Value: &goast.Ident{Name: "error"}},
+			expected: "<-chan error",
+		},
+		{
+			name: "channel of receive channels",
+			expr: &goast.ChanType{
+				Dir:   goast.SEND | goast.RECV,
+				Value: &goast.ChanType{Dir: goast.RECV, Value: &goast.Ident{Name: "int"}},
+			},
+			expected: "chan (<-chan int)",
+		},
+		{
+			name:     "variadic",
+			expr:     &goast.Ellipsis{Elt: &goast.Ident{Name: "any"}},
+			expected: "...any",
+		},
 		{
 			name:     "unknown type",
 			expr:     &goast.BasicLit{Kind: token.INT, Value: "42"},
@@ -1139,3 +1348,140 @@ func test2() int {
 		t.Errorf("expected distance 0 for same nodes, got %d", distance)
 	}
 }
+
+func TestDetector_FindSimilarFunctionsBetween(t *testing.T) {
+	left := []*ast.Function{
+		testhelpers.CreateFunctionFromSource(t, `package main
+func add1(a, b int) int { return a + b }`, "add1"),
+		testhelpers.CreateFunctionFromSource(t, `package main
+func add2(x, y int) int { return x + y }`, "add2"),
+	}
+	right := []*ast.Function{
+		testhelpers.CreateFunctionFromSource(t, `package main
+func add3(p, q int) int { return p + q }`, "add3"),
+	}
+
+	detector := NewDetector(0.8)
+	matches := detector.FindSimilarFunctionsBetween(left, right)
+
+	// add1 and add2 are only on the left, so they must never be paired together
+	if len(matches) != len(left) {
+		t.Fatalf("Expected %d cross-set matches, got %d", len(left), len(matches))
+	}
+
+	for _, match := range matches {
+		if match.Function2.Name != "add3" {
+			t.Errorf("Expected Function2 to come from right set, got %s", match.Function2.Name)
+		}
+		if match.Function1.Name == "add3" {
+			t.Errorf("Expected Function1 to come from left set, got %s", match.Function1.Name)
+		}
+	}
+
+	if got := detector.FindSimilarFunctionsBetween(left, nil); len(got) != 0 {
+		t.Errorf("Expected no matches with empty right set, got %d", len(got))
+	}
+}
+
+func TestDetector_FindMostSimilar(t *testing.T) {
+	query := testhelpers.CreateFunctionFromSource(t, `package main
+func add(a, b int) int { return a + b }`, "add")
+
+	candidates := []*ast.Function{
+		query, // the query itself must never be reported
+		testhelpers.CreateFunctionFromSource(t, `package main
+func sum(x, y int) int { return x + y }`, "sum"),
+		testhelpers.CreateFunctionFromSource(t, `package main
+func describe(name string, count int, verbose bool) (string, error) {
+	if verbose {
+		return name, nil
+	}
+	for i := 0; i < count; i++ {
+		name += "!"
+	}
+	return name, nil
+}`, "describe"),
+		testhelpers.CreateFunctionFromSource(t, `package main
+func sub(a, b int) int { return a - b }`, "sub"),
+	}
+
+	detector := NewDetector(0.8)
+
+	matches := detector.FindMostSimilar(query, candidates, 2, 0.0)
+	if len(matches) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(matches))
+	}
+
+	for i, match := range matches {
+		if match.Function1 != query {
+			t.Errorf("Expected Function1 to be the query, got %s", match.Function1.Name)
+		}
+		if match.Function2 == query {
+			t.Error("Query function must not be reported as its own neighbor")
+		}
+		if i > 0 && matches[i-1].Similarity < match.Similarity {
+			t.Errorf("Expected results sorted by descending similarity, got %v", matches)
+		}
+	}
+
+	if matches[0].Function2.Name != "sum" {
+		t.Errorf("Expected sum to be the nearest neighbor, got %s", matches[0].Function2.Name)
+	}
+
+	// Only sum is structurally identical to add once normalized
+	strict := detector.FindMostSimilar(query, candidates, 0, 1.0)
+	if len(strict) != 1 || strict[0].Function2.Name != "sum" {
+		t.Errorf("Expected minimum similarity to keep only sum, got %d results", len(strict))
+	}
+}
+
+func TestDetector_Normalization(t *testing.T) {
+	original := testhelpers.CreateFunctionFromSource(t, `package main
+func find(values []*Item, key string) *Item {
+	for i := 0; i < len(values); i++ {
+		item := values[i]
+		if item != nil && item.Key == key {
+			return item
+		}
+	}
+	return nil
+}`, "find")
+	rewritten := testhelpers.CreateFunctionFromSource(t, `package main
+func lookup(items []*Item, name string) *Item {
+	for _, entry := range items {
+		if nil != entry && name == entry.Key {
+			return entry
+		}
+	}
+	return nil
+}`, "lookup")
+
+	cfg := config.Default()
+	plain := NewDetectorWithConfig(0.8, cfg).CalculateSimilarity(original, rewritten)
+	if plain >= 1.0 {
+		t.Fatalf("expected the rewritten copy to differ without normalization rewrites, got %f", plain)
+	}
+
+	cfg = config.Default()
+	cfg.Similarity.Normalization = config.NormalizationConfig{
+		CommutativeOperands: true,
+		NilComparisons:      true,
+		RangeLoops:          true,
+	}
+	detector := NewDetectorWithConfig(0.8, cfg)
+	if got := detector.CalculateSimilarity(original, rewritten); got != 1.0 {
+		t.Errorf("expected the rewritten copy to be identical once normalized, got %f", got)
+	}
+	if got := detector.Explain(original, rewritten).Shortcut; got != ShortcutIdenticalNormalizedAST {
+		t.Errorf("expected the %s shortcut, got %q", ShortcutIdenticalNormalizedAST, got)
+	}
+
+	matches, err := NewDefaultParallelProcessor(detector, 2).FindSimilarFunctions(
+		[]*ast.Function{original, rewritten}, nil)
+	if err != nil {
+		t.Fatalf("FindSimilarFunctions() error = %v", err)
+	}
+	if len(matches) != 1 || matches[0].Similarity != 1.0 {
+		t.Errorf("expected the parallel processor to match the copies, got %+v", matches)
+	}
+}
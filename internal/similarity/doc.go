@@ -9,6 +9,16 @@
 //   - Token Sequence Analysis (30% weight): Levenshtein distance on normalized tokens
 //   - Structural Signatures (25% weight): Function body structure comparison
 //   - Function Signatures (15% weight): Parameter and return type analysis
+//   - Control-Flow Graphs (optional, disabled by default): Weisfeiler-Lehman comparison
+//     of the basic-block structure
+//   - Program-Dependence Graphs (optional, disabled by default): Order-independent matching
+//     of statements by their data and control dependences
+//   - API Usage (optional, disabled by default): Set and order of the called package
+//     functions and methods, before normalization
+//
+// Every metric implements the Metric interface and is looked up by name in a registry, so
+// the configuration can list the enabled metrics with their weights, including metrics
+// added with RegisterMetric, and the weight optimizers can search any set of them.
 //
 // The Detector class orchestrates the similarity analysis process, providing
 // configurable thresholds and performance optimizations including early termination
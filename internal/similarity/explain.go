@@ -0,0 +1,267 @@
+package similarity
+
+import (
+	"bytes"
+	goast "go/ast"
+	"go/format"
+	"go/token"
+	"strings"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/config"
+)
+
+// Prefilter check names reported by Explain.
+const (
+	PrefilterSignatureLength = "signature_length"
+	PrefilterLineRatio       = "line_ratio"
+	PrefilterStatementCount  = "statement_count"
+)
+
+// Component names of the built-in metrics reported by Explain. They match the keys of the
+// weights configuration; registered metrics are reported by their own names.
+const (
+	ComponentTreeEdit        = config.MetricTreeEdit
+	ComponentTokenSimilarity = config.MetricTokenSimilarity
+	ComponentStructural      = config.MetricStructural
+	ComponentSignature       = config.MetricSignature
+	ComponentControlFlow     = config.MetricControlFlow
+	ComponentDependence      = config.MetricDependence
+	ComponentAPIUsage        = config.MetricAPIUsage
+)
+
+// Shortcut reasons explaining why CalculateSimilarity did not use the weighted components.
+const (
+	ShortcutIdenticalHash          = "identical_hash"
+	ShortcutPrefilterRejected      = "prefilter_rejected"
+	ShortcutIdenticalNormalizedAST = "identical_normalized_ast"
+)
+
+// Alignment kinds of an AlignedLine.
+const (
+	AlignMatch     = "match"
+	AlignChanged   = "changed"
+	AlignLeftOnly  = "left_only"
+	AlignRightOnly = "right_only"
+)
+
+// PrefilterCheck is the outcome of one quick heuristic check performed before full comparison.
+type PrefilterCheck struct {
+	Name   string
+	Passed bool
+	Detail string
+}
+
+// ComponentScore is one weighted metric contributing to the combined similarity.
+type ComponentScore struct {
+	Name         string
+	Score        float64
+	Weight       float64
+	Contribution float64 // Score * Weight
+}
+
+// AlignedLine is one row of the aligned diff of two normalized function bodies.
+// Left or Right is empty for lines that exist on one side only.
+type AlignedLine struct {
+	Kind  string
+	Left  string
+	Right string
+}
+
+// Explanation describes how the similarity of two functions was computed.
+type Explanation struct {
+	Function1  *ast.Function
+	Function2  *ast.Function
+	Similarity float64 // Final score, as returned by CalculateSimilarity
+	Shortcut   string  // Non-empty when the score did not come from the weighted components
+	Weighted   float64 // Sum of component contributions
+	Components []ComponentScore
+	Prefilters []PrefilterCheck
+	Alignment  []AlignedLine
+}
+
+// Explain computes the similarity of two functions and reports every intermediate result:
+// the prefilter checks, each weighted component, any shortcut taken by CalculateSimilarity,
+// and a line-by-line alignment of the normalized bodies.
+// Components are always computed, even when a shortcut decided the final score.
+func (d *Detector) Explain(func1, func2 *ast.Function) *Explanation {
+	if func1 == nil || func2 == nil {
+		return nil
+	}
+
+	explanation := &Explanation{
+		Function1:  func1,
+		Function2:  func2,
+		Similarity: d.CalculateSimilarity(func1, func2),
+		Prefilters: d.prefilterChecks(func1, func2),
+	}
+
+	rewritten1, rewritten2 := d.rewrite(func1), d.rewrite(func2)
+
+	switch {
+	case func1.Hash() == func2.Hash():
+		explanation.Shortcut = ShortcutIdenticalHash
+	case !d.couldBeSimilar(func1, func2):
+		explanation.Shortcut = ShortcutPrefilterRejected
+	case d.compareNormalizedAST(rewritten1, rewritten2):
+		explanation.Shortcut = ShortcutIdenticalNormalizedAST
+	}
+
+	explanation.Components = d.weightedMetrics(rewritten1, rewritten2)
+
+	for _, component := range explanation.Components {
+		explanation.Weighted += component.Contribution
+	}
+
+	explanation.Alignment = AlignBodies(rewritten1, rewritten2)
+
+	return explanation
+}
+
+// newComponentScore creates a ComponentScore with its weighted contribution.
+func newComponentScore(name string, score, weight float64) ComponentScore {
+	return ComponentScore{
+		Name:         name,
+		Score:        score,
+		Weight:       weight,
+		Contribution: score * weight,
+	}
+}
+
+// AlignBodies aligns the normalized bodies of two functions line by line using the
+// longest common subsequence, so matching statements line up and differences stand out.
+func AlignBodies(func1, func2 *ast.Function) []AlignedLine {
+	return alignLines(normalizedBodyLines(func1), normalizedBodyLines(func2))
+}
+
+// AlignSources aligns the bodies of two functions as written, without normalization, so
+// that every edit turning the first into the second stands out, renames included.
+func AlignSources(func1, func2 *ast.Function) []AlignedLine {
+	var decl1, decl2 *goast.FuncDecl
+	if func1 != nil {
+		decl1 = func1.AST
+	}
+	if func2 != nil {
+		decl2 = func2.AST
+	}
+	return alignLines(bodyLines(decl1), bodyLines(decl2))
+}
+
+// normalizedBodyLines renders the normalized body of a function as trimmed source lines,
+// without the enclosing braces.
+func normalizedBodyLines(fn *ast.Function) []string {
+	if fn == nil || fn.AST == nil || fn.AST.Body == nil {
+		return nil
+	}
+
+	normalized := fn.Normalize()
+	if normalized == nil {
+		return nil
+	}
+
+	return bodyLines(normalized.AST)
+}
+
+// bodyLines renders the body of a declaration as trimmed source lines, without the
+// enclosing braces.
+func bodyLines(decl *goast.FuncDecl) []string {
+	if decl == nil || decl.Body == nil {
+		return nil
+	}
+
+	var lines []string
+	for _, stmt := range decl.Body.List {
+		lines = append(lines, renderStatementLines(stmt)...)
+	}
+
+	return lines
+}
+
+// renderStatementLines formats a statement and splits it into non-empty lines,
+// keeping relative indentation of nested blocks.
+func renderStatementLines(stmt goast.Stmt) []string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), stmt); err != nil {
+		return []string{"<unprintable statement>"}
+	}
+
+	var lines []string
+	for line := range strings.SplitSeq(buf.String(), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, strings.ReplaceAll(line, "\t", "  "))
+	}
+
+	return lines
+}
+
+// alignLines computes an LCS-based alignment of two line sequences. Runs of lines that
+// exist on only one side between two matches are paired up as changed lines.
+func alignLines(left, right []string) []AlignedLine {
+	// lcs[i][j] holds the LCS length of left[i:] and right[j:]
+	lcs := make([][]int, len(left)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(right)+1)
+	}
+
+	for i := len(left) - 1; i >= 0; i-- {
+		for j := len(right) - 1; j >= 0; j-- {
+			if left[i] == right[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var result []AlignedLine
+	var pendingLeft, pendingRight []string
+
+	flush := func() {
+		result = append(result, pairUnmatched(pendingLeft, pendingRight)...)
+		pendingLeft, pendingRight = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < len(left) && j < len(right) {
+		switch {
+		case left[i] == right[j]:
+			flush()
+			result = append(result, AlignedLine{Kind: AlignMatch, Left: left[i], Right: right[j]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			pendingLeft = append(pendingLeft, left[i])
+			i++
+		default:
+			pendingRight = append(pendingRight, right[j])
+			j++
+		}
+	}
+
+	pendingLeft = append(pendingLeft, left[i:]...)
+	pendingRight = append(pendingRight, right[j:]...)
+	flush()
+
+	return result
+}
+
+// pairUnmatched pairs unmatched lines side by side as changed lines; leftovers stay one-sided.
+func pairUnmatched(left, right []string) []AlignedLine {
+	var result []AlignedLine
+
+	for k := range max(len(left), len(right)) {
+		switch {
+		case k < len(left) && k < len(right):
+			result = append(result, AlignedLine{Kind: AlignChanged, Left: left[k], Right: right[k]})
+		case k < len(left):
+			result = append(result, AlignedLine{Kind: AlignLeftOnly, Left: left[k]})
+		default:
+			result = append(result, AlignedLine{Kind: AlignRightOnly, Right: right[k]})
+		}
+	}
+
+	return result
+}
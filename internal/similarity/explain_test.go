@@ -0,0 +1,173 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/testhelpers"
+)
+
+func TestDetector_Explain(t *testing.T) {
+	sum := testhelpers.CreateFunctionFromSource(t, `package main
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}`, "sum")
+	filtered := testhelpers.CreateFunctionFromSource(t, `package main
+func positive(items []int) int {
+	acc := 0
+	for _, item := range items {
+		if item < 0 {
+			continue
+		}
+		acc += item
+	}
+	return acc
+}`, "positive")
+
+	detector := NewDetector(0.8)
+	explanation := detector.Explain(sum, filtered)
+
+	if explanation.Similarity != detector.CalculateSimilarity(sum, filtered) {
+		t.Errorf("Expected explained score to equal CalculateSimilarity, got %f", explanation.Similarity)
+	}
+
+	if explanation.Shortcut != "" {
+		t.Errorf("Expected no shortcut for different bodies, got %s", explanation.Shortcut)
+	}
+
+	names := []string{ComponentTreeEdit, ComponentTokenSimilarity, ComponentStructural, ComponentSignature}
+	if len(explanation.Components) != len(names) {
+		t.Fatalf("Expected %d components, got %d", len(names), len(explanation.Components))
+	}
+
+	for i, component := range explanation.Components {
+		if component.Name != names[i] {
+			t.Errorf("Expected component %s, got %s", names[i], component.Name)
+		}
+	}
+
+	if testhelpers.AbsFloat(explanation.Weighted-explanation.Similarity) > 1e-9 {
+		t.Errorf("Expected weighted total %f to equal similarity %f", explanation.Weighted, explanation.Similarity)
+	}
+
+	for _, check := range explanation.Prefilters {
+		if !check.Passed {
+			t.Errorf("Expected prefilter %s to pass: %s", check.Name, check.Detail)
+		}
+	}
+
+	var rightOnly int
+	for _, line := range explanation.Alignment {
+		if line.Kind == AlignRightOnly {
+			rightOnly++
+		}
+	}
+	if rightOnly != 3 {
+		t.Errorf("Expected the 3-line if statement to be right-only, got %d right-only lines", rightOnly)
+	}
+}
+
+func TestDetector_ExplainShortcuts(t *testing.T) {
+	detector := NewDetector(0.8)
+
+	add := testhelpers.CreateFunctionFromSource(t, `package main
+func add(a, b int) int { return a + b }`, "add")
+	sum := testhelpers.CreateFunctionFromSource(t, `package main
+func sum(x, y int) int { return x + y }`, "sum")
+
+	if got := detector.Explain(add, sum).Shortcut; got != ShortcutIdenticalNormalizedAST {
+		t.Errorf("Expected %s shortcut, got %q", ShortcutIdenticalNormalizedAST, got)
+	}
+
+	short := &ast.Function{Name: "short", LineCount: 5}
+	long := &ast.Function{Name: "long", LineCount: 50}
+
+	explanation := detector.Explain(short, long)
+	if explanation.Shortcut != ShortcutPrefilterRejected {
+		t.Errorf("Expected %s shortcut, got %q", ShortcutPrefilterRejected, explanation.Shortcut)
+	}
+
+	failed := false
+	for _, check := range explanation.Prefilters {
+		if check.Name == PrefilterLineRatio && !check.Passed {
+			failed = true
+		}
+	}
+	if !failed {
+		t.Error("Expected line ratio prefilter to fail")
+	}
+
+	if detector.Explain(nil, add) != nil {
+		t.Error("Expected nil explanation for nil function")
+	}
+}
+
+func TestAlignLines(t *testing.T) {
+	left := []string{"a", "b", "c", "d"}
+	right := []string{"a", "x", "c", "d", "e"}
+
+	expected := []AlignedLine{
+		{Kind: AlignMatch, Left: "a", Right: "a"},
+		{Kind: AlignChanged, Left: "b", Right: "x"},
+		{Kind: AlignMatch, Left: "c", Right: "c"},
+		{Kind: AlignMatch, Left: "d", Right: "d"},
+		{Kind: AlignRightOnly, Right: "e"},
+	}
+
+	result := alignLines(left, right)
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %d aligned lines, got %d: %v", len(expected), len(result), result)
+	}
+
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Errorf("Line %d: expected %+v, got %+v", i, expected[i], result[i])
+		}
+	}
+
+	if got := alignLines(nil, nil); len(got) != 0 {
+		t.Errorf("Expected empty alignment, got %v", got)
+	}
+}
+
+func TestAlignSources(t *testing.T) {
+	before := testhelpers.CreateFunctionFromSource(t, `package main
+func load(path string) error {
+	data, err := read(path)
+	if err != nil {
+		return err
+	}
+	return parse(data)
+}`, "load")
+	after := testhelpers.CreateFunctionFromSource(t, `package main
+func load(file string) error {
+	data, err := read(file)
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	return parse(data)
+}`, "load")
+
+	var changed []AlignedLine
+	for _, line := range AlignSources(before, after) {
+		if line.Kind != AlignMatch {
+			changed = append(changed, line)
+		}
+	}
+
+	// Unlike AlignBodies, renames and changed literals are differences
+	if len(changed) != 2 {
+		t.Fatalf("expected 2 changed lines, got %+v", changed)
+	}
+	if changed[1].Left != "  return err" || changed[1].Right != `  return fmt.Errorf("read: %w", err)` {
+		t.Errorf("unexpected change %+v", changed[1])
+	}
+
+	if lines := AlignSources(before, nil); len(lines) != 5 || lines[0].Kind != AlignLeftOnly {
+		t.Errorf("expected every line of a removed function on the left, got %+v", lines)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"math"
 	"math/rand/v2"
 	"os"
+	"slices"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -21,14 +23,6 @@ const (
 	defaultEliteSize         = 5
 	stagnationEpsilon        = 1e-6
 	stagnationLimit          = 20
-	treeEditRange            = 0.4
-	treeEditOffset           = 0.1
-	tokenSimilarityRange     = 0.4
-	tokenSimilarityOffset    = 0.1
-	structuralRange          = 0.3
-	structuralOffset         = 0.1
-	signatureRange           = 0.25
-	signatureOffset          = 0.05
 	mutationSigmaBase        = 0.05
 	mutationAgeFactor        = 0.1
 	mutationProbability      = 0.3
@@ -46,12 +40,13 @@ type GeneticOptimizer struct {
 	mutationRate   float64
 	crossoverRate  float64
 	eliteSize      int
+	ranges         []MetricRange
 	random         *rand.Rand
 }
 
 // Individual represents a candidate solution in the genetic algorithm.
 type Individual struct {
-	Weights config.SimilarityWeights
+	Weights config.MetricWeights
 	Fitness float64
 	Age     int // Number of generations survived
 }
@@ -74,7 +69,7 @@ type GenerationStats struct {
 	Diversity    float64 // Population diversity metric
 }
 
-// NewGeneticOptimizer creates a new genetic algorithm optimizer.
+// NewGeneticOptimizer creates a new genetic algorithm optimizer of the base metrics.
 func NewGeneticOptimizer() *GeneticOptimizer {
 	return &GeneticOptimizer{
 		dataset:        GetBenchmarkDataset(),
@@ -83,6 +78,7 @@ func NewGeneticOptimizer() *GeneticOptimizer {
 		mutationRate:   defaultMutationRate,
 		crossoverRate:  defaultCrossoverRate,
 		eliteSize:      defaultEliteSize,
+		ranges:         DefaultMetricRanges(),
 		random:         newPseudoRandomGenerator(),
 	}
 }
@@ -106,6 +102,12 @@ func (g *GeneticOptimizer) SetParameters(
 	g.eliteSize = eliteSize
 }
 
+// SetMetricRanges sets the metrics whose weights are optimized, which may be any set of
+// registered metrics, and the ranges the weights of the initial population are drawn from.
+func (g *GeneticOptimizer) SetMetricRanges(ranges []MetricRange) {
+	g.ranges = slices.Clone(ranges)
+}
+
 // OptimizeWeights runs the genetic algorithm to find optimal weights.
 func (g *GeneticOptimizer) OptimizeWeights(t *testing.T) GeneticResult {
 	// Initialize population
@@ -181,28 +183,28 @@ func (g *GeneticOptimizer) initializePopulation() []Individual {
 	return population
 }
 
-// generateRandomWeights creates random weights that sum to approximately 1.0.
-func (g *GeneticOptimizer) generateRandomWeights() config.SimilarityWeights {
-	// Generate random values
-	treeEdit := g.random.Float64()*treeEditRange + treeEditOffset
-	tokenSim := g.random.Float64()*tokenSimilarityRange + tokenSimilarityOffset
-	structural := g.random.Float64()*structuralRange + structuralOffset
-	signature := g.random.Float64()*signatureRange + signatureOffset
+// generateRandomWeights creates random weights of the optimized metrics that sum to
+// approximately 1.0.
+func (g *GeneticOptimizer) generateRandomWeights() config.MetricWeights {
+	weights := make(config.MetricWeights, len(g.ranges))
+	for i, metric := range g.ranges {
+		weights[i] = config.MetricWeight{
+			Name:   metric.Name,
+			Weight: metric.Min + g.random.Float64()*(metric.Max-metric.Min),
+		}
+	}
 
-	// Normalize to sum to 1.0
-	total := treeEdit + tokenSim + structural + signature
-	treeEdit /= total
-	tokenSim /= total
-	structural /= total
-	signature /= total
-
-	return config.SimilarityWeights{
-		TreeEdit:           treeEdit,
-		TokenSimilarity:    tokenSim,
-		Structural:         structural,
-		Signature:          signature,
-		DifferentSignature: config.DifferentSignatureWeight,
+	return normalizeWeights(weights)
+}
+
+// normalizeWeights scales weights in place to sum to 1.0 and returns them.
+func normalizeWeights(weights config.MetricWeights) config.MetricWeights {
+	if total := weights.Sum(); total > 0 {
+		for i := range weights {
+			weights[i].Weight /= total
+		}
 	}
+	return weights
 }
 
 // evaluatePopulation calculates fitness for all individuals in the population.
@@ -267,14 +269,21 @@ func (g *GeneticOptimizer) calculatePopulationDiversity(population []Individual)
 	return totalDistance / float64(comparisons)
 }
 
-// calculateWeightDistance computes Euclidean distance between two weight vectors.
-func (g *GeneticOptimizer) calculateWeightDistance(w1, w2 config.SimilarityWeights) float64 {
-	diff1 := w1.TreeEdit - w2.TreeEdit
-	diff2 := w1.TokenSimilarity - w2.TokenSimilarity
-	diff3 := w1.Structural - w2.Structural
-	diff4 := w1.Signature - w2.Signature
+// calculateWeightDistance computes Euclidean distance between two weight vectors, where
+// a metric missing from one of them has a zero weight.
+func (g *GeneticOptimizer) calculateWeightDistance(w1, w2 config.MetricWeights) float64 {
+	var sum float64
+	for _, metric := range w1 {
+		diff := metric.Weight - w2.Weight(metric.Name)
+		sum += diff * diff
+	}
+	for _, metric := range w2 {
+		if !slices.Contains(w1.Names(), metric.Name) {
+			sum += metric.Weight * metric.Weight
+		}
+	}
 
-	return math.Sqrt(diff1*diff1 + diff2*diff2 + diff3*diff3 + diff4*diff4)
+	return math.Sqrt(sum)
 }
 
 // evolvePopulation creates the next generation through selection, crossover, and mutation.
@@ -364,29 +373,16 @@ func (g *GeneticOptimizer) crossover(parent1, parent2 Individual) Individual {
 	// Arithmetic crossover with random weight
 	alpha := g.random.Float64()
 
-	child := Individual{
-		Weights: config.SimilarityWeights{
-			TreeEdit:           alpha*parent1.Weights.TreeEdit + (1-alpha)*parent2.Weights.TreeEdit,
-			TokenSimilarity:    alpha*parent1.Weights.TokenSimilarity + (1-alpha)*parent2.Weights.TokenSimilarity,
-			Structural:         alpha*parent1.Weights.Structural + (1-alpha)*parent2.Weights.Structural,
-			Signature:          alpha*parent1.Weights.Signature + (1-alpha)*parent2.Weights.Signature,
-			DifferentSignature: config.DifferentSignatureWeight, // Keep penalty constant
-		},
-		Age: 0,
+	weights := make(config.MetricWeights, len(g.ranges))
+	for i, metric := range g.ranges {
+		weights[i] = config.MetricWeight{
+			Name:   metric.Name,
+			Weight: alpha*parent1.Weights.Weight(metric.Name) + (1-alpha)*parent2.Weights.Weight(metric.Name),
+		}
 	}
 
 	// Normalize weights to sum to 1.0
-	total := child.Weights.TreeEdit + child.Weights.TokenSimilarity +
-		child.Weights.Structural + child.Weights.Signature
-
-	if total > 0 {
-		child.Weights.TreeEdit /= total
-		child.Weights.TokenSimilarity /= total
-		child.Weights.Structural /= total
-		child.Weights.Signature /= total
-	}
-
-	return child
+	return Individual{Weights: normalizeWeights(weights), Age: 0}
 }
 
 // mutate applies random mutations to an individual.
@@ -397,40 +393,21 @@ func (g *GeneticOptimizer) mutate(individual Individual) Individual {
 	sigma := mutationSigmaBase * (1.0 + mutationAgeFactor*float64(individual.Age)) // Smaller mutations for older individuals
 
 	// Apply mutations to each weight
-	weights := []float64{
-		mutated.Weights.TreeEdit,
-		mutated.Weights.TokenSimilarity,
-		mutated.Weights.Structural,
-		mutated.Weights.Signature,
-	}
-
+	weights := slices.Clone(mutated.Weights)
 	for i := range weights {
 		if g.random.Float64() < mutationProbability {
 			mutation := g.random.NormFloat64() * sigma
-			weights[i] += mutation
+			weights[i].Weight += mutation
 
 			// Ensure positive weights
-			if weights[i] < minWeightThreshold {
-				weights[i] = minWeightThreshold
+			if weights[i].Weight < minWeightThreshold {
+				weights[i].Weight = minWeightThreshold
 			}
 		}
 	}
 
 	// Normalize to sum to 1.0
-	total := weights[0] + weights[1] + weights[2] + weights[3]
-	if total > 0 {
-		for i := range weights {
-			weights[i] /= total
-		}
-	}
-
-	mutated.Weights = config.SimilarityWeights{
-		TreeEdit:           weights[0],
-		TokenSimilarity:    weights[1],
-		Structural:         weights[2],
-		Signature:          weights[3],
-		DifferentSignature: config.DifferentSignatureWeight,
-	}
+	mutated.Weights = normalizeWeights(weights)
 
 	return mutated
 }
@@ -455,12 +432,10 @@ func (g *GeneticOptimizer) PrintGeneticReport(result GeneticResult, baselineScor
 	write("Improvement: %.6f (%.2f%%)\n", improvement, improvement/baselineScore*percentageMultiplier)
 
 	write("\n--- OPTIMIZED WEIGHTS ---\n")
-	write("TreeEdit:        %.4f\n", best.Weights.TreeEdit)
-	write("TokenSimilarity: %.4f\n", best.Weights.TokenSimilarity)
-	write("Structural:      %.4f\n", best.Weights.Structural)
-	write("Signature:       %.4f\n", best.Weights.Signature)
-	write("Weight Sum:      %.4f\n",
-		best.Weights.TreeEdit+best.Weights.TokenSimilarity+best.Weights.Structural+best.Weights.Signature)
+	for _, metric := range best.Weights {
+		write("%-17s %.4f\n", metric.Name+":", metric.Weight)
+	}
+	write("%-17s %.4f\n", "Weight Sum:", best.Weights.Sum())
 
 	write("\n--- EVOLUTION PROGRESS ---\n")
 	write("Generation  Best Score  Avg Score   Diversity\n")
@@ -486,9 +461,11 @@ func (g *GeneticOptimizer) PrintGeneticReport(result GeneticResult, baselineScor
 		for i := 0; i < 5 && i < len(result.FinalPopulation); i++ {
 			ind := result.FinalPopulation[i]
 			write("%d. Score: %.6f, Age: %d\n", i+1, ind.Fitness, ind.Age)
-			write("   Weights: [%.3f, %.3f, %.3f, %.3f]\n",
-				ind.Weights.TreeEdit, ind.Weights.TokenSimilarity,
-				ind.Weights.Structural, ind.Weights.Signature)
+			weights := make([]string, len(ind.Weights))
+			for j, metric := range ind.Weights {
+				weights[j] = fmt.Sprintf("%s=%.3f", metric.Name, metric.Weight)
+			}
+			write("   Weights: [%s]\n", strings.Join(weights, ", "))
 		}
 	}
 }
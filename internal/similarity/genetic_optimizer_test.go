@@ -2,6 +2,7 @@ package similarity
 
 import (
 	"math"
+	"slices"
 	"testing"
 
 	"github.com/paveg/similarity-go/internal/config"
@@ -66,34 +67,56 @@ func TestGeneticOptimizer_generateRandomWeights(t *testing.T) {
 		weights := optimizer.generateRandomWeights()
 
 		// Check weights are positive
-		if weights.TreeEdit <= 0 || weights.TokenSimilarity <= 0 ||
-			weights.Structural <= 0 || weights.Signature <= 0 {
+		if hasNonPositiveWeight(weights) {
 			t.Error("All weights should be positive")
 		}
 
 		// Check weights sum approximately to 1.0
-		total := weights.TreeEdit + weights.TokenSimilarity + weights.Structural + weights.Signature
+		total := weights.Sum()
 		if math.Abs(total-1.0) > 0.001 {
 			t.Errorf("Weights don't sum to 1.0: %f", total)
 		}
 
-		// Check penalty weight is constant
-		if weights.DifferentSignature != 0.3 {
-			t.Errorf("Expected penalty weight 0.3, got %f", weights.DifferentSignature)
-		}
-
 		// Check weights remain within reasonable normalized bounds.
-		if weights.TreeEdit < 0.05 || weights.TreeEdit > 0.7 {
-			t.Errorf("TreeEdit weight out of reasonable range: %f", weights.TreeEdit)
+		if weights.Weight(config.MetricTreeEdit) < 0.05 || weights.Weight(config.MetricTreeEdit) > 0.7 {
+			t.Errorf("TreeEdit weight out of reasonable range: %f", weights.Weight(config.MetricTreeEdit))
 		}
-		if weights.TokenSimilarity < 0.05 || weights.TokenSimilarity > 0.7 {
-			t.Errorf("TokenSimilarity weight out of reasonable range: %f", weights.TokenSimilarity)
+		if weights.Weight(config.MetricTokenSimilarity) < 0.05 || weights.Weight(config.MetricTokenSimilarity) > 0.7 {
+			t.Errorf("TokenSimilarity weight out of reasonable range: %f", weights.Weight(config.MetricTokenSimilarity))
 		}
-		if weights.Structural < 0.05 || weights.Structural > 0.6 {
-			t.Errorf("Structural weight out of reasonable range: %f", weights.Structural)
+		if weights.Weight(config.MetricStructural) < 0.05 || weights.Weight(config.MetricStructural) > 0.6 {
+			t.Errorf("Structural weight out of reasonable range: %f", weights.Weight(config.MetricStructural))
 		}
-		if weights.Signature < 0.05 || weights.Signature > 0.4 {
-			t.Errorf("Signature weight out of reasonable range: %f", weights.Signature)
+		if weights.Weight(config.MetricSignature) < 0.05 || weights.Weight(config.MetricSignature) > 0.4 {
+			t.Errorf("Signature weight out of reasonable range: %f", weights.Weight(config.MetricSignature))
+		}
+	}
+}
+
+func TestGeneticOptimizer_SetMetricRanges(t *testing.T) {
+	optimizer := NewGeneticOptimizer()
+	optimizer.SetMetricRanges([]MetricRange{
+		{Name: config.MetricTreeEdit, Min: 0.2, Max: 0.4},
+		{Name: config.MetricDependence, Min: 0.1, Max: 0.3},
+		{Name: config.MetricAPIUsage, Min: 0.1, Max: 0.3},
+	})
+
+	want := []string{config.MetricTreeEdit, config.MetricDependence, config.MetricAPIUsage}
+	parent1 := Individual{Weights: optimizer.generateRandomWeights()}
+	parent2 := Individual{Weights: optimizer.generateRandomWeights()}
+	for _, weights := range []config.MetricWeights{
+		parent1.Weights,
+		optimizer.crossover(parent1, parent2).Weights,
+		optimizer.mutate(parent1).Weights,
+	} {
+		if !slices.Equal(weights.Names(), want) {
+			t.Errorf("Expected weights of %v, got %v", want, weights)
+		}
+		if hasNonPositiveWeight(weights) {
+			t.Errorf("All weights should be positive, got %v", weights)
+		}
+		if math.Abs(weights.Sum()-1.0) > 0.001 {
+			t.Errorf("Weights don't sum to 1.0: %f", weights.Sum())
 		}
 	}
 }
@@ -116,12 +139,11 @@ func TestGeneticOptimizer_initializePopulation(t *testing.T) {
 
 		// Check weights are valid
 		weights := individual.Weights
-		if weights.TreeEdit <= 0 || weights.TokenSimilarity <= 0 ||
-			weights.Structural <= 0 || weights.Signature <= 0 {
+		if hasNonPositiveWeight(weights) {
 			t.Errorf("Individual %d has invalid weights", i)
 		}
 
-		total := weights.TreeEdit + weights.TokenSimilarity + weights.Structural + weights.Signature
+		total := weights.Sum()
 		if math.Abs(total-1.0) > 0.001 {
 			t.Errorf("Individual %d weights don't sum to 1.0: %f", i, total)
 		}
@@ -131,19 +153,9 @@ func TestGeneticOptimizer_initializePopulation(t *testing.T) {
 func TestGeneticOptimizer_calculateWeightDistance(t *testing.T) {
 	optimizer := NewGeneticOptimizer()
 
-	w1 := config.SimilarityWeights{
-		TreeEdit:        0.3,
-		TokenSimilarity: 0.3,
-		Structural:      0.25,
-		Signature:       0.15,
-	}
+	w1 := baseWeights(0.3, 0.3, 0.25, 0.15)
 
-	w2 := config.SimilarityWeights{
-		TreeEdit:        0.35,
-		TokenSimilarity: 0.25,
-		Structural:      0.25,
-		Signature:       0.15,
-	}
+	w2 := baseWeights(0.35, 0.25, 0.25, 0.15)
 
 	distance := optimizer.calculateWeightDistance(w1, w2)
 
@@ -169,23 +181,13 @@ func TestGeneticOptimizer_crossover(t *testing.T) {
 	optimizer := NewGeneticOptimizer()
 
 	parent1 := Individual{
-		Weights: config.SimilarityWeights{
-			TreeEdit:        0.4,
-			TokenSimilarity: 0.3,
-			Structural:      0.2,
-			Signature:       0.1,
-		},
-		Age: 5,
+		Weights: baseWeights(0.4, 0.3, 0.2, 0.1),
+		Age:     5,
 	}
 
 	parent2 := Individual{
-		Weights: config.SimilarityWeights{
-			TreeEdit:        0.2,
-			TokenSimilarity: 0.4,
-			Structural:      0.25,
-			Signature:       0.15,
-		},
-		Age: 3,
+		Weights: baseWeights(0.2, 0.4, 0.25, 0.15),
+		Age:     3,
 	}
 
 	// Perform multiple crossovers to test consistency
@@ -198,43 +200,36 @@ func TestGeneticOptimizer_crossover(t *testing.T) {
 		}
 
 		// Check weights are positive
-		if child.Weights.TreeEdit <= 0 || child.Weights.TokenSimilarity <= 0 ||
-			child.Weights.Structural <= 0 || child.Weights.Signature <= 0 {
+		if hasNonPositiveWeight(child.Weights) {
 			t.Error("Child weights should be positive")
 		}
 
 		// Check weights sum to 1.0
-		total := child.Weights.TreeEdit + child.Weights.TokenSimilarity +
-			child.Weights.Structural + child.Weights.Signature
+		total := child.Weights.Sum()
 		if math.Abs(total-1.0) > 0.001 {
 			t.Errorf("Child weights don't sum to 1.0: %f", total)
 		}
 
-		// Check penalty weight is preserved
-		if child.Weights.DifferentSignature != 0.3 {
-			t.Error("Child should preserve penalty weight")
-		}
-
 		// Child weights should be between parent weights
 		for j := range 4 {
 			var childWeight, parent1Weight, parent2Weight float64
 			switch j {
 			case 0:
-				childWeight = child.Weights.TreeEdit
-				parent1Weight = parent1.Weights.TreeEdit
-				parent2Weight = parent2.Weights.TreeEdit
+				childWeight = child.Weights.Weight(config.MetricTreeEdit)
+				parent1Weight = parent1.Weights.Weight(config.MetricTreeEdit)
+				parent2Weight = parent2.Weights.Weight(config.MetricTreeEdit)
 			case 1:
-				childWeight = child.Weights.TokenSimilarity
-				parent1Weight = parent1.Weights.TokenSimilarity
-				parent2Weight = parent2.Weights.TokenSimilarity
+				childWeight = child.Weights.Weight(config.MetricTokenSimilarity)
+				parent1Weight = parent1.Weights.Weight(config.MetricTokenSimilarity)
+				parent2Weight = parent2.Weights.Weight(config.MetricTokenSimilarity)
 			case 2:
-				childWeight = child.Weights.Structural
-				parent1Weight = parent1.Weights.Structural
-				parent2Weight = parent2.Weights.Structural
+				childWeight = child.Weights.Weight(config.MetricStructural)
+				parent1Weight = parent1.Weights.Weight(config.MetricStructural)
+				parent2Weight = parent2.Weights.Weight(config.MetricStructural)
 			case 3:
-				childWeight = child.Weights.Signature
-				parent1Weight = parent1.Weights.Signature
-				parent2Weight = parent2.Weights.Signature
+				childWeight = child.Weights.Weight(config.MetricSignature)
+				parent1Weight = parent1.Weights.Weight(config.MetricSignature)
+				parent2Weight = parent2.Weights.Weight(config.MetricSignature)
 			}
 
 			minParent := math.Min(parent1Weight, parent2Weight)
@@ -253,13 +248,8 @@ func TestGeneticOptimizer_mutate(t *testing.T) {
 	optimizer := NewGeneticOptimizer()
 
 	individual := Individual{
-		Weights: config.SimilarityWeights{
-			TreeEdit:        0.3,
-			TokenSimilarity: 0.3,
-			Structural:      0.25,
-			Signature:       0.15,
-		},
-		Age: 2,
+		Weights: baseWeights(0.3, 0.3, 0.25, 0.15),
+		Age:     2,
 	}
 
 	// Perform multiple mutations to test consistency
@@ -267,23 +257,16 @@ func TestGeneticOptimizer_mutate(t *testing.T) {
 		mutated := optimizer.mutate(individual)
 
 		// Check weights are positive
-		if mutated.Weights.TreeEdit <= 0 || mutated.Weights.TokenSimilarity <= 0 ||
-			mutated.Weights.Structural <= 0 || mutated.Weights.Signature <= 0 {
+		if hasNonPositiveWeight(mutated.Weights) {
 			t.Error("Mutated weights should be positive")
 		}
 
 		// Check weights sum to 1.0
-		total := mutated.Weights.TreeEdit + mutated.Weights.TokenSimilarity +
-			mutated.Weights.Structural + mutated.Weights.Signature
+		total := mutated.Weights.Sum()
 		if math.Abs(total-1.0) > 0.001 {
 			t.Errorf("Mutated weights don't sum to 1.0: %f", total)
 		}
 
-		// Check penalty weight is preserved
-		if mutated.Weights.DifferentSignature != 0.3 {
-			t.Error("Mutated individual should preserve penalty weight")
-		}
-
 		// Age should be preserved
 		if mutated.Age != individual.Age {
 			t.Error("Mutation should preserve age")
@@ -353,12 +336,11 @@ func TestGeneticOptimizer_OptimizeWeights_SmallScale(t *testing.T) {
 
 	// Validate best weights
 	weights := result.BestIndividual.Weights
-	if weights.TreeEdit <= 0 || weights.TokenSimilarity <= 0 ||
-		weights.Structural <= 0 || weights.Signature <= 0 {
+	if hasNonPositiveWeight(weights) {
 		t.Error("Best weights should be positive")
 	}
 
-	total := weights.TreeEdit + weights.TokenSimilarity + weights.Structural + weights.Signature
+	total := weights.Sum()
 	if math.Abs(total-1.0) > 0.01 {
 		t.Errorf("Best weights don't sum to 1.0: %f", total)
 	}
@@ -382,8 +364,8 @@ func TestGeneticOptimizer_calculatePopulationDiversity(t *testing.T) {
 
 	// Test with identical population (zero diversity)
 	identicalPop := []Individual{
-		{Weights: config.SimilarityWeights{TreeEdit: 0.25, TokenSimilarity: 0.25, Structural: 0.25, Signature: 0.25}},
-		{Weights: config.SimilarityWeights{TreeEdit: 0.25, TokenSimilarity: 0.25, Structural: 0.25, Signature: 0.25}},
+		{Weights: baseWeights(0.25, 0.25, 0.25, 0.25)},
+		{Weights: baseWeights(0.25, 0.25, 0.25, 0.25)},
 	}
 
 	diversity := optimizer.calculatePopulationDiversity(identicalPop)
@@ -393,9 +375,9 @@ func TestGeneticOptimizer_calculatePopulationDiversity(t *testing.T) {
 
 	// Test with diverse population
 	diversePop := []Individual{
-		{Weights: config.SimilarityWeights{TreeEdit: 0.5, TokenSimilarity: 0.2, Structural: 0.2, Signature: 0.1}},
-		{Weights: config.SimilarityWeights{TreeEdit: 0.1, TokenSimilarity: 0.5, Structural: 0.2, Signature: 0.2}},
-		{Weights: config.SimilarityWeights{TreeEdit: 0.2, TokenSimilarity: 0.2, Structural: 0.5, Signature: 0.1}},
+		{Weights: baseWeights(0.5, 0.2, 0.2, 0.1)},
+		{Weights: baseWeights(0.1, 0.5, 0.2, 0.2)},
+		{Weights: baseWeights(0.2, 0.2, 0.5, 0.1)},
 	}
 
 	diversity = optimizer.calculatePopulationDiversity(diversePop)
@@ -0,0 +1,161 @@
+package similarity
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/config"
+)
+
+// Features are the inputs a metric compares: the two functions, whose declarations are
+// parsed in their AST field, and the configuration of the detector.
+type Features struct {
+	Function1 *ast.Function
+	Function2 *ast.Function
+	Config    *config.Config
+	detector  *Detector
+}
+
+// Metric is a similarity measure between two functions, combined into the similarity
+// score with the weight configured for its name.
+type Metric interface {
+	// Name identifies the metric in the configuration and in explanations.
+	Name() string
+	// Compute returns the similarity of the features, from 0.0 to 1.0.
+	Compute(features Features) float64
+}
+
+// metricFunc is a Metric computed by a function.
+type metricFunc struct {
+	name    string
+	compute func(features Features) float64
+}
+
+// NewMetric returns a Metric named name computed by compute.
+func NewMetric(name string, compute func(features Features) float64) Metric {
+	return metricFunc{name: name, compute: compute}
+}
+
+// Name returns the name of the metric.
+func (m metricFunc) Name() string {
+	return m.name
+}
+
+// Compute computes the metric.
+func (m metricFunc) Compute(features Features) float64 {
+	return m.compute(features)
+}
+
+// metricRegistry holds the metrics that can be enabled by name.
+var metricRegistry = struct {
+	sync.RWMutex
+	metrics map[string]Metric
+}{metrics: builtinMetrics()}
+
+// builtinMetrics returns the metrics of this package by name.
+func builtinMetrics() map[string]Metric {
+	metrics := []Metric{
+		NewMetric(ComponentTreeEdit, func(f Features) float64 {
+			return f.detector.calculateTreeEditSimilarity(f.Function1, f.Function2)
+		}),
+		NewMetric(ComponentTokenSimilarity, func(f Features) float64 {
+			return TokenSequenceSimilarity(f.Function1, f.Function2)
+		}),
+		NewMetric(ComponentStructural, func(f Features) float64 {
+			return f.detector.calculateStructuralSimilarity(f.Function1, f.Function2)
+		}),
+		NewMetric(ComponentSignature, func(f Features) float64 {
+			return f.detector.calculateSignatureSimilarity(f.Function1, f.Function2)
+		}),
+		NewMetric(ComponentControlFlow, func(f Features) float64 {
+			return ControlFlowSimilarity(f.Function1, f.Function2)
+		}),
+		NewMetric(ComponentDependence, func(f Features) float64 {
+			return DependenceSimilarity(f.Function1, f.Function2)
+		}),
+		NewMetric(ComponentAPIUsage, func(f Features) float64 {
+			return f.detector.calculateAPIUsageSimilarity(f.Function1, f.Function2)
+		}),
+	}
+
+	registry := make(map[string]Metric, len(metrics))
+	for _, metric := range metrics {
+		registry[metric.Name()] = metric
+	}
+	return registry
+}
+
+// RegisterMetric makes metric available to the metrics configuration under its name.
+// Metrics must be registered before the configuration enabling them is validated, and
+// their names must not collide with registered metrics.
+func RegisterMetric(metric Metric) error {
+	if metric == nil || metric.Name() == "" {
+		return errors.New("metrics must be named")
+	}
+
+	metricRegistry.Lock()
+	defer metricRegistry.Unlock()
+
+	if _, exists := metricRegistry.metrics[metric.Name()]; exists {
+		return fmt.Errorf("metric %q is already registered", metric.Name())
+	}
+	metricRegistry.metrics[metric.Name()] = metric
+	return nil
+}
+
+// LookupMetric returns the registered metric of the given name.
+func LookupMetric(name string) (Metric, bool) {
+	metricRegistry.RLock()
+	defer metricRegistry.RUnlock()
+
+	metric, exists := metricRegistry.metrics[name]
+	return metric, exists
+}
+
+// MetricNames returns the names of the registered metrics, sorted.
+func MetricNames() []string {
+	metricRegistry.RLock()
+	defer metricRegistry.RUnlock()
+
+	names := make([]string, 0, len(metricRegistry.metrics))
+	for name := range metricRegistry.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateMetrics checks that every metric enabled by cfg is registered.
+func ValidateMetrics(cfg *config.Config) error {
+	for _, enabled := range cfg.Similarity.EnabledMetrics() {
+		if _, exists := LookupMetric(enabled.Name); !exists {
+			return fmt.Errorf(
+				"metric %q is not registered, expected one of %s",
+				enabled.Name,
+				strings.Join(MetricNames(), ", "),
+			)
+		}
+	}
+	return nil
+}
+
+// weightedMetrics computes the enabled metrics of the detector for two functions, in
+// their configured order. Metrics that are not registered are skipped.
+func (d *Detector) weightedMetrics(func1, func2 *ast.Function) []ComponentScore {
+	features := Features{Function1: func1, Function2: func2, Config: d.config, detector: d}
+
+	enabled := d.config.Similarity.EnabledMetrics()
+	components := make([]ComponentScore, 0, len(enabled))
+	for _, weighted := range enabled {
+		metric, exists := LookupMetric(weighted.Name)
+		if !exists {
+			continue
+		}
+		components = append(components, newComponentScore(weighted.Name, metric.Compute(features), weighted.Weight))
+	}
+	return components
+}
@@ -0,0 +1,121 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/paveg/similarity-go/internal/config"
+	"github.com/paveg/similarity-go/internal/testhelpers"
+)
+
+// testMetricName names the metric registered by the tests of this file.
+const testMetricName = "test_same_name"
+
+// registerTestMetric registers a metric scoring 1 for functions of the same name, once
+// per test binary.
+func registerTestMetric(t *testing.T) {
+	t.Helper()
+
+	if _, exists := LookupMetric(testMetricName); exists {
+		return
+	}
+	err := RegisterMetric(NewMetric(testMetricName, func(f Features) float64 {
+		if f.Function1.Name == f.Function2.Name {
+			return 1.0
+		}
+		return 0.0
+	}))
+	if err != nil {
+		t.Fatalf("RegisterMetric() error = %v", err)
+	}
+}
+
+func TestRegisterMetric(t *testing.T) {
+	registerTestMetric(t)
+
+	for _, name := range []string{
+		ComponentTreeEdit, ComponentTokenSimilarity, ComponentStructural, ComponentSignature,
+		ComponentControlFlow, ComponentDependence, ComponentAPIUsage, testMetricName,
+	} {
+		if _, exists := LookupMetric(name); !exists {
+			t.Errorf("expected metric %s to be registered", name)
+		}
+	}
+
+	constant := func(Features) float64 { return 1.0 }
+	for _, metric := range []Metric{nil, NewMetric("", constant), NewMetric(ComponentTreeEdit, constant)} {
+		if err := RegisterMetric(metric); err == nil {
+			t.Errorf("expected RegisterMetric(%v) to fail", metric)
+		}
+	}
+}
+
+func TestValidateMetrics(t *testing.T) {
+	registerTestMetric(t)
+
+	cfg := config.Default()
+	if err := ValidateMetrics(cfg); err != nil {
+		t.Errorf("unexpected error for the default metrics: %v", err)
+	}
+
+	cfg.Similarity.Metrics = config.MetricWeights{
+		{Name: ComponentTreeEdit, Weight: 0.5},
+		{Name: testMetricName, Weight: 0.5},
+	}
+	if err := ValidateMetrics(cfg); err != nil {
+		t.Errorf("unexpected error for a registered metric: %v", err)
+	}
+
+	cfg.Similarity.Metrics = append(cfg.Similarity.Metrics, config.MetricWeight{Name: "naming_style", Weight: 0.1})
+	if err := ValidateMetrics(cfg); err == nil {
+		t.Error("expected an error for a metric that is not registered")
+	}
+}
+
+func TestDetector_Metrics(t *testing.T) {
+	registerTestMetric(t)
+
+	first := testhelpers.CreateFunctionFromSource(t, `package main
+func process(items []int) int {
+	total := 0
+	for _, item := range items {
+		if item > 0 {
+			total += item
+		}
+	}
+	return total
+}`, "process")
+	second := testhelpers.CreateFunctionFromSource(t, `package main
+func process(values []int) int {
+	count := 0
+	for i := 0; i < len(values); i++ {
+		count++
+	}
+	return count
+}`, "process")
+
+	// The metrics list computes the same score as the weights it lists
+	legacy := NewDetectorWithConfig(0.8, config.Default())
+	listed := config.Default()
+	listed.Similarity.Metrics = listed.Similarity.EnabledMetrics()
+	if got, want := NewDetectorWithConfig(0.8, listed).CalculateSimilarity(first, second),
+		legacy.CalculateSimilarity(first, second); got != want {
+		t.Errorf("metrics list similarity = %f, weights similarity = %f", got, want)
+	}
+
+	cfg := config.Default()
+	cfg.Similarity.Metrics = config.MetricWeights{
+		{Name: ComponentTreeEdit, Weight: 0.4},
+		{Name: testMetricName, Weight: 0.6},
+	}
+	explanation := NewDetectorWithConfig(0.8, cfg).Explain(first, second)
+
+	if len(explanation.Components) != 2 || explanation.Components[1].Name != testMetricName {
+		t.Fatalf("expected tree_edit and %s components, got %+v", testMetricName, explanation.Components)
+	}
+	if explanation.Components[1].Contribution != 0.6 {
+		t.Errorf("expected the registered metric to contribute 0.6, got %f", explanation.Components[1].Contribution)
+	}
+	if testhelpers.AbsFloat(explanation.Weighted-explanation.Similarity) > 1e-9 {
+		t.Errorf("expected weighted total %f to equal similarity %f", explanation.Weighted, explanation.Similarity)
+	}
+}
@@ -27,13 +27,7 @@ func TestWeightOptimizationWorkflow(t *testing.T) {
 	// Step 2: Run statistical validation on current weights
 	t.Log("Step 2: Running statistical validation on current weights...")
 	validator := NewStatisticalValidator()
-	currentWeights := config.SimilarityWeights{
-		TreeEdit:           config.TreeEditWeight,
-		TokenSimilarity:    config.TokenSimilarityWeight,
-		Structural:         config.StructuralWeight,
-		Signature:          config.SignatureWeight,
-		DifferentSignature: config.DifferentSignatureWeight,
-	}
+	currentWeights := config.Default().Similarity.EnabledMetrics()
 
 	currentValidation := validator.ValidateWeights(t, currentWeights)
 	t.Logf("Current MAE: %.6f, R²: %.6f, F1: %.6f",
@@ -99,7 +93,8 @@ func TestWeightOptimizationWorkflow(t *testing.T) {
 	configUpdater := NewConfigUpdater()
 
 	// Validate weights before update
-	err := configUpdater.ValidateWeightSum(bestWeights)
+	updatedWeights := bestWeights.Apply(config.Default().Similarity.Weights)
+	err := configUpdater.ValidateWeightSum(updatedWeights)
 	if err != nil {
 		t.Fatalf("Best weights validation failed: %v", err)
 	}
@@ -108,7 +103,7 @@ func TestWeightOptimizationWorkflow(t *testing.T) {
 	tempDir := t.TempDir()
 	yamlFile := filepath.Join(tempDir, "optimized_config.yaml")
 
-	err = configUpdater.CreateYAMLConfig(bestWeights, yamlFile)
+	err = configUpdater.CreateYAMLConfig(updatedWeights, yamlFile)
 	if err != nil {
 		t.Fatalf("Failed to create YAML config: %v", err)
 	}
@@ -136,7 +131,7 @@ func TestWeightOptimizationWorkflow(t *testing.T) {
 	t.Log("Step 9: Running performance comparison...")
 	performanceComparison := map[string]struct {
 		score      float64
-		weights    config.SimilarityWeights
+		weights    config.MetricWeights
 		validation ValidationResult
 	}{
 		"baseline":  {currentScore, currentWeights, currentValidation},
@@ -236,11 +231,11 @@ func TestOptimizationComparison(t *testing.T) {
 	// Time each approach
 	approaches := []struct {
 		name string
-		fn   func() (float64, config.SimilarityWeights)
+		fn   func() (float64, config.MetricWeights)
 	}{
 		{
 			name: "Grid Search",
-			fn: func() (float64, config.SimilarityWeights) {
+			fn: func() (float64, config.MetricWeights) {
 				start := time.Now()
 				result := optimizer.GridSearchOptimize(t)
 				duration := time.Since(start)
@@ -250,7 +245,7 @@ func TestOptimizationComparison(t *testing.T) {
 		},
 		{
 			name: "Genetic Algorithm",
-			fn: func() (float64, config.SimilarityWeights) {
+			fn: func() (float64, config.MetricWeights) {
 				start := time.Now()
 				result := genetic.OptimizeWeights(t)
 				duration := time.Since(start)
@@ -263,7 +258,7 @@ func TestOptimizationComparison(t *testing.T) {
 
 	results := make(map[string]struct {
 		score       float64
-		weights     config.SimilarityWeights
+		weights     config.MetricWeights
 		improvement float64
 	})
 
@@ -274,7 +269,7 @@ func TestOptimizationComparison(t *testing.T) {
 
 		results[approach.name] = struct {
 			score       float64
-			weights     config.SimilarityWeights
+			weights     config.MetricWeights
 			improvement float64
 		}{score, weights, improvement}
 
@@ -521,13 +516,7 @@ func BenchmarkOptimizationPerformance(b *testing.B) {
 
 	b.Run("StatisticalValidation", func(b *testing.B) {
 		validator := NewStatisticalValidator()
-		weights := config.SimilarityWeights{
-			TreeEdit:           0.35,
-			TokenSimilarity:    0.30,
-			Structural:         0.25,
-			Signature:          0.10,
-			DifferentSignature: 0.30,
-		}
+		weights := baseWeights(0.35, 0.30, 0.25, 0.10)
 
 		// Create a dummy test for the benchmark
 		t := &testing.T{}
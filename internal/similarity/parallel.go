@@ -86,7 +86,7 @@ func (p *DefaultParallelProcessor) FindSimilarFunctions(
 func (p *DefaultParallelProcessor) preNormalizeFunctions(functions []*ast.Function) []*ast.Function {
 	normalizedFunctions := make([]*ast.Function, len(functions))
 	for i, fn := range functions {
-		normalizedFunctions[i] = fn.Normalize()
+		normalizedFunctions[i] = p.detector.Normalize(fn)
 	}
 	return normalizedFunctions
 }
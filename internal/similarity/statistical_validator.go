@@ -100,7 +100,7 @@ func NewStatisticalValidator() *StatisticalValidator {
 }
 
 // ValidateWeights performs comprehensive statistical validation of weights.
-func (sv *StatisticalValidator) ValidateWeights(t *testing.T, weights config.SimilarityWeights) ValidationResult {
+func (sv *StatisticalValidator) ValidateWeights(t *testing.T, weights config.MetricWeights) ValidationResult {
 	// Get basic evaluation results
 	optimizer := &WeightOptimizer{dataset: sv.dataset}
 	_, basicResults := optimizer.EvaluateWeights(t, weights)
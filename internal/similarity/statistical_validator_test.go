@@ -3,8 +3,6 @@ package similarity
 import (
 	"math"
 	"testing"
-
-	"github.com/paveg/similarity-go/internal/config"
 )
 
 func TestStatisticalValidator_NewStatisticalValidator(t *testing.T) {
@@ -133,13 +131,7 @@ func TestStatisticalValidator_getRanks(t *testing.T) {
 func TestStatisticalValidator_ValidateWeights(t *testing.T) {
 	validator := NewStatisticalValidator()
 
-	weights := config.SimilarityWeights{
-		TreeEdit:           0.3,
-		TokenSimilarity:    0.3,
-		Structural:         0.25,
-		Signature:          0.15,
-		DifferentSignature: 0.3,
-	}
+	weights := baseWeights(0.3, 0.3, 0.25, 0.15)
 
 	result := validator.ValidateWeights(t, weights)
 
@@ -482,13 +474,7 @@ func TestStatisticalValidator_calculateStatsForGroup(t *testing.T) {
 
 func BenchmarkStatisticalValidator_ValidateWeights(b *testing.B) {
 	validator := NewStatisticalValidator()
-	weights := config.SimilarityWeights{
-		TreeEdit:           0.3,
-		TokenSimilarity:    0.3,
-		Structural:         0.25,
-		Signature:          0.15,
-		DifferentSignature: 0.3,
-	}
+	weights := baseWeights(0.3, 0.3, 0.25, 0.15)
 
 	// Create a dummy test for the benchmark
 	t := &testing.T{}
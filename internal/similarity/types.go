@@ -0,0 +1,141 @@
+package similarity
+
+import (
+	goast "go/ast"
+	"sort"
+	"strings"
+
+	"github.com/paveg/similarity-go/internal/ast"
+)
+
+// TypeMatch represents a similarity match between two type declarations.
+type TypeMatch struct {
+	Type1      *ast.TypeDecl
+	Type2      *ast.TypeDecl
+	Similarity float64
+}
+
+// CalculateTypeSimilarity calculates the similarity between two type declarations of the
+// same kind, between 0.0 and 1.0. Structs are compared by the types of their fields in
+// order, ignoring field names and tags; interfaces by their methods in any order; function
+// types by their parameter and result types. Types of different kinds, and types without
+// any element such as struct{}, score 0.
+func (d *Detector) CalculateTypeSimilarity(type1, type2 *ast.TypeDecl) float64 {
+	if type1 == nil || type2 == nil || type1.AST == nil || type2.AST == nil || type1.Kind() != type2.Kind() {
+		return 0.0
+	}
+
+	elements1 := d.typeElements(type1.AST)
+	elements2 := d.typeElements(type2.AST)
+	if len(elements1) == 0 || len(elements2) == 0 {
+		return 0.0
+	}
+
+	common := longestCommonSubsequence(elements1, elements2)
+	return 2.0 * float64(common) / float64(len(elements1)+len(elements2))
+}
+
+// FindSimilarTypes finds all pairs of similar type declarations above the threshold.
+func (d *Detector) FindSimilarTypes(types []*ast.TypeDecl) []TypeMatch {
+	var matches []TypeMatch
+
+	for i := range types {
+		for j := i + 1; j < len(types); j++ {
+			similarity := d.CalculateTypeSimilarity(types[i], types[j])
+			if d.IsAboveThreshold(similarity) {
+				matches = append(matches, TypeMatch{
+					Type1:      types[i],
+					Type2:      types[j],
+					Similarity: similarity,
+				})
+			}
+		}
+	}
+
+	return matches
+}
+
+// FindSimilarTypesBetween finds similar pairs where one type declaration comes from left
+// and the other from right, like FindSimilarFunctionsBetween.
+func (d *Detector) FindSimilarTypesBetween(left, right []*ast.TypeDecl) []TypeMatch {
+	var matches []TypeMatch
+
+	for _, leftType := range left {
+		for _, rightType := range right {
+			similarity := d.CalculateTypeSimilarity(leftType, rightType)
+			if d.IsAboveThreshold(similarity) {
+				matches = append(matches, TypeMatch{
+					Type1:      leftType,
+					Type2:      rightType,
+					Similarity: similarity,
+				})
+			}
+		}
+	}
+
+	return matches
+}
+
+// typeElements returns the elements a declared type is compared by. Type parameters are
+// named by position, so that renaming them does not count.
+func (d *Detector) typeElements(spec *goast.TypeSpec) []string {
+	names := d.fieldListNames(spec.TypeParams)
+
+	switch t := spec.Type.(type) {
+	case *goast.StructType:
+		var elements []string
+		for _, field := range t.Fields.List {
+			fieldType := d.renderType(field.Type, names)
+			if len(field.Names) == 0 {
+				elements = append(elements, "embedded "+fieldType)
+				continue
+			}
+			for range field.Names {
+				elements = append(elements, fieldType)
+			}
+		}
+		return elements
+	case *goast.InterfaceType:
+		var elements []string
+		for _, method := range t.Methods.List {
+			methodType := d.renderType(method.Type, names)
+			if len(method.Names) == 0 {
+				elements = append(elements, "embedded "+methodType)
+				continue
+			}
+			for _, name := range method.Names {
+				elements = append(elements, name.Name+strings.TrimPrefix(methodType, "func"))
+			}
+		}
+		// Method order does not matter in interfaces
+		sort.Strings(elements)
+		return elements
+	case *goast.FuncType:
+		elements := d.renderFieldTypes(t.Params, names)
+		for _, result := range d.renderFieldTypes(t.Results, names) {
+			elements = append(elements, "result "+result)
+		}
+		return elements
+	default:
+		return nil
+	}
+}
+
+// longestCommonSubsequence returns the length of the longest common subsequence of a and b.
+func longestCommonSubsequence(a, b []string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				current[j+1] = previous[j] + 1
+			} else {
+				current[j+1] = max(previous[j+1], current[j])
+			}
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
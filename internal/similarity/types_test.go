@@ -0,0 +1,87 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/paveg/similarity-go/internal/ast"
+)
+
+// parseTypes parses source and returns its type declarations.
+func parseTypes(t *testing.T, source string) []*ast.TypeDecl {
+	t.Helper()
+
+	result := ast.NewParser().ParseSource("types.go", []byte(source))
+	if result.IsErr() {
+		t.Fatalf("failed to parse source: %v", result.Error())
+	}
+	return result.Unwrap().Types
+}
+
+func TestDetector_CalculateTypeSimilarity(t *testing.T) {
+	types := parseTypes(t, "package sample\n"+`
+type Address struct {
+	Street string `+"`json:\"street\"`"+`
+	City   string
+	Zip    int
+}
+
+type Location struct {
+	Line   string
+	Town   string
+	Postal int
+}
+
+type Contact struct {
+	Street string
+	City   string
+	Phone  []string
+}
+
+type Empty struct{}
+
+type Reader interface {
+	Close() error
+	Read(p []byte) (int, error)
+}
+
+type Source interface {
+	Read(buf []byte) (int, error)
+	Close() error
+}
+
+type Handler func(string) error
+`)
+	byName := make(map[string]*ast.TypeDecl, len(types))
+	for _, typeDecl := range types {
+		byName[typeDecl.Name] = typeDecl
+	}
+
+	tests := []struct {
+		name     string
+		type1    string
+		type2    string
+		expected float64
+	}{
+		{name: "field names and tags are ignored", type1: "Address", type2: "Location", expected: 1.0},
+		{name: "field types are compared in order", type1: "Address", type2: "Contact", expected: 2.0 / 3.0},
+		{name: "method order and parameter names are ignored", type1: "Reader", type2: "Source", expected: 1.0},
+		{name: "different kinds", type1: "Address", type2: "Reader", expected: 0.0},
+		{name: "empty struct", type1: "Empty", type2: "Empty", expected: 0.0},
+		{name: "function type", type1: "Handler", type2: "Handler", expected: 1.0},
+	}
+
+	detector := NewDetector(0.8)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detector.CalculateTypeSimilarity(byName[tt.type1], byName[tt.type2])
+			if diff := got - tt.expected; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("CalculateTypeSimilarity(%s, %s) = %f, want %f", tt.type1, tt.type2, got, tt.expected)
+			}
+		})
+	}
+
+	matches := detector.FindSimilarTypes(types)
+	if len(matches) != 2 {
+		t.Errorf("expected the Address/Location and Reader/Source pairs, got %d matches", len(matches))
+	}
+}
@@ -36,25 +36,24 @@ func TestWeightOptimizationDemo(t *testing.T) {
 	t.Log("🔍 Running limited grid search...")
 
 	// Test just a few weight combinations manually
-	testWeights := []config.SimilarityWeights{
+	testWeights := []config.MetricWeights{
 		// Current weights
-		{TreeEdit: 0.3, TokenSimilarity: 0.3, Structural: 0.25, Signature: 0.15, DifferentSignature: 0.3},
+		baseWeights(0.3, 0.3, 0.25, 0.15),
 		// More emphasis on tree edit
-		{TreeEdit: 0.4, TokenSimilarity: 0.25, Structural: 0.25, Signature: 0.1, DifferentSignature: 0.3},
+		baseWeights(0.4, 0.25, 0.25, 0.1),
 		// More emphasis on token similarity
-		{TreeEdit: 0.25, TokenSimilarity: 0.4, Structural: 0.25, Signature: 0.1, DifferentSignature: 0.3},
+		baseWeights(0.25, 0.4, 0.25, 0.1),
 		// Balanced approach
-		{TreeEdit: 0.35, TokenSimilarity: 0.35, Structural: 0.2, Signature: 0.1, DifferentSignature: 0.3},
+		baseWeights(0.35, 0.35, 0.2, 0.1),
 	}
 
 	bestScore := currentScore
-	var bestWeights config.SimilarityWeights
+	var bestWeights config.MetricWeights
 
 	for i, weights := range testWeights {
 		score, _ := optimizer.EvaluateWeights(t, weights)
 		t.Logf("  Configuration %d: Score = %.4f", i+1, score)
-		t.Logf("    TreeEdit=%.2f, Token=%.2f, Struct=%.2f, Sig=%.2f",
-			weights.TreeEdit, weights.TokenSimilarity, weights.Structural, weights.Signature)
+		t.Logf("    %v", weights)
 
 		if score > bestScore {
 			bestScore = score
@@ -66,8 +65,7 @@ func TestWeightOptimizationDemo(t *testing.T) {
 	if bestScore > currentScore {
 		improvement := (bestScore - currentScore) / currentScore * 100
 		t.Logf("🎉 Found improvement: %.4f → %.4f (%.2f%% better)", currentScore, bestScore, improvement)
-		t.Logf("💡 Best weights: TreeEdit=%.3f, Token=%.3f, Struct=%.3f, Sig=%.3f",
-			bestWeights.TreeEdit, bestWeights.TokenSimilarity, bestWeights.Structural, bestWeights.Signature)
+		t.Logf("💡 Best weights: %v", bestWeights)
 	} else {
 		t.Log("📝 Current weights appear to be well-optimized for this dataset")
 	}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"math"
 	"os"
+	"slices"
 	"testing"
 
 	"github.com/paveg/similarity-go/internal/config"
@@ -25,21 +26,46 @@ const (
 	maxWorstCasesToReport   = 5
 )
 
+// MetricRange bounds the weight of a metric explored by the optimizers.
+type MetricRange struct {
+	Name string
+	Min  float64
+	Max  float64
+}
+
+// DefaultMetricRanges returns the ranges of the base metrics explored by default.
+func DefaultMetricRanges() []MetricRange {
+	return []MetricRange{
+		{Name: config.MetricTreeEdit, Min: treeEditMin, Max: treeEditMax},
+		{Name: config.MetricTokenSimilarity, Min: tokenSimilarityMin, Max: tokenSimilarityMax},
+		{Name: config.MetricStructural, Min: structuralMin, Max: structuralMax},
+		{Name: config.MetricSignature, Min: signatureMin, Max: signatureMax},
+	}
+}
+
 // WeightOptimizer optimizes similarity algorithm weights using benchmark data.
 type WeightOptimizer struct {
 	dataset []BenchmarkCase
+	ranges  []MetricRange
 }
 
-// NewWeightOptimizer creates a new weight optimizer.
+// NewWeightOptimizer creates a new weight optimizer of the base metrics.
 func NewWeightOptimizer() *WeightOptimizer {
 	return &WeightOptimizer{
 		dataset: GetBenchmarkDataset(),
+		ranges:  DefaultMetricRanges(),
 	}
 }
 
+// SetMetricRanges sets the metrics whose weights are optimized, which may be any set of
+// registered metrics, and the ranges their weights are searched in.
+func (wo *WeightOptimizer) SetMetricRanges(ranges []MetricRange) {
+	wo.ranges = slices.Clone(ranges)
+}
+
 // OptimizationResult contains the results of weight optimization.
 type OptimizationResult struct {
-	BestWeights     config.SimilarityWeights
+	BestWeights     config.MetricWeights
 	BestScore       float64
 	IterationCount  int
 	DetailedResults []CaseResult
@@ -54,13 +80,12 @@ type CaseResult struct {
 	Category string
 }
 
-// EvaluateWeights evaluates a set of weights against the benchmark dataset.
-func (wo *WeightOptimizer) EvaluateWeights(t *testing.T, weights config.SimilarityWeights) (float64, []CaseResult) {
+// EvaluateWeights evaluates the weights of a set of metrics against the benchmark dataset.
+func (wo *WeightOptimizer) EvaluateWeights(t *testing.T, weights config.MetricWeights) (float64, []CaseResult) {
 	// Create detector with given weights
 	cfg := config.Default()
-	cfg.Similarity.Weights = weights
-	detector := NewDetector(config.DefaultThreshold)
-	detector.config = cfg
+	cfg.Similarity.Metrics = weights
+	detector := NewDetectorWithConfig(config.DefaultThreshold, cfg)
 
 	var totalError float64
 	var results []CaseResult
@@ -89,63 +114,48 @@ func (wo *WeightOptimizer) EvaluateWeights(t *testing.T, weights config.Similari
 	return score, results
 }
 
-// GridSearchOptimize performs grid search optimization over weight space.
+// GridSearchOptimize performs grid search optimization over the weight space of the
+// optimized metrics, evaluating every combination summing to 1.0.
 func (wo *WeightOptimizer) GridSearchOptimize(t *testing.T) OptimizationResult {
-	bestScore := -1.0
-	var bestWeights config.SimilarityWeights
-	var bestResults []CaseResult
-	iterationCount := 0
-
-	for treeEdit := treeEditMin; treeEdit <= treeEditMax; treeEdit += gridSearchStep {
-		for tokenSim := tokenSimilarityMin; tokenSim <= tokenSimilarityMax; tokenSim += gridSearchStep {
-			for structural := structuralMin; structural <= structuralMax; structural += gridSearchStep {
-				for signature := signatureMin; signature <= signatureMax; signature += gridSearchStep {
-					// Ensure weights sum to approximately 1.0
-					total := treeEdit + tokenSim + structural + signature
-					if math.Abs(total-1.0) > weightSumTolerance {
-						continue
-					}
-
-					weights := config.SimilarityWeights{
-						TreeEdit:           treeEdit,
-						TokenSimilarity:    tokenSim,
-						Structural:         structural,
-						Signature:          signature,
-						DifferentSignature: config.DifferentSignatureWeight, // Keep penalty constant
-					}
-
-					score, results := wo.EvaluateWeights(t, weights)
-					iterationCount++
-
-					if score > bestScore {
-						bestScore = score
-						bestWeights = weights
-						bestResults = results
-					}
-				}
+	result := OptimizationResult{BestScore: -1.0}
+	weights := make(config.MetricWeights, len(wo.ranges))
+
+	var search func(index int, total float64)
+	search = func(index int, total float64) {
+		if index == len(wo.ranges) {
+			// Ensure weights sum to approximately 1.0
+			if math.Abs(total-config.WeightSumTarget) > weightSumTolerance {
+				return
 			}
+
+			score, results := wo.EvaluateWeights(t, weights)
+			result.IterationCount++
+
+			if score > result.BestScore {
+				result.BestScore = score
+				result.BestWeights = slices.Clone(weights)
+				result.DetailedResults = results
+			}
+			return
 		}
-	}
 
-	return OptimizationResult{
-		BestWeights:     bestWeights,
-		BestScore:       bestScore,
-		IterationCount:  iterationCount,
-		DetailedResults: bestResults,
+		metric := wo.ranges[index]
+		for weight := metric.Min; weight <= metric.Max; weight += gridSearchStep {
+			if total+weight > config.WeightSumTarget+weightSumTolerance {
+				break
+			}
+			weights[index] = config.MetricWeight{Name: metric.Name, Weight: weight}
+			search(index+1, total+weight)
+		}
 	}
+	search(0, 0)
+
+	return result
 }
 
-// AnalyzeCurrentWeights analyzes the performance of current default weights.
+// AnalyzeCurrentWeights analyzes the performance of the metrics enabled by default.
 func (wo *WeightOptimizer) AnalyzeCurrentWeights(t *testing.T) (float64, []CaseResult) {
-	currentWeights := config.SimilarityWeights{
-		TreeEdit:           config.TreeEditWeight,
-		TokenSimilarity:    config.TokenSimilarityWeight,
-		Structural:         config.StructuralWeight,
-		Signature:          config.SignatureWeight,
-		DifferentSignature: config.DifferentSignatureWeight,
-	}
-
-	return wo.EvaluateWeights(t, currentWeights)
+	return wo.EvaluateWeights(t, config.Default().Similarity.EnabledMetrics())
 }
 
 // PrintOptimizationReport prints a detailed report of optimization results.
@@ -166,19 +176,13 @@ func (wo *WeightOptimizer) printOptimizationReport(out io.Writer, result Optimiz
 	write("Improvement: %.4f (%.2f%%)\n", improvement, improvement/currentScore*percentageMultiplier100)
 
 	write("\n--- CURRENT vs OPTIMIZED WEIGHTS ---\n")
-	write("Algorithm        Current  Optimized  Change\n")
-	write("TreeEdit         %.3f    %.3f      %+.3f\n",
-		config.TreeEditWeight, result.BestWeights.TreeEdit,
-		result.BestWeights.TreeEdit-config.TreeEditWeight)
-	write("TokenSimilarity  %.3f    %.3f      %+.3f\n",
-		config.TokenSimilarityWeight, result.BestWeights.TokenSimilarity,
-		result.BestWeights.TokenSimilarity-config.TokenSimilarityWeight)
-	write("Structural       %.3f    %.3f      %+.3f\n",
-		config.StructuralWeight, result.BestWeights.Structural,
-		result.BestWeights.Structural-config.StructuralWeight)
-	write("Signature        %.3f    %.3f      %+.3f\n",
-		config.SignatureWeight, result.BestWeights.Signature,
-		result.BestWeights.Signature-config.SignatureWeight)
+	write("Metric            Current  Optimized  Change\n")
+	current := config.Default().Similarity.EnabledMetrics()
+	for _, metric := range result.BestWeights {
+		write("%-16s  %.3f    %.3f      %+.3f\n",
+			metric.Name, current.Weight(metric.Name), metric.Weight,
+			metric.Weight-current.Weight(metric.Name))
+	}
 
 	write("\n--- PERFORMANCE BY CATEGORY ---\n")
 	categoryErrors := make(map[string][]float64)
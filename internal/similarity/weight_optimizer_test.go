@@ -2,6 +2,7 @@ package similarity
 
 import (
 	"math"
+	"slices"
 	"testing"
 
 	"github.com/paveg/similarity-go/internal/config"
@@ -13,43 +14,25 @@ func TestWeightOptimizer_EvaluateWeights(t *testing.T) {
 
 	tests := []struct {
 		name           string
-		weights        config.SimilarityWeights
+		weights        config.MetricWeights
 		expectedScore  float64 // approximate expected score
 		scoreThreshold float64 // tolerance for score comparison
 	}{
 		{
-			name: "perfect_weights_high_score",
-			weights: config.SimilarityWeights{
-				TreeEdit:           0.35,
-				TokenSimilarity:    0.30,
-				Structural:         0.25,
-				Signature:          0.10,
-				DifferentSignature: 0.3,
-			},
+			name:           "perfect_weights_high_score",
+			weights:        baseWeights(0.35, 0.30, 0.25, 0.10),
 			expectedScore:  0.79,
 			scoreThreshold: 0.05,
 		},
 		{
-			name: "unbalanced_weights_lower_score",
-			weights: config.SimilarityWeights{
-				TreeEdit:           0.9, // Too high
-				TokenSimilarity:    0.05,
-				Structural:         0.03,
-				Signature:          0.02,
-				DifferentSignature: 0.3,
-			},
+			name:           "unbalanced_weights_lower_score",
+			weights:        baseWeights(0.9, 0.05, 0.03, 0.02),
 			expectedScore:  0.5, // Should be lower due to imbalance
 			scoreThreshold: 0.3,
 		},
 		{
-			name: "current_default_weights",
-			weights: config.SimilarityWeights{
-				TreeEdit:           config.TreeEditWeight,
-				TokenSimilarity:    config.TokenSimilarityWeight,
-				Structural:         config.StructuralWeight,
-				Signature:          config.SignatureWeight,
-				DifferentSignature: config.DifferentSignatureWeight,
-			},
+			name:           "current_default_weights",
+			weights:        baseWeights(config.TreeEditWeight, config.TokenSimilarityWeight, config.StructuralWeight, config.SignatureWeight),
 			expectedScore:  0.79,
 			scoreThreshold: 0.05,
 		},
@@ -114,14 +97,13 @@ func TestWeightOptimizer_GridSearchOptimize(t *testing.T) {
 
 	// Verify weights sum approximately to 1
 	weights := result.BestWeights
-	total := weights.TreeEdit + weights.TokenSimilarity + weights.Structural + weights.Signature
+	total := weights.Sum()
 	if math.Abs(total-1.0) > 0.02 {
 		t.Errorf("Best weights don't sum to ~1.0: %f", total)
 	}
 
 	// Each weight should be positive
-	if weights.TreeEdit <= 0 || weights.TokenSimilarity <= 0 ||
-		weights.Structural <= 0 || weights.Signature <= 0 {
+	if hasNonPositiveWeight(weights) {
 		t.Error("All weights should be positive")
 	}
 
@@ -216,13 +198,7 @@ func TestWeightOptimizer_ConsistencyCheck(t *testing.T) {
 	optimizer := NewWeightOptimizer()
 
 	// Same weights should produce same results
-	weights := config.SimilarityWeights{
-		TreeEdit:           0.3,
-		TokenSimilarity:    0.3,
-		Structural:         0.25,
-		Signature:          0.15,
-		DifferentSignature: 0.3,
-	}
+	weights := baseWeights(0.3, 0.3, 0.25, 0.15)
 
 	score1, results1 := optimizer.EvaluateWeights(t, weights)
 	score2, results2 := optimizer.EvaluateWeights(t, weights)
@@ -254,41 +230,23 @@ func TestWeightOptimizer_EdgeCases(t *testing.T) {
 
 	tests := []struct {
 		name    string
-		weights config.SimilarityWeights
+		weights config.MetricWeights
 		valid   bool
 	}{
 		{
-			name: "zero_weights",
-			weights: config.SimilarityWeights{
-				TreeEdit:           0,
-				TokenSimilarity:    0,
-				Structural:         0,
-				Signature:          0,
-				DifferentSignature: 0.3,
-			},
-			valid: false, // Should handle gracefully but may produce poor results
+			name:    "zero_weights",
+			weights: baseWeights(0, 0, 0, 0),
+			valid:   false, // Should handle gracefully but may produce poor results
 		},
 		{
-			name: "single_weight_dominant",
-			weights: config.SimilarityWeights{
-				TreeEdit:           1.0,
-				TokenSimilarity:    0,
-				Structural:         0,
-				Signature:          0,
-				DifferentSignature: 0.3,
-			},
-			valid: true,
+			name:    "single_weight_dominant",
+			weights: baseWeights(1.0, 0, 0, 0),
+			valid:   true,
 		},
 		{
-			name: "negative_weights",
-			weights: config.SimilarityWeights{
-				TreeEdit:           -0.1,
-				TokenSimilarity:    0.5,
-				Structural:         0.3,
-				Signature:          0.3,
-				DifferentSignature: 0.3,
-			},
-			valid: false,
+			name:    "negative_weights",
+			weights: baseWeights(-0.1, 0.5, 0.3, 0.3),
+			valid:   false,
 		},
 	}
 
@@ -318,15 +276,47 @@ func TestWeightOptimizer_EdgeCases(t *testing.T) {
 	}
 }
 
-func BenchmarkWeightOptimizer_EvaluateWeights(b *testing.B) {
+func TestWeightOptimizer_GridSearchOptimize_MetricRanges(t *testing.T) {
 	optimizer := NewWeightOptimizer()
-	weights := config.SimilarityWeights{
-		TreeEdit:           0.3,
-		TokenSimilarity:    0.3,
-		Structural:         0.25,
-		Signature:          0.15,
-		DifferentSignature: 0.3,
+	optimizer.SetMetricRanges([]MetricRange{
+		{Name: config.MetricTreeEdit, Min: 0.4, Max: 0.5},
+		{Name: config.MetricTokenSimilarity, Min: 0.3, Max: 0.4},
+		{Name: config.MetricControlFlow, Min: 0.2, Max: 0.3},
+	})
+
+	result := optimizer.GridSearchOptimize(t)
+
+	if result.IterationCount == 0 {
+		t.Fatal("Expected weight combinations summing to 1.0 to be evaluated")
+	}
+	if !slices.Equal(result.BestWeights.Names(), []string{
+		config.MetricTreeEdit, config.MetricTokenSimilarity, config.MetricControlFlow,
+	}) {
+		t.Errorf("Best weights should cover the optimized metrics, got %v", result.BestWeights)
+	}
+	if math.Abs(result.BestWeights.Sum()-1.0) > 0.02 {
+		t.Errorf("Best weights don't sum to ~1.0: %f", result.BestWeights.Sum())
+	}
+}
+
+// baseWeights returns the weights of the four base metrics.
+func baseWeights(treeEdit, tokenSimilarity, structural, signature float64) config.MetricWeights {
+	return config.MetricWeights{
+		{Name: config.MetricTreeEdit, Weight: treeEdit},
+		{Name: config.MetricTokenSimilarity, Weight: tokenSimilarity},
+		{Name: config.MetricStructural, Weight: structural},
+		{Name: config.MetricSignature, Weight: signature},
 	}
+}
+
+// hasNonPositiveWeight reports whether one of the weights is zero or negative.
+func hasNonPositiveWeight(weights config.MetricWeights) bool {
+	return slices.ContainsFunc(weights, func(metric config.MetricWeight) bool { return metric.Weight <= 0 })
+}
+
+func BenchmarkWeightOptimizer_EvaluateWeights(b *testing.B) {
+	optimizer := NewWeightOptimizer()
+	weights := baseWeights(0.3, 0.3, 0.25, 0.15)
 
 	// Create a dummy test for the benchmark
 	t := &testing.T{}
@@ -0,0 +1,17 @@
+// Package vcs reads the Go sources of a git repository at given revisions, so that they
+// can be analyzed like any directory tree.
+//
+// It runs the git command of the system, which must be in the PATH.
+//
+// Key Components:
+//   - Export: Writes the Go files and go.mod files of a revision into a directory
+//   - Log: Lists the first-parent commits leading to a revision, oldest first
+//   - ReadFile: Reads one file at a revision
+//
+// Example Usage:
+//
+//	dir, err := os.MkdirTemp("", "base")
+//	if err == nil {
+//		err = vcs.Export(ctx, ".", "main", dir)
+//	}
+package vcs
@@ -0,0 +1,190 @@
+package vcs
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportedFilePerm is the permission of the files written by Export.
+const exportedFilePerm = 0o644
+
+// exportedDirPerm is the permission of the directories created by Export.
+const exportedDirPerm = 0o755
+
+// Export writes the Go files and go.mod files of repo at revision into dir, keeping
+// their paths relative to the root of the repository. Other files are skipped, as only
+// they matter to the analysis and to the import paths of packages.
+func Export(ctx context.Context, repo, revision, dir string) error {
+	// Stop git when the archive is not read to the end
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	args := []string{"archive", "--format=tar", "--end-of-options", revision}
+	cmd, stderr := command(ctx, repo, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to read archive of %s: %w", revision, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start git %s: %w", args[0], err)
+	}
+
+	if err := extract(tar.NewReader(stdout), revision, dir); err != nil {
+		cancel()
+		_ = cmd.Wait()
+		return err
+	}
+
+	// Drain the padding following the end of the archive before waiting for git
+	if _, err := io.Copy(io.Discard, stdout); err != nil {
+		cancel()
+		_ = cmd.Wait()
+		return fmt.Errorf("failed to read archive of %s: %w", revision, err)
+	}
+	if err := cmd.Wait(); err != nil {
+		return gitError(ctx, args, err, stderr)
+	}
+	return nil
+}
+
+// extract writes the files of archive selected by exported into dir.
+func extract(archive *tar.Reader, revision, dir string) error {
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive of %s: %w", revision, err)
+		}
+
+		if header.Typeflag != tar.TypeReg || !exported(header.Name) {
+			continue
+		}
+		if err := writeFile(dir, header.Name, archive); err != nil {
+			return err
+		}
+	}
+}
+
+// exported reports whether a file of the repository is written by Export.
+func exported(name string) bool {
+	return strings.HasSuffix(name, ".go") || path.Base(name) == "go.mod"
+}
+
+// writeFile writes the content of r to name under dir, refusing names that escape dir.
+func writeFile(dir, name string, r io.Reader) error {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return fmt.Errorf("refusing to write %s outside of %s", name, dir)
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), exportedDirPerm); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, exportedFilePerm)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", target, err)
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	return file.Close()
+}
+
+// run runs git with args in repo and returns its standard output. The standard error of
+// git is included in the returned error. Revisions come from users, so callers pass
+// --end-of-options before them to keep a revision such as --output=file from being
+// parsed as an option.
+func run(ctx context.Context, repo string, args ...string) ([]byte, error) {
+	cmd, stderr := command(ctx, repo, args...)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, gitError(ctx, args, err, stderr)
+	}
+	return output, nil
+}
+
+// command prepares git with args in repo, collecting its standard error in the returned
+// buffer.
+func command(ctx context.Context, repo string, args ...string) (*exec.Cmd, *bytes.Buffer) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repo}, args...)...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	return cmd, &stderr
+}
+
+// gitError describes the failure err of git with args, including its standard error.
+// The error of ctx is returned instead once it is done.
+func gitError(ctx context.Context, args []string, err error, stderr *bytes.Buffer) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+}
+
+// logFieldSeparator separates the fields of a commit in the output of Log.
+const logFieldSeparator = "\x00"
+
+// logFields is the number of fields Log reads for every commit.
+const logFields = 3
+
+// Commit is a commit of a repository.
+type Commit struct {
+	Hash    string
+	Time    time.Time
+	Subject string
+}
+
+// Log returns the commits reachable from revision by following first parents, oldest
+// first, so that merged branches count as the merge commit only.
+func Log(ctx context.Context, repo, revision string) ([]Commit, error) {
+	output, err := run(ctx, repo,
+		"log", "--first-parent", "--reverse", "--format=%H%x00%ct%x00%s", "--end-of-options", revision, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.SplitN(line, logFieldSeparator, logFields)
+		if len(fields) != logFields {
+			continue
+		}
+		seconds, parseErr := strconv.ParseInt(fields[1], 10, 64)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid time of commit %s: %w", fields[0], parseErr)
+		}
+		commits = append(commits, Commit{Hash: fields[0], Time: time.Unix(seconds, 0).UTC(), Subject: fields[2]})
+	}
+	return commits, nil
+}
+
+// ReadFile returns the content of the file at path, relative to the root of repo, at
+// revision. The error wraps os.ErrNotExist when the revision has no such file.
+func ReadFile(ctx context.Context, repo, revision, name string) ([]byte, error) {
+	listing, err := run(ctx, repo, "ls-tree", "--name-only", "--end-of-options", revision, "--", name)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(string(listing)) != name {
+		return nil, fmt.Errorf("%s at %s: %w", name, revision, os.ErrNotExist)
+	}
+
+	return run(ctx, repo, "cat-file", "--end-of-options", "blob", revision+":"+name)
+}
@@ -0,0 +1,123 @@
+package vcs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// gitRepo creates a repository with one commit holding files and returns its path.
+func gitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, output)
+		}
+	}
+	return repo
+}
+
+func TestExport(t *testing.T) {
+	repo := gitRepo(t, map[string]string{
+		"go.mod":           "module example.com/app\n",
+		"README.md":        "# app\n",
+		"internal/util.go": "package internal\n",
+	})
+
+	dir := t.TempDir()
+	if err := Export(context.Background(), repo, "HEAD", dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"go.mod", "internal/util.go"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("expected %s to be exported: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "README.md")); !os.IsNotExist(err) {
+		t.Errorf("expected README.md to be skipped, got %v", err)
+	}
+
+	if err := Export(context.Background(), repo, "no-such-revision", t.TempDir()); err == nil {
+		t.Error("expected an error for an unknown revision")
+	}
+}
+
+func TestLogAndReadFile(t *testing.T) {
+	repo := gitRepo(t, map[string]string{"app/main.go": "package main\n"})
+
+	if err := os.WriteFile(filepath.Join(repo, "app", "main.go"), []byte("package app\n"), 0o600); err != nil {
+		t.Fatalf("failed to write change: %v", err)
+	}
+	cmd := exec.Command("git", "-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com",
+		"commit", "-q", "-a", "-m", "rename package")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v: %s", err, output)
+	}
+
+	commits, err := Log(context.Background(), repo, "HEAD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(commits) != 2 || commits[0].Subject != "initial" || commits[1].Subject != "rename package" {
+		t.Fatalf("expected both commits oldest first, got %+v", commits)
+	}
+	if commits[0].Time.IsZero() || len(commits[0].Hash) == 0 {
+		t.Errorf("expected hash and time, got %+v", commits[0])
+	}
+
+	content, err := ReadFile(context.Background(), repo, commits[0].Hash, "app/main.go")
+	if err != nil || string(content) != "package main\n" {
+		t.Errorf("expected the first version, got %q (%v)", content, err)
+	}
+
+	if _, err := ReadFile(context.Background(), repo, "HEAD", "app/missing.go"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a missing file, got %v", err)
+	}
+}
+
+func TestRevisionsAreNotOptions(t *testing.T) {
+	repo := gitRepo(t, map[string]string{"app/main.go": "package main\n"})
+
+	// Without --end-of-options, git would write to the file named by the revision
+	output := filepath.Join(t.TempDir(), "output")
+	revision := "--output=" + output
+
+	if err := Export(context.Background(), repo, revision, t.TempDir()); err == nil {
+		t.Error("Export: expected an error for a revision looking like an option")
+	}
+	if _, err := Log(context.Background(), repo, revision); err == nil {
+		t.Error("Log: expected an error for a revision looking like an option")
+	}
+	if _, err := ReadFile(context.Background(), repo, revision, "app/main.go"); err == nil {
+		t.Error("ReadFile: expected an error for a revision looking like an option")
+	}
+
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("expected %s not to be written, got %v", output, err)
+	}
+}
@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"fmt"
+)
+
+// Shard selects a deterministic share of the pairwise comparisons, so the work of one
+// analysis can be split across processes. Pairs are numbered in the order
+// FindSimilarFunctions enumerates them and dealt round-robin to the shards.
+// Index runs from 1 to Count; the zero value selects every pair.
+type Shard struct {
+	Index int
+	Count int
+}
+
+// Validate checks that the shard describes one of Count shards.
+func (s Shard) Validate() error {
+	if s == (Shard{}) {
+		return nil
+	}
+
+	if s.Count < 1 || s.Index < 1 || s.Index > s.Count {
+		return fmt.Errorf("invalid shard %d/%d: index must be between 1 and the shard count", s.Index, s.Count)
+	}
+
+	return nil
+}
+
+// Includes reports whether the pair with the given number belongs to the shard.
+func (s Shard) Includes(pair int) bool {
+	if s.Count <= 1 {
+		return true
+	}
+	return pair%s.Count == s.Index-1
+}
+
+// String returns the shard in "index/count" form.
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/config"
+	"github.com/paveg/similarity-go/internal/similarity"
+)
+
+func TestShardValidate(t *testing.T) {
+	tests := []struct {
+		shard       Shard
+		expectError bool
+	}{
+		{shard: Shard{}},
+		{shard: Shard{Index: 1, Count: 1}},
+		{shard: Shard{Index: 3, Count: 3}},
+		{shard: Shard{Index: 0, Count: 3}, expectError: true},
+		{shard: Shard{Index: 4, Count: 3}, expectError: true},
+		{shard: Shard{Index: 1, Count: 0}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		err := tt.shard.Validate()
+		if tt.expectError && err == nil {
+			t.Errorf("expected error for shard %s", tt.shard)
+		}
+		if !tt.expectError && err != nil {
+			t.Errorf("unexpected error for shard %s: %v", tt.shard, err)
+		}
+	}
+}
+
+func TestSimilarityWorkerFindSimilarFunctionsShard(t *testing.T) {
+	cfg := config.Default()
+	detector := similarity.NewDetectorWithConfig(0.1, cfg)
+	functions := createTestFunctionSet(7)
+
+	type pair struct{ a, b *ast.Function }
+	collect := func(matches []similarity.Match) map[pair]float64 {
+		result := make(map[pair]float64, len(matches))
+		for _, match := range matches {
+			result[pair{match.Function1, match.Function2}] = match.Similarity
+		}
+		return result
+	}
+
+	full, err := NewSimilarityWorker(detector, 2, 0.1).FindSimilarFunctions(functions, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := collect(full)
+
+	const shardCount = 3
+	merged := make(map[pair]float64)
+	totalPairs := 0
+	for index := 1; index <= shardCount; index++ {
+		var shardTotal int
+		worker := NewSimilarityWorker(detector, 2, 0.1)
+		matches, shardErr := worker.FindSimilarFunctionsShard(
+			functions,
+			Shard{Index: index, Count: shardCount},
+			func(_, total int) { shardTotal = total },
+		)
+		if shardErr != nil {
+			t.Fatalf("shard %d: unexpected error: %v", index, shardErr)
+		}
+		totalPairs += shardTotal
+
+		for key, score := range collect(matches) {
+			if _, exists := merged[key]; exists {
+				t.Errorf("pair %s-%s compared by more than one shard", key.a.Name, key.b.Name)
+			}
+			merged[key] = score
+		}
+	}
+
+	// 7 functions make 21 pairs, dealt 7 per shard
+	if totalPairs != len(functions)*(len(functions)-1)/2 {
+		t.Errorf("expected every pair to be compared once, got %d comparisons", totalPairs)
+	}
+
+	if len(merged) != len(expected) {
+		t.Fatalf("expected %d merged matches, got %d", len(expected), len(merged))
+	}
+	for key, score := range expected {
+		if merged[key] != score {
+			t.Errorf("pair %s-%s: expected %f, got %f", key.a.Name, key.b.Name, score, merged[key])
+		}
+	}
+
+	if _, err := NewSimilarityWorker(detector, 2, 0.1).FindSimilarFunctionsShard(
+		functions, Shard{Index: 2, Count: 1}, nil,
+	); err == nil {
+		t.Error("expected error for invalid shard")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"sort"
 	"sync"
 
 	"github.com/paveg/similarity-go/internal/ast"
@@ -37,12 +38,23 @@ type SimilarityWorker struct {
 	detector  *similarity.Detector
 	workers   int
 	threshold float64
+	ctx       context.Context
 	matchesCh chan *similarity.Match
 	resultsCh chan ComparisonResult
 }
 
 // NewSimilarityWorker creates a new similarity worker with the specified detector and worker count.
 func NewSimilarityWorker(detector *similarity.Detector, workers int, threshold float64) *SimilarityWorker {
+	return NewSimilarityWorkerWithContext(context.Background(), detector, workers, threshold)
+}
+
+// NewSimilarityWorkerWithContext creates a similarity worker whose comparisons stop when ctx is cancelled.
+func NewSimilarityWorkerWithContext(
+	ctx context.Context,
+	detector *similarity.Detector,
+	workers int,
+	threshold float64,
+) *SimilarityWorker {
 	if workers <= 0 {
 		workers = runtime.NumCPU()
 	}
@@ -51,6 +63,7 @@ func NewSimilarityWorker(detector *similarity.Detector, workers int, threshold f
 		detector:  detector,
 		workers:   workers,
 		threshold: threshold,
+		ctx:       ctx,
 		matchesCh: make(chan *similarity.Match, workers*ChannelBufferMultiplier),
 		resultsCh: make(chan ComparisonResult, workers*ChannelBufferMultiplier),
 	}
@@ -61,20 +74,75 @@ func (sw *SimilarityWorker) FindSimilarFunctions(
 	functions []*ast.Function,
 	progressCallback func(completed, total int),
 ) ([]similarity.Match, error) {
+	return sw.FindSimilarFunctionsShard(functions, Shard{}, progressCallback)
+}
+
+// FindSimilarFunctionsShard finds similar functions among the pairs selected by shard.
+// Running every shard of the same function list yields exactly the matches of
+// FindSimilarFunctions. Progress is reported against the pairs of the shard only.
+func (sw *SimilarityWorker) FindSimilarFunctionsShard(
+	functions []*ast.Function,
+	shard Shard,
+	progressCallback func(completed, total int),
+) ([]similarity.Match, error) {
+	if err := shard.Validate(); err != nil {
+		return nil, err
+	}
+
 	if len(functions) < MinFunctionCountForComparison {
 		return nil, nil
 	}
 
-	// Calculate total number of comparisons
-	totalComparisons := len(functions) * (len(functions) - 1) / MinFunctionCountForComparison
-
-	// Create jobs for all function pairs
-	jobs := make(chan ComparisonJob, totalComparisons)
+	// Select the function pairs of this shard
+	var selected []ComparisonJob
+	pair := 0
 	for i := range functions {
 		for j := i + 1; j < len(functions); j++ {
+			if shard.Includes(pair) {
+				selected = append(selected, ComparisonJob{
+					Function1: functions[i],
+					Function2: functions[j],
+					Index1:    i,
+					Index2:    j,
+				})
+			}
+			pair++
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, nil
+	}
+
+	jobs := make(chan ComparisonJob, len(selected))
+	for _, job := range selected {
+		jobs <- job
+	}
+	close(jobs) // No more jobs will be sent
+
+	return sw.runJobs(jobs, len(selected), progressCallback)
+}
+
+// FindSimilarFunctionsBetween finds similar pairs where one function comes from left
+// and the other from right, using parallel processing. Pairs within the same side are
+// never compared. Index1 refers to left and Index2 to right.
+func (sw *SimilarityWorker) FindSimilarFunctionsBetween(
+	left, right []*ast.Function,
+	progressCallback func(completed, total int),
+) ([]similarity.Match, error) {
+	if len(left) == 0 || len(right) == 0 {
+		return nil, nil
+	}
+
+	totalComparisons := len(left) * len(right)
+
+	// Create jobs for all cross-set pairs
+	jobs := make(chan ComparisonJob, totalComparisons)
+	for i := range left {
+		for j := range right {
 			jobs <- ComparisonJob{
-				Function1: functions[i],
-				Function2: functions[j],
+				Function1: left[i],
+				Function2: right[j],
 				Index1:    i,
 				Index2:    j,
 			}
@@ -82,8 +150,17 @@ func (sw *SimilarityWorker) FindSimilarFunctions(
 	}
 	close(jobs) // No more jobs will be sent
 
+	return sw.runJobs(jobs, totalComparisons, progressCallback)
+}
+
+// runJobs processes the given comparison jobs with the worker goroutines and collects matches.
+func (sw *SimilarityWorker) runJobs(
+	jobs <-chan ComparisonJob,
+	totalComparisons int,
+	progressCallback func(completed, total int),
+) ([]similarity.Match, error) {
 	// Start workers
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(sw.ctx)
 	defer cancel()
 
 	var wg sync.WaitGroup
@@ -95,7 +172,7 @@ func (sw *SimilarityWorker) FindSimilarFunctions(
 	}
 
 	// Collect results
-	var matches []similarity.Match
+	var found []ComparisonResult
 	var errors []error
 	completed := 0
 
@@ -116,13 +193,30 @@ func (sw *SimilarityWorker) FindSimilarFunctions(
 		if result.Error != nil {
 			errors = append(errors, result.Error)
 		} else if result.Match != nil {
-			matches = append(matches, *result.Match)
+			found = append(found, result)
 		}
 	}
 
+	// Return matches in job order, whatever order the workers finished in
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Index1 != found[j].Index1 {
+			return found[i].Index1 < found[j].Index1
+		}
+		return found[i].Index2 < found[j].Index2
+	})
+
+	matches := make([]similarity.Match, 0, len(found))
+	for _, result := range found {
+		matches = append(matches, *result.Match)
+	}
+
 	// Return any errors that occurred
 	if len(errors) > 0 {
-		return matches, fmt.Errorf("encountered %d errors during similarity calculation", len(errors))
+		return matches, fmt.Errorf(
+			"encountered %d errors during similarity calculation: %w",
+			len(errors),
+			errors[0],
+		)
 	}
 
 	return matches, nil
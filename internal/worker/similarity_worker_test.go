@@ -1,6 +1,8 @@
 package worker
 
 import (
+	"context"
+	"errors"
 	"runtime"
 	"testing"
 
@@ -287,3 +289,72 @@ func TestComparisonResult(t *testing.T) {
 		t.Errorf("unexpected error: %v", result.Error)
 	}
 }
+
+func TestSimilarityWorkerFindSimilarFunctionsBetween(t *testing.T) {
+	cfg := config.Default()
+	detector := similarity.NewDetectorWithConfig(0.1, cfg) // Low threshold to get matches
+
+	left := createTestFunctionSet(3)
+	right := createTestFunctionSet(4)
+
+	var lastCompleted, lastTotal int
+	worker := NewSimilarityWorker(detector, 2, 0.1)
+	matches, err := worker.FindSimilarFunctionsBetween(left, right, func(completed, total int) {
+		lastCompleted, lastTotal = completed, total
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Only cross-set pairs are compared: len(left) * len(right)
+	expectedTotal := len(left) * len(right)
+	if lastTotal != expectedTotal || lastCompleted != expectedTotal {
+		t.Errorf("expected %d/%d comparisons, got %d/%d", expectedTotal, expectedTotal, lastCompleted, lastTotal)
+	}
+
+	leftSet := make(map[*ast.Function]bool, len(left))
+	for _, fn := range left {
+		leftSet[fn] = true
+	}
+	for _, match := range matches {
+		if !leftSet[match.Function1] || leftSet[match.Function2] {
+			t.Errorf("match %s-%s is not a left/right pair", match.Function1.Name, match.Function2.Name)
+		}
+	}
+
+	// Matches come back in job order regardless of which worker finished first
+	serial := NewSimilarityWorker(detector, 1, 0.1)
+	serialMatches, err := serial.FindSimilarFunctionsBetween(left, right, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(matches) == 0 || len(serialMatches) != len(matches) {
+		t.Fatalf("expected the same matches with one worker, got %d and %d", len(serialMatches), len(matches))
+	}
+	for i := range matches {
+		if matches[i].Function1 != serialMatches[i].Function1 || matches[i].Function2 != serialMatches[i].Function2 {
+			t.Errorf("match %d differs: %s-%s vs %s-%s", i, matches[i].Function1.Name, matches[i].Function2.Name,
+				serialMatches[i].Function1.Name, serialMatches[i].Function2.Name)
+		}
+	}
+
+	emptyWorker := NewSimilarityWorker(detector, 2, 0.1)
+	matches, err = emptyWorker.FindSimilarFunctionsBetween(left, nil, nil)
+	if err != nil || len(matches) != 0 {
+		t.Errorf("expected no matches and no error for empty right set, got %d, %v", len(matches), err)
+	}
+}
+
+func TestSimilarityWorkerCancelledContext(t *testing.T) {
+	cfg := config.Default()
+	detector := similarity.NewDetectorWithConfig(0.1, cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	worker := NewSimilarityWorkerWithContext(ctx, detector, 2, 0.1)
+	_, err := worker.FindSimilarFunctions(createTestFunctionSet(4), nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,284 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/config"
+	"github.com/paveg/similarity-go/internal/similarity"
+	"github.com/paveg/similarity-go/internal/worker"
+)
+
+// logPrefix is prepended to every diagnostic message written to the logger.
+const logPrefix = "[similarity-go] "
+
+// Analyzer finds similar functions in Go source code.
+// An Analyzer is safe to reuse for several analyses but not for concurrent ones.
+type Analyzer struct {
+	config   *config.Config
+	logger   io.Writer
+	progress func(completed, total int)
+	shard    worker.Shard
+}
+
+// New creates an Analyzer from the default configuration and the given options.
+// It returns an error when an option fails or the resulting configuration is invalid.
+func New(opts ...Option) (*Analyzer, error) {
+	s := &settings{config: config.Default()}
+
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	if err := similarity.ValidateMetrics(s.config); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &Analyzer{
+		config:   s.config,
+		logger:   s.logger,
+		progress: s.progress,
+		shard:    s.shard,
+	}, nil
+}
+
+// Analyze is a convenience wrapper creating an Analyzer and analyzing targets with it.
+func Analyze(ctx context.Context, targets []string, opts ...Option) (*Report, error) {
+	a, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.Analyze(ctx, targets)
+}
+
+// Analyze compares every function found in targets with every other one and groups
+// the similar ones. Targets are Go files or directories, scanned recursively;
+// Go-style "dir/..." patterns are accepted. Unreadable targets are skipped.
+// With the types unit, type declarations are compared with each other as well.
+func (a *Analyzer) Analyze(ctx context.Context, targets []string) (*Report, error) {
+	parser := ast.NewParser()
+
+	decls, err := a.parseDeclarations(ctx, parser, targets)
+	if err != nil {
+		return nil, err
+	}
+	functions := a.analyzedFunctions(decls)
+
+	a.logf("Found %d functions for analysis", len(functions))
+
+	matches, err := a.findSimilarFunctions(ctx, functions)
+	if err != nil {
+		return nil, err
+	}
+
+	units := newTypeUnits()
+	typeMatches, err := a.findSimilarTypes(ctx, decls.types, units)
+	if err != nil {
+		return nil, err
+	}
+	matches = append(matches, typeMatches...)
+
+	summary := Summary{
+		TotalFunctions: len(functions),
+		TotalTypes:     a.analyzedTypes(decls),
+	}
+	variants := a.typeVariants(functions)
+	return a.buildReport(summary, matches, variants, a.config.CLI.DefaultThreshold, units.describe), nil
+}
+
+// Compare only evaluates pairs made of one function from left and one from right,
+// never two functions from the same side. Every reported function carries its Side.
+func (a *Analyzer) Compare(ctx context.Context, left, right []string) (*Report, error) {
+	if len(left) == 0 || len(right) == 0 {
+		return nil, errors.New("both left and right targets are required")
+	}
+
+	parser := ast.NewParser()
+
+	// Parse each side separately so functions keep their origin
+	leftDecls, err := a.parseDeclarations(ctx, parser, left)
+	if err != nil {
+		return nil, err
+	}
+
+	rightDecls, err := a.parseDeclarations(ctx, parser, right)
+	if err != nil {
+		return nil, err
+	}
+
+	leftFunctions := a.analyzedFunctions(leftDecls)
+	rightFunctions := a.analyzedFunctions(rightDecls)
+
+	a.logf("Found %d left and %d right functions for comparison", len(leftFunctions), len(rightFunctions))
+
+	matches, err := a.findSimilarFunctionsBetween(ctx, leftFunctions, rightFunctions)
+	if err != nil {
+		return nil, err
+	}
+
+	units := newTypeUnits()
+	typeMatches, err := a.findSimilarTypesBetween(ctx, leftDecls.types, rightDecls.types, units)
+	if err != nil {
+		return nil, err
+	}
+	matches = append(matches, typeMatches...)
+
+	sides := make(map[*ast.Function]string, len(leftFunctions)+len(rightFunctions))
+	for _, fn := range leftFunctions {
+		sides[fn] = SideLeft
+	}
+	for _, fn := range rightFunctions {
+		sides[fn] = SideRight
+	}
+	for decl, fn := range units.standIns {
+		sides[fn] = SideRight
+		if slices.Contains(leftDecls.types, decl) {
+			sides[fn] = SideLeft
+		}
+	}
+
+	summary := Summary{
+		TotalFunctions: len(leftFunctions) + len(rightFunctions),
+		TotalTypes:     a.analyzedTypes(leftDecls) + a.analyzedTypes(rightDecls),
+		LeftFunctions:  len(leftFunctions),
+		RightFunctions: len(rightFunctions),
+	}
+	// Only variants found on different sides are compared
+	var variants []similarity.Match
+	for _, match := range a.typeVariants(slices.Concat(leftFunctions, rightFunctions)) {
+		if sides[match.Function1] != sides[match.Function2] {
+			variants = append(variants, match)
+		}
+	}
+
+	return a.buildReport(summary, matches, variants, a.config.CLI.DefaultThreshold, func(fn *ast.Function) FunctionRef {
+		ref := units.describe(fn)
+		ref.Side = sides[fn]
+		return ref
+	}), nil
+}
+
+// buildReport groups matches found at threshold, and type variants apart from them, and
+// completes summary with the numbers of groups. Every group is checked for inconsistent renames before only the top groups are
+// kept, so that the rules of the report do not depend on the limit.
+func (a *Analyzer) buildReport(
+	summary Summary,
+	matches, variants []similarity.Match,
+	threshold float64,
+	describe func(fn *ast.Function) FunctionRef,
+) *Report {
+	groups := groupTypeVariants(matches, variants, a.config.Processing.Grouping, threshold)
+	summary.SimilarGroups = len(groups)
+	summary.TotalDuplications = countDuplications(groups)
+
+	result := a.buildGroups(groups, describe)
+	renames := a.renameInconsistencies(result, matches)
+	if top := a.config.Output.Top; top > 0 && len(result) > top {
+		result = result[:top]
+	}
+
+	return &Report{
+		SchemaVersion:         SchemaVersion,
+		Summary:               summary,
+		SimilarGroups:         result,
+		RenameInconsistencies: renames,
+	}
+}
+
+// analyzedFunctions returns the functions of decls when the funcs unit is enabled.
+func (a *Analyzer) analyzedFunctions(decls declarations) []*ast.Function {
+	if !a.config.Processing.HasUnit(UnitFuncs) {
+		return nil
+	}
+	return decls.functions
+}
+
+// analyzedTypes returns the number of type declarations of decls when the types unit is
+// enabled.
+func (a *Analyzer) analyzedTypes(decls declarations) int {
+	if !a.config.Processing.HasUnit(UnitTypes) {
+		return 0
+	}
+	return len(decls.types)
+}
+
+// newDetector creates a detector using the analyzer configuration.
+func (a *Analyzer) newDetector() *similarity.Detector {
+	return similarity.NewDetectorWithConfig(a.config.CLI.DefaultThreshold, a.config)
+}
+
+// newWorker creates a parallel similarity worker bound to ctx.
+func (a *Analyzer) newWorker(ctx context.Context, detector *similarity.Detector) *worker.SimilarityWorker {
+	a.logf("Using parallel processing with %d workers", a.config.CLI.DefaultWorkers)
+	return worker.NewSimilarityWorkerWithContext(
+		ctx,
+		detector,
+		a.config.CLI.DefaultWorkers,
+		a.config.CLI.DefaultThreshold,
+	)
+}
+
+// findSimilarFunctions finds similar functions using the appropriate processing method.
+func (a *Analyzer) findSimilarFunctions(ctx context.Context, functions []*ast.Function) ([]similarity.Match, error) {
+	detector := a.newDetector()
+
+	if a.config.CLI.DefaultWorkers > 1 {
+		matches, err := a.newWorker(ctx, detector).FindSimilarFunctions(functions, a.progress)
+		if err != nil {
+			return nil, fmt.Errorf("parallel similarity calculation failed: %w", err)
+		}
+		return matches, nil
+	}
+
+	// Use serial processing
+	a.logf("Using serial processing")
+	matches := detector.FindSimilarFunctions(functions)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// findSimilarFunctionsBetween finds cross-set similar functions using the appropriate processing method.
+func (a *Analyzer) findSimilarFunctionsBetween(
+	ctx context.Context,
+	left, right []*ast.Function,
+) ([]similarity.Match, error) {
+	detector := a.newDetector()
+
+	if a.config.CLI.DefaultWorkers > 1 {
+		matches, err := a.newWorker(ctx, detector).FindSimilarFunctionsBetween(left, right, a.progress)
+		if err != nil {
+			return nil, fmt.Errorf("parallel similarity calculation failed: %w", err)
+		}
+		return matches, nil
+	}
+
+	// Use serial processing
+	a.logf("Using serial processing")
+	matches := detector.FindSimilarFunctionsBetween(left, right)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// logf writes a diagnostic message when a logger is configured.
+func (a *Analyzer) logf(format string, args ...any) {
+	if a.logger == nil {
+		return
+	}
+	_, _ = fmt.Fprintf(a.logger, logPrefix+format+"\n", args...)
+}
@@ -0,0 +1,535 @@
+package analyzer_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+const analyzerTestSource = `package sample
+
+func Sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+`
+
+// writeTestFile writes analyzerTestSource to name inside a new temporary directory.
+func writeTestFile(t *testing.T, name string) string {
+	t.Helper()
+
+	return writeTestFileIn(t, t.TempDir(), name)
+}
+
+// writeTestFileIn writes analyzerTestSource to name inside dir.
+func writeTestFileIn(t *testing.T, dir, name string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(analyzerTestSource), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	return path
+}
+
+func TestNewValidatesOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []analyzer.Option
+	}{
+		{name: "threshold out of range", opts: []analyzer.Option{analyzer.WithThreshold(1.5)}},
+		{name: "non-positive min lines", opts: []analyzer.Option{analyzer.WithMinLines(0)}},
+		{name: "weights not summing to one", opts: []analyzer.Option{analyzer.WithWeights(analyzer.Weights{
+			TreeEdit: 0.5, TokenSimilarity: 0.5, Structural: 0.5, Signature: 0.5,
+		})}},
+		{name: "missing config file", opts: []analyzer.Option{analyzer.WithConfigFile("nonexistent.yaml")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := analyzer.New(tt.opts...); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	if _, err := analyzer.New(analyzer.WithWeights(analyzer.DefaultWeights())); err != nil {
+		t.Errorf("unexpected error for default weights: %v", err)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	file1 := writeTestFile(t, "a.go")
+	file2 := writeTestFile(t, "b.go")
+
+	for _, workers := range []int{1, 2} {
+		report, err := analyzer.Analyze(
+			context.Background(),
+			[]string{file1, filepath.Dir(file2) + "/..."},
+			analyzer.WithMinLines(3),
+			analyzer.WithWorkers(workers),
+			analyzer.WithRefactorSuggestion("extract it"),
+		)
+		if err != nil {
+			t.Fatalf("workers=%d: unexpected error: %v", workers, err)
+		}
+
+		if report.Summary.TotalFunctions != 2 || report.Summary.SimilarGroups != 1 {
+			t.Fatalf("workers=%d: unexpected summary %+v", workers, report.Summary)
+		}
+
+		group := report.SimilarGroups[0]
+		if group.SimilarityScore != 1.0 || len(group.Functions) != 2 || group.RefactorSuggestion != "extract it" {
+			t.Errorf("workers=%d: unexpected group %+v", workers, group)
+		}
+		if group.Functions[0].Function != "Sum" || group.Functions[0].Hash == "" {
+			t.Errorf("workers=%d: unexpected function %+v", workers, group.Functions[0])
+		}
+	}
+}
+
+func TestAnalyzeNormalization(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(file, []byte(analyzerTestSource+`
+func Total(items []int) int {
+	var sum int = 0
+	for i := 0; i < len(items); i++ {
+		item := items[i]
+		sum += item
+	}
+	return sum
+}
+`), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	for _, workers := range []int{1, 2} {
+		report, err := analyzer.Analyze(
+			context.Background(),
+			[]string{file},
+			analyzer.WithMinLines(3),
+			analyzer.WithThreshold(1.0),
+			analyzer.WithWorkers(workers),
+			analyzer.WithNormalization(analyzer.Normalization{VarDeclarations: true, RangeLoops: true}),
+		)
+		if err != nil {
+			t.Fatalf("workers=%d: unexpected error: %v", workers, err)
+		}
+		if report.Summary.SimilarGroups != 1 {
+			t.Errorf("workers=%d: expected the rewritten copy to be identical, got %+v", workers, report.Summary)
+		}
+	}
+}
+
+func TestAnalyzeTop(t *testing.T) {
+	dir := filepath.Dir(writeTestFile(t, "a.go"))
+	writeTestFileIn(t, dir, "b.go")
+
+	report, err := analyzer.Analyze(context.Background(), []string{dir}, analyzer.WithMinLines(3), analyzer.WithTop(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	group := report.SimilarGroups[0]
+	// Sum has 7 lines and a complexity of 2
+	if group.DuplicatedLines != 7 || group.RefactoringValue <= 0 || group.Functions[0].Complexity != 2 {
+		t.Errorf("unexpected group %+v", group)
+	}
+
+	if _, err := analyzer.New(analyzer.WithTop(-1)); err == nil {
+		t.Error("expected error for negative top")
+	}
+}
+
+func TestAnalyzeExtraction(t *testing.T) {
+	dir := filepath.Dir(writeTestFile(t, "a.go"))
+	writeTestFileIn(t, dir, "b.go")
+
+	report, err := analyzer.Analyze(context.Background(), []string{dir}, analyzer.WithMinLines(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Identical copies are replaced by a shared function without extra parameters
+	extraction := report.SimilarGroups[0].Extraction
+	if extraction == nil {
+		t.Fatal("expected an extraction")
+	}
+	if extraction.Signature != "func sum(values []int) int" || len(extraction.Parameters) != 0 {
+		t.Errorf("unexpected extraction %+v", extraction)
+	}
+	if len(extraction.CallSites) != 2 || extraction.CallSites[1].File != filepath.Join(dir, "b.go") ||
+		extraction.CallSites[1].Call != "return sum(values)" {
+		t.Errorf("unexpected call sites %+v", extraction.CallSites)
+	}
+}
+
+func TestAnalyzeMethodFamily(t *testing.T) {
+	source := "package api\n"
+	for _, typ := range []string{"CreateUserRequest", "UpdateUserRequest", "InviteUserRequest"} {
+		source += strings.ReplaceAll(`
+func (r *T) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if !strings.Contains(r.Email, "@") {
+		return errors.New("email is invalid")
+	}
+	return nil
+}
+`, "T", typ)
+	}
+
+	path := filepath.Join(t.TempDir(), "requests.go")
+	if err := os.WriteFile(path, []byte(source), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	report, err := analyzer.Analyze(context.Background(), []string{path}, analyzer.WithMinLines(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(report.SimilarGroups) != 1 {
+		t.Fatalf("expected one group, got %+v", report.SimilarGroups)
+	}
+	group := report.SimilarGroups[0]
+	if group.Kind != analyzer.GroupKindMethodFamily || group.MethodFamily == nil {
+		t.Fatalf("expected a method family, got kind %s", group.Kind)
+	}
+	if len(group.MethodFamily.Types) != 3 || group.MethodFamily.Strategy != analyzer.FamilyStrategyEmbedding {
+		t.Errorf("unexpected family %+v", group.MethodFamily)
+	}
+	if !strings.Contains(group.RefactorSuggestion, "Email, Name") {
+		t.Errorf("unexpected suggestion %q", group.RefactorSuggestion)
+	}
+}
+
+func TestAnalyzeTypes(t *testing.T) {
+	dir := t.TempDir()
+	for _, pkg := range []string{"billing", "shipping"} {
+		source := "package " + pkg + `
+
+type Address struct {
+	Street  string
+	City    string
+	Country string
+	Zip     int
+}
+
+func Format(street, city string) string {
+	result := street
+	result += ", "
+	result += city
+	return result
+}
+`
+		if err := os.MkdirAll(filepath.Join(dir, pkg), 0o755); err != nil {
+			t.Fatalf("failed to create package directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, pkg, "address.go"), []byte(source), 0o600); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+	}
+
+	report, err := analyzer.Analyze(context.Background(), []string{dir}, analyzer.WithMinLines(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Summary.TotalTypes != 0 || len(report.SimilarGroups) != 1 ||
+		report.SimilarGroups[0].Kind != analyzer.GroupKindClones {
+		t.Fatalf("expected only the function clones by default, got %+v", report.SimilarGroups)
+	}
+
+	report, err = analyzer.Analyze(
+		context.Background(),
+		[]string{dir},
+		analyzer.WithMinLines(3),
+		analyzer.WithUnits(analyzer.UnitTypes),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.Summary.TotalFunctions != 0 || report.Summary.TotalTypes != 2 {
+		t.Errorf("unexpected summary %+v", report.Summary)
+	}
+	if len(report.SimilarGroups) != 1 {
+		t.Fatalf("expected one group, got %+v", report.SimilarGroups)
+	}
+	group := report.SimilarGroups[0]
+	if group.Kind != analyzer.GroupKindTypes || len(group.Functions) != 2 {
+		t.Fatalf("expected a group of two types, got %+v", group)
+	}
+	for _, ref := range group.Functions {
+		if ref.TypeKind != "struct" || ref.Function != "Address" || ref.StartLine != 3 {
+			t.Errorf("unexpected type reference %+v", ref)
+		}
+	}
+	if !strings.Contains(group.RefactorSuggestion, "struct billing.Address") {
+		t.Errorf("unexpected suggestion %q", group.RefactorSuggestion)
+	}
+}
+
+func TestAnalyzeRenameInconsistencies(t *testing.T) {
+	source := `package sample
+
+func TotalWidth(boxes []Box) int {
+	sum := 0
+	for _, box := range boxes {
+		sum += box.Width
+		sum += box.Padding
+	}
+	return sum
+}
+
+func TotalHeight(boxes []Box) int {
+	total := 0
+	for _, box := range boxes {
+		total += box.Height
+		sum += box.Padding
+	}
+	return total
+}
+`
+	path := filepath.Join(t.TempDir(), "boxes.go")
+	if err := os.WriteFile(path, []byte(source), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	report, err := analyzer.Analyze(context.Background(), []string{path}, analyzer.WithMinLines(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(report.RenameInconsistencies) != 1 {
+		t.Fatalf("expected one inconsistent rename, got %+v", report.RenameInconsistencies)
+	}
+	found := report.RenameInconsistencies[0]
+	if found.Function.Function != "TotalHeight" || found.Line != 16 || found.Expected != "total" || found.Found != "sum" {
+		t.Errorf("unexpected finding %+v", found)
+	}
+	if found.GroupID != report.SimilarGroups[0].ID {
+		t.Errorf("finding refers to group %s, expected %s", found.GroupID, report.SimilarGroups[0].ID)
+	}
+	if report.Violations(analyzer.RuleInconsistentRename) != 1 || report.Violations(analyzer.RuleClones) != 1 {
+		t.Errorf("unexpected violations %d and %d",
+			report.Violations(analyzer.RuleInconsistentRename), report.Violations(analyzer.RuleClones))
+	}
+}
+
+func TestAnalyzeRenameInconsistenciesOncePerSite(t *testing.T) {
+	source := `package sample
+
+func AddInto(into, from []int) {
+	for i := range into {
+		into[i] += from[i]
+		into[i] *= 2
+		into[i] -= from[i]
+	}
+}
+
+func AddFrom(dst, src []int) {
+	for i := range dst {
+		dst[i] += src[i]
+		dst[i] *= 2
+		src[i] -= src[i]
+	}
+}
+`
+	path := filepath.Join(t.TempDir(), "add.go")
+	if err := os.WriteFile(path, []byte(source), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	report, err := analyzer.Analyze(context.Background(), []string{path}, analyzer.WithMinLines(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The site is found in both directions, but only reported in the copy with the bug
+	if report.Violations(analyzer.RuleInconsistentRename) != 1 {
+		t.Fatalf("expected one inconsistent rename, got %+v", report.RenameInconsistencies)
+	}
+	found := report.RenameInconsistencies[0]
+	if found.Function.Function != "AddFrom" || found.Line != 15 || found.OriginalLine != 7 ||
+		found.Expected != "dst" || found.Found != "src" {
+		t.Errorf("unexpected finding %+v", found)
+	}
+}
+
+func TestAnalyzeGenericExtraction(t *testing.T) {
+	source := "package sample\n"
+	for _, typ := range []string{"int", "int64", "float64"} {
+		source += strings.ReplaceAll(`
+func Sum_T_Values(values []T) T {
+	var total T
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+`, "T", typ)
+	}
+
+	path := filepath.Join(t.TempDir(), "sum.go")
+	if err := os.WriteFile(path, []byte(source), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	// Type variants are grouped even though their different signatures lower their scores
+	report, err := analyzer.Analyze(context.Background(), []string{path}, analyzer.WithMinLines(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Copies that only differ by types are replaced by a generic function
+	if len(report.SimilarGroups) != 1 || len(report.SimilarGroups[0].Functions) != 3 {
+		t.Fatalf("expected one group of 3 functions, got %+v", report.SimilarGroups)
+	}
+	extraction := report.SimilarGroups[0].Extraction
+	if extraction == nil || !extraction.Generic ||
+		extraction.Signature != "func sum[T ~int | ~int64 | ~float64](values []T) T" {
+		t.Errorf("unexpected extraction %+v", extraction)
+	}
+}
+
+func TestAnalyzeTypeVariantsBelowThreshold(t *testing.T) {
+	source := "package sample\n"
+	for _, typ := range []string{"int", "float64"} {
+		source += strings.ReplaceAll(`
+func Sum_T_(values []T) T {
+	var total T
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func Max_T_(values []T) T {
+	var total T
+	for _, v := range values {
+		total = max(total, v)
+	}
+	return total
+}
+`, "T", typ)
+	}
+
+	path := filepath.Join(t.TempDir(), "reduce.go")
+	if err := os.WriteFile(path, []byte(source), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	report, err := analyzer.Analyze(context.Background(), []string{path}, analyzer.WithMinLines(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Each function is grouped with its copy for the other type, not only with its
+	// neighbour for the same type
+	generic := make(map[string]bool)
+	for _, group := range report.SimilarGroups {
+		if group.Extraction == nil || !group.Extraction.Generic {
+			continue
+		}
+		var names []string
+		for _, fn := range group.Functions {
+			names = append(names, fn.Function)
+		}
+		generic[strings.Join(names, ",")] = true
+	}
+	if !generic["Sum_int_,Sum_float64_"] || !generic["Max_int_,Max_float64_"] {
+		t.Errorf("expected generic groups for Sum and Max, got %+v", report.SimilarGroups)
+	}
+}
+
+func TestAnalyzeCancelled(t *testing.T) {
+	file := writeTestFile(t, "a.go")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := analyzer.Analyze(ctx, []string{filepath.Dir(file)}, analyzer.WithMinLines(3))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	left := writeTestFile(t, "left.go")
+	right := writeTestFile(t, "right.go")
+
+	a, err := analyzer.New(analyzer.WithMinLines(3), analyzer.WithWorkers(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report, err := a.Compare(context.Background(), []string{left}, []string{right})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.Summary.LeftFunctions != 1 || report.Summary.RightFunctions != 1 || len(report.SimilarGroups) != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	sides := map[string]string{}
+	for _, fn := range report.SimilarGroups[0].Functions {
+		sides[fn.Side] = fn.File
+	}
+	if sides[analyzer.SideLeft] != left || sides[analyzer.SideRight] != right {
+		t.Errorf("expected one function labeled per side, got %v", sides)
+	}
+
+	if _, err := a.Compare(context.Background(), []string{left}, nil); err == nil {
+		t.Error("expected error for missing right targets")
+	}
+}
+
+func TestFindAndExplain(t *testing.T) {
+	file := writeTestFile(t, "a.go")
+	other := writeTestFile(t, "b.go")
+
+	a, err := analyzer.New(analyzer.WithMinLines(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := a.Find(
+		context.Background(),
+		analyzer.Query{Reference: file + ":Sum", Top: 5},
+		[]string{filepath.Dir(file), filepath.Dir(other)},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The query itself is excluded, so only the copy in the other file is reported
+	if result.Summary.TotalFunctions != 1 || len(result.Results) != 1 || result.Results[0].File != other {
+		t.Errorf("unexpected find result %+v", result)
+	}
+
+	if _, err := a.Find(context.Background(), analyzer.Query{}, []string{file}); err == nil {
+		t.Error("expected error for empty query")
+	}
+
+	explanation, err := a.Explain(context.Background(), file+":Sum", other+":Sum")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !explanation.AboveThreshold || explanation.SimilarityScore != 1.0 || len(explanation.Functions) != 2 {
+		t.Errorf("unexpected explanation %+v", explanation)
+	}
+}
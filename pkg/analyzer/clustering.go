@@ -0,0 +1,247 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/config"
+	"github.com/paveg/similarity-go/internal/similarity"
+)
+
+// missingLink marks a pair of clusters with at least one dissimilar cross pair.
+const missingLink = -1.0
+
+// refineGroups splits connected components into tighter groups according to strategy.
+// Functions of different components are never similar, so every component is
+// clustered on its own.
+func refineGroups(
+	components [][]*ast.Function,
+	graph map[string]map[string]similarity.Match,
+	strategy string,
+	threshold float64,
+) [][]*ast.Function {
+	var groups [][]*ast.Function
+
+	for _, component := range components {
+		// Sort members so the clustering does not depend on map iteration order
+		sort.Slice(component, func(i, j int) bool {
+			return functionKey(component[i]) < functionKey(component[j])
+		})
+
+		switch strategy {
+		case config.GroupingCompleteLinkage, config.GroupingAverageLinkage:
+			groups = append(groups, agglomerate(component, graph, strategy, threshold)...)
+		case config.GroupingCliques:
+			groups = append(groups, extractCliques(component, graph)...)
+		default:
+			groups = append(groups, component)
+		}
+	}
+
+	return groups
+}
+
+// agglomerate clusters functions bottom-up, repeatedly merging the two clusters with
+// the strongest linkage. Complete linkage only merges clusters whose cross pairs are
+// all similar and scores them by their weakest pair; average linkage scores them by
+// their mean cross similarity, counting dissimilar pairs as 0, and stops below threshold.
+func agglomerate(
+	functions []*ast.Function,
+	graph map[string]map[string]similarity.Match,
+	strategy string,
+	threshold float64,
+) [][]*ast.Function {
+	n := len(functions)
+	clusters := make([][]*ast.Function, n)
+	active := make([]bool, n)
+
+	// sums holds the total and mins the lowest cross similarity between two clusters
+	sums := make([][]float64, n)
+	mins := make([][]float64, n)
+	for i, fn := range functions {
+		clusters[i] = []*ast.Function{fn}
+		active[i] = true
+		sums[i] = make([]float64, n)
+		mins[i] = make([]float64, n)
+		for j, other := range functions {
+			mins[i][j] = missingLink
+			if match, exists := graph[functionKey(fn)][functionKey(other)]; exists {
+				sums[i][j] = match.Similarity
+				mins[i][j] = match.Similarity
+			}
+		}
+	}
+
+	linkage := func(i, j int) (float64, bool) {
+		if strategy == config.GroupingCompleteLinkage {
+			return mins[i][j], mins[i][j] != missingLink
+		}
+		average := sums[i][j] / float64(len(clusters[i])*len(clusters[j]))
+		return average, average >= threshold
+	}
+
+	for {
+		bestI, bestJ, best := -1, -1, missingLink
+		for i := range n {
+			if !active[i] {
+				continue
+			}
+			for j := i + 1; j < n; j++ {
+				if !active[j] {
+					continue
+				}
+				if score, ok := linkage(i, j); ok && score > best {
+					bestI, bestJ, best = i, j, score
+				}
+			}
+		}
+
+		if bestI < 0 {
+			break
+		}
+
+		// Merge bestJ into bestI and update its linkage to every other cluster
+		clusters[bestI] = append(clusters[bestI], clusters[bestJ]...)
+		active[bestJ] = false
+		for k := range n {
+			if !active[k] || k == bestI {
+				continue
+			}
+			sums[bestI][k] += sums[bestJ][k]
+			sums[k][bestI] = sums[bestI][k]
+			mins[bestI][k] = min(mins[bestI][k], mins[bestJ][k])
+			mins[k][bestI] = mins[bestI][k]
+		}
+	}
+
+	var groups [][]*ast.Function
+	for i, cluster := range clusters {
+		if active[i] && len(cluster) > 1 {
+			groups = append(groups, cluster)
+		}
+	}
+
+	return groups
+}
+
+// extractCliques partitions functions into sets of mutually similar functions by
+// repeatedly removing the largest one. Among cliques of the same size, the one with
+// the highest cohesion wins.
+func extractCliques(functions []*ast.Function, graph map[string]map[string]similarity.Match) [][]*ast.Function {
+	remaining := functions
+	var groups [][]*ast.Function
+
+	for len(remaining) > 1 {
+		clique := largestClique(remaining, graph)
+		if len(clique) < 2 {
+			break
+		}
+
+		inClique := make(map[int]bool, len(clique))
+		group := make([]*ast.Function, 0, len(clique))
+		for _, index := range clique {
+			inClique[index] = true
+			group = append(group, remaining[index])
+		}
+		groups = append(groups, group)
+
+		var rest []*ast.Function
+		for i, fn := range remaining {
+			if !inClique[i] {
+				rest = append(rest, fn)
+			}
+		}
+		remaining = rest
+	}
+
+	return groups
+}
+
+// largestClique returns the indexes of the largest clique among functions, found with
+// the Bron-Kerbosch algorithm with pivoting.
+func largestClique(functions []*ast.Function, graph map[string]map[string]similarity.Match) []int {
+	n := len(functions)
+	similarities := make([][]float64, n)
+	for i, fn := range functions {
+		similarities[i] = make([]float64, n)
+		for j, other := range functions {
+			similarities[i][j] = missingLink
+			if match, exists := graph[functionKey(fn)][functionKey(other)]; exists {
+				similarities[i][j] = match.Similarity
+			}
+		}
+	}
+
+	adjacent := func(i, j int) bool { return similarities[i][j] != missingLink }
+
+	cohesion := func(clique []int) float64 {
+		lowest := 1.0
+		for i, a := range clique {
+			for _, b := range clique[i+1:] {
+				lowest = min(lowest, similarities[a][b])
+			}
+		}
+		return lowest
+	}
+
+	var best []int
+	bestCohesion := missingLink
+
+	var search func(clique, candidates, excluded []int)
+	search = func(clique, candidates, excluded []int) {
+		if len(candidates) == 0 && len(excluded) == 0 {
+			if len(clique) < len(best) {
+				return
+			}
+			if score := cohesion(clique); len(clique) > len(best) || score > bestCohesion {
+				best = append([]int(nil), clique...)
+				bestCohesion = score
+			}
+			return
+		}
+
+		// Only branch on candidates that are not neighbors of the pivot
+		pivot := append(append([]int(nil), candidates...), excluded...)[0]
+		for _, candidate := range append([]int(nil), candidates...) {
+			if adjacent(pivot, candidate) {
+				continue
+			}
+
+			var nextCandidates, nextExcluded []int
+			for _, other := range candidates {
+				if adjacent(candidate, other) {
+					nextCandidates = append(nextCandidates, other)
+				}
+			}
+			for _, other := range excluded {
+				if adjacent(candidate, other) {
+					nextExcluded = append(nextExcluded, other)
+				}
+			}
+
+			search(append(clique, candidate), nextCandidates, nextExcluded)
+
+			candidates = removeIndex(candidates, candidate)
+			excluded = append(excluded, candidate)
+		}
+	}
+
+	all := make([]int, n)
+	for i := range all {
+		all[i] = i
+	}
+	search(nil, all, nil)
+
+	return best
+}
+
+// removeIndex returns indexes without value.
+func removeIndex(indexes []int, value int) []int {
+	result := make([]int, 0, len(indexes))
+	for _, index := range indexes {
+		if index != value {
+			result = append(result, index)
+		}
+	}
+	return result
+}
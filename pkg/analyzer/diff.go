@@ -0,0 +1,247 @@
+package analyzer
+
+import (
+	"sort"
+)
+
+// Statuses of a group in a report diff.
+const (
+	DiffNew       = "new"
+	DiffResolved  = "resolved"
+	DiffGrown     = "grown"
+	DiffShrunk    = "shrunk"
+	DiffChanged   = "changed"
+	DiffUnchanged = "unchanged"
+)
+
+// ReportDiff is the result of comparing two reports of the same codebase.
+type ReportDiff struct {
+	SchemaVersion string        `json:"schema_version" yaml:"schema_version" doc:"Version of the report schema (major.minor)."`
+	Summary       DiffSummary   `json:"summary" yaml:"summary" doc:"Aggregate numbers about the diff."`
+	Groups        []GroupChange `json:"groups" yaml:"groups" doc:"Every group of either report with its status."`
+}
+
+// DiffSummary contains aggregate numbers about a report diff.
+type DiffSummary struct {
+	OldGroups       int `json:"old_groups" yaml:"old_groups" doc:"Number of groups in the old report."`
+	NewGroups       int `json:"new_groups" yaml:"new_groups" doc:"Number of groups in the new report."`
+	OldDuplications int `json:"old_duplications" yaml:"old_duplications" doc:"Duplicated functions in the old report."`
+	NewDuplications int `json:"new_duplications" yaml:"new_duplications" doc:"Duplicated functions in the new report."`
+	New             int `json:"new" yaml:"new" doc:"Groups only found in the new report."`
+	Resolved        int `json:"resolved" yaml:"resolved" doc:"Groups only found in the old report."`
+	Grown           int `json:"grown" yaml:"grown" doc:"Groups that gained members."`
+	Shrunk          int `json:"shrunk" yaml:"shrunk" doc:"Groups that lost members."`
+	Changed         int `json:"changed" yaml:"changed" doc:"Groups that replaced members without changing size."`
+	Unchanged       int `json:"unchanged" yaml:"unchanged" doc:"Groups with the same members."`
+}
+
+// GroupChange describes how one group changed between two reports.
+type GroupChange struct {
+	Status          string        `json:"status" yaml:"status" doc:"One of new, resolved, grown, shrunk, changed or unchanged."`
+	OldID           string        `json:"old_id,omitempty" yaml:"old_id,omitempty" doc:"ID of the group in the old report."`
+	NewID           string        `json:"new_id,omitempty" yaml:"new_id,omitempty" doc:"ID of the group in the new report."`
+	OldSize         int           `json:"old_size" yaml:"old_size" doc:"Number of members in the old report."`
+	NewSize         int           `json:"new_size" yaml:"new_size" doc:"Number of members in the new report."`
+	SimilarityScore float64       `json:"similarity_score" yaml:"similarity_score" doc:"Latest similarity of the group."`
+	Functions       []FunctionRef `json:"functions" yaml:"functions" doc:"Latest members of the group."`
+	Added           []FunctionRef `json:"added,omitempty" yaml:"added,omitempty" doc:"Members only found in the new report."`
+	Removed         []FunctionRef `json:"removed,omitempty" yaml:"removed,omitempty" doc:"Members only found in the old report."`
+}
+
+// DiffReports matches the groups of two reports and classifies each of them.
+//
+// Groups are matched through the fingerprints of their members, which do not depend
+// on file paths or line numbers, so moving code around does not create spurious
+// changes. Each old group is paired with at most one new group, preferring the pairs
+// sharing the most members. Reports written before fingerprints existed fall back to
+// matching members by file and function name.
+func DiffReports(oldReport, newReport *Report) *ReportDiff {
+	pairs := matchGroups(oldReport.SimilarGroups, newReport.SimilarGroups, memberKey)
+
+	matchedOld := make(map[int]bool, len(pairs))
+	matchedNew := make(map[int]bool, len(pairs))
+	for _, pair := range pairs {
+		matchedOld[pair.oldIndex] = true
+		matchedNew[pair.newIndex] = true
+	}
+
+	diff := &ReportDiff{
+		SchemaVersion: SchemaVersion,
+		Summary: DiffSummary{
+			OldGroups:       len(oldReport.SimilarGroups),
+			NewGroups:       len(newReport.SimilarGroups),
+			OldDuplications: oldReport.Summary.TotalDuplications,
+			NewDuplications: newReport.Summary.TotalDuplications,
+		},
+	}
+
+	// Report groups in the order of the new report, then the resolved ones
+	newToOld := make(map[int]int, len(pairs))
+	for _, pair := range pairs {
+		newToOld[pair.newIndex] = pair.oldIndex
+	}
+
+	for newIndex, group := range newReport.SimilarGroups {
+		if !matchedNew[newIndex] {
+			diff.Groups = append(diff.Groups, GroupChange{
+				Status:          DiffNew,
+				NewID:           group.ID,
+				NewSize:         len(group.Functions),
+				SimilarityScore: group.SimilarityScore,
+				Functions:       group.Functions,
+				Added:           group.Functions,
+			})
+			continue
+		}
+
+		diff.Groups = append(diff.Groups, compareGroups(oldReport.SimilarGroups[newToOld[newIndex]], group))
+	}
+
+	for oldIndex, group := range oldReport.SimilarGroups {
+		if matchedOld[oldIndex] {
+			continue
+		}
+		diff.Groups = append(diff.Groups, GroupChange{
+			Status:          DiffResolved,
+			OldID:           group.ID,
+			OldSize:         len(group.Functions),
+			SimilarityScore: group.SimilarityScore,
+			Functions:       group.Functions,
+			Removed:         group.Functions,
+		})
+	}
+
+	for _, change := range diff.Groups {
+		switch change.Status {
+		case DiffNew:
+			diff.Summary.New++
+		case DiffResolved:
+			diff.Summary.Resolved++
+		case DiffGrown:
+			diff.Summary.Grown++
+		case DiffShrunk:
+			diff.Summary.Shrunk++
+		case DiffChanged:
+			diff.Summary.Changed++
+		case DiffUnchanged:
+			diff.Summary.Unchanged++
+		}
+	}
+
+	return diff
+}
+
+// groupPair is an old group matched with a new group.
+type groupPair struct {
+	oldIndex int
+	newIndex int
+	overlap  int
+}
+
+// matchGroups pairs old and new groups greedily by the number of members sharing a key.
+func matchGroups(oldGroups, newGroups []Group, key func(FunctionRef) string) []groupPair {
+	oldMembers := make([]map[string]int, len(oldGroups))
+	for i, group := range oldGroups {
+		oldMembers[i] = memberCounts(group.Functions, key)
+	}
+
+	var candidates []groupPair
+	for newIndex, group := range newGroups {
+		newMembers := memberCounts(group.Functions, key)
+		for oldIndex := range oldGroups {
+			if overlap := sharedMembers(oldMembers[oldIndex], newMembers); overlap > 0 {
+				candidates = append(candidates, groupPair{oldIndex: oldIndex, newIndex: newIndex, overlap: overlap})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].overlap > candidates[j].overlap
+	})
+
+	usedOld := make(map[int]bool)
+	usedNew := make(map[int]bool)
+	var pairs []groupPair
+	for _, candidate := range candidates {
+		if usedOld[candidate.oldIndex] || usedNew[candidate.newIndex] {
+			continue
+		}
+		usedOld[candidate.oldIndex] = true
+		usedNew[candidate.newIndex] = true
+		pairs = append(pairs, candidate)
+	}
+
+	return pairs
+}
+
+// compareGroups classifies a matched pair of groups and lists the members that changed.
+func compareGroups(oldGroup, newGroup Group) GroupChange {
+	change := GroupChange{
+		Status:          DiffUnchanged,
+		OldID:           oldGroup.ID,
+		NewID:           newGroup.ID,
+		OldSize:         len(oldGroup.Functions),
+		NewSize:         len(newGroup.Functions),
+		SimilarityScore: newGroup.SimilarityScore,
+		Functions:       newGroup.Functions,
+		Added:           missingMembers(newGroup.Functions, memberCounts(oldGroup.Functions, memberKey)),
+		Removed:         missingMembers(oldGroup.Functions, memberCounts(newGroup.Functions, memberKey)),
+	}
+
+	switch {
+	case change.NewSize > change.OldSize:
+		change.Status = DiffGrown
+	case change.NewSize < change.OldSize:
+		change.Status = DiffShrunk
+	case len(change.Added) > 0 || len(change.Removed) > 0:
+		change.Status = DiffChanged
+	}
+
+	return change
+}
+
+// memberKey identifies a function across reports.
+func memberKey(fn FunctionRef) string {
+	if fn.Fingerprint != "" {
+		return fn.Fingerprint
+	}
+	return fn.File + ":" + fn.DisplayName()
+}
+
+// memberCounts counts the members of a group by key. Identical copies share a memberKey.
+func memberCounts(functions []FunctionRef, key func(FunctionRef) string) map[string]int {
+	counts := make(map[string]int, len(functions))
+	for _, fn := range functions {
+		counts[key(fn)]++
+	}
+	return counts
+}
+
+// sharedMembers returns the size of the intersection of two member multisets.
+func sharedMembers(a, b map[string]int) int {
+	shared := 0
+	for key, count := range a {
+		shared += min(count, b[key])
+	}
+	return shared
+}
+
+// missingMembers returns the functions whose keys are not covered by counts.
+func missingMembers(functions []FunctionRef, counts map[string]int) []FunctionRef {
+	remaining := make(map[string]int, len(counts))
+	for key, count := range counts {
+		remaining[key] = count
+	}
+
+	var missing []FunctionRef
+	for _, fn := range functions {
+		key := memberKey(fn)
+		if remaining[key] > 0 {
+			remaining[key]--
+			continue
+		}
+		missing = append(missing, fn)
+	}
+
+	return missing
+}
@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"testing"
+)
+
+// diffTestGroup builds a group whose members are identified by fingerprint.
+func diffTestGroup(id string, fingerprints ...string) Group {
+	functions := make([]FunctionRef, 0, len(fingerprints))
+	for i, fingerprint := range fingerprints {
+		functions = append(functions, FunctionRef{
+			File:        "file.go",
+			Function:    fingerprint,
+			StartLine:   i * 10,
+			Fingerprint: fingerprint,
+		})
+	}
+	return Group{ID: id, Functions: functions}
+}
+
+func TestDiffReports(t *testing.T) {
+	oldReport := &Report{
+		Summary: Summary{TotalDuplications: 9},
+		SimilarGroups: []Group{
+			diffTestGroup("group_1", "a", "b"),
+			diffTestGroup("group_2", "c", "d", "e"),
+			diffTestGroup("group_3", "f", "g"),
+			diffTestGroup("group_4", "x", "y"),
+		},
+	}
+	newReport := &Report{
+		Summary: Summary{TotalDuplications: 9},
+		SimilarGroups: []Group{
+			diffTestGroup("group_1", "c", "d"),      // shrunk from group_2
+			diffTestGroup("group_2", "a", "b", "h"), // grown from group_1
+			diffTestGroup("group_3", "g", "f"),      // unchanged, members reordered
+			diffTestGroup("group_4", "i", "j"),      // new
+		},
+	}
+
+	diff := DiffReports(oldReport, newReport)
+
+	expected := []struct {
+		status string
+		oldID  string
+		newID  string
+	}{
+		{status: DiffShrunk, oldID: "group_2", newID: "group_1"},
+		{status: DiffGrown, oldID: "group_1", newID: "group_2"},
+		{status: DiffUnchanged, oldID: "group_3", newID: "group_3"},
+		{status: DiffNew, newID: "group_4"},
+		{status: DiffResolved, oldID: "group_4"},
+	}
+
+	if len(diff.Groups) != len(expected) {
+		t.Fatalf("expected %d group changes, got %d", len(expected), len(diff.Groups))
+	}
+	for i, want := range expected {
+		got := diff.Groups[i]
+		if got.Status != want.status || got.OldID != want.oldID || got.NewID != want.newID {
+			t.Errorf("change %d: expected %+v, got %s %s -> %s", i, want, got.Status, got.OldID, got.NewID)
+		}
+	}
+
+	grown := diff.Groups[1]
+	if len(grown.Added) != 1 || grown.Added[0].Fingerprint != "h" || len(grown.Removed) != 0 {
+		t.Errorf("expected h to be added to the grown group, got %+v", grown)
+	}
+
+	shrunk := diff.Groups[0]
+	if len(shrunk.Removed) != 1 || shrunk.Removed[0].Fingerprint != "e" {
+		t.Errorf("expected e to be removed from the shrunk group, got %+v", shrunk)
+	}
+
+	summary := diff.Summary
+	if summary.New != 1 || summary.Resolved != 1 || summary.Grown != 1 || summary.Shrunk != 1 || summary.Unchanged != 1 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+}
+
+func TestDiffReportsIgnoresMovedFunctions(t *testing.T) {
+	oldGroup := diffTestGroup("group_1", "a", "b")
+	newGroup := diffTestGroup("group_7", "a", "b")
+	for i := range newGroup.Functions {
+		newGroup.Functions[i].File = "moved/file.go"
+		newGroup.Functions[i].StartLine += 100
+	}
+
+	diff := DiffReports(&Report{SimilarGroups: []Group{oldGroup}}, &Report{SimilarGroups: []Group{newGroup}})
+
+	if diff.Summary.Unchanged != 1 || len(diff.Groups[0].Added) != 0 || len(diff.Groups[0].Removed) != 0 {
+		t.Errorf("expected moved group to be unchanged, got %+v", diff.Groups)
+	}
+}
+
+func TestDiffReportsIdenticalCopies(t *testing.T) {
+	// Identical copies share a fingerprint; removing one of three must still shrink the group
+	oldReport := &Report{SimilarGroups: []Group{diffTestGroup("group_1", "a", "a", "a")}}
+	newReport := &Report{SimilarGroups: []Group{diffTestGroup("group_1", "a", "a")}}
+
+	diff := DiffReports(oldReport, newReport)
+
+	if diff.Summary.Shrunk != 1 || len(diff.Groups[0].Removed) != 1 {
+		t.Errorf("expected one copy to be removed, got %+v", diff.Groups)
+	}
+}
+
+func TestDiffReportsReplacedMembers(t *testing.T) {
+	// A member swapped for another keeps the size of the group but is not unchanged
+	oldReport := &Report{SimilarGroups: []Group{diffTestGroup("group_1", "a", "b", "c")}}
+	newReport := &Report{SimilarGroups: []Group{diffTestGroup("group_1", "a", "b", "d")}}
+
+	diff := DiffReports(oldReport, newReport)
+
+	change := diff.Groups[0]
+	if change.Status != DiffChanged || diff.Summary.Changed != 1 || diff.Summary.Unchanged != 0 {
+		t.Fatalf("expected the group to be changed, got %+v (summary %+v)", change, diff.Summary)
+	}
+	if len(change.Added) != 1 || change.Added[0].Fingerprint != "d" ||
+		len(change.Removed) != 1 || change.Removed[0].Fingerprint != "c" {
+		t.Errorf("expected d to replace c, got %+v", change)
+	}
+}
@@ -0,0 +1,40 @@
+// Package analyzer is the stable library API of similarity-go. It finds similar
+// Go functions the same way the command line tool does, returning typed results
+// instead of writing JSON or YAML, so the detector can be embedded in linters,
+// editor plugins and CI bots.
+//
+// An Analyzer is configured with functional options that mirror the sections of
+// the YAML configuration file. Every option starts from the defaults, and the
+// resulting configuration is validated by New.
+//
+// Operations:
+//   - Analyze: compare every function in the targets with every other one
+//   - Compare: compare only functions from a left set with functions from a right set
+//   - Find: rank the functions most similar to one query function or snippet
+//   - Explain: break down the similarity score of two functions
+//
+// All operations accept a context.Context; cancelling it stops directory scanning
+// and parallel comparisons and returns the context error.
+//
+// Example Usage:
+//
+//	a, err := analyzer.New(
+//		analyzer.WithThreshold(0.85),
+//		analyzer.WithWorkers(4),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//
+//	report, err := a.Analyze(ctx, []string{"./internal/..."})
+//	if err != nil {
+//		return err
+//	}
+//
+//	for _, group := range report.SimilarGroups {
+//		fmt.Println(group.ID, group.SimilarityScore, len(group.Functions))
+//	}
+//
+// Exported types and options follow semantic versioning: fields and options may
+// be added, but existing ones are not removed or changed within a major version.
+package analyzer
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"context"
+
+	"github.com/paveg/similarity-go/internal/ast"
+)
+
+// Explain computes the similarity of two functions given as "file.go:FunctionName"
+// references and reports every step of the calculation.
+func (a *Analyzer) Explain(ctx context.Context, reference1, reference2 string) (*Explanation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	parser := ast.NewParser()
+
+	func1, err := loadFunctionReference(parser, reference1)
+	if err != nil {
+		return nil, err
+	}
+
+	func2, err := loadFunctionReference(parser, reference2)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation := a.newDetector().Explain(func1, func2)
+	threshold := a.config.CLI.DefaultThreshold
+
+	result := &Explanation{
+		SchemaVersion:   SchemaVersion,
+		Functions:       []FunctionRef{newFunctionRef(func1), newFunctionRef(func2)},
+		SimilarityScore: explanation.Similarity,
+		Threshold:       threshold,
+		AboveThreshold:  explanation.Similarity >= threshold,
+		Shortcut:        explanation.Shortcut,
+		WeightedScore:   explanation.Weighted,
+		Prefilters:      make([]PrefilterCheck, 0, len(explanation.Prefilters)),
+		Components:      make([]ComponentScore, 0, len(explanation.Components)),
+		Alignment:       make([]AlignedLine, 0, len(explanation.Alignment)),
+	}
+
+	for _, check := range explanation.Prefilters {
+		result.Prefilters = append(result.Prefilters, PrefilterCheck(check))
+	}
+	for _, component := range explanation.Components {
+		result.Components = append(result.Components, ComponentScore(component))
+	}
+	for _, line := range explanation.Alignment {
+		result.Alignment = append(result.Alignment, AlignedLine(line))
+	}
+
+	return result, nil
+}
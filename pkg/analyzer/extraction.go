@@ -0,0 +1,69 @@
+package analyzer
+
+import (
+	goast "go/ast"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/refactor"
+)
+
+// extractGroup proposes a shared function replacing members, whose references are
+// functions in the same order. It returns nil when a member has no syntax tree, as after
+// Merge when its file cannot be parsed again, or when the members cannot be replaced by
+// a single function. The extraction is generic when the members only differ by types
+// and share a type shape.
+func (a *Analyzer) extractGroup(members []*ast.Function, functions []FunctionRef) *Extraction {
+	decls := make([]*goast.FuncDecl, len(members))
+	for i, fn := range members {
+		if fn.AST == nil {
+			return nil
+		}
+		decls[i] = fn.AST
+	}
+
+	extracted, err := refactor.Extract(decls)
+	if err != nil {
+		a.logf("No extraction for %s and %d similar functions: %v", functions[0].DisplayName(), len(functions)-1, err)
+		return nil
+	}
+
+	extraction := &Extraction{
+		Name:       extracted.Name,
+		Signature:  extracted.Signature,
+		Body:       extracted.Body,
+		Parameters: make([]ExtractedParameter, 0, len(extracted.Parameters)),
+		CallSites:  make([]CallSite, 0, len(extracted.Calls)),
+		Generic:    extracted.Generic && a.sameTypeShape(members),
+		Imports:    extracted.Imports,
+	}
+	for _, param := range extracted.Parameters {
+		extraction.Parameters = append(extraction.Parameters, ExtractedParameter{
+			Name:   param.Name,
+			Type:   param.Type,
+			Kind:   param.Kind,
+			Values: param.Values,
+		})
+	}
+	for i, call := range extracted.Calls {
+		extraction.CallSites = append(extraction.CallSites, CallSite{
+			File:     functions[i].File,
+			Function: functions[i].DisplayName(),
+			Call:     call,
+		})
+	}
+
+	return extraction
+}
+
+// sameTypeShape reports whether members have the same signature up to a consistent
+// substitution of basic types, as copies of a function written for int and float64.
+func (a *Analyzer) sameTypeShape(members []*ast.Function) bool {
+	detector := a.newDetector()
+	shape := detector.TypeShape(members[0])
+	for _, fn := range members[1:] {
+		if detector.TypeShape(fn) != shape {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,188 @@
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	goast "go/ast"
+	"go/format"
+	"go/token"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/paveg/similarity-go/internal/ast"
+)
+
+// Strategies proposed to consolidate a method family.
+const (
+	// FamilyStrategyInterface declares an interface for the method and moves the shared
+	// logic into a helper.
+	FamilyStrategyInterface = "interface"
+	// FamilyStrategyEmbedding moves the fields the methods use into a struct embedded in
+	// every type, and declares the method once on it.
+	FamilyStrategyEmbedding = "embedding"
+)
+
+// methodFamily returns the method family formed by members, or nil when they are not all
+// methods sharing a name on distinct receiver types.
+func methodFamily(members []*ast.Function) *MethodFamily {
+	receivers := make(map[string]bool, len(members))
+	for _, fn := range members {
+		if fn.Receiver == "" || fn.Name != members[0].Name {
+			return nil
+		}
+
+		key := fn.ImportPath + "\x00" + fn.Package + "\x00" + strings.TrimPrefix(fn.Receiver, "*")
+		if receivers[key] {
+			return nil
+		}
+		receivers[key] = true
+	}
+
+	family := &MethodFamily{
+		Method:    members[0].Name,
+		Types:     familyTypes(members),
+		Strategy:  FamilyStrategyInterface,
+		Interface: familyInterface(members[0]),
+	}
+	if fields, ok := sharedReceiverFields(members); ok {
+		family.Strategy = FamilyStrategyEmbedding
+		family.Fields = fields
+	}
+
+	return family
+}
+
+// familyTypes lists the receiver types of members, qualified by their package when the
+// members span several packages.
+func familyTypes(members []*ast.Function) []string {
+	qualify := false
+	for _, fn := range members {
+		qualify = qualify || fn.Package != members[0].Package || fn.ImportPath != members[0].ImportPath
+	}
+
+	types := make([]string, len(members))
+	for i, fn := range members {
+		types[i] = fn.Receiver
+		if qualify && fn.Package != "" {
+			name := strings.TrimPrefix(fn.Receiver, "*")
+			types[i] = strings.TrimSuffix(fn.Receiver, name) + fn.Package + "." + name
+		}
+	}
+	return types
+}
+
+// familyInterface renders the declaration of an interface made of the method of fn, named
+// after the method as Go interfaces with a single method usually are.
+func familyInterface(fn *ast.Function) string {
+	signature := "()"
+	if fn.AST != nil {
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), fn.AST.Type); err == nil {
+			signature = strings.TrimPrefix(buf.String(), "func")
+		}
+	}
+
+	return fmt.Sprintf("type %s interface {\n\t%s%s\n}", interfaceName(fn.Name), fn.Name, signature)
+}
+
+// interfaceName names a single-method interface after its method, such as Validator for
+// Validate or Closer for Close.
+func interfaceName(method string) string {
+	switch {
+	case strings.HasSuffix(method, "er"):
+		return method
+	case strings.HasSuffix(method, "ate"):
+		return strings.TrimSuffix(method, "e") + "or"
+	case strings.HasSuffix(method, "e"):
+		return method + "r"
+	default:
+		return method + "er"
+	}
+}
+
+// sharedReceiverFields returns the receiver fields every member uses, when all members use
+// the same fields and use their receiver for nothing else, so that the fields and the
+// method can move into an embedded struct. It reports false otherwise, or when a member
+// has no syntax tree, as after Merge.
+func sharedReceiverFields(members []*ast.Function) ([]string, bool) {
+	var shared []string
+	for i, fn := range members {
+		fields, ok := receiverFields(fn.AST)
+		if !ok || len(fields) == 0 {
+			return nil, false
+		}
+		if i > 0 && !slices.Equal(fields, shared) {
+			return nil, false
+		}
+		shared = fields
+	}
+	return shared, true
+}
+
+// receiverFields returns the sorted receiver fields a method reads or writes. It reports
+// false when the method calls a method of its receiver or uses the receiver itself, as
+// neither would move with the fields.
+func receiverFields(decl *goast.FuncDecl) ([]string, bool) {
+	if decl == nil || decl.Body == nil || decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return nil, false
+	}
+	names := decl.Recv.List[0].Names
+	if len(names) == 0 || names[0].Name == "_" {
+		return nil, true
+	}
+	receiver := names[0].Name
+
+	fields := make(map[string]bool)
+	selected := make(map[*goast.Ident]bool)
+	ok := true
+	goast.Inspect(decl.Body, func(n goast.Node) bool {
+		switch node := n.(type) {
+		case *goast.CallExpr:
+			if sel, isSelector := node.Fun.(*goast.SelectorExpr); isSelector && isIdent(sel.X, receiver) {
+				ok = false
+			}
+		case *goast.SelectorExpr:
+			if ident, isName := node.X.(*goast.Ident); isName && ident.Name == receiver {
+				fields[node.Sel.Name] = true
+				selected[ident] = true
+			}
+		case *goast.Ident:
+			if node.Name == receiver && !selected[node] {
+				ok = false
+			}
+		}
+		return ok
+	})
+	if !ok {
+		return nil, false
+	}
+
+	result := make([]string, 0, len(fields))
+	for field := range fields {
+		result = append(result, field)
+	}
+	sort.Strings(result)
+	return result, true
+}
+
+// familySuggestion describes how to consolidate a method family.
+func familySuggestion(family *MethodFamily) string {
+	types := strings.Join(family.Types, ", ")
+	if family.Strategy == FamilyStrategyEmbedding {
+		return fmt.Sprintf(
+			"Move fields %s into a struct embedded in %s and declare %s once on it",
+			strings.Join(family.Fields, ", "), types, family.Method,
+		)
+	}
+	return fmt.Sprintf(
+		"Declare interface %s for %s on %s and move their shared logic into a helper",
+		interfaceName(family.Method), family.Method, types,
+	)
+}
+
+// isIdent reports whether expr is the identifier name.
+func isIdent(expr goast.Expr, name string) bool {
+	ident, ok := expr.(*goast.Ident)
+	return ok && ident.Name == name
+}
@@ -0,0 +1,141 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/paveg/similarity-go/internal/ast"
+)
+
+// parseMethods parses source and returns its functions.
+func parseMethods(t *testing.T, source string) []*ast.Function {
+	t.Helper()
+
+	result := ast.NewParser().ParseSource("family.go", []byte("package api\n"+source))
+	if result.IsErr() {
+		t.Fatalf("failed to parse: %v", result.Error())
+	}
+	return result.Unwrap().Functions
+}
+
+func TestMethodFamily(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		want      *MethodFamily
+		notFamily bool
+	}{
+		{
+			name: "same fields",
+			src: `
+func (r *CreateRequest) Validate() error {
+	if r.Name == "" || r.Email == "" {
+		return errMissing
+	}
+	return nil
+}
+
+func (r *UpdateRequest) Validate() error {
+	if r.Name == "" || r.Email == "" {
+		return errMissing
+	}
+	return nil
+}`,
+			want: &MethodFamily{
+				Method:    "Validate",
+				Types:     []string{"*CreateRequest", "*UpdateRequest"},
+				Strategy:  FamilyStrategyEmbedding,
+				Interface: "type Validator interface {\n\tValidate() error\n}",
+				Fields:    []string{"Email", "Name"},
+			},
+		},
+		{
+			name: "receiver used beyond its fields",
+			src: `
+func (c *Client) Close(ctx context.Context) error {
+	c.conn.Close()
+	return c.flush(ctx)
+}
+
+func (s Server) Close(ctx context.Context) error {
+	s.conn.Close()
+	return s.flush(ctx)
+}`,
+			want: &MethodFamily{
+				Method:    "Close",
+				Types:     []string{"*Client", "Server"},
+				Strategy:  FamilyStrategyInterface,
+				Interface: "type Closer interface {\n\tClose(ctx context.Context) error\n}",
+			},
+		},
+		{
+			name: "different names",
+			src: `
+func (c *Client) Open() error { return nil }
+func (c *Server) Close() error { return nil }`,
+			notFamily: true,
+		},
+		{
+			name: "same receiver type",
+			src: `
+func (c *Client) Close() error { return nil }
+func (c Client) Close() error { return nil }`,
+			notFamily: true,
+		},
+		{
+			name: "function",
+			src: `
+func (c *Client) Close() error { return nil }
+func Close() error { return nil }`,
+			notFamily: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			family := methodFamily(parseMethods(t, tt.src))
+			if tt.notFamily {
+				if family != nil {
+					t.Errorf("expected no family, got %+v", family)
+				}
+				return
+			}
+			if !reflect.DeepEqual(family, tt.want) {
+				t.Errorf("family = %+v, want %+v", family, tt.want)
+			}
+		})
+	}
+}
+
+func TestMethodFamilyAcrossPackages(t *testing.T) {
+	members := []*ast.Function{
+		{Name: "Validate", Receiver: "*Request", Package: "users"},
+		{Name: "Validate", Receiver: "Request", Package: "orders"},
+	}
+
+	family := methodFamily(members)
+	if family == nil {
+		t.Fatal("expected a family for types of different packages")
+	}
+	if want := []string{"*users.Request", "orders.Request"}; !reflect.DeepEqual(family.Types, want) {
+		t.Errorf("types = %v, want %v", family.Types, want)
+	}
+
+	// Without syntax trees, as after Merge, fields are unknown
+	if family.Strategy != FamilyStrategyInterface {
+		t.Errorf("strategy = %s, want %s", family.Strategy, FamilyStrategyInterface)
+	}
+}
+
+func TestInterfaceName(t *testing.T) {
+	for method, want := range map[string]string{
+		"Validate": "Validator",
+		"Close":    "Closer",
+		"Read":     "Reader",
+		"Handler":  "Handler",
+	} {
+		if got := interfaceName(method); got != want {
+			t.Errorf("interfaceName(%s) = %s, want %s", method, got, want)
+		}
+	}
+}
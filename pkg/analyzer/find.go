@@ -0,0 +1,193 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/paveg/similarity-go/internal/ast"
+)
+
+const (
+	// snippetFileName is the file name reported for query snippets.
+	snippetFileName = "<stdin>"
+	// snippetPackageClause is prepended to snippets that lack a package clause.
+	snippetPackageClause = "package snippet\n\n"
+)
+
+// Query describes the function Find looks for. Exactly one of Reference or Snippet must be set.
+type Query struct {
+	// Reference is a "path/to/file.go:FunctionName" reference to an existing function.
+	// Methods may be qualified by their receiver type, as in "client.go:(*Client).Close"
+	// or "client.go:Client.Close".
+	Reference string
+	// Snippet is Go source containing the function; the package clause may be omitted.
+	Snippet []byte
+	// Top is the maximum number of neighbors returned; zero or less returns all of them.
+	Top int
+	// MinSimilarity drops neighbors scoring below it; zero keeps every neighbor.
+	MinSimilarity float64
+}
+
+// Find compares the query function with every function in targets and returns
+// its nearest neighbors, most similar first. The query itself is never reported.
+func (a *Analyzer) Find(ctx context.Context, query Query, targets []string) (*FindResult, error) {
+	parser := ast.NewParser()
+
+	queryFunction, err := a.resolveQuery(parser, query)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := a.parseAllTargets(ctx, parser, targets)
+	if err != nil {
+		return nil, err
+	}
+	candidates = excludeFunction(candidates, queryFunction)
+
+	a.logf("Comparing %s against %d functions", queryFunction.Name, len(candidates))
+
+	nearest := a.newDetector().FindMostSimilar(queryFunction, candidates, query.Top, query.MinSimilarity)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]Neighbor, 0, len(nearest))
+	for i, match := range nearest {
+		results = append(results, Neighbor{
+			FunctionRef:     newFunctionRef(match.Function2),
+			Rank:            i + 1,
+			SimilarityScore: match.Similarity,
+		})
+	}
+
+	return &FindResult{
+		SchemaVersion: SchemaVersion,
+		Query:         newFunctionRef(queryFunction),
+		Summary: FindSummary{
+			TotalFunctions: len(candidates),
+			Results:        len(results),
+		},
+		Results: results,
+	}, nil
+}
+
+// resolveQuery returns the function described by query.
+func (a *Analyzer) resolveQuery(parser *ast.Parser, query Query) (*ast.Function, error) {
+	switch {
+	case query.Reference != "" && query.Snippet != nil:
+		return nil, errors.New("query must set either a reference or a snippet, not both")
+	case query.Reference != "":
+		return loadFunctionReference(parser, query.Reference)
+	case query.Snippet != nil:
+		return parseSnippet(parser, query.Snippet)
+	default:
+		return nil, errors.New("query must set a reference or a snippet")
+	}
+}
+
+// loadFunctionReference parses the file of a "file.go:FunctionName" reference and returns the function.
+func loadFunctionReference(parser *ast.Parser, reference string) (*ast.Function, error) {
+	file, name, err := splitFunctionReference(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	result := parser.ParseFile(file)
+	if result.IsErr() {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, result.Error())
+	}
+
+	return selectFunction(result.Unwrap().Functions, file, name)
+}
+
+// splitFunctionReference splits "path/to/file.go:Name" into its file and function name.
+func splitFunctionReference(reference string) (string, string, error) {
+	idx := strings.LastIndex(reference, ":")
+	if idx <= 0 || idx == len(reference)-1 || !strings.HasSuffix(reference[:idx], ".go") {
+		return "", "", fmt.Errorf("invalid function reference %q: expected file.go:FunctionName", reference)
+	}
+
+	return reference[:idx], reference[idx+1:], nil
+}
+
+// selectFunction picks the function with the given name, rejecting missing or ambiguous names.
+// A name qualified by a receiver type only matches methods of that type.
+func selectFunction(functions []*ast.Function, file, name string) (*ast.Function, error) {
+	var found []*ast.Function
+	for _, fn := range functions {
+		if fn.Name == name || strings.Contains(name, ".") && trimReceiverSyntax(fn.MethodName()) == trimReceiverSyntax(name) {
+			found = append(found, fn)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("function %s not found in %s", name, file)
+	case 1:
+		return found[0], nil
+	default:
+		candidates := make([]string, len(found))
+		for i, fn := range found {
+			candidates[i] = fmt.Sprintf("%s at line %d", fn.MethodName(), fn.StartLine)
+		}
+		return nil, fmt.Errorf(
+			"function name %s is ambiguous in %s (declared as %s)",
+			name,
+			file,
+			strings.Join(candidates, ", "),
+		)
+	}
+}
+
+// trimReceiverSyntax removes the parentheses and pointer of a receiver-qualified name, so
+// that (*Client).Close and Client.Close refer to the same method.
+func trimReceiverSyntax(name string) string {
+	return strings.NewReplacer("(", "", ")", "", "*", "").Replace(name)
+}
+
+// parseSnippet parses a snippet and returns its first function.
+// A package clause is added when the snippet does not have one.
+func parseSnippet(parser *ast.Parser, src []byte) (*ast.Function, error) {
+	result := parser.ParseSource(snippetFileName, src)
+	if result.IsErr() {
+		wrapped := append([]byte(snippetPackageClause), src...)
+		result = parser.ParseSource(snippetFileName, wrapped)
+	}
+	if result.IsErr() {
+		return nil, fmt.Errorf("failed to parse snippet: %w", result.Error())
+	}
+
+	functions := result.Unwrap().Functions
+	if len(functions) == 0 {
+		return nil, errors.New("snippet does not contain a function declaration")
+	}
+
+	return functions[0], nil
+}
+
+// excludeFunction removes the function declared at the same location as query, so a
+// query taken from one of the targets is not reported as its own nearest neighbor.
+func excludeFunction(functions []*ast.Function, query *ast.Function) []*ast.Function {
+	queryPath := canonicalPath(query.File)
+
+	result := make([]*ast.Function, 0, len(functions))
+	for _, fn := range functions {
+		if fn.StartLine == query.StartLine && canonicalPath(fn.File) == queryPath {
+			continue
+		}
+		result = append(result, fn)
+	}
+
+	return result
+}
+
+// canonicalPath returns an absolute, cleaned version of path for comparisons.
+func canonicalPath(path string) string {
+	if absPath, err := filepath.Abs(path); err == nil {
+		return absPath
+	}
+	return filepath.Clean(path)
+}
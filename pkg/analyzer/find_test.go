@@ -0,0 +1,92 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/paveg/similarity-go/internal/ast"
+)
+
+func TestSplitFunctionReference(t *testing.T) {
+	tests := []struct {
+		name        string
+		reference   string
+		file        string
+		function    string
+		expectError bool
+	}{
+		{name: "relative path", reference: "./pkg/foo.go:ParseConfig", file: "./pkg/foo.go", function: "ParseConfig"},
+		{name: "windows path", reference: `C:\src\foo.go:Run`, file: `C:\src\foo.go`, function: "Run"},
+		{name: "missing function", reference: "foo.go:", expectError: true},
+		{name: "missing separator", reference: "foo.go", expectError: true},
+		{name: "not a go file", reference: "foo.txt:Run", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, function, err := splitFunctionReference(tt.reference)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error for %q", tt.reference)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if file != tt.file || function != tt.function {
+				t.Errorf("expected %s and %s, got %s and %s", tt.file, tt.function, file, function)
+			}
+		})
+	}
+}
+
+func TestSelectFunctionAmbiguous(t *testing.T) {
+	functions := []*ast.Function{
+		{Name: "Close", StartLine: 3},
+		{Name: "Close", StartLine: 9},
+	}
+
+	if _, err := selectFunction(functions, "client.go", "Close"); err == nil {
+		t.Error("expected error for ambiguous function name")
+	}
+	if _, err := selectFunction(functions, "client.go", "Open"); err == nil {
+		t.Error("expected error for missing function name")
+	}
+}
+
+func TestSelectFunctionReceiver(t *testing.T) {
+	functions := []*ast.Function{
+		{Name: "Close", Receiver: "*Client", StartLine: 3},
+		{Name: "Close", Receiver: "*Server", StartLine: 9},
+		{Name: "String", Receiver: "Point", StartLine: 15},
+	}
+
+	tests := []struct {
+		name string
+		line int
+	}{
+		{name: "(*Client).Close", line: 3},
+		{name: "Server.Close", line: 9},
+		{name: "Point.String", line: 15},
+		{name: "String", line: 15},
+	}
+	for _, tt := range tests {
+		fn, err := selectFunction(functions, "net.go", tt.name)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if fn.StartLine != tt.line {
+			t.Errorf("%s: selected line %d, want %d", tt.name, fn.StartLine, tt.line)
+		}
+	}
+
+	_, err := selectFunction(functions, "net.go", "Close")
+	if err == nil || !strings.Contains(err.Error(), "(*Client).Close at line 3") {
+		t.Errorf("expected ambiguity error listing receivers, got %v", err)
+	}
+	if _, err := selectFunction(functions, "net.go", "Other.Close"); err == nil {
+		t.Error("expected error for a receiver without that method")
+	}
+}
@@ -0,0 +1,225 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/refactor"
+)
+
+// testFileSuffix marks Go test files, whose functions other files cannot call.
+const testFileSuffix = "_test.go"
+
+// FixResult lists the exact clones Fix rewrote to delegate to a canonical copy and the
+// updated source of the files declaring them.
+type FixResult struct {
+	Delegations []Delegation // Rewritten functions, sorted by location
+	Files       []FileChange // Changed files, sorted by path
+}
+
+// Delegation is a function whose body was replaced by a call to an exact clone of it.
+type Delegation struct {
+	Function  FunctionRef // Rewritten function
+	Canonical FunctionRef // Function it now calls
+}
+
+// FileChange is the source of a file before and after Fix.
+type FileChange struct {
+	Path   string
+	Before []byte
+	After  []byte
+}
+
+// Diff returns the change in unified diff format.
+func (c FileChange) Diff() string {
+	return refactor.UnifiedDiff(filepath.ToSlash(c.Path), c.Before, c.After)
+}
+
+// Fix finds exact clones declared in the same package among targets and rewrites every
+// copy but one to delegate to it. Exact clones are Type-1 and Type-2 clones that only
+// differ by formatting, comments, and the names of their local variables and parameters.
+// The canonical copy is the first one by location, preferring files that are not tests.
+// Fix does not write files: the result holds their updated source.
+func (a *Analyzer) Fix(ctx context.Context, targets []string) (*FixResult, error) {
+	parser := ast.NewParser()
+
+	functions, err := a.parseAllTargets(ctx, parser, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	a.logf("Found %d functions, looking for exact clones", len(functions))
+
+	packages := make(map[string]string)
+	buckets := make(map[string][]*ast.Function)
+	for _, fn := range functions {
+		if !delegable(fn) {
+			continue
+		}
+		pkg, pkgErr := packageName(fn.File, packages)
+		if pkgErr != nil {
+			a.logf("Skipping %s: %v", fn.File, pkgErr)
+			continue
+		}
+		key := strings.Join([]string{filepath.Dir(fn.File), pkg, declShape(fn.AST)}, "\x00")
+		buckets[key] = append(buckets[key], fn)
+	}
+
+	// Canonical copy of every rewritten function
+	canonicals := make(map[*ast.Function]*ast.Function)
+	for _, bucket := range buckets {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for duplicate, canonical := range exactClones(bucket) {
+			canonicals[duplicate] = canonical
+		}
+	}
+
+	return a.applyDelegations(canonicals)
+}
+
+// delegable reports whether fn could be rewritten to call another function or be called
+// by one.
+func delegable(fn *ast.Function) bool {
+	switch fn.Name {
+	case "init", "main", "_":
+		return false
+	default:
+		return fn.AST != nil && fn.AST.Body != nil
+	}
+}
+
+// declShape summarizes the shape of a declaration, which exact clones share.
+func declShape(decl *goast.FuncDecl) string {
+	results := 0
+	if decl.Type.Results != nil {
+		results = decl.Type.Results.NumFields()
+	}
+	return fmt.Sprintf("%t/%d/%d/%d", decl.Recv != nil, decl.Type.Params.NumFields(), results, len(decl.Body.List))
+}
+
+// exactClones partitions functions into sets of exact clones and maps every member of a
+// set to its canonical copy, except the canonical copy itself.
+func exactClones(functions []*ast.Function) map[*ast.Function]*ast.Function {
+	sort.Slice(functions, func(i, j int) bool {
+		iTest, jTest := strings.HasSuffix(functions[i].File, testFileSuffix), strings.HasSuffix(functions[j].File, testFileSuffix)
+		if iTest != jTest {
+			return jTest
+		}
+		return lessLocation(newFunctionRef(functions[i]), newFunctionRef(functions[j]))
+	})
+
+	canonicals := make(map[*ast.Function]*ast.Function)
+	for i, canonical := range functions {
+		if _, assigned := canonicals[canonical]; assigned {
+			continue
+		}
+		for _, duplicate := range functions[i+1:] {
+			if _, assigned := canonicals[duplicate]; assigned {
+				continue
+			}
+			if _, err := refactor.Delegate(canonical.AST, duplicate.AST); err == nil {
+				canonicals[duplicate] = canonical
+			}
+		}
+	}
+
+	return canonicals
+}
+
+// applyDelegations rewrites the files declaring the duplicates of canonicals.
+func (a *Analyzer) applyDelegations(canonicals map[*ast.Function]*ast.Function) (*FixResult, error) {
+	byFile := make(map[string][]*ast.Function)
+	for duplicate := range canonicals {
+		byFile[duplicate.File] = append(byFile[duplicate.File], duplicate)
+	}
+
+	result := &FixResult{}
+	for path, duplicates := range byFile {
+		before, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, before, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		var edits []refactor.BodyEdit
+		for _, duplicate := range duplicates {
+			decl := findDecl(fset, file, duplicate)
+			if decl == nil {
+				return nil, fmt.Errorf("%s:%d: %s changed since it was parsed", path, duplicate.StartLine, duplicate.Name)
+			}
+
+			body, delegateErr := refactor.Delegate(canonicals[duplicate].AST, decl)
+			if delegateErr != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, duplicate.StartLine, delegateErr)
+			}
+			edits = append(edits, refactor.BodyEdit{Decl: decl, Body: body})
+
+			result.Delegations = append(result.Delegations, Delegation{
+				Function:  newFunctionRef(duplicate),
+				Canonical: newFunctionRef(canonicals[duplicate]),
+			})
+		}
+
+		after, err := refactor.ApplyEdits(fset, file, before, edits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewrite %s: %w", path, err)
+		}
+		result.Files = append(result.Files, FileChange{Path: path, Before: before, After: after})
+	}
+
+	sort.Slice(result.Delegations, func(i, j int) bool {
+		return lessLocation(result.Delegations[i].Function, result.Delegations[j].Function)
+	})
+	sort.Slice(result.Files, func(i, j int) bool {
+		return result.Files[i].Path < result.Files[j].Path
+	})
+
+	a.logf("Rewrote %d functions in %d files", len(result.Delegations), len(result.Files))
+
+	return result, nil
+}
+
+// findDecl returns the declaration of fn in file, or nil when the file changed.
+func findDecl(fset *token.FileSet, file *goast.File, fn *ast.Function) *goast.FuncDecl {
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*goast.FuncDecl)
+		if ok && funcDecl.Name.Name == fn.Name && fset.Position(funcDecl.Pos()).Line == fn.StartLine {
+			return funcDecl
+		}
+	}
+	return nil
+}
+
+// packageName returns the package declared by a file, caching it in packages.
+func packageName(path string, packages map[string]string) (string, error) {
+	if name, exists := packages[path]; exists {
+		return name, nil
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly)
+	if err != nil {
+		return "", fmt.Errorf("failed to read package clause: %w", err)
+	}
+	if file.Name == nil {
+		return "", errors.New("missing package clause")
+	}
+
+	packages[path] = file.Name.Name
+	return file.Name.Name, nil
+}
@@ -0,0 +1,72 @@
+package analyzer_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+const fixTestCopy = `package sample
+
+import "strings"
+
+func Total(items []int) int {
+	acc := 0
+	for _, item := range items {
+		acc += item
+	}
+	return acc
+}
+
+func Title(s string) string {
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+`
+
+func TestFix(t *testing.T) {
+	dir := filepath.Dir(writeTestFile(t, "a.go"))
+	copyPath := filepath.Join(dir, "b.go")
+	if err := os.WriteFile(copyPath, []byte(fixTestCopy), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	// A copy in another package must not be rewritten
+	other := filepath.Join(dir, "other")
+	if err := os.Mkdir(other, 0o700); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	writeTestFileIn(t, other, "c.go")
+
+	a, err := analyzer.New(analyzer.WithMinLines(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := a.Fix(context.Background(), []string{dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Delegations) != 1 || len(result.Files) != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if d := result.Delegations[0]; d.Function.Function != "Total" || d.Canonical.Function != "Sum" {
+		t.Errorf("unexpected delegation %+v", d)
+	}
+
+	change := result.Files[0]
+	if change.Path != copyPath || string(change.Before) != fixTestCopy {
+		t.Errorf("unexpected change of %s", change.Path)
+	}
+	if !strings.Contains(string(change.After), "func Total(items []int) int {\n\treturn Sum(items)\n}") ||
+		!strings.Contains(string(change.After), `import "strings"`) {
+		t.Errorf("unexpected source:\n%s", change.After)
+	}
+	if diff := change.Diff(); !strings.Contains(diff, "+\treturn Sum(items)\n") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+}
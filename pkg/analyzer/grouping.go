@@ -0,0 +1,440 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/config"
+	"github.com/paveg/similarity-go/internal/similarity"
+	"github.com/paveg/similarity-go/pkg/mathutil"
+)
+
+// groupIDLength is the number of hex characters of a group ID hash.
+const groupIDLength = 12
+
+// buildSimilarityGraph creates a graph of function similarities from matches.
+func buildSimilarityGraph(
+	matches []similarity.Match,
+) (map[string]map[string]similarity.Match, map[string]*ast.Function) {
+	functionGraph := make(map[string]map[string]similarity.Match)
+	allFunctions := make(map[string]*ast.Function)
+
+	for _, match := range matches {
+		hash1 := functionKey(match.Function1)
+		hash2 := functionKey(match.Function2)
+
+		// Store functions by identity
+		allFunctions[hash1] = match.Function1
+		allFunctions[hash2] = match.Function2
+
+		// Add edges in both directions
+		if functionGraph[hash1] == nil {
+			functionGraph[hash1] = make(map[string]similarity.Match)
+		}
+		if functionGraph[hash2] == nil {
+			functionGraph[hash2] = make(map[string]similarity.Match)
+		}
+
+		functionGraph[hash1][hash2] = match
+		functionGraph[hash2][hash1] = similarity.Match{
+			Function1:  match.Function2,
+			Function2:  match.Function1,
+			Similarity: match.Similarity,
+		}
+	}
+
+	return functionGraph, allFunctions
+}
+
+// groupSimilarMatches groups similar matches by functions that appear together.
+// Connected components of the similarity graph are split further according to the
+// grouping strategy; threshold is the similarity the matches were found with.
+func groupSimilarMatches(matches []similarity.Match, strategy string, threshold float64) [][]similarity.Match {
+	if len(matches) == 0 {
+		return nil
+	}
+
+	// Create a graph of function similarities
+	functionGraph, allFunctions := buildSimilarityGraph(matches)
+
+	// Use Union-Find (Disjoint Set) to group connected components
+	groups := findConnectedGroups(functionGraph, allFunctions)
+	if strategy != config.GroupingComponents {
+		groups = refineGroups(groups, functionGraph, strategy, threshold)
+	}
+
+	// Order members so the matches of a group, and the scores summed from them, are stable
+	for _, group := range groups {
+		sort.Slice(group, func(i, j int) bool {
+			return functionKey(group[i]) < functionKey(group[j])
+		})
+	}
+
+	// Convert to the required format
+	return convertGroupsToMatches(groups, functionGraph)
+}
+
+// convertGroupsToMatches converts function groups to similarity match groups.
+func convertGroupsToMatches(
+	groups [][]*ast.Function,
+	functionGraph map[string]map[string]similarity.Match,
+) [][]similarity.Match {
+	var result [][]similarity.Match
+
+	for _, group := range groups {
+		const minGroupSize = 2
+		if len(group) < minGroupSize {
+			continue // Skip single function groups
+		}
+
+		var groupMatches []similarity.Match
+		added := make(map[string]bool)
+
+		// Generate all pairwise matches within the group
+		for i, func1 := range group {
+			for j := i + 1; j < len(group); j++ {
+				func2 := group[j]
+				hash1 := functionKey(func1)
+				hash2 := functionKey(func2)
+
+				// Avoid duplicate matches
+				key := generateMatchKey(hash1, hash2)
+
+				if !added[key] {
+					if match, exists := functionGraph[hash1][hash2]; exists {
+						groupMatches = append(groupMatches, match)
+						added[key] = true
+					}
+				}
+			}
+		}
+
+		if len(groupMatches) > 0 {
+			result = append(result, groupMatches)
+		}
+	}
+
+	return result
+}
+
+// functionKey identifies a function node in the similarity graph. The structural hash
+// alone is not enough: identical copies in different files share it, and keying on it
+// would collapse them into a single node.
+func functionKey(fn *ast.Function) string {
+	return fmt.Sprintf("%s:%d:%s", fn.File, fn.StartLine, fn.Hash())
+}
+
+// generateMatchKey creates a consistent key for a pair of function hashes.
+func generateMatchKey(hash1, hash2 string) string {
+	return mathutil.CreateConsistentKey(hash1, hash2)
+}
+
+// findConnectedGroups uses DFS to find connected components in the similarity graph.
+func findConnectedGroups(
+	graph map[string]map[string]similarity.Match,
+	allFunctions map[string]*ast.Function,
+) [][]*ast.Function {
+	visited := make(map[string]bool)
+	var groups [][]*ast.Function
+
+	// Perform DFS from each unvisited node
+	for functionHash := range allFunctions {
+		if !visited[functionHash] {
+			var group []*ast.Function
+			dfsVisit(graph, allFunctions, functionHash, visited, &group)
+			if len(group) > 1 { // Only include groups with multiple functions
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	return groups
+}
+
+// dfsVisit performs depth-first search to collect all connected functions.
+func dfsVisit(graph map[string]map[string]similarity.Match, allFunctions map[string]*ast.Function,
+	currentHash string, visited map[string]bool, group *[]*ast.Function) {
+	visited[currentHash] = true
+	if function, exists := allFunctions[currentHash]; exists {
+		*group = append(*group, function)
+	}
+
+	// Visit all connected functions
+	if neighbors, exists := graph[currentHash]; exists {
+		for neighborHash := range neighbors {
+			if !visited[neighborHash] {
+				dfsVisit(graph, allFunctions, neighborHash, visited, group)
+			}
+		}
+	}
+}
+
+// typeVariants pairs the functions that are identical apart from the basic types of their
+// signatures, whatever their similarity. Signatures differing by types are penalized, so
+// such copies often score below the threshold although they form a generic function.
+func (a *Analyzer) typeVariants(functions []*ast.Function) []similarity.Match {
+	detector := a.newDetector()
+
+	var keys []string
+	buckets := make(map[string][]*ast.Function)
+	for _, fn := range functions {
+		key := detector.TypeVariantKey(fn)
+		if key == "" {
+			continue
+		}
+		if _, exists := buckets[key]; !exists {
+			keys = append(keys, key)
+		}
+		buckets[key] = append(buckets[key], fn)
+	}
+
+	var matches []similarity.Match
+	for _, key := range keys {
+		bucket := buckets[key]
+		if !slices.ContainsFunc(bucket[1:], func(fn *ast.Function) bool {
+			return fn.GetSignature() != bucket[0].GetSignature()
+		}) {
+			// Copies written for the same types are left to the similarity search
+			continue
+		}
+		for i, first := range bucket {
+			for _, second := range bucket[i+1:] {
+				matches = append(matches, similarity.Match{
+					Function1:  first,
+					Function2:  second,
+					Similarity: detector.CalculateSimilarity(first, second),
+				})
+			}
+		}
+	}
+
+	return matches
+}
+
+// groupTypeVariants groups the type variants apart from the other matches, as their
+// scores may be below the threshold the grouping strategy splits groups at. Matches
+// between members of the same variant group are left out of the other groups.
+func groupTypeVariants(
+	matches, variants []similarity.Match,
+	strategy string,
+	threshold float64,
+) [][]similarity.Match {
+	variantGroups := groupSimilarMatches(variants, config.GroupingComponents, threshold)
+
+	groupOf := make(map[string]int)
+	for i, group := range variantGroups {
+		for _, match := range group {
+			groupOf[functionKey(match.Function1)] = i + 1
+			groupOf[functionKey(match.Function2)] = i + 1
+		}
+	}
+
+	var rest []similarity.Match
+	for _, match := range matches {
+		group := groupOf[functionKey(match.Function1)]
+		if group == 0 || group != groupOf[functionKey(match.Function2)] {
+			rest = append(rest, match)
+		}
+	}
+
+	return append(groupSimilarMatches(rest, strategy, threshold), variantGroups...)
+}
+
+// countDuplications counts the total number of unique duplicate functions across all groups.
+func countDuplications(groups [][]similarity.Match) int {
+	uniqueFunctions := make(map[string]bool)
+
+	for _, group := range groups {
+		for _, match := range group {
+			// Use function identity to count unique functions
+			uniqueFunctions[functionKey(match.Function1)] = true
+			uniqueFunctions[functionKey(match.Function2)] = true
+		}
+	}
+
+	return len(uniqueFunctions)
+}
+
+// buildGroups converts similarity match groups into report groups, describing each
+// function with describe. The result is deterministic: members are sorted by location,
+// and groups as described by sortGroups. Group IDs are derived from the fingerprints of
+// the members. Members whose differences are expressions get an extraction, and methods
+// sharing a name on different receiver types form a method family.
+func (a *Analyzer) buildGroups(groups [][]similarity.Match, describe func(fn *ast.Function) FunctionRef) []Group {
+	var result []Group
+	compare := compareMembers(a.newDetector())
+
+	for _, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+
+		members := groupFunctions(group)
+		functions := make([]FunctionRef, len(members))
+		for i, fn := range members {
+			functions[i] = describe(fn)
+		}
+
+		// Sort members along with their references so the extraction follows the same order
+		order := make([]int, len(members))
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(i, j int) bool {
+			return lessLocation(functions[order[i]], functions[order[j]])
+		})
+		sortedMembers := make([]*ast.Function, len(members))
+		sortedFunctions := make([]FunctionRef, len(members))
+		for i, index := range order {
+			sortedMembers[i] = members[index]
+			sortedFunctions[i] = functions[index]
+		}
+		members, functions = sortedMembers, sortedFunctions
+
+		reportGroup := Group{
+			ID:                 groupID(functions),
+			Kind:               GroupKindClones,
+			SimilarityScore:    averageSimilarity(group),
+			Cohesion:           groupCohesion(members, group, compare),
+			DuplicatedLines:    duplicatedLines(functions),
+			Functions:          functions,
+			RefactorSuggestion: a.config.Output.RefactorSuggestion,
+			Extraction:         a.extractGroup(members, functions),
+		}
+		if functions[0].TypeKind != "" {
+			reportGroup.Kind = GroupKindTypes
+			reportGroup.RefactorSuggestion = typeSuggestion(functions)
+		} else if family := methodFamily(members); family != nil {
+			reportGroup.Kind = GroupKindMethodFamily
+			reportGroup.MethodFamily = family
+			reportGroup.RefactorSuggestion = familySuggestion(family)
+		}
+		reportGroup.RefactoringValue = refactoringValue(reportGroup)
+
+		result = append(result, reportGroup)
+	}
+
+	sortGroups(result)
+
+	// Groups made of the same fingerprints are told apart by their rank
+	seen := make(map[string]int, len(result))
+	for i := range result {
+		id := result[i].ID
+		seen[id]++
+		if seen[id] > 1 {
+			result[i].ID = fmt.Sprintf("%s_%d", id, seen[id])
+		}
+	}
+
+	return result
+}
+
+// groupID derives the ID of a group from the location-independent keys of its members,
+// so a group keeps its ID across runs as long as its members do not change.
+func groupID(functions []FunctionRef) string {
+	keys := make([]string, 0, len(functions))
+	for _, fn := range functions {
+		keys = append(keys, memberKey(fn))
+	}
+	sort.Strings(keys)
+
+	sum := sha256.Sum256([]byte(strings.Join(keys, "\n")))
+	return "group_" + hex.EncodeToString(sum[:])[:groupIDLength]
+}
+
+// lessLocation orders functions by file, then start line, then name.
+func lessLocation(a, b FunctionRef) bool {
+	if a.File != b.File {
+		return a.File < b.File
+	}
+	if a.StartLine != b.StartLine {
+		return a.StartLine < b.StartLine
+	}
+	if a.Function != b.Function {
+		return a.Function < b.Function
+	}
+	return a.Side < b.Side
+}
+
+// groupFunctions returns the distinct functions of a group in order of appearance.
+func groupFunctions(group []similarity.Match) []*ast.Function {
+	seen := make(map[string]bool)
+	var functions []*ast.Function
+
+	for _, match := range group {
+		for _, fn := range []*ast.Function{match.Function1, match.Function2} {
+			if key := functionKey(fn); !seen[key] {
+				seen[key] = true
+				functions = append(functions, fn)
+			}
+		}
+	}
+
+	return functions
+}
+
+// averageSimilarity returns the mean similarity of the similar pairs of a group.
+func averageSimilarity(group []similarity.Match) float64 {
+	total := 0.0
+	for _, match := range group {
+		total += match.Similarity
+	}
+	return total / float64(len(group))
+}
+
+// groupCohesion returns the lowest similarity between any two members of a group, whose
+// similar pairs are group. Pairs below the threshold, as in a group held together by a
+// chain of similar pairs, are compared with compare.
+func groupCohesion(members []*ast.Function, group []similarity.Match, compare func(fn1, fn2 *ast.Function) float64) float64 {
+	known := make(map[[2]*ast.Function]float64, len(group))
+	for _, match := range group {
+		known[[2]*ast.Function{match.Function1, match.Function2}] = match.Similarity
+		known[[2]*ast.Function{match.Function2, match.Function1}] = match.Similarity
+	}
+
+	lowest := 1.0
+	for i, first := range members {
+		for _, second := range members[i+1:] {
+			score, exists := known[[2]*ast.Function{first, second}]
+			if !exists {
+				score = compare(first, second)
+			}
+			lowest = min(lowest, score)
+		}
+	}
+	return lowest
+}
+
+// compareMembers returns the similarity of two members of a group computed by detector.
+// Type declarations and functions whose syntax tree is not available, as after Merge
+// when their file cannot be parsed again, cannot be compared and have no similarity.
+func compareMembers(detector *similarity.Detector) func(fn1, fn2 *ast.Function) float64 {
+	return func(fn1, fn2 *ast.Function) float64 {
+		if fn1.AST == nil || fn2.AST == nil {
+			return 0
+		}
+		return detector.CalculateSimilarity(fn1, fn2)
+	}
+}
+
+// newFunctionRef creates the public reference of a parsed function.
+func newFunctionRef(fn *ast.Function) FunctionRef {
+	return FunctionRef{
+		File:          fn.File,
+		Function:      fn.Name,
+		QualifiedName: fn.QualifiedName(),
+		Receiver:      fn.Receiver,
+		Package:       fn.Package,
+		ImportPath:    fn.ImportPath,
+		StartLine:     fn.StartLine,
+		EndLine:       fn.EndLine,
+		Hash:          fn.Hash(),
+		Fingerprint:   fn.Fingerprint(),
+		Complexity:    fn.Complexity(),
+	}
+}
@@ -0,0 +1,274 @@
+package analyzer
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/config"
+	"github.com/paveg/similarity-go/internal/similarity"
+)
+
+func TestGenerateMatchKey(t *testing.T) {
+	key1 := generateMatchKey("abc", "def")
+	key2 := generateMatchKey("def", "abc")
+
+	// Should generate the same key regardless of order
+	if key1 != key2 {
+		t.Errorf("Expected same key, got %s and %s", key1, key2)
+	}
+
+	// Should contain both hashes
+	if !strings.Contains(key1, "abc") || !strings.Contains(key1, "def") {
+		t.Errorf("Key %s should contain both hashes", key1)
+	}
+}
+
+func TestGroupSimilarMatches(t *testing.T) {
+	// Create mock functions for testing
+	func1 := &ast.Function{Name: "func1", File: "file1.go"}
+	func2 := &ast.Function{Name: "func2", File: "file2.go"}
+	func3 := &ast.Function{Name: "func3", File: "file3.go"}
+
+	// Test with empty matches
+	matches := []similarity.Match{}
+	groups := groupSimilarMatches(matches, config.GroupingComponents, 0.8)
+	if groups != nil {
+		t.Errorf("Expected nil groups for empty matches, got %d groups", len(groups))
+	}
+
+	// Test with single match
+	matches = []similarity.Match{
+		{Function1: func1, Function2: func2, Similarity: 0.8},
+	}
+	groups = groupSimilarMatches(matches, config.GroupingComponents, 0.8)
+	if len(groups) != 1 {
+		t.Errorf("Expected 1 group for single match, got %d", len(groups))
+	}
+	if len(groups[0]) != 1 {
+		t.Errorf("Expected 1 match in group, got %d", len(groups[0]))
+	}
+
+	// Test with multiple matches forming chain
+	matches = []similarity.Match{
+		{Function1: func1, Function2: func2, Similarity: 0.8},
+		{Function1: func2, Function2: func3, Similarity: 0.9},
+	}
+	groups = groupSimilarMatches(matches, config.GroupingComponents, 0.8)
+	if len(groups) != 1 {
+		t.Errorf("Expected 1 group for chained matches, got %d", len(groups))
+	}
+	if len(groups[0]) != 2 {
+		t.Errorf("Expected 2 matches in group, got %d", len(groups[0]))
+	}
+}
+
+// groupSizes returns the sorted sizes of the groups.
+func groupSizes(groups [][]similarity.Match) []int {
+	var sizes []int
+	for _, group := range groups {
+		sizes = append(sizes, len(groupFunctions(group)))
+	}
+	sort.Ints(sizes)
+	return sizes
+}
+
+func TestGroupingStrategies(t *testing.T) {
+	functions := make([]*ast.Function, 5)
+	for i := range functions {
+		functions[i] = &ast.Function{Name: string(rune('A' + i)), File: "file.go", StartLine: i * 10}
+	}
+	a, b, c, d, e := functions[0], functions[1], functions[2], functions[3], functions[4]
+
+	// A, B and C are all similar; D is only similar to C and E only to D
+	matches := []similarity.Match{
+		{Function1: a, Function2: b, Similarity: 0.95},
+		{Function1: a, Function2: c, Similarity: 0.9},
+		{Function1: b, Function2: c, Similarity: 0.9},
+		{Function1: c, Function2: d, Similarity: 0.85},
+		{Function1: d, Function2: e, Similarity: 0.82},
+	}
+
+	tests := []struct {
+		strategy string
+		want     []int
+	}{
+		{strategy: config.GroupingComponents, want: []int{5}},
+		{strategy: config.GroupingCompleteLinkage, want: []int{2, 3}},
+		{strategy: config.GroupingAverageLinkage, want: []int{2, 3}},
+		{strategy: config.GroupingCliques, want: []int{2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			groups := groupSimilarMatches(matches, tt.strategy, 0.8)
+			if got := groupSizes(groups); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("group sizes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupCohesion(t *testing.T) {
+	a := &ast.Function{Name: "A", File: "a.go"}
+	b := &ast.Function{Name: "B", File: "b.go"}
+	c := &ast.Function{Name: "C", File: "c.go"}
+	members := []*ast.Function{a, b, c}
+
+	chain := []similarity.Match{
+		{Function1: a, Function2: b, Similarity: 0.9},
+		{Function1: b, Function2: c, Similarity: 0.8},
+	}
+	compared := 0
+	compare := func(fn1, fn2 *ast.Function) float64 {
+		compared++
+		return 0.6
+	}
+	if cohesion := groupCohesion(members, chain, compare); cohesion != 0.6 || compared != 1 {
+		t.Errorf("expected the missing pair to be compared once, got cohesion %f after %d comparisons", cohesion, compared)
+	}
+
+	clique := append(chain, similarity.Match{Function1: c, Function2: a, Similarity: 0.85})
+	compared = 0
+	if cohesion := groupCohesion(members, clique, compare); cohesion != 0.8 || compared != 0 {
+		t.Errorf("expected cohesion 0.8 for a clique, got %f after %d comparisons", cohesion, compared)
+	}
+}
+
+func TestGroupCohesionOfChain(t *testing.T) {
+	functions := parseMethods(t, `
+func Short(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func Middle(values []int) int {
+	total := 0
+	for _, v := range values {
+		if v > 0 {
+			total += v
+		}
+	}
+	return total
+}
+
+func Long(values []int) int {
+	total := 0
+	for _, v := range values {
+		if v > 0 {
+			total += v * v
+		} else {
+			total -= v
+		}
+	}
+	return total
+}`)
+
+	a, err := New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	detector := a.newDetector()
+	chain := []similarity.Match{
+		{Function1: functions[0], Function2: functions[1], Similarity: 0.95},
+		{Function1: functions[1], Function2: functions[2], Similarity: 0.95},
+	}
+
+	// The ends of the chain are compared instead of counting as 0
+	want := detector.CalculateSimilarity(functions[0], functions[2])
+	if got := groupCohesion(functions, chain, compareMembers(detector)); got != want || got == 0 {
+		t.Errorf("cohesion = %f, want %f", got, want)
+	}
+}
+
+func TestBuildGroupsDeterministic(t *testing.T) {
+	a, err := New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// describe uses the name as fingerprint, so moving a function keeps its identity
+	describe := func(fn *ast.Function) FunctionRef {
+		return FunctionRef{
+			File: fn.File, Function: fn.Name, StartLine: fn.StartLine, EndLine: fn.EndLine, Fingerprint: fn.Name,
+		}
+	}
+
+	build := func(dir string, reverse bool) []Group {
+		fn := func(name string, line int) *ast.Function {
+			return &ast.Function{Name: name, File: dir + "/" + name + ".go", StartLine: line, EndLine: line + 9}
+		}
+		a1, b1, c1, d1 := fn("a", 1), fn("b", 2), fn("c", 3), fn("d", 4)
+		matches := []similarity.Match{
+			{Function1: a1, Function2: b1, Similarity: 0.9},
+			{Function1: c1, Function2: d1, Similarity: 0.95},
+			{Function1: b1, Function2: c1, Similarity: 0.5},
+		}
+		if reverse {
+			slices.Reverse(matches)
+		}
+		return a.buildGroups(groupSimilarMatches(matches, config.GroupingCliques, 0.5), describe)
+	}
+
+	first := build("x", false)
+	if len(first) != 2 || first[0].SimilarityScore != 0.95 || first[0].Functions[0].Function != "c" {
+		t.Fatalf("expected the most valuable group first, got %+v", first)
+	}
+
+	for range 10 {
+		if again := build("x", true); !reflect.DeepEqual(again, first) {
+			t.Fatalf("output changed between runs:\n%+v\n%+v", first, again)
+		}
+	}
+
+	moved := build("moved", false)
+	for i := range first {
+		if moved[i].ID != first[i].ID {
+			t.Errorf("group ID changed after moving files: %s -> %s", first[i].ID, moved[i].ID)
+		}
+	}
+	if first[0].ID == first[1].ID || !strings.HasPrefix(first[0].ID, "group_") {
+		t.Errorf("unexpected group IDs %s and %s", first[0].ID, first[1].ID)
+	}
+}
+
+func TestCountDuplications(t *testing.T) {
+	func1 := &ast.Function{Name: "func1", File: "file1.go"}
+	func2 := &ast.Function{Name: "func2", File: "file2.go"}
+	func3 := &ast.Function{Name: "func3", File: "file3.go"}
+
+	// Test empty groups
+	groups := [][]similarity.Match{}
+	count := countDuplications(groups)
+	if count != 0 {
+		t.Errorf("Expected 0 duplications for empty groups, got %d", count)
+	}
+
+	// Test single group with matches
+	groups = [][]similarity.Match{
+		{
+			{Function1: func1, Function2: func2, Similarity: 0.8},
+			{Function1: func2, Function2: func3, Similarity: 0.9},
+		},
+	}
+	count = countDuplications(groups)
+	if count != 3 {
+		t.Errorf("Expected 3 unique functions, got %d", count)
+	}
+
+	// Test multiple groups
+	groups = [][]similarity.Match{
+		{{Function1: func1, Function2: func2, Similarity: 0.8}}, // 2 functions
+		{{Function1: func3, Function2: func1, Similarity: 0.7}}, // 1 new function (func3)
+	}
+	count = countDuplications(groups)
+	if count != 3 {
+		t.Errorf("Expected 3 total unique functions, got %d", count)
+	}
+}
@@ -0,0 +1,430 @@
+package analyzer
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/similarity"
+	"github.com/paveg/similarity-go/internal/vcs"
+)
+
+const (
+	// DefaultHistorySamples is the number of revisions sampled by History when the query
+	// does not set one.
+	DefaultHistorySamples = 10
+	// defaultHistoryRevision is the revision traced by History when the query does not
+	// set one.
+	defaultHistoryRevision = "HEAD"
+	// driftTolerance is the change of similarity within which a group is stable.
+	driftTolerance = 0.05
+)
+
+// HistoryQuery selects what History traces.
+type HistoryQuery struct {
+	// Revision is the revision whose clone groups are traced; HEAD when empty.
+	Revision string
+	// Samples is the number of revisions, spread evenly over the history and including
+	// Revision, at which the similarity of each group is measured; DefaultHistorySamples
+	// when zero or less.
+	Samples int
+	// Paths restricts the analysis to files and directories relative to the root of the
+	// repository; the whole tree when empty.
+	Paths []string
+}
+
+// History finds the clone groups of a revision of the git repository repo and traces them
+// back through the first-parent history: the commit introducing each copy, found by
+// bisecting the history for the first commit declaring the function in its file, when the
+// group appeared with its second copy, and how the similarity of the copies drifted over
+// sampled revisions. Every sampled revision is analyzed as well, so that former copies of
+// a group and groups that have since dissolved are reported. Functions are matched across
+// revisions by file and receiver-qualified name, so a copy that was moved or renamed is
+// considered introduced by the move.
+func (a *Analyzer) History(ctx context.Context, repo string, query HistoryQuery) (*HistoryReport, error) {
+	revision := cmp.Or(query.Revision, defaultHistoryRevision)
+	samples := query.Samples
+	if samples <= 0 {
+		samples = DefaultHistorySamples
+	}
+
+	commits, err := vcs.Log(ctx, repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits lead to %s", revision)
+	}
+	a.logf("Tracing %d commits leading to %s", len(commits), revision)
+
+	dir, err := os.MkdirTemp("", exportPattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create directory for %s: %w", revision, err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	current := commits[len(commits)-1]
+	if err := vcs.Export(ctx, repo, current.Hash, dir); err != nil {
+		return nil, err
+	}
+
+	all, err := a.historyGroups(ctx, dir, query.Paths)
+	if err != nil {
+		return nil, err
+	}
+	groups := all
+	if top := a.config.Output.Top; top > 0 && len(groups) > top {
+		groups = groups[:top]
+	}
+
+	h := &historian{
+		analyzer: a,
+		repo:     repo,
+		root:     dir,
+		commits:  commits,
+		detector: a.newDetector(),
+		parser:   ast.NewParser(),
+		files:    make(map[string]map[string]*ast.Function),
+	}
+
+	report := &HistoryReport{
+		SchemaVersion: SchemaVersion,
+		Repository:    repo,
+		Revision:      newCommitRef(current),
+		Commits:       len(commits),
+		Groups:        make([]GroupHistory, 0, len(groups)),
+	}
+
+	sampled := sampleCommits(len(commits), samples)
+	for _, index := range sampled {
+		report.Samples = append(report.Samples, newCommitRef(commits[index]))
+	}
+
+	lineages, err := h.lineages(ctx, sampled, all, query.Paths)
+	if err != nil {
+		return nil, err
+	}
+	byGroup := make(map[string]*lineage, len(all))
+	for _, line := range lineages {
+		if line.last == len(commits)-1 {
+			byGroup[line.group.ID] = line
+		} else {
+			report.Dissolved = append(report.Dissolved, PastGroup{
+				FirstSeen:       newCommitRef(commits[line.first]),
+				LastSeen:        newCommitRef(commits[line.last]),
+				SimilarityScore: line.group.SimilarityScore,
+				Functions:       line.group.Functions,
+			})
+		}
+	}
+
+	for _, group := range groups {
+		history, traceErr := h.trace(ctx, group, sampled, byGroup[group.ID])
+		if traceErr != nil {
+			return nil, traceErr
+		}
+		report.Groups = append(report.Groups, history)
+	}
+
+	return report, nil
+}
+
+// historyGroups finds the clone groups of the tree exported to root, limited to paths,
+// with files relative to root.
+func (a *Analyzer) historyGroups(ctx context.Context, root string, paths []string) ([]Group, error) {
+	targets := []string{root}
+	if len(paths) > 0 {
+		targets = make([]string, 0, len(paths))
+		for _, path := range paths {
+			if !filepath.IsLocal(trimRecursivePattern(path)) {
+				return nil, fmt.Errorf("path %s is outside the repository", path)
+			}
+			targets = append(targets, filepath.Join(root, path))
+		}
+	}
+
+	decls, err := a.parseDeclarations(ctx, ast.NewParser(), targets)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := a.findSimilarFunctions(ctx, decls.functions)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.buildGroups(
+		groupSimilarMatches(matches, a.config.Processing.Grouping, a.config.CLI.DefaultThreshold),
+		func(fn *ast.Function) FunctionRef { return relativeRef(root, fn) },
+	), nil
+}
+
+// historian looks up functions at the commits of a repository.
+type historian struct {
+	analyzer *Analyzer
+	repo     string
+	root     string
+	commits  []vcs.Commit
+	detector *similarity.Detector
+	parser   *ast.Parser
+	// files holds the functions of a file at a commit by sibling key.
+	files map[string]map[string]*ast.Function
+}
+
+// lineage is a clone group followed through the sampled commits where it was found.
+type lineage struct {
+	// first and last are the indexes of the first and last commits finding the group.
+	first, last int
+	// group is the group as last found.
+	group Group
+	// found holds the indexes of the commits finding the group.
+	found map[int]bool
+	// members holds every member found, as last found, by sibling key.
+	members map[string]FunctionRef
+}
+
+// update records group as found at the commit of the given index.
+func (l *lineage) update(index int, group Group) {
+	l.last = index
+	l.group = group
+	l.found[index] = true
+	for _, fn := range group.Functions {
+		l.members[siblingKey(fn)] = fn
+	}
+}
+
+// lineages analyzes the sampled commits, oldest first, and follows their groups through
+// them. The last sampled commit is the traced revision, whose groups are given as current.
+// A group is continued by the group of a later commit sharing the most members with it.
+func (h *historian) lineages(ctx context.Context, sampled []int, current []Group, paths []string) ([]*lineage, error) {
+	var lineages []*lineage
+
+	for _, index := range sampled {
+		groups := current
+		if index != len(h.commits)-1 {
+			var err error
+			if groups, err = h.groupsAt(ctx, h.commits[index], paths); err != nil {
+				return nil, err
+			}
+		}
+
+		previous := make([]Group, len(lineages))
+		for i, line := range lineages {
+			previous[i] = line.group
+		}
+		continued := make(map[int]bool)
+		for _, pair := range matchGroups(previous, groups, siblingKey) {
+			lineages[pair.oldIndex].update(index, groups[pair.newIndex])
+			continued[pair.newIndex] = true
+		}
+
+		for i, group := range groups {
+			if continued[i] {
+				continue
+			}
+			line := &lineage{first: index, found: make(map[int]bool), members: make(map[string]FunctionRef)}
+			line.update(index, group)
+			lineages = append(lineages, line)
+		}
+	}
+
+	return lineages, nil
+}
+
+// groupsAt exports commit and finds its clone groups, limited to paths.
+func (h *historian) groupsAt(ctx context.Context, commit vcs.Commit, paths []string) ([]Group, error) {
+	dir, err := os.MkdirTemp("", exportPattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create directory for %s: %w", commit.Hash, err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	if err := vcs.Export(ctx, h.repo, commit.Hash, dir); err != nil {
+		return nil, err
+	}
+	return h.analyzer.historyGroups(ctx, dir, paths)
+}
+
+// trace builds the history of group, measuring its similarity at the sampled commits.
+// The lineage of the group, if any, tells where it was found and its former copies.
+func (h *historian) trace(ctx context.Context, group Group, sampled []int, line *lineage) (GroupHistory, error) {
+	introduced := make([]int, len(group.Functions))
+	for i, member := range group.Functions {
+		index, err := h.introduction(ctx, member)
+		if err != nil {
+			return GroupHistory{}, err
+		}
+		introduced[i] = index
+	}
+
+	order := make([]int, len(group.Functions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return introduced[order[i]] < introduced[order[j]] })
+
+	history := GroupHistory{GroupID: group.ID, Trend: TrendStable}
+	for _, i := range order {
+		history.Copies = append(history.Copies, CopyOrigin{
+			Function:   group.Functions[i],
+			Introduced: newCommitRef(h.commits[introduced[i]]),
+		})
+	}
+	// The group appears with its second copy
+	appeared := introduced[order[1]]
+	history.FirstSeen = newCommitRef(h.commits[appeared])
+
+	const minGroupSize = 2
+	for _, index := range sampled {
+		if index < appeared {
+			continue
+		}
+		point, err := h.measure(ctx, group, index)
+		if err != nil {
+			return GroupHistory{}, err
+		}
+		point.Grouped = line != nil && line.found[index]
+		if point.Members >= minGroupSize {
+			history.Timeline = append(history.Timeline, point)
+		}
+	}
+
+	if len(history.Timeline) > 0 {
+		history.Drift = history.Timeline[len(history.Timeline)-1].Similarity - history.Timeline[0].Similarity
+	}
+	switch {
+	case history.Drift < -driftTolerance:
+		history.Trend = TrendDiverging
+	case history.Drift > driftTolerance:
+		history.Trend = TrendConverging
+	}
+
+	if line != nil {
+		current := memberCounts(group.Functions, siblingKey)
+		for key, fn := range line.members {
+			if current[key] == 0 {
+				history.FormerCopies = append(history.FormerCopies, fn)
+			}
+		}
+		sort.Slice(history.FormerCopies, func(i, j int) bool {
+			return lessLocation(history.FormerCopies[i], history.FormerCopies[j])
+		})
+	}
+
+	return history, nil
+}
+
+// introduction bisects the commits for the first one declaring member, assuming that the
+// function stays declared once introduced. It returns the index of the commit.
+func (h *historian) introduction(ctx context.Context, member FunctionRef) (int, error) {
+	low, high := 0, len(h.commits)-1
+	for low < high {
+		mid := (low + high) / 2
+		fn, err := h.lookup(ctx, h.commits[mid], member)
+		if err != nil {
+			return 0, err
+		}
+		if fn != nil {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+	return low, nil
+}
+
+// measure returns the number of members of group existing at the commit of the given
+// index and the average similarity of their pairs.
+func (h *historian) measure(ctx context.Context, group Group, index int) (HistoryPoint, error) {
+	commit := h.commits[index]
+
+	var members []*ast.Function
+	for _, member := range group.Functions {
+		fn, err := h.lookup(ctx, commit, member)
+		if err != nil {
+			return HistoryPoint{}, err
+		}
+		if fn != nil {
+			members = append(members, fn)
+		}
+	}
+
+	point := HistoryPoint{Commit: commit.Hash, Members: len(members)}
+	pairs := 0
+	for i, first := range members {
+		for _, second := range members[i+1:] {
+			point.Similarity += h.detector.CalculateSimilarity(first, second)
+			pairs++
+		}
+	}
+	if pairs > 0 {
+		point.Similarity /= float64(pairs)
+	}
+	return point, nil
+}
+
+// lookup returns the function declared as member at commit, or nil when the commit does
+// not declare it. Files that cannot be parsed declare no function.
+func (h *historian) lookup(ctx context.Context, commit vcs.Commit, member FunctionRef) (*ast.Function, error) {
+	key := commit.Hash + "\x00" + member.File
+	functions, exists := h.files[key]
+	if !exists {
+		src, err := vcs.ReadFile(ctx, h.repo, commit.Hash, member.File)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return nil, err
+		default:
+			functions = h.parse(commit, member.File, src)
+		}
+		h.files[key] = functions
+	}
+	return functions[siblingKey(member)], nil
+}
+
+// parse returns the functions of a file at commit by sibling key.
+func (h *historian) parse(commit vcs.Commit, name string, src []byte) map[string]*ast.Function {
+	result := h.parser.ParseSource(filepath.Join(h.root, filepath.FromSlash(name)), src)
+	if result.IsErr() {
+		h.analyzer.logf("Skipping %s at %s: %v", name, commit.Hash, result.Error())
+		return nil
+	}
+
+	functions := make(map[string]*ast.Function)
+	for _, fn := range result.Unwrap().Functions {
+		functions[siblingKey(relativeRef(h.root, fn))] = fn
+	}
+	return functions
+}
+
+// sampleCommits returns up to samples indexes spread evenly over count commits, oldest
+// first, always including the last commit.
+func sampleCommits(count, samples int) []int {
+	if samples >= count {
+		samples = count
+	}
+	if samples == 1 {
+		return []int{count - 1}
+	}
+
+	indexes := make([]int, 0, samples)
+	for i := range samples {
+		index := i * (count - 1) / (samples - 1)
+		if len(indexes) == 0 || indexes[len(indexes)-1] != index {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes
+}
+
+// newCommitRef describes commit.
+func newCommitRef(commit vcs.Commit) CommitRef {
+	return CommitRef{Commit: commit.Hash, Date: commit.Time.Format(time.RFC3339), Subject: commit.Subject}
+}
@@ -0,0 +1,189 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// loaderSource declares a loader of each of names; the first one is rewritten when
+// diverged.
+func loaderSource(diverged bool, names ...string) string {
+	source := "package store\n"
+	for i, name := range names {
+		body := `	row, err := db.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	return decodeNAME(row), nil`
+		if diverged && i == 0 {
+			body = `	if id <= 0 {
+		return nil, errInvalidID
+	}
+	rows, err := db.QueryAll(ctx, "SELECT * FROM NAME WHERE id = ?", id)
+	if err != nil {
+		log.Printf("query failed: %v", err)
+		return nil, fmt.Errorf("load NAME %d: %w", id, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		return scanNAME(rows)
+	}
+	return nil, errNotFound`
+		}
+		source += strings.ReplaceAll("\nfunc LoadNAME(db *DB, id int) (*NAME, error) {\n"+body+"\n}\n", "NAME", name)
+	}
+	return source
+}
+
+// colorsSource declares a color table of each of names.
+func colorsSource(names ...string) string {
+	source := "package store\n"
+	for _, name := range names {
+		source += strings.ReplaceAll(`
+func NAMEColor(code int) string {
+	switch code {
+	case 1:
+		return "NAME-red"
+	case 2:
+		return "NAME-green"
+	}
+	return "NAME"
+}
+`, "NAME", name)
+	}
+	return source
+}
+
+func TestHistory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{
+			"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com",
+		}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, output)
+		}
+	}
+	commit := func(message string, files map[string]string) {
+		t.Helper()
+		for name, content := range files {
+			path := filepath.Join(repo, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatalf("failed to create directory: %v", err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatalf("failed to write %s: %v", name, err)
+			}
+		}
+		git("add", "-A")
+		git("commit", "-q", "-m", message)
+	}
+
+	git("init", "-q")
+	commit("add user loader", map[string]string{"store/loaders.go": loaderSource(false, "User")})
+	commit("add readme", map[string]string{"README.md": "# store\n"})
+	commit("add order loader", map[string]string{"store/loaders.go": loaderSource(false, "User", "Order")})
+	commit("add invoice loader", map[string]string{
+		"store/loaders.go": loaderSource(false, "User", "Order", "Invoice"),
+		"store/account.go": loaderSource(false, "Account"),
+		"store/colors.go":  colorsSource("Theme", "Locale"),
+	})
+	commit("remove account loader", map[string]string{
+		"store/account.go": "package store\n",
+		"store/colors.go":  colorsSource(),
+	})
+	commit("rewrite user loader", map[string]string{"store/loaders.go": loaderSource(true, "User", "Order", "Invoice")})
+
+	a, err := New(WithMinLines(3), WithThreshold(0.6))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report, err := a.History(context.Background(), repo, HistoryQuery{Paths: []string{"store/..."}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Commits != 6 || len(report.Samples) != 6 || report.Revision.Subject != "rewrite user loader" {
+		t.Fatalf("expected every commit to be sampled, got %+v", report)
+	}
+	if len(report.Groups) != 1 {
+		t.Fatalf("expected one group, got %+v", report.Groups)
+	}
+
+	group := report.Groups[0]
+	introduced := make(map[string]string)
+	for _, copy := range group.Copies {
+		introduced[copy.Function.Function] = copy.Introduced.Subject
+	}
+	if introduced["LoadUser"] != "add user loader" || introduced["LoadOrder"] != "add order loader" ||
+		introduced["LoadInvoice"] != "add invoice loader" {
+		t.Errorf("unexpected introducing commits %v", introduced)
+	}
+	if group.Copies[0].Function.Function != "LoadUser" || group.Copies[0].Function.File != "store/loaders.go" {
+		t.Errorf("expected the oldest copy first, got %+v", group.Copies[0].Function)
+	}
+	if group.FirstSeen.Subject != "add order loader" {
+		t.Errorf("expected the group to appear with the order loader, got %+v", group.FirstSeen)
+	}
+
+	if len(group.Timeline) != 4 || group.Timeline[0].Members != 2 || group.Timeline[3].Members != 3 {
+		t.Fatalf("expected four points from the second copy on, got %+v", group.Timeline)
+	}
+	for _, point := range group.Timeline {
+		if !point.Grouped {
+			t.Errorf("expected the copies to be grouped at every point, got %+v", group.Timeline)
+			break
+		}
+	}
+
+	// Copies and groups that no longer exist are found by analyzing the past revisions
+	if len(group.FormerCopies) != 1 || group.FormerCopies[0].Function != "LoadAccount" ||
+		group.FormerCopies[0].File != "store/account.go" {
+		t.Errorf("expected the removed account loader as a former copy, got %+v", group.FormerCopies)
+	}
+	if len(report.Dissolved) != 1 {
+		t.Fatalf("expected one dissolved group, got %+v", report.Dissolved)
+	}
+	dissolved := report.Dissolved[0]
+	if len(dissolved.Functions) != 2 || dissolved.FirstSeen.Subject != "add invoice loader" ||
+		dissolved.LastSeen.Subject != "add invoice loader" {
+		t.Errorf("expected the color tables to dissolve after it was added, got %+v", dissolved)
+	}
+	if group.Timeline[0].Similarity != 1 || group.Drift >= -driftTolerance || group.Trend != TrendDiverging {
+		t.Errorf("expected the rewrite to diverge the copies, got drift %.3f (%s) over %+v",
+			group.Drift, group.Trend, group.Timeline)
+	}
+
+	if _, err := a.History(context.Background(), repo, HistoryQuery{Paths: []string{"../elsewhere"}}); err == nil {
+		t.Error("expected an error for a path outside the repository")
+	}
+	if _, err := a.History(context.Background(), repo, HistoryQuery{Revision: "no-such-revision"}); err == nil {
+		t.Error("expected an error for an unknown revision")
+	}
+}
+
+func TestSampleCommits(t *testing.T) {
+	tests := []struct {
+		count, samples int
+		want           []int
+	}{
+		{count: 3, samples: 10, want: []int{0, 1, 2}},
+		{count: 10, samples: 4, want: []int{0, 3, 6, 9}},
+		{count: 5, samples: 1, want: []int{4}},
+	}
+	for _, tt := range tests {
+		if got := sampleCommits(tt.count, tt.samples); !slices.Equal(got, tt.want) {
+			t.Errorf("sampleCommits(%d, %d) = %v, want %v", tt.count, tt.samples, got, tt.want)
+		}
+	}
+}
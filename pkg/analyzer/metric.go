@@ -0,0 +1,80 @@
+package analyzer
+
+import (
+	"errors"
+	goast "go/ast"
+
+	"github.com/paveg/similarity-go/internal/config"
+	"github.com/paveg/similarity-go/internal/similarity"
+)
+
+// Names of the built-in metrics accepted by WithMetrics.
+const (
+	MetricTreeEdit        = config.MetricTreeEdit
+	MetricTokenSimilarity = config.MetricTokenSimilarity
+	MetricStructural      = config.MetricStructural
+	MetricSignature       = config.MetricSignature
+	MetricControlFlow     = config.MetricControlFlow
+	MetricDependence      = config.MetricDependence
+	MetricAPIUsage        = config.MetricAPIUsage
+)
+
+// Metric is a similarity measure between two functions. Once registered with
+// RegisterMetric, it can be enabled by name with WithMetrics or the metrics list of the
+// configuration file, and its score is combined with the other enabled metrics.
+type Metric interface {
+	// Name identifies the metric in the configuration and in explanations.
+	Name() string
+	// Compute returns the similarity of two functions, from 0.0 to 1.0.
+	Compute(features Features) float64
+}
+
+// Features are the functions a Metric compares.
+type Features struct {
+	// File1 and File2 are the files declaring the functions.
+	File1, File2 string
+	// Decl1 and Decl2 are the declarations of the functions, as parsed.
+	Decl1, Decl2 *goast.FuncDecl
+}
+
+// MetricWeight enables a metric by name with its weight.
+type MetricWeight struct {
+	Name   string
+	Weight float64
+}
+
+// RegisterMetric makes metric available to WithMetrics and configuration files under its
+// name, which must not be taken by a built-in or registered metric. Metrics are
+// registered for the whole process, typically from an init function.
+func RegisterMetric(metric Metric) error {
+	if metric == nil {
+		return errors.New("metric must not be nil")
+	}
+
+	return similarity.RegisterMetric(similarity.NewMetric(metric.Name(), func(f similarity.Features) float64 {
+		return metric.Compute(Features{
+			File1: f.Function1.File,
+			File2: f.Function2.File,
+			Decl1: f.Function1.AST,
+			Decl2: f.Function2.AST,
+		})
+	}))
+}
+
+// MetricNames returns the names of the built-in and registered metrics, sorted.
+func MetricNames() []string {
+	return similarity.MetricNames()
+}
+
+// WithMetrics sets the metrics combined into the similarity score and their weights,
+// replacing those of WithWeights. The weights must be positive and sum to 1.0, and every
+// metric must be built in or registered.
+func WithMetrics(metrics ...MetricWeight) Option {
+	return func(s *settings) error {
+		s.config.Similarity.Metrics = make(config.MetricWeights, len(metrics))
+		for i, metric := range metrics {
+			s.config.Similarity.Metrics[i] = config.MetricWeight(metric)
+		}
+		return nil
+	}
+}
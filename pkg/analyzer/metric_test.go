@@ -0,0 +1,79 @@
+package analyzer_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+// paramCountMetric scores 1 for functions with as many parameters, and 0 otherwise.
+type paramCountMetric struct{}
+
+func (paramCountMetric) Name() string { return "test_param_count" }
+
+func (paramCountMetric) Compute(features analyzer.Features) float64 {
+	if features.Decl1.Type.Params.NumFields() == features.Decl2.Type.Params.NumFields() {
+		return 1.0
+	}
+	return 0.0
+}
+
+func TestRegisteredMetric(t *testing.T) {
+	if !slices.Contains(analyzer.MetricNames(), paramCountMetric{}.Name()) {
+		if err := analyzer.RegisterMetric(paramCountMetric{}); err != nil {
+			t.Fatalf("RegisterMetric() error = %v", err)
+		}
+	}
+	if err := analyzer.RegisterMetric(paramCountMetric{}); err == nil {
+		t.Error("expected an error registering a metric twice")
+	}
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	configYAML := `similarity:
+  metrics:
+    - name: tree_edit
+      weight: 0.5
+    - name: test_param_count
+      weight: 0.5
+`
+	if err := os.WriteFile(configPath, []byte(configYAML), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	file := writeTestFileIn(t, dir, "a.go")
+	other := writeTestFileIn(t, dir, "b.go")
+
+	a, err := analyzer.New(analyzer.WithConfigFile(configPath))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	explanation, err := a.Explain(context.Background(), file+":Sum", other+":Sum")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, component := range explanation.Components {
+		names = append(names, component.Name)
+	}
+	if !slices.Equal(names, []string{analyzer.MetricTreeEdit, "test_param_count"}) {
+		t.Fatalf("unexpected components %v", names)
+	}
+	if explanation.Components[1].Score != 1.0 {
+		t.Errorf("expected the registered metric to score 1, got %f", explanation.Components[1].Score)
+	}
+
+	_, err = analyzer.New(analyzer.WithMetrics(
+		analyzer.MetricWeight{Name: analyzer.MetricTreeEdit, Weight: 0.5},
+		analyzer.MetricWeight{Name: "naming_style", Weight: 0.5},
+	))
+	if err == nil {
+		t.Error("expected an error enabling a metric that is not registered")
+	}
+}
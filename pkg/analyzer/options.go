@@ -0,0 +1,290 @@
+package analyzer
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/paveg/similarity-go/internal/config"
+	"github.com/paveg/similarity-go/internal/worker"
+)
+
+// Option configures an Analyzer. Options are applied in order, so later options
+// override earlier ones.
+type Option func(*settings) error
+
+// settings holds the configuration assembled from options.
+type settings struct {
+	config   *config.Config
+	logger   io.Writer
+	progress func(completed, total int)
+	shard    worker.Shard
+}
+
+// Grouping strategies accepted by WithGrouping.
+const (
+	// GroupingComponents groups every function transitively connected by a similar pair.
+	GroupingComponents = config.GroupingComponents
+	// GroupingCompleteLinkage only groups functions that are all similar to each other,
+	// merging the most similar clusters first.
+	GroupingCompleteLinkage = config.GroupingCompleteLinkage
+	// GroupingAverageLinkage merges clusters while their average similarity, counting
+	// pairs below the threshold as 0, reaches the threshold.
+	GroupingAverageLinkage = config.GroupingAverageLinkage
+	// GroupingCliques repeatedly extracts the largest set of functions that are all
+	// similar to each other.
+	GroupingCliques = config.GroupingCliques
+)
+
+// Analysis units accepted by WithUnits.
+const (
+	// UnitFuncs compares function and method bodies.
+	UnitFuncs = config.UnitFuncs
+	// UnitTypes compares struct, interface and function type declarations.
+	UnitTypes = config.UnitTypes
+)
+
+// Weights mirrors the similarity weights of the configuration file.
+// TreeEdit, TokenSimilarity, Structural and Signature must be positive and sum to 1.0.
+type Weights struct {
+	TreeEdit           float64
+	TokenSimilarity    float64
+	Structural         float64
+	Signature          float64
+	DifferentSignature float64 // Penalty factor applied when signatures differ
+	ControlFlow        float64 // Weight of the control-flow graph metric; 0 disables it
+	Dependence         float64 // Weight of the program-dependence graph metric; 0 disables it
+	APIUsage           float64 // Weight of the API usage metric; 0 disables it
+}
+
+// Thresholds mirrors the similarity thresholds of the configuration file.
+type Thresholds struct {
+	DefaultSimilarOperations float64
+	StatementCountPenalty    float64
+	MinSimilarity            float64
+}
+
+// Limits mirrors the performance and quality limits of the configuration file.
+type Limits struct {
+	MaxSignatureLengthDiff int
+	MaxLineDifferenceRatio float64
+	MaxCacheSize           int
+}
+
+// Normalization mirrors the normalization rewrites of the configuration file, bringing
+// equivalent code written in different ways to one canonical form before comparison.
+type Normalization struct {
+	CommutativeOperands bool // Order the operands of commutative operators, so a + b matches b + a
+	NegatedConditions   bool // Rewrite if !c {A} else {B} as if c {B} else {A}
+	NilComparisons      bool // Write comparisons with nil as x != nil rather than nil != x
+	VarDeclarations     bool // Rewrite var x T = v as x := v
+	IncDec              bool // Rewrite i += 1 as i++ and i -= 1 as i--
+	RangeLoops          bool // Rewrite index loops over slices and counts as range loops
+}
+
+// DefaultWeights returns the weights used when WithWeights is not given.
+func DefaultWeights() Weights {
+	w := config.Default().Similarity.Weights
+	return Weights{
+		TreeEdit:           w.TreeEdit,
+		TokenSimilarity:    w.TokenSimilarity,
+		Structural:         w.Structural,
+		Signature:          w.Signature,
+		DifferentSignature: w.DifferentSignature,
+	}
+}
+
+// WithConfigFile loads a YAML configuration file as the base configuration.
+// It replaces every setting made by earlier options, so pass it first.
+func WithConfigFile(path string) Option {
+	return func(s *settings) error {
+		if !fileExists(path) {
+			return fmt.Errorf("config file %s does not exist", path)
+		}
+
+		cfg, err := config.Load(path)
+		if err != nil {
+			return err
+		}
+
+		s.config = cfg
+		return nil
+	}
+}
+
+// WithThreshold sets the minimum similarity (0.0-1.0) for two functions to match.
+func WithThreshold(threshold float64) Option {
+	return func(s *settings) error {
+		s.config.CLI.DefaultThreshold = threshold
+		return nil
+	}
+}
+
+// WithMinLines sets the minimum number of lines a function needs to be analyzed.
+func WithMinLines(minLines int) Option {
+	return func(s *settings) error {
+		s.config.CLI.DefaultMinLines = minLines
+		return nil
+	}
+}
+
+// WithWorkers sets the number of parallel workers. Values of 1 or less compare serially.
+func WithWorkers(workers int) Option {
+	return func(s *settings) error {
+		s.config.CLI.DefaultWorkers = workers
+		return nil
+	}
+}
+
+// WithCache enables or disables result caching.
+func WithCache(enabled bool) Option {
+	return func(s *settings) error {
+		s.config.CLI.DefaultCache = enabled
+		return nil
+	}
+}
+
+// WithWeights sets the weights used to combine the built-in similarity metrics. Use
+// WithMetrics to enable registered metrics.
+func WithWeights(weights Weights) Option {
+	return func(s *settings) error {
+		s.config.Similarity.Weights = config.SimilarityWeights{
+			TreeEdit:           weights.TreeEdit,
+			TokenSimilarity:    weights.TokenSimilarity,
+			Structural:         weights.Structural,
+			Signature:          weights.Signature,
+			DifferentSignature: weights.DifferentSignature,
+			ControlFlow:        weights.ControlFlow,
+			Dependence:         weights.Dependence,
+			APIUsage:           weights.APIUsage,
+		}
+		return nil
+	}
+}
+
+// WithAPIUsageIgnore sets the path.Match patterns of the calls left out of the API usage
+// metric, replacing the default logging calls. No pattern compares every call.
+func WithAPIUsageIgnore(patterns ...string) Option {
+	return func(s *settings) error {
+		s.config.Similarity.APIUsage.Ignore = patterns
+		return nil
+	}
+}
+
+// WithNormalization selects the rewrites applied to functions before they are compared,
+// so that superficially rewritten copies are still detected. Every rewrite is disabled
+// by default.
+func WithNormalization(normalization Normalization) Option {
+	return func(s *settings) error {
+		s.config.Similarity.Normalization = config.NormalizationConfig(normalization)
+		return nil
+	}
+}
+
+// WithThresholds sets the secondary thresholds used inside the similarity metrics.
+func WithThresholds(thresholds Thresholds) Option {
+	return func(s *settings) error {
+		s.config.Similarity.Thresholds = config.SimilarityThresholds{
+			DefaultSimilarOperations: thresholds.DefaultSimilarOperations,
+			StatementCountPenalty:    thresholds.StatementCountPenalty,
+			MinSimilarity:            thresholds.MinSimilarity,
+		}
+		return nil
+	}
+}
+
+// WithLimits sets the performance and quality limits used by the prefilters and cache.
+func WithLimits(limits Limits) Option {
+	return func(s *settings) error {
+		s.config.Similarity.Limits = config.SimilarityLimits{
+			MaxSignatureLengthDiff: limits.MaxSignatureLengthDiff,
+			MaxLineDifferenceRatio: limits.MaxLineDifferenceRatio,
+			MaxCacheSize:           limits.MaxCacheSize,
+		}
+		return nil
+	}
+}
+
+// WithMaxEmptyVsPopulated sets how many statements a function may have while still
+// being compared with a function that has an empty body.
+func WithMaxEmptyVsPopulated(statements int) Option {
+	return func(s *settings) error {
+		s.config.Processing.MaxEmptyVsPopulated = statements
+		return nil
+	}
+}
+
+// WithRefactorSuggestion sets the suggestion attached to every reported group.
+func WithRefactorSuggestion(suggestion string) Option {
+	return func(s *settings) error {
+		s.config.Output.RefactorSuggestion = suggestion
+		return nil
+	}
+}
+
+// WithGrouping sets how similar pairs are clustered into groups: GroupingComponents
+// (the default), GroupingCompleteLinkage, GroupingAverageLinkage or GroupingCliques.
+func WithGrouping(strategy string) Option {
+	return func(s *settings) error {
+		s.config.Processing.Grouping = strategy
+		return nil
+	}
+}
+
+// WithUnits sets the kinds of declarations compared: UnitFuncs (the default), UnitTypes
+// or both. Types are only compared with types.
+func WithUnits(units ...string) Option {
+	return func(s *settings) error {
+		s.config.Processing.Units = units
+		return nil
+	}
+}
+
+// WithTop limits reports to the n groups with the highest refactoring value.
+// The summary still counts every group; 0 reports every group.
+func WithTop(n int) Option {
+	return func(s *settings) error {
+		s.config.Output.Top = n
+		return nil
+	}
+}
+
+// WithIgnoreFile sets the ignore file whose patterns exclude files while scanning directories.
+// An empty path disables the ignore file.
+func WithIgnoreFile(path string) Option {
+	return func(s *settings) error {
+		s.config.Ignore.DefaultFile = path
+		return nil
+	}
+}
+
+// WithLogger enables diagnostic messages, such as skipped files, written to w.
+func WithLogger(w io.Writer) Option {
+	return func(s *settings) error {
+		s.logger = w
+		return nil
+	}
+}
+
+// WithProgress registers a callback reporting the number of completed comparisons.
+// It is only called when comparisons run in parallel.
+func WithProgress(callback func(completed, total int)) Option {
+	return func(s *settings) error {
+		s.progress = callback
+		return nil
+	}
+}
+
+// WithShard restricts Partial to shard index (1 to count) of count. The pairwise
+// comparisons are dealt round-robin to the shards, so running every shard over the
+// same targets and merging the results with Merge yields the report of Analyze.
+func WithShard(index, count int) Option {
+	return func(s *settings) error {
+		shard := worker.Shard{Index: index, Count: count}
+		if err := shard.Validate(); err != nil {
+			return err
+		}
+
+		s.shard = shard
+		return nil
+	}
+}
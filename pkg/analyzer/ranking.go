@@ -0,0 +1,57 @@
+package analyzer
+
+import (
+	"math"
+	"sort"
+)
+
+// valuePrecision rounds refactoring values to two decimals.
+const valuePrecision = 100
+
+// duplicatedLines returns the number of lines removed by consolidating a group into its
+// longest member.
+func duplicatedLines(functions []FunctionRef) int {
+	total, longest := 0, 0
+	for _, fn := range functions {
+		lines := fn.EndLine - fn.StartLine + 1
+		total += lines
+		longest = max(longest, lines)
+	}
+	return total - longest
+}
+
+// refactoringValue estimates the value of consolidating a group as the duplicated lines
+// it removes, weighted by how similar its members are and by the square root of their
+// average complexity: removing copies of branchy code pays off more than removing
+// copies of straight-line code.
+func refactoringValue(group Group) float64 {
+	if len(group.Functions) == 0 {
+		return 0
+	}
+
+	complexity := 0
+	for _, fn := range group.Functions {
+		complexity += max(fn.Complexity, 1)
+	}
+	averageComplexity := float64(complexity) / float64(len(group.Functions))
+
+	value := float64(group.DuplicatedLines) * group.SimilarityScore * math.Sqrt(averageComplexity)
+	return math.Round(value*valuePrecision) / valuePrecision
+}
+
+// sortGroups orders groups by descending refactoring value, then descending score,
+// then descending size, then location of their first member.
+func sortGroups(groups []Group) {
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].RefactoringValue != groups[j].RefactoringValue {
+			return groups[i].RefactoringValue > groups[j].RefactoringValue
+		}
+		if groups[i].SimilarityScore != groups[j].SimilarityScore {
+			return groups[i].SimilarityScore > groups[j].SimilarityScore
+		}
+		if len(groups[i].Functions) != len(groups[j].Functions) {
+			return len(groups[i].Functions) > len(groups[j].Functions)
+		}
+		return lessLocation(groups[i].Functions[0], groups[j].Functions[0])
+	})
+}
@@ -0,0 +1,43 @@
+package analyzer
+
+import (
+	"testing"
+)
+
+func TestRefactoringValue(t *testing.T) {
+	functions := []FunctionRef{
+		{File: "a.go", StartLine: 1, EndLine: 20, Complexity: 4},
+		{File: "b.go", StartLine: 1, EndLine: 10, Complexity: 4},
+		{File: "c.go", StartLine: 1, EndLine: 15, Complexity: 4},
+	}
+
+	if lines := duplicatedLines(functions); lines != 25 {
+		t.Fatalf("expected 25 duplicated lines, got %d", lines)
+	}
+
+	group := Group{Functions: functions, SimilarityScore: 0.9, DuplicatedLines: 25}
+	// 25 lines * 0.9 similarity * sqrt(4) complexity
+	if value := refactoringValue(group); value != 45 {
+		t.Errorf("expected value 45, got %f", value)
+	}
+
+	if value := refactoringValue(Group{}); value != 0 {
+		t.Errorf("expected no value for an empty group, got %f", value)
+	}
+}
+
+func TestSortGroupsByValue(t *testing.T) {
+	groups := []Group{
+		{ID: "low", RefactoringValue: 5, SimilarityScore: 1.0, Functions: []FunctionRef{{File: "a.go"}}},
+		{ID: "high", RefactoringValue: 50, SimilarityScore: 0.8, Functions: []FunctionRef{{File: "b.go"}}},
+		{ID: "tie-later", RefactoringValue: 5, SimilarityScore: 1.0, Functions: []FunctionRef{{File: "c.go"}}},
+	}
+
+	sortGroups(groups)
+
+	for i, want := range []string{"high", "low", "tie-later"} {
+		if groups[i].ID != want {
+			t.Errorf("position %d: expected %s, got %s", i, want, groups[i].ID)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package analyzer
+
+import (
+	"fmt"
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"sort"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/refactor"
+	"github.com/paveg/similarity-go/internal/similarity"
+)
+
+// renameCheckMinSimilarity is the similarity from which pairs are close enough copies to
+// be checked for inconsistent renames.
+const renameCheckMinSimilarity = 0.9
+
+// renameInconsistencies checks the highly similar pairs of every group for variables
+// renamed inconsistently, a typical copy-paste bug. The declarations are parsed again
+// from their files, so that identifiers are mapped before normalization and reported at
+// their line. Groups of type declarations are skipped.
+func (a *Analyzer) renameInconsistencies(groups []Group, matches []similarity.Match) []RenameInconsistency {
+	minSimilarity := max(a.config.CLI.DefaultThreshold, renameCheckMinSimilarity)
+
+	similar := make(map[string]bool)
+	for _, match := range matches {
+		if match.Similarity >= minSimilarity {
+			similar[pairKey(match.Function1.File, match.Function1.StartLine, match.Function2.File, match.Function2.StartLine)] = true
+		}
+	}
+
+	files := make(map[string]*parsedFile)
+	var result []RenameInconsistency
+	for _, group := range groups {
+		if group.Kind == GroupKindTypes {
+			continue
+		}
+		for i, first := range group.Functions {
+			for _, second := range group.Functions[i+1:] {
+				if !similar[pairKey(first.File, first.StartLine, second.File, second.StartLine)] {
+					continue
+				}
+				result = append(result, oneFindingPerSite(
+					a.checkRenames(files, group.ID, first, second),
+					a.checkRenames(files, group.ID, second, first),
+				)...)
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Function.File != result[j].Function.File {
+			return result[i].Function.File < result[j].Function.File
+		}
+		return result[i].Line < result[j].Line
+	})
+	return result
+}
+
+// renameFinding is an inconsistent rename along with the number of places where the
+// variable is renamed consistently.
+type renameFinding struct {
+	RenameInconsistency
+	consistent int
+}
+
+// checkRenames reports the places where clone does not follow the renaming of the
+// variables of original it applies everywhere else.
+func (a *Analyzer) checkRenames(files map[string]*parsedFile, groupID string, original, clone FunctionRef) []renameFinding {
+	originalDecl, originalFset := a.parsedDecl(files, original)
+	cloneDecl, fset := a.parsedDecl(files, clone)
+	if originalDecl == nil || cloneDecl == nil {
+		return nil
+	}
+
+	var result []renameFinding
+	for _, found := range refactor.RenameInconsistencies(originalDecl, cloneDecl) {
+		originalLine := originalFset.Position(found.Original).Line
+		result = append(result, renameFinding{RenameInconsistency{
+			Rule:         RuleInconsistentRename,
+			GroupID:      groupID,
+			Function:     clone,
+			Original:     original,
+			Line:         fset.Position(found.Pos).Line,
+			OriginalLine: originalLine,
+			Variable:     found.Name,
+			Expected:     found.Renamed,
+			Found:        found.Found,
+			Message: fmt.Sprintf(
+				"%s is used where %s is expected: %s of %s, used at line %d, is renamed to %s in %d other places",
+				found.Found, found.Renamed, found.Name, original.DisplayName(),
+				originalLine, found.Renamed, found.Consistent,
+			),
+		}, found.Consistent})
+	}
+	return result
+}
+
+// oneFindingPerSite combines the findings of both directions of a pair. An inconsistent
+// site is usually found in each direction, once in each function, and only the finding
+// whose variable is renamed consistently in more places is kept, as the other direction
+// mistakes the correct copy for the clone.
+func oneFindingPerSite(forward, backward []renameFinding) []RenameInconsistency {
+	kept := append([]renameFinding(nil), forward...)
+	for _, found := range backward {
+		same := slices.IndexFunc(kept, func(other renameFinding) bool {
+			return other.Line == found.OriginalLine && other.OriginalLine == found.Line
+		})
+		switch {
+		case same < 0:
+			kept = append(kept, found)
+		case found.consistent > kept[same].consistent:
+			kept[same] = found
+		}
+	}
+
+	result := make([]RenameInconsistency, len(kept))
+	for i, found := range kept {
+		result[i] = found.RenameInconsistency
+	}
+	return result
+}
+
+// parsedFile is a file parsed again to check renames or merge partial reports.
+type parsedFile struct {
+	fset *token.FileSet
+	file *goast.File
+}
+
+// parsedDecl returns the declaration of ref parsed again from its file, along with the
+// file set locating it. It returns nil when the file cannot be parsed or no longer
+// declares the function at the same line.
+func (a *Analyzer) parsedDecl(files map[string]*parsedFile, ref FunctionRef) (*goast.FuncDecl, *token.FileSet) {
+	parsed, exists := files[ref.File]
+	if !exists {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, ref.File, nil, parser.ParseComments)
+		if err != nil {
+			a.logf("Failed to parse %s again: %v", ref.File, err)
+			file = nil
+		}
+		parsed = &parsedFile{fset: fset, file: file}
+		files[ref.File] = parsed
+	}
+	if parsed.file == nil {
+		return nil, nil
+	}
+
+	return findDecl(parsed.fset, parsed.file, &ast.Function{Name: ref.Function, StartLine: ref.StartLine}), parsed.fset
+}
+
+// pairKey identifies an unordered pair of functions by their locations.
+func pairKey(file1 string, line1 int, file2 string, line2 int) string {
+	first := fmt.Sprintf("%s:%d", file1, line1)
+	second := fmt.Sprintf("%s:%d", file2, line2)
+	if second < first {
+		first, second = second, first
+	}
+	return first + "\x00" + second
+}
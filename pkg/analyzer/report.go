@@ -0,0 +1,304 @@
+package analyzer
+
+// Sides of a function in a comparison report.
+const (
+	SideLeft  = "left"
+	SideRight = "right"
+)
+
+// Kinds of findings a group reports.
+const (
+	// GroupKindClones is a group of similar functions.
+	GroupKindClones = "clones"
+	// GroupKindMethodFamily is a group of methods sharing a name on different receiver types.
+	GroupKindMethodFamily = "method_family"
+	// GroupKindTypes is a group of similar type declarations.
+	GroupKindTypes = "types"
+)
+
+// Rules a report can be checked against, such as by the --fail-on flag.
+const (
+	// RuleClones is broken by every group of similar declarations.
+	RuleClones = "clones"
+	// RuleInconsistentRename is broken by every clone that does not rename a variable the
+	// same way everywhere.
+	RuleInconsistentRename = "inconsistent-rename"
+)
+
+// Rules returns the rules a report can be checked against.
+func Rules() []string {
+	return []string{RuleClones, RuleInconsistentRename}
+}
+
+// Report is the result of an analysis.
+type Report struct {
+	SchemaVersion         string                `json:"schema_version" yaml:"schema_version" doc:"Version of the report schema (major.minor)."`
+	Summary               Summary               `json:"summary" yaml:"summary" doc:"Aggregate numbers about the analysis."`
+	SimilarGroups         []Group               `json:"similar_groups" yaml:"similar_groups" doc:"Groups of similar functions."`
+	RenameInconsistencies []RenameInconsistency `json:"rename_inconsistencies,omitempty" yaml:"rename_inconsistencies,omitempty" doc:"Clones that do not rename a variable the same way everywhere (inconsistent-rename rule)."`
+}
+
+// Violations returns the number of findings of the report breaking rule.
+func (r *Report) Violations(rule string) int {
+	switch rule {
+	case RuleClones:
+		return r.Summary.SimilarGroups
+	case RuleInconsistentRename:
+		return len(r.RenameInconsistencies)
+	default:
+		return 0
+	}
+}
+
+// RenameInconsistency is a line of a clone using another name for a variable it renames
+// everywhere else, typically a copy-paste bug where one use was forgotten.
+type RenameInconsistency struct {
+	Rule         string      `json:"rule" yaml:"rule" doc:"Rule broken by the finding: inconsistent-rename."`
+	GroupID      string      `json:"group_id" yaml:"group_id" doc:"ID of the group holding both functions."`
+	Function     FunctionRef `json:"function" yaml:"function" doc:"Function holding the suspicious line."`
+	Original     FunctionRef `json:"original" yaml:"original" doc:"Similar function whose variable is renamed."`
+	Line         int         `json:"line" yaml:"line" doc:"Suspicious line of function."`
+	OriginalLine int         `json:"original_line" yaml:"original_line" doc:"Line of original at the same place."`
+	Variable     string      `json:"variable" yaml:"variable" doc:"Variable of the original function."`
+	Expected     string      `json:"expected" yaml:"expected" doc:"Name function uses for the variable everywhere else."`
+	Found        string      `json:"found" yaml:"found" doc:"Name function uses on the suspicious line."`
+	Message      string      `json:"message" yaml:"message" doc:"Human-readable description of the finding."`
+}
+
+// Summary contains aggregate numbers about an analysis.
+type Summary struct {
+	TotalFunctions    int `json:"total_functions" yaml:"total_functions" doc:"Number of functions analyzed."`
+	TotalTypes        int `json:"total_types,omitempty" yaml:"total_types,omitempty" doc:"Number of type declarations analyzed (types unit only)."`
+	SimilarGroups     int `json:"similar_groups" yaml:"similar_groups" doc:"Number of similar groups."`
+	TotalDuplications int `json:"total_duplications" yaml:"total_duplications" doc:"Number of distinct functions that belong to a group."`
+	LeftFunctions     int `json:"left_functions,omitempty" yaml:"left_functions,omitempty" doc:"Number of left-hand functions (compare only)."`
+	RightFunctions    int `json:"right_functions,omitempty" yaml:"right_functions,omitempty" doc:"Number of right-hand functions (compare only)."`
+}
+
+// Group is a set of functions that are similar to each other.
+type Group struct {
+	ID                 string        `json:"id" yaml:"id" doc:"Identifier derived from the member fingerprints, stable across runs."`
+	Kind               string        `json:"kind" yaml:"kind" doc:"Kind of finding: clones, method_family for methods sharing a name on different receiver types, or types for type declarations."`
+	SimilarityScore    float64       `json:"similarity_score" yaml:"similarity_score" doc:"Average similarity of the similar pairs in the group (0.0-1.0)."`
+	Cohesion           float64       `json:"cohesion" yaml:"cohesion" doc:"Lowest similarity between any two members, including pairs below the threshold."`
+	DuplicatedLines    int           `json:"duplicated_lines" yaml:"duplicated_lines" doc:"Lines removed by keeping only the longest member."`
+	RefactoringValue   float64       `json:"refactoring_value" yaml:"refactoring_value" doc:"Estimated value of consolidating the group; groups are sorted by it."`
+	Functions          []FunctionRef `json:"functions" yaml:"functions" doc:"Members of the group."`
+	RefactorSuggestion string        `json:"refactor_suggestion" yaml:"refactor_suggestion" doc:"Suggested refactoring."`
+	Extraction         *Extraction   `json:"extraction,omitempty" yaml:"extraction,omitempty" doc:"Shared function that could replace the members, when their differences are expressions."`
+	MethodFamily       *MethodFamily `json:"method_family,omitempty" yaml:"method_family,omitempty" doc:"Receiver types implementing the method, for method_family groups."`
+}
+
+// MethodFamily is a method implemented in parallel by several receiver types, and how to
+// consolidate it.
+type MethodFamily struct {
+	Method    string   `json:"method" yaml:"method" doc:"Name of the method."`
+	Types     []string `json:"types" yaml:"types" doc:"Receiver types declaring the method, in the order of functions."`
+	Strategy  string   `json:"strategy" yaml:"strategy" doc:"Proposed consolidation: interface, or embedding when the methods only use the same receiver fields."`
+	Interface string   `json:"interface" yaml:"interface" doc:"Declaration of an interface made of the method."`
+	Fields    []string `json:"fields,omitempty" yaml:"fields,omitempty" doc:"Receiver fields to move into the embedded struct (embedding only)."`
+}
+
+// Extraction is a shared function that could replace every member of a group. Sub-expressions
+// that differ between the members become its parameters.
+type Extraction struct {
+	Name       string               `json:"name" yaml:"name" doc:"Proposed name of the shared function."`
+	Signature  string               `json:"signature" yaml:"signature" doc:"Signature of the shared function."`
+	Body       string               `json:"body" yaml:"body" doc:"Sketch of the shared function."`
+	Parameters []ExtractedParameter `json:"parameters" yaml:"parameters" doc:"Differences between the members, turned into parameters."`
+	CallSites  []CallSite           `json:"call_sites" yaml:"call_sites" doc:"Body replacing each member, in the order of functions."`
+	Generic    bool                 `json:"generic" yaml:"generic" doc:"The members only differ by types, so the shared function is a generic version of them."`
+	Imports    []string             `json:"imports,omitempty" yaml:"imports,omitempty" doc:"Packages the shared function needs besides those of the members, such as cmp."`
+}
+
+// ExtractedParameter is a difference between the members of a group.
+type ExtractedParameter struct {
+	Name   string   `json:"name" yaml:"name" doc:"Name of the parameter, or of the type parameter for types."`
+	Type   string   `json:"type" yaml:"type" doc:"Type of the parameter, or constraint inferred for the type parameter; any when unknown."`
+	Kind   string   `json:"kind" yaml:"kind" doc:"What differs: literal, identifier, call, type or expression."`
+	Values []string `json:"values" yaml:"values" doc:"Code of the difference in each member, in the order of functions."`
+}
+
+// CallSite is the call of the shared function replacing the body of a member.
+type CallSite struct {
+	File     string `json:"file" yaml:"file" doc:"Path of the file declaring the member."`
+	Function string `json:"function" yaml:"function" doc:"Qualified name of the member."`
+	Call     string `json:"call" yaml:"call" doc:"Statement replacing the body of the member."`
+}
+
+// FunctionRef identifies a function, or a type declaration in types groups, and its location.
+type FunctionRef struct {
+	File          string `json:"file" yaml:"file" doc:"Path of the file declaring the function."`
+	Function      string `json:"function" yaml:"function" doc:"Name of the function."`
+	QualifiedName string `json:"qualified_name,omitempty" yaml:"qualified_name,omitempty" doc:"Name qualified by the package name and receiver type, such as store.(*Client).Close."`
+	Receiver      string `json:"receiver,omitempty" yaml:"receiver,omitempty" doc:"Receiver type of a method, such as *Client."`
+	Package       string `json:"package,omitempty" yaml:"package,omitempty" doc:"Name of the declaring package."`
+	ImportPath    string `json:"import_path,omitempty" yaml:"import_path,omitempty" doc:"Import path of the declaring package, when it belongs to a module."`
+	StartLine     int    `json:"start_line" yaml:"start_line" doc:"First line of the declaration."`
+	EndLine       int    `json:"end_line" yaml:"end_line" doc:"Last line of the declaration."`
+	Hash          string `json:"hash" yaml:"hash" doc:"Structural hash of the normalized function."`
+	Fingerprint   string `json:"fingerprint" yaml:"fingerprint" doc:"Location-independent identity of the function, stable when it moves."`
+	Complexity    int    `json:"complexity" yaml:"complexity" doc:"Cyclomatic complexity of the function."`
+	Side          string `json:"side,omitempty" yaml:"side,omitempty" doc:"Side the function belongs to: left or right (compare only)."`
+	TypeKind      string `json:"type_kind,omitempty" yaml:"type_kind,omitempty" doc:"Kind of a type declaration: struct, interface or func; empty for functions."`
+}
+
+// DisplayName returns the qualified name of the function, or its name in reports written
+// before qualified names existed.
+func (ref FunctionRef) DisplayName() string {
+	if ref.QualifiedName != "" {
+		return ref.QualifiedName
+	}
+	return ref.Function
+}
+
+// FindResult is the result of a nearest-neighbor search.
+type FindResult struct {
+	SchemaVersion string      `json:"schema_version" yaml:"schema_version" doc:"Version of the report schema (major.minor)."`
+	Query         FunctionRef `json:"query" yaml:"query" doc:"Function whose neighbors were searched."`
+	Summary       FindSummary `json:"summary" yaml:"summary" doc:"Aggregate numbers about the search."`
+	Results       []Neighbor  `json:"results" yaml:"results" doc:"Nearest neighbors, most similar first."`
+}
+
+// FindSummary contains aggregate numbers about a nearest-neighbor search.
+type FindSummary struct {
+	TotalFunctions int `json:"total_functions" yaml:"total_functions" doc:"Number of candidate functions compared with the query."`
+	Results        int `json:"results" yaml:"results" doc:"Number of neighbors reported."`
+}
+
+// Neighbor is a function found by Find, ranked by similarity to the query.
+type Neighbor struct {
+	FunctionRef `yaml:",inline"`
+
+	Rank            int     `json:"rank" yaml:"rank" doc:"Position in the results, starting at 1."`
+	SimilarityScore float64 `json:"similarity_score" yaml:"similarity_score" doc:"Similarity to the query (0.0-1.0)."`
+}
+
+// Explanation describes how the similarity of two functions was computed.
+type Explanation struct {
+	SchemaVersion   string           `json:"schema_version" yaml:"schema_version" doc:"Version of the report schema (major.minor)."`
+	Functions       []FunctionRef    `json:"functions" yaml:"functions" doc:"The two functions compared."`
+	SimilarityScore float64          `json:"similarity_score" yaml:"similarity_score" doc:"Final similarity (0.0-1.0)."`
+	Threshold       float64          `json:"threshold" yaml:"threshold" doc:"Configured similarity threshold."`
+	AboveThreshold  bool             `json:"above_threshold" yaml:"above_threshold" doc:"Whether the similarity reaches the threshold."`
+	Shortcut        string           `json:"shortcut" yaml:"shortcut" doc:"Shortcut that decided the score, empty when the weighted score was used."`
+	WeightedScore   float64          `json:"weighted_score" yaml:"weighted_score" doc:"Weighted combination of the components."`
+	Prefilters      []PrefilterCheck `json:"prefilters" yaml:"prefilters" doc:"Quick checks performed before the full comparison."`
+	Components      []ComponentScore `json:"components" yaml:"components" doc:"Weighted metrics making up the weighted score."`
+	Alignment       []AlignedLine    `json:"alignment" yaml:"alignment" doc:"Aligned diff of the normalized bodies."`
+}
+
+// PrefilterCheck is the outcome of one quick heuristic check performed before full comparison.
+type PrefilterCheck struct {
+	Name   string `json:"name" yaml:"name" doc:"Name of the check."`
+	Passed bool   `json:"passed" yaml:"passed" doc:"Whether the pair passed the check."`
+	Detail string `json:"detail" yaml:"detail" doc:"Human-readable detail of the check."`
+}
+
+// ComponentScore is one weighted metric contributing to the combined similarity.
+type ComponentScore struct {
+	Name         string  `json:"name" yaml:"name" doc:"Name of the metric."`
+	Score        float64 `json:"score" yaml:"score" doc:"Score of the metric (0.0-1.0)."`
+	Weight       float64 `json:"weight" yaml:"weight" doc:"Configured weight of the metric."`
+	Contribution float64 `json:"contribution" yaml:"contribution" doc:"Score multiplied by weight."`
+}
+
+// SiblingReport lists the clone groups of a base tree that a head tree changed
+// inconsistently.
+type SiblingReport struct {
+	SchemaVersion       string               `json:"schema_version" yaml:"schema_version" doc:"Version of the report schema (major.minor)."`
+	Base                string               `json:"base" yaml:"base" doc:"Base directory or revision."`
+	Head                string               `json:"head" yaml:"head" doc:"Head directory or revision."`
+	Summary             SiblingSummary       `json:"summary" yaml:"summary" doc:"Aggregate numbers about the comparison."`
+	InconsistentChanges []InconsistentChange `json:"inconsistent_changes" yaml:"inconsistent_changes" doc:"Groups where some members changed and others did not."`
+}
+
+// SiblingSummary contains aggregate numbers about a comparison of clone siblings.
+type SiblingSummary struct {
+	Groups              int `json:"groups" yaml:"groups" doc:"Number of similar groups in the base tree."`
+	ChangedGroups       int `json:"changed_groups" yaml:"changed_groups" doc:"Number of groups with at least one changed member."`
+	InconsistentChanges int `json:"inconsistent_changes" yaml:"inconsistent_changes" doc:"Number of groups with both changed and untouched members."`
+}
+
+// InconsistentChange is a clone group of which the head tree changed some members but
+// left others untouched. Functions are located in the head tree.
+type InconsistentChange struct {
+	GroupID   string           `json:"group_id" yaml:"group_id" doc:"ID of the group in the base tree."`
+	Changed   []ChangedSibling `json:"changed" yaml:"changed" doc:"Members changed by the head tree."`
+	Untouched []FunctionRef    `json:"untouched" yaml:"untouched" doc:"Members left unchanged by the head tree."`
+	Message   string           `json:"message" yaml:"message" doc:"Human-readable description of the finding."`
+}
+
+// ChangedSibling is a member of a clone group changed by the head tree.
+type ChangedSibling struct {
+	Function FunctionRef   `json:"function" yaml:"function" doc:"Changed function, located in the head tree."`
+	Changes  []AlignedLine `json:"changes" yaml:"changes" doc:"Changed lines of the body: left is the base version, right the head version."`
+}
+
+// AlignedLine is one row of the aligned diff of two normalized function bodies.
+// Kind is one of "match", "changed", "left_only" or "right_only".
+type AlignedLine struct {
+	Kind  string `json:"kind" yaml:"kind" doc:"One of match, changed, left_only or right_only."`
+	Left  string `json:"left" yaml:"left" doc:"Line of the first function, empty for right_only."`
+	Right string `json:"right" yaml:"right" doc:"Line of the second function, empty for left_only."`
+}
+
+// Trends of the similarity of a clone group over its history.
+const (
+	TrendStable     = "stable"
+	TrendDiverging  = "diverging"
+	TrendConverging = "converging"
+)
+
+// HistoryReport traces the clone groups of a revision back through the history of a
+// repository.
+type HistoryReport struct {
+	SchemaVersion string         `json:"schema_version" yaml:"schema_version" doc:"Version of the report schema (major.minor)."`
+	Repository    string         `json:"repository" yaml:"repository" doc:"Path of the git repository."`
+	Revision      CommitRef      `json:"revision" yaml:"revision" doc:"Revision whose clone groups are traced."`
+	Commits       int            `json:"commits" yaml:"commits" doc:"Number of first-parent commits leading to the revision."`
+	Samples       []CommitRef    `json:"samples" yaml:"samples" doc:"Revisions at which the similarity of the groups was measured, oldest first."`
+	Groups        []GroupHistory `json:"groups" yaml:"groups" doc:"History of each clone group of the revision."`
+	Dissolved     []PastGroup    `json:"dissolved,omitempty" yaml:"dissolved,omitempty" doc:"Clone groups found at sampled revisions that no longer exist at the revision, oldest first."`
+}
+
+// CommitRef identifies a commit.
+type CommitRef struct {
+	Commit  string `json:"commit" yaml:"commit" doc:"Full commit hash."`
+	Date    string `json:"date" yaml:"date" doc:"Commit date in RFC 3339 format."`
+	Subject string `json:"subject" yaml:"subject" doc:"First line of the commit message."`
+}
+
+// GroupHistory is the history of a clone group: when it appeared, where each copy came
+// from and how similar the copies have been since.
+type GroupHistory struct {
+	GroupID      string         `json:"group_id" yaml:"group_id" doc:"ID of the group at the revision."`
+	FirstSeen    CommitRef      `json:"first_seen" yaml:"first_seen" doc:"Commit introducing the second copy, when the group appeared."`
+	Copies       []CopyOrigin   `json:"copies" yaml:"copies" doc:"Members of the group with the commit introducing each, oldest first."`
+	Timeline     []HistoryPoint `json:"timeline" yaml:"timeline" doc:"Similarity of the members at the sampled revisions where at least two existed."`
+	Drift        float64        `json:"drift" yaml:"drift" doc:"Change of similarity from the first point of the timeline to the revision; negative when the copies diverged."`
+	Trend        string         `json:"trend" yaml:"trend" doc:"One of stable, diverging or converging."`
+	FormerCopies []FunctionRef  `json:"former_copies,omitempty" yaml:"former_copies,omitempty" doc:"Functions grouped with the copies at sampled revisions that are no longer members, such as removed, moved or renamed copies, as last seen."`
+}
+
+// CopyOrigin is a member of a clone group and the commit introducing it.
+type CopyOrigin struct {
+	Function   FunctionRef `json:"function" yaml:"function" doc:"Member of the group at the revision."`
+	Introduced CommitRef   `json:"introduced" yaml:"introduced" doc:"First commit declaring the function in its file."`
+}
+
+// HistoryPoint is the similarity of the members of a clone group at a sampled revision.
+type HistoryPoint struct {
+	Commit     string  `json:"commit" yaml:"commit" doc:"Sampled commit hash."`
+	Members    int     `json:"members" yaml:"members" doc:"Number of members existing at the commit."`
+	Similarity float64 `json:"similarity" yaml:"similarity" doc:"Average similarity of the pairs of existing members."`
+	Grouped    bool    `json:"grouped" yaml:"grouped" doc:"Whether the analysis of the commit found the members as a group."`
+}
+
+// PastGroup is a clone group found at sampled revisions but not at the traced revision.
+type PastGroup struct {
+	FirstSeen       CommitRef     `json:"first_seen" yaml:"first_seen" doc:"First sampled revision where the group was found."`
+	LastSeen        CommitRef     `json:"last_seen" yaml:"last_seen" doc:"Last sampled revision where the group was found."`
+	SimilarityScore float64       `json:"similarity_score" yaml:"similarity_score" doc:"Similarity of the group at its last sampled revision."`
+	Functions       []FunctionRef `json:"functions" yaml:"functions" doc:"Members of the group at its last sampled revision."`
+}
@@ -0,0 +1,324 @@
+package analyzer
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/paveg/similarity-go/internal/ast"
+)
+
+// functionIgnorePrefix marks the lines of the ignore file that match functions by their
+// qualified name instead of files by their path.
+const functionIgnorePrefix = "func:"
+
+// declarations are the functions and type declarations found in targets.
+type declarations struct {
+	functions []*ast.Function
+	types     []*ast.TypeDecl
+}
+
+// add appends the declarations of other.
+func (d *declarations) add(other declarations) {
+	d.functions = append(d.functions, other.functions...)
+	d.types = append(d.types, other.types...)
+}
+
+// parseAllTargets parses all target files and directories, returning all functions.
+// Errors from individual targets are logged but do not stop processing.
+func (a *Analyzer) parseAllTargets(ctx context.Context, parser *ast.Parser, targets []string) ([]*ast.Function, error) {
+	decls, err := a.parseDeclarations(ctx, parser, targets)
+	if err != nil {
+		return nil, err
+	}
+	return decls.functions, nil
+}
+
+// parseDeclarations parses all target files and directories, returning all functions and
+// type declarations. Errors from individual targets are logged but do not stop processing.
+func (a *Analyzer) parseDeclarations(ctx context.Context, parser *ast.Parser, targets []string) (declarations, error) {
+	var all declarations
+
+	for _, target := range targets {
+		if err := ctx.Err(); err != nil {
+			return declarations{}, err
+		}
+
+		a.logf("Parsing target: %s", target)
+
+		// Accept Go-style recursive patterns; directories are always scanned recursively
+		target = trimRecursivePattern(target)
+
+		// Process target (file or directory)
+		var decls declarations
+		var parseErr error
+
+		if strings.HasSuffix(target, ".go") {
+			decls, parseErr = a.parseGoFile(parser, target)
+		} else {
+			decls, parseErr = a.scanDirectory(ctx, parser, target)
+		}
+
+		if parseErr != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return declarations{}, ctxErr
+			}
+			a.logf("Error processing %s: %v", target, parseErr)
+			continue
+		}
+
+		all.add(decls)
+	}
+
+	return all, nil
+}
+
+// trimRecursivePattern turns Go-style "dir/..." patterns into the directory itself.
+func trimRecursivePattern(target string) string {
+	if target == "..." {
+		return "."
+	}
+	return strings.TrimSuffix(target, "/...")
+}
+
+// parseGoFile parses a single Go file and returns the functions and type declarations that
+// meet the minimum line criteria.
+func (a *Analyzer) parseGoFile(parser *ast.Parser, filePath string) (declarations, error) {
+	result := parser.ParseFile(filePath)
+	if result.IsErr() {
+		return declarations{}, result.Error()
+	}
+
+	parseResult := result.Unwrap()
+	var decls declarations
+
+	var ignoredFunctions []string
+	if a.config.Ignore.DefaultFile != "" {
+		ignoredFunctions = functionIgnorePatterns(a.config.GetIgnoreFilePath())
+	}
+
+	// Filter functions by minimum lines and ignore rules
+	for _, fn := range parseResult.Functions {
+		if fn.LineCount < a.config.CLI.DefaultMinLines {
+			continue
+		}
+		if matchesFunctionPatterns(fn, ignoredFunctions) {
+			a.logf("Ignoring %s", fn.QualifiedName())
+			continue
+		}
+		decls.functions = append(decls.functions, fn)
+	}
+
+	for _, typeDecl := range parseResult.Types {
+		if typeDecl.LineCount >= a.config.CLI.DefaultMinLines {
+			decls.types = append(decls.types, typeDecl)
+		}
+	}
+
+	return decls, nil
+}
+
+// scanDirectory recursively scans a directory for Go files and parses them.
+func (a *Analyzer) scanDirectory(ctx context.Context, parser *ast.Parser, dirPath string) (declarations, error) {
+	info, err := os.Stat(dirPath)
+	if err != nil {
+		return declarations{}, fmt.Errorf("cannot access %s: %w", dirPath, err)
+	}
+
+	if !info.IsDir() {
+		return a.parseGoFile(parser, dirPath)
+	}
+
+	var all declarations
+	walkFunc := a.createWalkFunc(ctx, parser, &all)
+
+	err = filepath.Walk(dirPath, walkFunc)
+	if err != nil {
+		return declarations{}, fmt.Errorf("error walking directory %s: %w", dirPath, err)
+	}
+
+	return all, nil
+}
+
+// createWalkFunc creates a filepath.WalkFunc for directory traversal.
+func (a *Analyzer) createWalkFunc(
+	ctx context.Context,
+	parser *ast.Parser,
+	all *declarations,
+) filepath.WalkFunc {
+	return func(path string, _ os.FileInfo, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		if err != nil {
+			a.logf("Error accessing %s: %v", path, err)
+			return nil
+		}
+
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		if a.shouldIgnoreFile(path) {
+			a.logf("Ignoring %s", path)
+			return nil
+		}
+
+		a.processGoFile(parser, path, all)
+		return nil
+	}
+}
+
+// processGoFile parses a Go file and adds its declarations to the collection.
+func (a *Analyzer) processGoFile(parser *ast.Parser, path string, all *declarations) {
+	a.logf("Parsing file: %s", path)
+
+	decls, parseErr := a.parseGoFile(parser, path)
+	if parseErr != nil {
+		a.logf("Error parsing %s: %v", path, parseErr)
+		return
+	}
+
+	all.add(decls)
+}
+
+// shouldIgnoreFile determines if a file should be ignored based on configuration.
+func (a *Analyzer) shouldIgnoreFile(filePath string) bool {
+	// Skip hidden files and directories
+	base := filepath.Base(filePath)
+	if strings.HasPrefix(base, ".") {
+		return true
+	}
+
+	// Skip vendor directories
+	if strings.Contains(filePath, "/vendor/") || strings.Contains(filePath, "\\vendor\\") {
+		return true
+	}
+
+	// Skip common build/output directories
+	ignoreDirs := []string{"/bin/", "/build/", "/dist/", "/target/", "/.git/"}
+	for _, ignoreDir := range ignoreDirs {
+		if strings.Contains(filePath, ignoreDir) ||
+			strings.Contains(filePath, strings.ReplaceAll(ignoreDir, "/", "\\")) {
+			return true
+		}
+	}
+
+	// Check .similarityignore file patterns
+	if a.config.Ignore.DefaultFile != "" {
+		return matchesIgnorePatterns(filePath, a.config.GetIgnoreFilePath())
+	}
+
+	return false
+}
+
+// matchesIgnorePatterns checks if a file path matches any patterns in the ignore file.
+func matchesIgnorePatterns(filePath, ignoreFilePath string) bool {
+	ignoreFile, err := os.Open(ignoreFilePath)
+	if err != nil {
+		// If ignore file doesn't exist or can't be read, don't ignore anything
+		return false
+	}
+	defer ignoreFile.Close()
+
+	scanner := bufio.NewScanner(ignoreFile)
+	for scanner.Scan() {
+		pattern := strings.TrimSpace(scanner.Text())
+
+		// Skip empty lines, comments and function rules
+		if pattern == "" || strings.HasPrefix(pattern, "#") || strings.HasPrefix(pattern, functionIgnorePrefix) {
+			continue
+		}
+
+		// Check if pattern matches the file path
+		if matchesPattern(filePath, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// functionIgnorePatterns returns the function rules of the ignore file, without their
+// prefix. They are empty when the file doesn't exist or can't be read.
+func functionIgnorePatterns(ignoreFilePath string) []string {
+	ignoreFile, err := os.Open(ignoreFilePath)
+	if err != nil {
+		return nil
+	}
+	defer ignoreFile.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(ignoreFile)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if pattern, ok := strings.CutPrefix(line, functionIgnorePrefix); ok && strings.TrimSpace(pattern) != "" {
+			patterns = append(patterns, strings.TrimSpace(pattern))
+		}
+	}
+
+	return patterns
+}
+
+// matchesFunctionPatterns checks if a function matches any of the glob patterns, applied to
+// its qualified name (store.(*Client).Close) and to its name qualified by the import path
+// of its package (example.com/store.(*Client).Close).
+func matchesFunctionPatterns(fn *ast.Function, patterns []string) bool {
+	names := []string{fn.QualifiedName()}
+	if fn.ImportPath != "" {
+		names = append(names, fn.ImportPath+"."+fn.MethodName())
+	}
+
+	for _, pattern := range patterns {
+		for _, name := range names {
+			if matched, err := path.Match(pattern, name); err == nil && matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matchesPattern checks if a file path matches a glob-like pattern.
+func matchesPattern(filePath, pattern string) bool {
+	// Normalize path separators
+	filePath = filepath.ToSlash(filePath)
+	pattern = filepath.ToSlash(pattern)
+
+	// Handle simple wildcards and exact matches
+	matched, err := filepath.Match(pattern, filepath.Base(filePath))
+	if err == nil && matched {
+		return true
+	}
+
+	// Check if pattern matches anywhere in the path
+	if strings.Contains(filePath, pattern) {
+		return true
+	}
+
+	// Handle directory patterns (ending with /)
+	if strings.HasSuffix(pattern, "/") {
+		dirPattern := strings.TrimSuffix(pattern, "/")
+		if strings.Contains(filePath, "/"+dirPattern+"/") || strings.HasPrefix(filePath, dirPattern+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fileExists checks if a regular file exists.
+func fileExists(path string) bool {
+	if path == "" {
+		return false
+	}
+
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,295 @@
+package analyzer
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/config"
+)
+
+func TestMatchesPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		pattern  string
+		expected bool
+	}{
+		{
+			name:     "exact match",
+			path:     "test.go",
+			pattern:  "test.go",
+			expected: true,
+		},
+		{
+			name:     "wildcard match",
+			path:     "main_test.go",
+			pattern:  "*_test.go",
+			expected: true,
+		},
+		{
+			name:     "no match",
+			path:     "main.go",
+			pattern:  "*_test.go",
+			expected: false,
+		},
+		{
+			name:     "directory pattern with slash",
+			path:     "vendor/pkg/file.go",
+			pattern:  "vendor/",
+			expected: true,
+		},
+		{
+			name:     "contains pattern",
+			path:     "vendor/pkg/file.go",
+			pattern:  "vendor",
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := matchesPattern(tt.path, tt.pattern)
+			if result != tt.expected {
+				t.Errorf("matchesPattern(%s, %s) = %v, expected %v",
+					tt.path, tt.pattern, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestShouldIgnoreFile(t *testing.T) {
+	cfg := config.Default()
+	cfg.Ignore.Patterns = []string{"*_test.go", "vendor/", ".git/"}
+	a := &Analyzer{config: cfg}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected bool
+	}{
+		{
+			name:     "should ignore hidden files",
+			path:     ".hidden",
+			expected: true,
+		},
+		{
+			name:     "should ignore vendor files",
+			path:     "some/vendor/pkg/file.go",
+			expected: true,
+		},
+		{
+			name:     "should not ignore regular go files",
+			path:     "main.go",
+			expected: false,
+		},
+		{
+			name:     "should ignore git directories",
+			path:     "some/.git/config",
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := a.shouldIgnoreFile(tt.path)
+			if result != tt.expected {
+				t.Errorf("shouldIgnoreFile(%s) = %v, expected %v",
+					tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseAllTargets(t *testing.T) {
+	parser := ast.NewParser()
+	a := &Analyzer{config: config.Default(), logger: io.Discard}
+	ctx := context.Background()
+
+	// Test with empty targets
+	functions, err := a.parseAllTargets(ctx, parser, []string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(functions) != 0 {
+		t.Errorf("Expected 0 functions for empty targets, got %d", len(functions))
+	}
+
+	// Test with invalid target (should be skipped)
+	functions, _ = a.parseAllTargets(ctx, parser, []string{"nonexistent.go"})
+	if len(functions) != 0 {
+		t.Errorf("Expected 0 functions for nonexistent file, got %d", len(functions))
+	}
+
+	// Test with testdata directory (may not exist)
+	functions, _ = a.parseAllTargets(ctx, parser, []string{"./testdata"})
+	// Should return empty slice for nonexistent directory, not nil
+	if len(functions) != 0 {
+		t.Errorf("Expected 0 functions for nonexistent testdata directory, got %d", len(functions))
+	}
+}
+
+func TestParseGoFile(t *testing.T) {
+	parser := ast.NewParser()
+	a := &Analyzer{config: config.Default()}
+
+	// Test with nonexistent file
+	decls, err := a.parseGoFile(parser, "nonexistent.go")
+	if err == nil {
+		t.Error("Expected error for nonexistent file")
+	}
+	if len(decls.functions) != 0 {
+		t.Errorf("Expected 0 functions for nonexistent file, got %d", len(decls.functions))
+	}
+}
+
+func TestScanDirectory(t *testing.T) {
+	parser := ast.NewParser()
+	a := &Analyzer{config: config.Default()}
+	ctx := context.Background()
+
+	// Test with nonexistent directory
+	decls, err := a.scanDirectory(ctx, parser, "nonexistent")
+	if err == nil {
+		t.Error("Expected error for nonexistent directory")
+	}
+	if len(decls.functions) != 0 {
+		t.Errorf("Expected 0 functions for nonexistent directory, got %d", len(decls.functions))
+	}
+
+	// Test with current directory (should find some Go files)
+	decls, err = a.scanDirectory(ctx, parser, ".")
+	if err != nil {
+		t.Errorf("Unexpected error scanning current directory: %v", err)
+	}
+	// Should return valid functions slice
+	if decls.functions == nil {
+		t.Error("Expected non-nil functions slice from current directory")
+	}
+}
+
+func TestMatchesIgnorePatterns(t *testing.T) {
+	tempDir := t.TempDir()
+	ignoreFile := filepath.Join(tempDir, ".gitignore")
+
+	// Create ignore file with patterns
+	content := "vendor/\n*.tmp\n# comment line\n\ntest_data/"
+	err := os.WriteFile(ignoreFile, []byte(content), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create ignore file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected bool
+	}{
+		{
+			name:     "matches vendor pattern",
+			path:     "vendor/pkg/file.go",
+			expected: true,
+		},
+		{
+			name:     "matches tmp pattern",
+			path:     "temp.tmp",
+			expected: true,
+		},
+		{
+			name:     "matches test_data pattern",
+			path:     "test_data/file.go",
+			expected: true,
+		},
+		{
+			name:     "no match",
+			path:     "main.go",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := matchesIgnorePatterns(tt.path, ignoreFile)
+			if result != tt.expected {
+				t.Errorf("matchesIgnorePatterns(%s, %s) = %v, expected %v",
+					tt.path, ignoreFile, result, tt.expected)
+			}
+		})
+	}
+
+	// Test with nonexistent ignore file
+	result := matchesIgnorePatterns("main.go", "nonexistent.gitignore")
+	if result != false {
+		t.Error("Expected false for nonexistent ignore file")
+	}
+}
+
+func TestFunctionIgnoreRules(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	writeFile("go.mod", "module example.com/app\n")
+	source := writeFile("net.go", `package net
+
+func (c *Client) Close() error {
+	return nil
+}
+
+func (s *Server) Close() error {
+	return nil
+}
+
+func Dial() error {
+	return nil
+}
+`)
+	ignoreFile := writeFile(".similarityignore", "func:net.(\\*Client).Close\nfunc:example.com/app.Dial\n")
+
+	cfg := config.Default()
+	cfg.CLI.DefaultMinLines = 1
+	cfg.Ignore.DefaultFile = ignoreFile
+	a := &Analyzer{config: cfg}
+
+	decls, err := a.parseGoFile(ast.NewParser(), source)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	functions := decls.functions
+	if len(functions) != 1 || functions[0].QualifiedName() != "net.(*Server).Close" {
+		names := make([]string, len(functions))
+		for i, fn := range functions {
+			names[i] = fn.QualifiedName()
+		}
+		t.Errorf("Expected only net.(*Server).Close, got %v", names)
+	}
+	if functions[0].ImportPath != "example.com/app" {
+		t.Errorf("Expected import path example.com/app, got %q", functions[0].ImportPath)
+	}
+
+	// Function rules are not file patterns
+	if matchesIgnorePatterns(source, ignoreFile) {
+		t.Error("Function rules should not ignore files")
+	}
+}
+
+func TestTrimRecursivePattern(t *testing.T) {
+	for input, expected := range map[string]string{
+		"./...":     ".",
+		"...":       ".",
+		"./pkg/...": "./pkg",
+		"./pkg":     "./pkg",
+		"main.go":   "main.go",
+	} {
+		if got := trimRecursivePattern(input); got != expected {
+			t.Errorf("trimRecursivePattern(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
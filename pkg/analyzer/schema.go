@@ -0,0 +1,211 @@
+package analyzer
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the version of the report schema, written to the schema_version field
+// of every report. The minor version grows when fields are added; the major version
+// changes when fields are removed or change meaning.
+const SchemaVersion = "1.12"
+
+// Schema kinds accepted by JSONSchema.
+const (
+	SchemaKindReport   = "report"
+	SchemaKindFind     = "find"
+	SchemaKindExplain  = "explain"
+	SchemaKindDiff     = "diff"
+	SchemaKindPartial  = "partial"
+	SchemaKindSiblings = "siblings"
+	SchemaKindHistory  = "history"
+)
+
+const (
+	// jsonSchemaDialect is the JSON Schema draft the generated documents conform to.
+	jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+	// schemaIDBase prefixes the $id of the generated documents.
+	schemaIDBase = "https://github.com/paveg/similarity-go/schema/"
+	// schemaDefsPrefix is the JSON pointer prefix of shared definitions.
+	schemaDefsPrefix = "#/$defs/"
+)
+
+// SchemaKinds returns the kinds of documents a JSON Schema can be generated for.
+func SchemaKinds() []string {
+	return []string{
+		SchemaKindReport, SchemaKindFind, SchemaKindExplain, SchemaKindDiff, SchemaKindPartial, SchemaKindSiblings,
+		SchemaKindHistory,
+	}
+}
+
+// JSONSchema returns the JSON Schema describing the output of the given kind.
+// The result is ready to be encoded with encoding/json.
+func JSONSchema(kind string) (map[string]any, error) {
+	var root reflect.Type
+	var title string
+
+	switch kind {
+	case SchemaKindReport:
+		root, title = reflect.TypeFor[Report](), "similarity-go report"
+	case SchemaKindFind:
+		root, title = reflect.TypeFor[FindResult](), "similarity-go find result"
+	case SchemaKindExplain:
+		root, title = reflect.TypeFor[Explanation](), "similarity-go explanation"
+	case SchemaKindDiff:
+		root, title = reflect.TypeFor[ReportDiff](), "similarity-go report diff"
+	case SchemaKindPartial:
+		root, title = reflect.TypeFor[PartialReport](), "similarity-go partial report"
+	case SchemaKindSiblings:
+		root, title = reflect.TypeFor[SiblingReport](), "similarity-go sibling report"
+	case SchemaKindHistory:
+		root, title = reflect.TypeFor[HistoryReport](), "similarity-go history report"
+	default:
+		return nil, fmt.Errorf("unknown schema kind %q (expected one of %s)", kind, strings.Join(SchemaKinds(), ", "))
+	}
+
+	defs := make(map[string]any)
+	schema := objectSchema(root, defs)
+	schema["$schema"] = jsonSchemaDialect
+	schema["$id"] = schemaIDBase + SchemaVersion + "/" + kind + ".json"
+	schema["title"] = title
+	if len(defs) > 0 {
+		schema["$defs"] = defs
+	}
+
+	return schema, nil
+}
+
+// ReadReport decodes a JSON or YAML report written by Analyze or Compare.
+// Reports with a different major schema version are rejected.
+func ReadReport(r io.Reader) (*Report, error) {
+	var report Report
+	if err := readDocument(r, "report", &report, func() string { return report.SchemaVersion }); err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// readDocument decodes a JSON or YAML document into v and checks its schema version.
+func readDocument(r io.Reader, what string, v any, version func() string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", what, err)
+	}
+
+	// YAML is a superset of JSON, and every field carries matching tags
+	if unmarshalErr := yaml.Unmarshal(data, v); unmarshalErr != nil {
+		return fmt.Errorf("failed to decode %s: %w", what, unmarshalErr)
+	}
+
+	return checkSchemaVersion(what, version())
+}
+
+// checkSchemaVersion verifies that a decoded document can be read by this version.
+func checkSchemaVersion(what, version string) error {
+	if version == "" {
+		return fmt.Errorf("%s has no schema_version; expected %s", what, SchemaVersion)
+	}
+
+	major, _, _ := strings.Cut(version, ".")
+	supportedMajor, _, _ := strings.Cut(SchemaVersion, ".")
+	if major != supportedMajor {
+		return fmt.Errorf("unsupported %s schema_version %s; expected %s.x", what, version, supportedMajor)
+	}
+
+	return nil
+}
+
+// objectSchema describes a struct type as a JSON object. Nested structs are added to defs.
+func objectSchema(t reflect.Type, defs map[string]any) map[string]any {
+	properties := make(map[string]any)
+	required := []string{}
+
+	for field := range structFields(t) {
+		name, omitEmpty := jsonFieldName(field)
+
+		property := typeSchema(field.Type, defs)
+		if doc := field.Tag.Get("doc"); doc != "" {
+			property["description"] = doc
+		}
+
+		properties[name] = property
+		if !omitEmpty {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// structFields yields the exported fields of t, flattening embedded structs like encoding/json.
+func structFields(t reflect.Type) func(yield func(reflect.StructField) bool) {
+	return func(yield func(reflect.StructField) bool) {
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				for embedded := range structFields(field.Type) {
+					if !yield(embedded) {
+						return
+					}
+				}
+				continue
+			}
+
+			if !yield(field) {
+				return
+			}
+		}
+	}
+}
+
+// jsonFieldName returns the JSON name of a field and whether it is omitted when empty.
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(options, "omitempty")
+}
+
+// typeSchema describes a Go type. Struct types are referenced through defs.
+func typeSchema(t reflect.Type, defs map[string]any) map[string]any {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Pointer:
+		return typeSchema(t.Elem(), defs)
+	case reflect.Slice:
+		// Empty results are encoded as null by encoding/json
+		return map[string]any{
+			"type":  []string{"array", "null"},
+			"items": typeSchema(t.Elem(), defs),
+		}
+	case reflect.Struct:
+		if _, exists := defs[t.Name()]; !exists {
+			defs[t.Name()] = nil // Reserve the name before recursing
+			defs[t.Name()] = objectSchema(t, defs)
+		}
+		return map[string]any{"$ref": schemaDefsPrefix + t.Name()}
+	default:
+		return map[string]any{}
+	}
+}
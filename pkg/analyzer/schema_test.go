@@ -0,0 +1,257 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/paveg/similarity-go/internal/ast"
+)
+
+// updateGoldenEnv regenerates the golden files instead of comparing against them when set.
+const updateGoldenEnv = "UPDATE_GOLDEN"
+
+// goldenReport is a fixed report covering every field of the schema.
+func goldenReport() *Report {
+	return &Report{
+		SchemaVersion: SchemaVersion,
+		Summary: Summary{
+			TotalFunctions:    12,
+			TotalTypes:        4,
+			SimilarGroups:     3,
+			TotalDuplications: 5,
+			LeftFunctions:     5,
+			RightFunctions:    7,
+		},
+		SimilarGroups: []Group{
+			{
+				ID:              "group_1",
+				Kind:            GroupKindClones,
+				SimilarityScore: 0.95,
+				Functions: []FunctionRef{
+					{
+						File:          "upstream/user.go",
+						Function:      "ProcessUser",
+						QualifiedName: "users.ProcessUser",
+						Package:       "users",
+						ImportPath:    "example.com/upstream/users",
+						StartLine:     10,
+						EndLine:       25,
+						Hash:          "a1b2c3d4e5f60718",
+						Fingerprint:   "5f1c0e2d9b8a7766",
+						Side:          SideLeft,
+					},
+					{
+						File:        "fork/admin.go",
+						Function:    "ProcessAdmin",
+						StartLine:   15,
+						EndLine:     30,
+						Hash:        "0817e6f5d4c3b2a1",
+						Fingerprint: "6677a8b9d2e0c1f5",
+						Side:        SideRight,
+					},
+				},
+				RefactorSuggestion: "Consider extracting common logic into a shared function",
+				Extraction: &Extraction{
+					Name:      "process",
+					Signature: "func process(id int, text string)",
+					Body:      "func process(id int, text string) {\n\tlog.Println(text, id)\n}",
+					Parameters: []ExtractedParameter{
+						{Name: "text", Type: "string", Kind: "literal", Values: []string{`"user"`, `"admin"`}},
+					},
+					CallSites: []CallSite{
+						{File: "upstream/user.go", Function: "ProcessUser", Call: `process(id, "user")`},
+						{File: "fork/admin.go", Function: "ProcessAdmin", Call: `process(id, "admin")`},
+					},
+				},
+			},
+			{
+				ID:              "group_2",
+				Kind:            GroupKindMethodFamily,
+				SimilarityScore: 0.9,
+				Functions: []FunctionRef{
+					{
+						File:          "api/create.go",
+						Function:      "Validate",
+						QualifiedName: "api.(*CreateRequest).Validate",
+						Receiver:      "*CreateRequest",
+						Package:       "api",
+						StartLine:     5,
+						EndLine:       12,
+					},
+					{
+						File:          "api/update.go",
+						Function:      "Validate",
+						QualifiedName: "api.(*UpdateRequest).Validate",
+						Receiver:      "*UpdateRequest",
+						Package:       "api",
+						StartLine:     5,
+						EndLine:       12,
+					},
+				},
+				RefactorSuggestion: "Move fields Email, Name into a struct embedded in *CreateRequest, *UpdateRequest and declare Validate once on it",
+				MethodFamily: &MethodFamily{
+					Method:    "Validate",
+					Types:     []string{"*CreateRequest", "*UpdateRequest"},
+					Strategy:  FamilyStrategyEmbedding,
+					Interface: "type Validator interface {\n\tValidate() error\n}",
+					Fields:    []string{"Email", "Name"},
+				},
+			},
+			{
+				ID:              "group_3",
+				Kind:            GroupKindTypes,
+				SimilarityScore: 1,
+				Functions: []FunctionRef{
+					{
+						File:          "billing/address.go",
+						Function:      "Address",
+						QualifiedName: "billing.Address",
+						Package:       "billing",
+						StartLine:     3,
+						EndLine:       8,
+						TypeKind:      ast.TypeKindStruct,
+					},
+					{
+						File:          "shipping/address.go",
+						Function:      "Address",
+						QualifiedName: "shipping.Address",
+						Package:       "shipping",
+						StartLine:     3,
+						EndLine:       8,
+						TypeKind:      ast.TypeKindStruct,
+					},
+				},
+				RefactorSuggestion: "Declare struct billing.Address once and reuse it in place of the similar declarations",
+			},
+		},
+		RenameInconsistencies: []RenameInconsistency{
+			{
+				Rule:         RuleInconsistentRename,
+				GroupID:      "group_1",
+				Function:     FunctionRef{File: "fork/admin.go", Function: "ProcessAdmin", StartLine: 15, EndLine: 30},
+				Original:     FunctionRef{File: "upstream/user.go", Function: "ProcessUser", StartLine: 10, EndLine: 25},
+				Line:         22,
+				OriginalLine: 17,
+				Variable:     "user",
+				Expected:     "admin",
+				Found:        "user",
+				Message: "user is used where admin is expected: user of users.ProcessUser, used at line 17, " +
+					"is renamed to admin in 3 other places",
+			},
+		},
+	}
+}
+
+// assertGolden compares got with the golden file, or rewrites it when updateGoldenEnv is set.
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if os.Getenv(updateGoldenEnv) != "" {
+		if err := os.WriteFile(path, got, 0o600); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with %s=1 to create it): %v", updateGoldenEnv, err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s changed; if the change is intended, bump SchemaVersion and run with %s=1\ngot:\n%s",
+			path, updateGoldenEnv, got)
+	}
+}
+
+func TestJSONSchemaGolden(t *testing.T) {
+	for _, kind := range SchemaKinds() {
+		t.Run(kind, func(t *testing.T) {
+			schema, err := JSONSchema(kind)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := json.MarshalIndent(schema, "", "  ")
+			if err != nil {
+				t.Fatalf("failed to encode schema: %v", err)
+			}
+
+			assertGolden(t, kind+".schema.json", append(got, '\n'))
+		})
+	}
+
+	if _, err := JSONSchema("unknown"); err == nil {
+		t.Error("expected error for unknown schema kind")
+	}
+}
+
+func TestReportGolden(t *testing.T) {
+	jsonData, err := json.MarshalIndent(goldenReport(), "", "  ")
+	if err != nil {
+		t.Fatalf("failed to encode JSON: %v", err)
+	}
+	assertGolden(t, "report.golden.json", append(jsonData, '\n'))
+
+	yamlData, err := yaml.Marshal(goldenReport())
+	if err != nil {
+		t.Fatalf("failed to encode YAML: %v", err)
+	}
+	assertGolden(t, "report.golden.yaml", yamlData)
+}
+
+func TestReadReportRoundTrip(t *testing.T) {
+	for _, name := range []string{"report.golden.json", "report.golden.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			file, err := os.Open(filepath.Join("testdata", name))
+			if err != nil {
+				t.Fatalf("failed to open golden file: %v", err)
+			}
+			defer file.Close()
+
+			report, err := ReadReport(file)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(report, goldenReport()) {
+				t.Errorf("decoded report differs from the original:\n%+v", report)
+			}
+		})
+	}
+}
+
+func TestReadReportSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectError bool
+	}{
+		{name: "older minor version", input: `{"schema_version": "1.0"}`},
+		{name: "newer minor version", input: `{"schema_version": "1.7"}`},
+		{name: "yaml", input: "schema_version: \"1.0\"\nsimilar_groups: []\n"},
+		{name: "newer major version", input: `{"schema_version": "2.0"}`, expectError: true},
+		{name: "missing version", input: `{"summary": {}}`, expectError: true},
+		{name: "malformed", input: `{"schema_version": `, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadReport(strings.NewReader(tt.input))
+			if tt.expectError && err == nil {
+				t.Error("expected error")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/similarity"
+	"github.com/paveg/similarity-go/internal/worker"
+)
+
+// PartialReport holds the matches found by one shard of an analysis, before grouping.
+type PartialReport struct {
+	SchemaVersion  string         `json:"schema_version" yaml:"schema_version" doc:"Version of the report schema (major.minor)."`
+	Shard          ShardInfo      `json:"shard" yaml:"shard" doc:"Shard that produced the matches."`
+	Threshold      float64        `json:"threshold" yaml:"threshold" doc:"Similarity threshold used by the shard."`
+	TotalFunctions int            `json:"total_functions" yaml:"total_functions" doc:"Number of functions analyzed by every shard."`
+	TotalTypes     int            `json:"total_types,omitempty" yaml:"total_types,omitempty" doc:"Number of type declarations analyzed by every shard (types unit only)."`
+	Functions      []FunctionRef  `json:"functions" yaml:"functions" doc:"Functions referenced by the matches."`
+	Matches        []PartialMatch `json:"matches" yaml:"matches" doc:"Similar pairs found by the shard."`
+	TypeVariants   []PartialMatch `json:"type_variants,omitempty" yaml:"type_variants,omitempty" doc:"Pairs of functions identical apart from types, whatever their similarity (first shard only)."`
+}
+
+// ShardInfo identifies one of several shards.
+type ShardInfo struct {
+	Index int `json:"index" yaml:"index" doc:"Index of the shard, from 1 to count."`
+	Count int `json:"count" yaml:"count" doc:"Total number of shards."`
+}
+
+// PartialMatch is a similar pair of functions, referenced by their position in Functions.
+type PartialMatch struct {
+	Function1       int     `json:"function1" yaml:"function1" doc:"Index of the first function in functions."`
+	Function2       int     `json:"function2" yaml:"function2" doc:"Index of the second function in functions."`
+	SimilarityScore float64 `json:"similarity_score" yaml:"similarity_score" doc:"Similarity of the pair (0.0-1.0)."`
+}
+
+// Partial compares the pairs of functions selected by the shard set with WithShard and
+// returns the matches without grouping them. Every shard must analyze the same targets
+// from the same working directory so the functions are enumerated identically.
+// Without WithShard, Partial compares every pair as the only shard.
+func (a *Analyzer) Partial(ctx context.Context, targets []string) (*PartialReport, error) {
+	parser := ast.NewParser()
+
+	decls, err := a.parseDeclarations(ctx, parser, targets)
+	if err != nil {
+		return nil, err
+	}
+	functions := a.analyzedFunctions(decls)
+
+	shard := a.shard
+	if shard == (worker.Shard{}) {
+		shard = worker.Shard{Index: 1, Count: 1}
+	}
+
+	a.logf("Found %d functions, comparing shard %s", len(functions), shard)
+
+	matches, err := a.newWorker(ctx, a.newDetector()).FindSimilarFunctionsShard(functions, shard, a.progress)
+	if err != nil {
+		return nil, fmt.Errorf("parallel similarity calculation failed: %w", err)
+	}
+
+	// Type declarations are few enough to be compared by the first shard alone, and type
+	// variants are found without comparing every pair
+	units := newTypeUnits()
+	var variants []similarity.Match
+	if shard.Index == 1 {
+		typeMatches, err := a.findSimilarTypes(ctx, decls.types, units)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, typeMatches...)
+		variants = a.typeVariants(functions)
+	}
+
+	partial := &PartialReport{
+		SchemaVersion:  SchemaVersion,
+		Shard:          ShardInfo{Index: shard.Index, Count: shard.Count},
+		Threshold:      a.config.CLI.DefaultThreshold,
+		TotalFunctions: len(functions),
+		TotalTypes:     a.analyzedTypes(decls),
+		Matches:        make([]PartialMatch, 0, len(matches)),
+	}
+
+	// Only keep the functions that take part in a match
+	indexes := make(map[*ast.Function]int)
+	indexOf := func(fn *ast.Function) int {
+		if index, exists := indexes[fn]; exists {
+			return index
+		}
+		indexes[fn] = len(partial.Functions)
+		partial.Functions = append(partial.Functions, units.describe(fn))
+		return indexes[fn]
+	}
+
+	for _, match := range matches {
+		partial.Matches = append(partial.Matches, PartialMatch{
+			Function1:       indexOf(match.Function1),
+			Function2:       indexOf(match.Function2),
+			SimilarityScore: match.Similarity,
+		})
+	}
+	for _, match := range variants {
+		partial.TypeVariants = append(partial.TypeVariants, PartialMatch{
+			Function1:       indexOf(match.Function1),
+			Function2:       indexOf(match.Function2),
+			SimilarityScore: match.Similarity,
+		})
+	}
+
+	return partial, nil
+}
+
+// Merge combines the partial reports of every shard of an analysis and groups their
+// matches, producing the report Analyze would have produced in a single process.
+// It fails unless exactly one partial report per shard is given.
+func (a *Analyzer) Merge(partials []*PartialReport) (*Report, error) {
+	if err := validatePartials(partials); err != nil {
+		return nil, err
+	}
+
+	// Functions are rebuilt from their references, with their declarations parsed again
+	// so groups get the same extractions and method families as in a single run
+	functions := make(map[string]*ast.Function)
+	files := make(map[string]*parsedFile)
+	refs := make(map[*ast.Function]FunctionRef)
+	var matches, variants []similarity.Match
+
+	for _, partial := range partials {
+		resolved := make([]*ast.Function, len(partial.Functions))
+		for i, ref := range partial.Functions {
+			key := fmt.Sprintf("%s:%d:%s", ref.File, ref.StartLine, ref.Hash)
+			fn, exists := functions[key]
+			if !exists {
+				fn = &ast.Function{
+					Name:       ref.Function,
+					Receiver:   ref.Receiver,
+					Package:    ref.Package,
+					ImportPath: ref.ImportPath,
+					File:       ref.File,
+					StartLine:  ref.StartLine,
+					EndLine:    ref.EndLine,
+					LineCount:  ref.EndLine - ref.StartLine + 1,
+				}
+				if ref.TypeKind == "" {
+					fn.AST, _ = a.parsedDecl(files, ref)
+					if parsed := files[ref.File]; parsed.file != nil {
+						fn.Imports = ast.ImportNames(parsed.file)
+					}
+				}
+				functions[key] = fn
+				refs[fn] = ref
+			}
+			resolved[i] = fn
+		}
+
+		partialMatches, err := resolveMatches(partial, partial.Matches, resolved)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, partialMatches...)
+
+		partialVariants, err := resolveMatches(partial, partial.TypeVariants, resolved)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, partialVariants...)
+	}
+
+	a.logf("Merged %d matches from %d shards", len(matches), len(partials))
+
+	summary := Summary{
+		TotalFunctions: partials[0].TotalFunctions,
+		TotalTypes:     partials[0].TotalTypes,
+	}
+	return a.buildReport(summary, matches, variants, partials[0].Threshold, func(fn *ast.Function) FunctionRef { return refs[fn] }), nil
+}
+
+// ReadPartialReport decodes a JSON or YAML partial report written by Partial.
+func ReadPartialReport(r io.Reader) (*PartialReport, error) {
+	var partial PartialReport
+	if err := readDocument(r, "partial report", &partial, func() string { return partial.SchemaVersion }); err != nil {
+		return nil, err
+	}
+
+	return &partial, nil
+}
+
+// validatePartials checks that partials are the complete set of shards of one analysis.
+func validatePartials(partials []*PartialReport) error {
+	if len(partials) == 0 {
+		return errors.New("no partial reports to merge")
+	}
+
+	first := partials[0]
+	seen := make(map[int]bool, len(partials))
+
+	for _, partial := range partials {
+		shard := worker.Shard{Index: partial.Shard.Index, Count: partial.Shard.Count}
+		if err := shard.Validate(); err != nil {
+			return err
+		}
+
+		switch {
+		case partial.Shard.Count != first.Shard.Count:
+			return fmt.Errorf("shard %s belongs to a run with a different shard count", shard)
+		case partial.TotalFunctions != first.TotalFunctions:
+			return fmt.Errorf("shard %s analyzed %d functions, expected %d; were the targets identical?",
+				shard, partial.TotalFunctions, first.TotalFunctions)
+		case partial.TotalTypes != first.TotalTypes:
+			return fmt.Errorf("shard %s analyzed %d type declarations, expected %d; were the targets identical?",
+				shard, partial.TotalTypes, first.TotalTypes)
+		case partial.Threshold != first.Threshold:
+			return fmt.Errorf("shard %s used threshold %.2f, expected %.2f",
+				shard, partial.Threshold, first.Threshold)
+		case seen[partial.Shard.Index]:
+			return fmt.Errorf("shard %s is given more than once", shard)
+		}
+
+		seen[partial.Shard.Index] = true
+	}
+
+	for index := 1; index <= first.Shard.Count; index++ {
+		if !seen[index] {
+			return fmt.Errorf("shard %d/%d is missing", index, first.Shard.Count)
+		}
+	}
+
+	return nil
+}
+
+// resolveMatches converts the pairs of partial into matches between the functions resolved
+// from its references.
+func resolveMatches(partial *PartialReport, pairs []PartialMatch, resolved []*ast.Function) ([]similarity.Match, error) {
+	matches := make([]similarity.Match, 0, len(pairs))
+	for _, match := range pairs {
+		if !validFunctionIndex(match.Function1, resolved) || !validFunctionIndex(match.Function2, resolved) {
+			return nil, fmt.Errorf("shard %d/%d references an unknown function", partial.Shard.Index, partial.Shard.Count)
+		}
+		matches = append(matches, similarity.Match{
+			Function1:  resolved[match.Function1],
+			Function2:  resolved[match.Function2],
+			Similarity: match.SimilarityScore,
+		})
+	}
+
+	return matches, nil
+}
+
+// validFunctionIndex reports whether index refers to one of functions.
+func validFunctionIndex(index int, functions []*ast.Function) bool {
+	return index >= 0 && index < len(functions)
+}
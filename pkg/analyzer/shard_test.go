@@ -0,0 +1,169 @@
+package analyzer_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/paveg/similarity-go/pkg/analyzer"
+)
+
+// runShards analyzes dir in count shards and round-trips each partial report through JSON.
+func runShards(t *testing.T, dir string, count int) []*analyzer.PartialReport {
+	t.Helper()
+
+	var partials []*analyzer.PartialReport
+	for index := 1; index <= count; index++ {
+		a, err := analyzer.New(analyzer.WithMinLines(3), analyzer.WithShard(index, count))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		partial, err := a.Partial(context.Background(), []string{dir})
+		if err != nil {
+			t.Fatalf("shard %d/%d: unexpected error: %v", index, count, err)
+		}
+
+		data, err := json.Marshal(partial)
+		if err != nil {
+			t.Fatalf("failed to encode partial report: %v", err)
+		}
+		decoded, err := analyzer.ReadPartialReport(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("failed to decode partial report: %v", err)
+		}
+		partials = append(partials, decoded)
+	}
+
+	return partials
+}
+
+func TestMergeMatchesAnalyze(t *testing.T) {
+	dir := filepath.Dir(writeTestFile(t, "a.go"))
+	for _, name := range []string{"b.go", "c.go", "d.go"} {
+		writeTestFileIn(t, dir, name)
+	}
+	// Method families need the declarations to render their interface
+	family := `package sample
+
+func (c *Client) Close(ctx context.Context) error {
+	c.conn.Close()
+	c.log("closed")
+	return c.flush(ctx)
+}
+
+func (s Server) Close(ctx context.Context) error {
+	s.conn.Close()
+	s.log("closed")
+	return s.flush(ctx)
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "close.go"), []byte(family), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	// Type variants are grouped whatever their scores, so no shard compares them
+	variants := `package sample
+
+func Largest(values []int64) int64 {
+	best := values[0]
+	for _, v := range values[1:] {
+		if v > best {
+			best = v
+		}
+	}
+	return best
+}
+
+func LargestName(values []string) string {
+	best := values[0]
+	for _, v := range values[1:] {
+		if v > best {
+			best = v
+		}
+	}
+	return best
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "largest.go"), []byte(variants), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	a, err := analyzer.New(analyzer.WithMinLines(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want, err := a.Analyze(context.Background(), []string{dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var extractions, families, generics int
+	for _, group := range want.SimilarGroups {
+		if group.Extraction != nil {
+			extractions++
+			if group.Extraction.Generic {
+				generics++
+			}
+		}
+		if group.MethodFamily != nil {
+			families++
+		}
+	}
+	if extractions == 0 || families == 0 || generics == 0 {
+		t.Fatalf("expected extractions, method families and generic extractions, got %+v", want.SimilarGroups)
+	}
+
+	for _, count := range []int{1, 2, 4} {
+		merged, err := a.Merge(runShards(t, dir, count))
+		if err != nil {
+			t.Fatalf("count=%d: unexpected error: %v", count, err)
+		}
+
+		if !reflect.DeepEqual(merged, want) {
+			got, _ := json.MarshalIndent(merged, "", "  ")
+			expected, _ := json.MarshalIndent(want, "", "  ")
+			t.Errorf("count=%d: merged report differs from a single run:\n%s\nwant\n%s", count, got, expected)
+		}
+	}
+}
+
+func TestMergeValidatesShards(t *testing.T) {
+	dir := filepath.Dir(writeTestFile(t, "a.go"))
+	writeTestFileIn(t, dir, "b.go")
+
+	a, err := analyzer.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	partials := runShards(t, dir, 3)
+	otherThreshold := *partials[1]
+	otherThreshold.Threshold = 0.5
+
+	tests := []struct {
+		name     string
+		partials []*analyzer.PartialReport
+	}{
+		{name: "no partials"},
+		{name: "missing shard", partials: partials[:2]},
+		{name: "duplicate shard", partials: []*analyzer.PartialReport{partials[0], partials[1], partials[1]}},
+		{name: "different threshold", partials: []*analyzer.PartialReport{partials[0], &otherThreshold, partials[2]}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.Merge(tt.partials); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	if _, err := analyzer.New(analyzer.WithShard(4, 3)); err == nil {
+		t.Error("expected error for shard index beyond the count")
+	}
+}
@@ -0,0 +1,192 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/similarity"
+	"github.com/paveg/similarity-go/internal/vcs"
+)
+
+// exportPattern names the temporary directories revisions are exported to.
+const exportPattern = "similarity-go-"
+
+// Siblings finds the clone groups of the base tree that the head tree changed
+// inconsistently: some members were edited while their siblings were left untouched,
+// such as a bug fix applied to only one copy. Groups are formed from base as Analyze
+// would; members are matched with head by file, relative to the root of each tree, and
+// by receiver-qualified name, so members that were moved, renamed or removed are
+// neither changed nor untouched.
+func (a *Analyzer) Siblings(ctx context.Context, base, head string) (*SiblingReport, error) {
+	for _, root := range []string{base, head} {
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", root)
+		}
+	}
+
+	parser := ast.NewParser()
+
+	baseDecls, err := a.parseDeclarations(ctx, parser, []string{base})
+	if err != nil {
+		return nil, err
+	}
+
+	headDecls, err := a.parseDeclarations(ctx, parser, []string{head})
+	if err != nil {
+		return nil, err
+	}
+
+	a.logf("Found %d base and %d head functions", len(baseDecls.functions), len(headDecls.functions))
+
+	matches, err := a.findSimilarFunctions(ctx, baseDecls.functions)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := a.buildGroups(
+		groupSimilarMatches(matches, a.config.Processing.Grouping, a.config.CLI.DefaultThreshold),
+		func(fn *ast.Function) FunctionRef { return relativeRef(base, fn) },
+	)
+
+	baseFunctions := make(map[string]*ast.Function, len(baseDecls.functions))
+	for _, fn := range baseDecls.functions {
+		ref := relativeRef(base, fn)
+		baseFunctions[fmt.Sprintf("%s:%d", ref.File, ref.StartLine)] = fn
+	}
+	headFunctions := make(map[string]*ast.Function, len(headDecls.functions))
+	for _, fn := range headDecls.functions {
+		headFunctions[siblingKey(relativeRef(head, fn))] = fn
+	}
+
+	report := &SiblingReport{
+		SchemaVersion:       SchemaVersion,
+		Base:                base,
+		Head:                head,
+		Summary:             SiblingSummary{Groups: len(groups)},
+		InconsistentChanges: []InconsistentChange{},
+	}
+
+	for _, group := range groups {
+		var change InconsistentChange
+		for _, member := range group.Functions {
+			baseFn := baseFunctions[fmt.Sprintf("%s:%d", member.File, member.StartLine)]
+			headFn, exists := headFunctions[siblingKey(member)]
+			if baseFn == nil || !exists {
+				continue
+			}
+
+			headRef := relativeRef(head, headFn)
+			if changes := changedLines(baseFn, headFn); len(changes) > 0 {
+				change.Changed = append(change.Changed, ChangedSibling{Function: headRef, Changes: changes})
+			} else {
+				change.Untouched = append(change.Untouched, headRef)
+			}
+		}
+
+		if len(change.Changed) == 0 {
+			continue
+		}
+		report.Summary.ChangedGroups++
+		if len(change.Untouched) == 0 {
+			continue
+		}
+
+		change.GroupID = group.ID
+		change.Message = siblingMessage(change)
+		report.InconsistentChanges = append(report.InconsistentChanges, change)
+	}
+	report.Summary.InconsistentChanges = len(report.InconsistentChanges)
+
+	return report, nil
+}
+
+// SiblingRevisions is Siblings between two revisions of the git repository repo, such as
+// a branch and the commit it is merged into. The Go files of each revision are exported
+// to a temporary directory, and reported files are relative to the root of repo.
+func (a *Analyzer) SiblingRevisions(ctx context.Context, repo, base, head string) (*SiblingReport, error) {
+	if base == "" || head == "" {
+		return nil, errors.New("both base and head revisions are required")
+	}
+
+	dirs := make([]string, 0, 2)
+	defer func() {
+		for _, dir := range dirs {
+			_ = os.RemoveAll(dir)
+		}
+	}()
+
+	for _, revision := range []string{base, head} {
+		dir, err := os.MkdirTemp("", exportPattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", revision, err)
+		}
+		dirs = append(dirs, dir)
+
+		a.logf("Exporting revision %s", revision)
+		if err := vcs.Export(ctx, repo, revision, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	report, err := a.Siblings(ctx, dirs[0], dirs[1])
+	if err != nil {
+		return nil, err
+	}
+
+	report.Base, report.Head = base, head
+	return report, nil
+}
+
+// relativeRef describes fn with its file relative to root, so that the functions of two
+// trees can be matched.
+func relativeRef(root string, fn *ast.Function) FunctionRef {
+	ref := newFunctionRef(fn)
+	if rel, err := filepath.Rel(root, fn.File); err == nil {
+		ref.File = filepath.ToSlash(rel)
+	}
+	return ref
+}
+
+// siblingKey identifies a function across two trees by its relative file and its
+// receiver-qualified name.
+func siblingKey(ref FunctionRef) string {
+	name := ref.Function
+	if ref.QualifiedName != "" {
+		name = strings.TrimPrefix(ref.QualifiedName, ref.Package+".")
+	}
+	return ref.File + "\x00" + name
+}
+
+// changedLines returns the lines of the body of before that head changed, removed or
+// added, as they are written.
+func changedLines(before, after *ast.Function) []AlignedLine {
+	var changes []AlignedLine
+	for _, line := range similarity.AlignSources(before, after) {
+		if line.Kind != similarity.AlignMatch {
+			changes = append(changes, AlignedLine(line))
+		}
+	}
+	return changes
+}
+
+// siblingMessage describes an inconsistent change.
+func siblingMessage(change InconsistentChange) string {
+	changed := make([]string, len(change.Changed))
+	for i, sibling := range change.Changed {
+		changed[i] = sibling.Function.DisplayName()
+	}
+	untouched := make([]string, len(change.Untouched))
+	for i, ref := range change.Untouched {
+		untouched[i] = ref.DisplayName()
+	}
+
+	return fmt.Sprintf(
+		"%s changed but similar %s did not; check whether the change applies to them",
+		strings.Join(changed, ", "), strings.Join(untouched, ", "),
+	)
+}
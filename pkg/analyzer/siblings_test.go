@@ -0,0 +1,138 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// siblingSource declares three copies of a loader; fixed makes the first one wrap its error.
+func siblingSource(fixed bool) string {
+	source := "package store\n"
+	for _, name := range []string{"User", "Order", "Invoice"} {
+		check := "return nil, err"
+		if fixed && name == "User" {
+			check = `return nil, fmt.Errorf("load user: %w", err)`
+		}
+		source += strings.NewReplacer("NAME", name, "CHECK", check).Replace(`
+func LoadNAME(db *DB, id int) (*NAME, error) {
+	row, err := db.Query(id)
+	if err != nil {
+		CHECK
+	}
+	return decodeNAME(row), nil
+}
+`)
+	}
+	return source
+}
+
+// writeTree writes files under a new directory and returns it.
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+// assertFixedUser checks that report flags the fix of LoadUser alone.
+func assertFixedUser(t *testing.T, report *SiblingReport) {
+	t.Helper()
+
+	if report.Summary.Groups != 1 || report.Summary.ChangedGroups != 1 || len(report.InconsistentChanges) != 1 {
+		t.Fatalf("expected one inconsistent change, got %+v", report)
+	}
+
+	change := report.InconsistentChanges[0]
+	if len(change.Changed) != 1 || change.Changed[0].Function.Function != "LoadUser" ||
+		change.Changed[0].Function.File != "store/loaders.go" {
+		t.Fatalf("expected LoadUser to be changed, got %+v", change.Changed)
+	}
+	changes := change.Changed[0].Changes
+	if len(changes) != 1 || changes[0].Left != "  return nil, err" ||
+		changes[0].Right != `  return nil, fmt.Errorf("load user: %w", err)` {
+		t.Errorf("unexpected changed lines %+v", changes)
+	}
+	if len(change.Untouched) != 2 || !strings.Contains(change.Message, "store.LoadOrder, store.LoadInvoice") {
+		t.Errorf("unexpected untouched siblings %+v: %s", change.Untouched, change.Message)
+	}
+}
+
+func TestSiblings(t *testing.T) {
+	base := writeTree(t, map[string]string{"store/loaders.go": siblingSource(false)})
+	head := writeTree(t, map[string]string{"store/loaders.go": siblingSource(true)})
+
+	a, err := New(WithMinLines(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report, err := a.Siblings(context.Background(), base, head)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertFixedUser(t, report)
+
+	// Unchanged trees have nothing to report
+	report, err = a.Siblings(context.Background(), base, base)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Summary.ChangedGroups != 0 || len(report.InconsistentChanges) != 0 {
+		t.Errorf("expected no change, got %+v", report)
+	}
+
+	if _, err := a.Siblings(context.Background(), base, filepath.Join(head, "missing")); err == nil {
+		t.Error("expected an error for a missing head directory")
+	}
+}
+
+func TestSiblingRevisions(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := writeTree(t, map[string]string{"store/loaders.go": siblingSource(false)})
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{
+			"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com",
+		}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, output)
+		}
+	}
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "base")
+	if err := os.WriteFile(filepath.Join(repo, "store", "loaders.go"), []byte(siblingSource(true)), 0o600); err != nil {
+		t.Fatalf("failed to write change: %v", err)
+	}
+	git("commit", "-q", "-a", "-m", "head")
+
+	a, err := New(WithMinLines(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report, err := a.SiblingRevisions(context.Background(), repo, "HEAD~1", "HEAD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Base != "HEAD~1" || report.Head != "HEAD" {
+		t.Errorf("expected revisions in the report, got %s and %s", report.Base, report.Head)
+	}
+	assertFixedUser(t, report)
+}
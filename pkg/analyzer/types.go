@@ -0,0 +1,121 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/paveg/similarity-go/internal/ast"
+	"github.com/paveg/similarity-go/internal/similarity"
+)
+
+// typeUnits turns similar type declarations into matches that go through the same grouping
+// as functions. Each declaration is stood in for by a function without syntax tree, whose
+// reference is recorded in refs.
+type typeUnits struct {
+	standIns map[*ast.TypeDecl]*ast.Function
+	refs     map[*ast.Function]FunctionRef
+}
+
+// newTypeUnits creates an empty set of type stand-ins.
+func newTypeUnits() *typeUnits {
+	return &typeUnits{
+		standIns: make(map[*ast.TypeDecl]*ast.Function),
+		refs:     make(map[*ast.Function]FunctionRef),
+	}
+}
+
+// matches converts type matches into function matches between stand-ins.
+func (u *typeUnits) matches(typeMatches []similarity.TypeMatch) []similarity.Match {
+	matches := make([]similarity.Match, 0, len(typeMatches))
+	for _, match := range typeMatches {
+		matches = append(matches, similarity.Match{
+			Function1:  u.standIn(match.Type1),
+			Function2:  u.standIn(match.Type2),
+			Similarity: match.Similarity,
+		})
+	}
+	return matches
+}
+
+// standIn returns the function standing in for decl, creating it on first use.
+func (u *typeUnits) standIn(decl *ast.TypeDecl) *ast.Function {
+	if fn, exists := u.standIns[decl]; exists {
+		return fn
+	}
+
+	fn := &ast.Function{
+		Name:       decl.Name,
+		Package:    decl.Package,
+		ImportPath: decl.ImportPath,
+		File:       decl.File,
+		StartLine:  decl.StartLine,
+		EndLine:    decl.EndLine,
+		LineCount:  decl.LineCount,
+	}
+	u.standIns[decl] = fn
+	u.refs[fn] = FunctionRef{
+		File:          decl.File,
+		Function:      decl.Name,
+		QualifiedName: decl.QualifiedName(),
+		Package:       decl.Package,
+		ImportPath:    decl.ImportPath,
+		StartLine:     decl.StartLine,
+		EndLine:       decl.EndLine,
+		Hash:          fn.Hash(),
+		Fingerprint:   decl.Fingerprint(),
+		Complexity:    1,
+		TypeKind:      decl.Kind(),
+	}
+	return fn
+}
+
+// describe returns the reference of fn, whether it stands in for a type or not.
+func (u *typeUnits) describe(fn *ast.Function) FunctionRef {
+	if ref, exists := u.refs[fn]; exists {
+		return ref
+	}
+	return newFunctionRef(fn)
+}
+
+// findSimilarTypes finds similar type declarations when the types unit is enabled.
+func (a *Analyzer) findSimilarTypes(ctx context.Context, types []*ast.TypeDecl, units *typeUnits) ([]similarity.Match, error) {
+	if !a.config.Processing.HasUnit(UnitTypes) {
+		return nil, nil
+	}
+
+	a.logf("Found %d type declarations for analysis", len(types))
+
+	matches := units.matches(a.newDetector().FindSimilarTypes(types))
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// findSimilarTypesBetween finds cross-set similar type declarations when the types unit is
+// enabled.
+func (a *Analyzer) findSimilarTypesBetween(
+	ctx context.Context,
+	left, right []*ast.TypeDecl,
+	units *typeUnits,
+) ([]similarity.Match, error) {
+	if !a.config.Processing.HasUnit(UnitTypes) {
+		return nil, nil
+	}
+
+	a.logf("Found %d left and %d right type declarations for comparison", len(left), len(right))
+
+	matches := units.matches(a.newDetector().FindSimilarTypesBetween(left, right))
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// typeSuggestion describes how to consolidate a group of similar type declarations.
+func typeSuggestion(functions []FunctionRef) string {
+	return fmt.Sprintf(
+		"Declare %s %s once and reuse it in place of the similar declarations",
+		functions[0].TypeKind, functions[0].QualifiedName,
+	)
+}